@@ -34,3 +34,14 @@ test-unit: | $(cert_manager_crds) $(approver_policy_crds) $(NEEDS_GO) $(NEEDS_GI
 		-ldflags $(go_manager_ldflags)
 
 	$(GO) tool cover -html=$(ARTIFACTS)/filtered.cov -o=$(ARTIFACTS)/filtered.html
+
+# FUZZ_TIME is the duration each fuzz target is run for by test-fuzz.
+FUZZ_TIME ?= 30s
+
+.PHONY: test-fuzz
+## Run each fuzz target for FUZZ_TIME. Seed corpora are run as part of
+## test-unit; this target explores beyond them.
+## @category Testing
+test-fuzz: | $(NEEDS_GO)
+	$(GO) test ./pkg/internal/csr/ -run='^$$' -fuzz='^FuzzDecode$$' -fuzztime=$(FUZZ_TIME)
+	$(GO) test ./pkg/internal/approver/allowed/ -run='^$$' -fuzz='^Fuzz_Evaluate$$' -fuzztime=$(FUZZ_TIME)
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+const (
+	// ApprovedByPolicyAnnotationKey is the annotation set on approved
+	// CertificateRequests, holding the name of the CertificateRequestPolicy
+	// that approved the request.
+	ApprovedByPolicyAnnotationKey = GroupName + "/approved-by-policy"
+
+	// ApprovedByPolicyGenerationAnnotationKey is the annotation set on
+	// approved CertificateRequests, holding the metadata.generation of the
+	// CertificateRequestPolicy that approved the request. This ties an
+	// approval to the exact revision of the allowed values that were in force
+	// at the time.
+	ApprovedByPolicyGenerationAnnotationKey = GroupName + "/approved-by-policy-generation"
+
+	// ApprovedByPoliciesAnnotationKey is the annotation set on approved
+	// CertificateRequests, holding a JSON list of the name and
+	// metadata.generation of every CertificateRequestPolicy that approved the
+	// request, for example:
+	// `[{"name":"policy-a","generation":1},{"name":"policy-b","generation":4}]`
+	ApprovedByPoliciesAnnotationKey = GroupName + "/approved-by-policies"
+
+	// MetricLabelAnnotationPrefix is the prefix of annotations set on
+	// CertificateRequestPolicies which add custom labels to the decision
+	// metrics of the policy, for example
+	// `metrics.policy.cert-manager.io/team: payments`. Only label names which
+	// approver-policy has been configured to export are used.
+	MetricLabelAnnotationPrefix = "metrics." + GroupName + "/"
+
+	// OverrideDenialAnnotationKey is the annotation set on CertificateRequests
+	// naming a CertificateRequestPolicyExemption that the request is evaluated
+	// under if no policy approves it. Since cert-manager does not allow a
+	// denial to be changed, the annotation must be present when the request is
+	// reviewed. It is typically set on the Certificate, which cert-manager
+	// copies to the CertificateRequests it creates, so that the request
+	// retried after a denial is reviewed under the exemption.
+	OverrideDenialAnnotationKey = GroupName + "/override-denial"
+
+	// OverrideChainAnnotationKey is the annotation set on CertificateRequests
+	// approved under a CertificateRequestPolicyExemption, holding a JSON
+	// object of the policies which denied the request, the exemption and its
+	// reason, and the policy which approved the request under the exemption.
+	OverrideChainAnnotationKey = GroupName + "/override-chain"
+
+	// WarningsAnnotationKey is the annotation set on approved
+	// CertificateRequests which evaluators raised warnings for, holding a JSON
+	// list of the warnings, each prefixed with the name of the policy, for
+	// example: `["policy-a: requested duration is within 10% of the maximum"]`
+	WarningsAnnotationKey = GroupName + "/warnings"
+)
@@ -67,6 +67,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&CertificateRequestPolicy{},
 		&CertificateRequestPolicyList{},
+		&CertificateRequestPolicyExemption{},
+		&CertificateRequestPolicyExemptionList{},
+		&CertificateRequestDecision{},
+		&CertificateRequestDecisionList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var CertificateRequestDecisionKind = "CertificateRequestDecision"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+//+kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Request",type="string",JSONPath=".spec.certificateRequest.name",description="CertificateRequest the decision was made on"
+// +kubebuilder:printcolumn:name="Verdict",type="string",JSONPath=".spec.verdict",description="Whether the request was approved or denied"
+// +kubebuilder:printcolumn:name="Decided",type="date",JSONPath=".spec.decidedAt",description="Timestamp the decision was made"
+//+kubebuilder:resource:categories=cert-manager,shortName=crdecision,scope=Namespaced
+
+// CertificateRequestDecision records the decision approver-policy made on a
+// CertificateRequest in the same namespace. Decisions are only recorded if
+// approver-policy is configured to, and are kept after the
+// CertificateRequest is deleted until they exceed the configured retention,
+// so that tooling has a durable record of decisions on requests which are
+// frequently deleted.
+type CertificateRequestDecision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificateRequestDecisionSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// CertificateRequestDecisionList is a list of CertificateRequestDecisions.
+type CertificateRequestDecisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateRequestDecision `json:"items"`
+}
+
+// CertificateRequestDecisionSpec is the decision made on a
+// CertificateRequest.
+type CertificateRequestDecisionSpec struct {
+	// CertificateRequest is the CertificateRequest the decision was made on.
+	CertificateRequest CertificateRequestDecisionRequest `json:"certificateRequest"`
+
+	// Verdict is whether the request was approved or denied.
+	Verdict CertificateRequestDecisionVerdict `json:"verdict"`
+
+	// Policies are the CertificateRequestPolicies which approved the request
+	// if it was approved, or which denied the request if it was denied,
+	// sorted by name. Empty if the request was denied because no policy was
+	// applicable to it.
+	// +optional
+	// +listType=atomic
+	Policies []CertificateRequestDecisionPolicy `json:"policies,omitempty"`
+
+	// Message is the message of the Approved or Denied condition set on the
+	// request, which holds the violations of each policy of denied requests.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// DecidedAt is the time the decision was made.
+	DecidedAt metav1.Time `json:"decidedAt"`
+}
+
+// CertificateRequestDecisionRequest identifies the CertificateRequest a
+// decision was made on.
+type CertificateRequestDecisionRequest struct {
+	// Name is the name of the CertificateRequest.
+	Name string `json:"name"`
+
+	// UID is the UID of the CertificateRequest, which distinguishes requests
+	// which were recreated with the same name.
+	UID types.UID `json:"uid"`
+
+	// Username is the name of the user which created the CertificateRequest.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// CreatedAt is the time the CertificateRequest was created.
+	CreatedAt metav1.Time `json:"createdAt"`
+}
+
+// CertificateRequestDecisionPolicy identifies a revision of a
+// CertificateRequestPolicy which made a decision.
+type CertificateRequestDecisionPolicy struct {
+	// Name is the name of the CertificateRequestPolicy.
+	Name string `json:"name"`
+
+	// Generation is the generation of the CertificateRequestPolicy which
+	// made the decision.
+	Generation int64 `json:"generation"`
+}
+
+// CertificateRequestDecisionVerdict is the verdict of a
+// CertificateRequestDecision.
+// +kubebuilder:validation:Enum=Approved;Denied
+type CertificateRequestDecisionVerdict string
+
+const (
+	// CertificateRequestDecisionApproved is the verdict of requests which
+	// were approved.
+	// +k8s:deepcopy-gen=false
+	CertificateRequestDecisionApproved CertificateRequestDecisionVerdict = "Approved"
+
+	// CertificateRequestDecisionDenied is the verdict of requests which were
+	// denied.
+	// +k8s:deepcopy-gen=false
+	CertificateRequestDecisionDenied CertificateRequestDecisionVerdict = "Denied"
+)
@@ -63,6 +63,16 @@ type CertificateRequestPolicySpec struct {
 	// +optional
 	Allowed *CertificateRequestPolicyAllowed `json:"allowed,omitempty"`
 
+	// BreakGlass marks this policy as an emergency policy which approves every
+	// CertificateRequest it selects and is bound to, without evaluating any
+	// allowed, constraints or plugins. A break glass policy must expire, and
+	// is deleted by approver-policy once it has expired. Every approval made
+	// by a break glass policy is logged and recorded as a Warning event.
+	// Break glass policies are only consulted when no other policy approves a
+	// request.
+	// +optional
+	BreakGlass *CertificateRequestPolicyBreakGlass `json:"breakGlass,omitempty"`
+
 	// Constraints define fields that _must_ be satisfied by a
 	// CertificateRequest for the request to be allowed by this policy.
 	// Omitted fields place no restrictions on the corresponding
@@ -84,6 +94,22 @@ type CertificateRequestPolicySpec struct {
 	Selector CertificateRequestPolicySelector `json:"selector"`
 }
 
+// CertificateRequestPolicyBreakGlass defines the expiry and justification of
+// an emergency break glass policy.
+type CertificateRequestPolicyBreakGlass struct {
+	// ExpiresAt is the time at which this break glass policy stops approving
+	// requests and is deleted. Must be in the future, and no further ahead
+	// than the --webhook-max-break-glass-duration of approver-policy, when
+	// the policy is created or updated.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+
+	// IncidentRef is a reference to the incident which justifies this break
+	// glass policy, such as a ticket number or URL. It is included in the
+	// logs and events of every approval made by the policy.
+	// +kubebuilder:validation:MinLength=1
+	IncidentRef string `json:"incidentRef"`
+}
+
 // CertificateRequestPolicyAllowed defines the allowed attributes for a
 // CertificateRequest.
 // A CertificateRequest can request _less_ than what is allowed,
@@ -272,25 +298,64 @@ type CertificateRequestPolicyConstraints struct {
 	// MinDuration defines the minimum duration for a certificate request.
 	// Values are inclusive (i.e. a value of `1h` will accept a duration of
 	// `1h`). MinDuration and MaxDuration may be the same value.
+	// Values may also be given in days, weeks, months or years, for example
+	// `90d` or `1y`.
 	// If set, a duration _must_ be requested in the CertificateRequest.
 	// An omitted field applies no minimum constraint for duration.
 	// +optional
-	MinDuration *metav1.Duration `json:"minDuration,omitempty"`
+	MinDuration *Duration `json:"minDuration,omitempty"`
 
 	// MaxDuration defines the maximum duration for a certificate request.
 	// for.
 	// Values are inclusive (i.e. a value of `1h` will accept a duration of
 	// `1h`). MinDuration and MaxDuration may be the same value.
+	// Values may also be given in days, weeks, months or years, for example
+	// `90d` or `1y`.
 	// If set, a duration _must_ be requested in the CertificateRequest.
 	// An omitted field applies no maximum constraint for duration.
 	// +optional
-	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+	MaxDuration *Duration `json:"maxDuration,omitempty"`
 
 	// PrivateKey defines constraints on the shape of private key
 	// allowed for a CertificateRequest.
 	// An omitted field applies no private key shape constraints.
 	// +optional
 	PrivateKey *CertificateRequestPolicyConstraintsPrivateKey `json:"privateKey,omitempty"`
+
+	// MatchOwningCertificate, if true, requires that the request is owned by
+	// a cert-manager Certificate, and that the subject and SANs of the
+	// request exactly match those defined on the owning Certificate. This
+	// prevents a compromised client from smuggling names into a request which
+	// are not present on the Certificate.
+	// Requests which are not owned by a Certificate are denied.
+	// An omitted field or false applies no owning Certificate constraint.
+	// +optional
+	MatchOwningCertificate *bool `json:"matchOwningCertificate,omitempty"`
+
+	// VerifyOwnerNamespace, if true, requires that every owner reference of
+	// the request to a cert-manager Certificate refers to a Certificate with
+	// the referenced UID in the namespace of the request. Owner references
+	// cannot cross namespaces, so a reference which does not resolve in the
+	// namespace of the request is treated as spoofed and the request is
+	// denied. Requests without Certificate owners are not affected.
+	// An omitted field or false applies no owner namespace constraint, unless
+	// it is enforced for all policies by approver-policy.
+	// +optional
+	VerifyOwnerNamespace *bool `json:"verifyOwnerNamespace,omitempty"`
+
+	// PublicIssuance, if true, marks the policy as approving requests to a
+	// publicly trusted CA, and enforces the CA/Browser Forum Baseline
+	// Requirements for TLS server certificates which can be checked before
+	// issuance:
+	// - DNS names must not be internal names, i.e. single label names or names
+	//   under a reserved or commonly used non-public top level domain such as
+	//   `.local`, `.internal` or `.corp`.
+	// - IP addresses must not be private, loopback, link-local or otherwise
+	//   reserved addresses.
+	// - A duration of no more than 398 days must be requested.
+	// An omitted field or false applies no public issuance constraint.
+	// +optional
+	PublicIssuance *bool `json:"publicIssuance,omitempty"`
 }
 
 // CertificateRequestPolicyConstraintsPrivateKey defines constraints on the shape of private key
@@ -315,8 +380,39 @@ type CertificateRequestPolicyConstraintsPrivateKey struct {
 	// An omitted field applies no maximum constraint on size.
 	// +optional
 	MaxSize *int `json:"maxSize,omitempty"`
+
+	// ECDSACurves defines the allowed elliptic curves of ECDSA private keys
+	// in a request. Private keys of other algorithms are not constrained.
+	// An omitted field permits any curve.
+	// +kubebuilder:validation:items:Enum=P-256;P-384;P-521
+	// +listType=set
+	// +optional
+	ECDSACurves []ECDSACurve `json:"ecdsaCurves,omitempty"`
+
+	// MinRSAPublicExponent defines the minimum public exponent of RSA private
+	// keys in a request. Values are inclusive. When defined, RSA keys with an
+	// even public exponent are also denied. Private keys of other algorithms
+	// are not constrained.
+	// An omitted field applies no constraint on the public exponent.
+	// +kubebuilder:validation:Minimum=3
+	// +optional
+	MinRSAPublicExponent *int `json:"minRSAPublicExponent,omitempty"`
 }
 
+// ECDSACurve is the name of an elliptic curve used by ECDSA private keys.
+type ECDSACurve string
+
+const (
+	// ECDSACurveP256 is the NIST P-256 curve, also known as secp256r1.
+	ECDSACurveP256 ECDSACurve = "P-256"
+
+	// ECDSACurveP384 is the NIST P-384 curve, also known as secp384r1.
+	ECDSACurveP384 ECDSACurve = "P-384"
+
+	// ECDSACurveP521 is the NIST P-521 curve, also known as secp521r1.
+	ECDSACurveP521 ECDSACurve = "P-521"
+)
+
 // CertificateRequestPolicyPluginData is configuration needed by the plugin
 // approver to evaluate a CertificateRequest on this policy.
 type CertificateRequestPolicyPluginData struct {
@@ -325,8 +421,37 @@ type CertificateRequestPolicyPluginData struct {
 	// this policy.
 	// +optional
 	Values map[string]string `json:"values,omitempty"`
+
+	// FailurePolicy defines what happens to CertificateRequests matching this
+	// policy while the plugin is not ready, or errors when evaluating a
+	// request.
+	// Deny will deny requests on this policy.
+	// Skip will evaluate requests on this policy without the plugin.
+	// Block will leave requests undecided until the plugin recovers.
+	// Defaults to Block.
+	// +kubebuilder:validation:Enum=Deny;Skip;Block
+	// +optional
+	FailurePolicy PluginFailurePolicy `json:"failurePolicy,omitempty"`
 }
 
+// PluginFailurePolicy defines how CertificateRequests are handled while a
+// plugin is not ready, or errors when evaluating a request.
+type PluginFailurePolicy string
+
+const (
+	// PluginFailurePolicyDeny will deny requests on the policy while the
+	// plugin is failing.
+	PluginFailurePolicyDeny PluginFailurePolicy = "Deny"
+
+	// PluginFailurePolicySkip will evaluate requests on the policy without the
+	// plugin while the plugin is failing.
+	PluginFailurePolicySkip PluginFailurePolicy = "Skip"
+
+	// PluginFailurePolicyBlock will leave requests undecided, and retry them,
+	// while the plugin is failing.
+	PluginFailurePolicyBlock PluginFailurePolicy = "Block"
+)
+
 // CertificateRequestPolicySelector is used for selecting over which
 // CertificateRequests this CertificateRequestPolicy is appropriate for, and if
 // so, will be used to evaluate the request.
@@ -353,8 +478,30 @@ type CertificateRequestPolicySelector struct {
 	// If this field is omitted, resources in all namespaces are checked.
 	// +optional
 	Namespace *CertificateRequestPolicySelectorNamespace `json:"namespace"`
+
+	// Mode controls whether an empty selector, one whose issuerRef and
+	// namespace selectors place no constraint on requests, matches all
+	// requests or none. Mode has no effect on a selector which constrains the
+	// issuer or namespace of requests.
+	// If omitted, the mode configured for the cluster is used, which matches
+	// all requests unless configured otherwise. The cluster may also be
+	// configured to reject empty selectors which do not set a mode.
+	// +kubebuilder:validation:Enum=All;None
+	// +optional
+	Mode *SelectorMode `json:"mode,omitempty"`
 }
 
+// SelectorMode controls which requests are matched by an empty selector.
+type SelectorMode string
+
+const (
+	// SelectorModeAll matches all requests with an empty selector.
+	SelectorModeAll SelectorMode = "All"
+
+	// SelectorModeNone matches no requests with an empty selector.
+	SelectorModeNone SelectorMode = "None"
+)
+
 // CertificateRequestPolicySelectorIssuerRef defines the selector for matching
 // the issuer reference of requests.
 type CertificateRequestPolicySelectorIssuerRef struct {
@@ -378,6 +525,17 @@ type CertificateRequestPolicySelectorIssuerRef struct {
 	// An omitted field matches all groups.
 	// +optional
 	Group *string `json:"group,omitempty"`
+
+	// Alias is the name of a logical issuer, such as `internal-mtls`, which
+	// the approver resolves to a concrete issuer using the issuer aliases
+	// configured for the cluster. This allows the same policy to select
+	// different issuers in different clusters or environments.
+	// Alias is matched exactly, and cannot be combined with Name, Kind or
+	// Group. If the alias is not configured in the cluster, the selector
+	// matches no requests.
+	// An omitted field does not select by alias.
+	// +optional
+	Alias *string `json:"alias,omitempty"`
 }
 
 // CertificateRequestPolicySelectorNamespace defines the selector for matching
@@ -408,6 +566,24 @@ type CertificateRequestPolicyStatus struct {
 	// +listMapKey=type
 	// +optional
 	Conditions []CertificateRequestPolicyCondition `json:"conditions,omitempty"`
+
+	// UnreadyPlugins is the list of plugins that are not ready for this
+	// policy, but whose failurePolicy is Deny or Skip so the policy remains
+	// Ready. Requests evaluated against this policy apply the failurePolicy of
+	// these plugins.
+	// +listType=set
+	// +optional
+	UnreadyPlugins []string `json:"unreadyPlugins,omitempty"`
+
+	// UnexercisedFields is the list of allowed and constraints fields of the
+	// spec which no request reviewed against this generation of the policy
+	// has exercised since approver-policy started. Allowed fields are
+	// exercised by requests for the attributes they allow, and constraints by
+	// requests which reach or exceed them. Only reported when field usage
+	// analysis is enabled, once enough requests have been reviewed.
+	// +listType=set
+	// +optional
+	UnexercisedFields []string `json:"unexercisedFields,omitempty"`
 }
 
 // CertificateRequestPolicyCondition contains condition information for a
@@ -454,4 +630,12 @@ const (
 	// evaluating CertificateRequests.
 	// +k8s:deepcopy-gen=false
 	CertificateRequestPolicyConditionReady CertificateRequestPolicyConditionType = "Ready"
+
+	// CertificateRequestPolicyConditionIssuersFound indicates whether the
+	// Issuers and ClusterIssuers named by the selector of the
+	// CertificateRequestPolicy exist. It is informational only, and does not
+	// affect whether the policy is Ready. Only set if approver-policy is
+	// configured to verify issuers.
+	// +k8s:deepcopy-gen=false
+	CertificateRequestPolicyConditionIssuersFound CertificateRequestPolicyConditionType = "IssuersFound"
 )
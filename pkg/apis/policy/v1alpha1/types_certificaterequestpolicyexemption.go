@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var CertificateRequestPolicyExemptionKind = "CertificateRequestPolicyExemption"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+//+kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".spec.policy",description="CertificateRequestPolicy that exempted requests are evaluated against"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace",description="Namespace of the exempted requests"
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".spec.expiresAt",description="Timestamp the exemption expires"
+//+kubebuilder:resource:categories=cert-manager,shortName=crpe,scope=Cluster
+
+// CertificateRequestPolicyExemption grants specific CertificateRequests an
+// exemption from the selector and RBAC bindings of a CertificateRequestPolicy.
+// A request which would otherwise be denied is evaluated against the
+// exempting policy if it carries the `policy.cert-manager.io/override-denial`
+// annotation naming this exemption. Creating exemptions should be restricted
+// to privileged users.
+type CertificateRequestPolicyExemption struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificateRequestPolicyExemptionSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// CertificateRequestPolicyExemptionList is a list of
+// CertificateRequestPolicyExemptions.
+type CertificateRequestPolicyExemptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateRequestPolicyExemption `json:"items"`
+}
+
+// CertificateRequestPolicyExemptionSpec defines the requests which are
+// exempted, and the policy they are evaluated against.
+type CertificateRequestPolicyExemptionSpec struct {
+	// CertificateNames are the names of the Certificates whose
+	// CertificateRequests are exempted. Requests are matched on their
+	// `cert-manager.io/certificate-name` annotation.
+	// +optional
+	// +listType=set
+	CertificateNames []string `json:"certificateNames,omitempty"`
+
+	// CertificateRequestNames are the names of the CertificateRequests which
+	// are exempted.
+	// +optional
+	// +listType=set
+	CertificateRequestNames []string `json:"certificateRequestNames,omitempty"`
+
+	// ExpiresAt is the time after which the exemption no longer applies.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+
+	// Namespace is the namespace of the exempted requests.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Policy is the name of the CertificateRequestPolicy which exempted
+	// requests are evaluated against, regardless of its selector and the RBAC
+	// bound to the requester. The request must still satisfy the allowed,
+	// constraints and plugins of the policy, and the policy must be Ready.
+	// +kubebuilder:validation:MinLength=1
+	Policy string `json:"policy"`
+
+	// Reason is the justification for the exemption, such as a ticket number
+	// or URL. It is recorded in the override chain of every request approved
+	// under the exemption.
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a duration which, as well as Go duration strings such as
+// `2160h`, accepts whole numbers of days (`90d`), weeks (`2w`), months of 30
+// days (`3mo`) and years of 365 days (`1y`). Units may not be mixed with Go
+// duration units. Durations are always written as Go duration strings, and
+// the mutating webhook of approver-policy stores them as such.
+// +kubebuilder:validation:Type=string
+type Duration struct {
+	time.Duration `json:",inline"`
+}
+
+// extendedDurationUnits are the units accepted by ParseDuration in addition to
+// those of time.ParseDuration. "mo" must be matched before any unit it is a
+// suffix of.
+var extendedDurationUnits = []struct {
+	suffix   string
+	duration time.Duration
+}{
+	{"mo", 30 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// ParseDuration parses a Go duration string, or a whole number of days, weeks,
+// months or years such as `90d` or `1y`.
+func ParseDuration(s string) (time.Duration, error) {
+	for _, unit := range extendedDurationUnits {
+		n, ok := strings.CutSuffix(s, unit.suffix)
+		if !ok {
+			continue
+		}
+		count, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			break
+		}
+		if limit := int64(math.MaxInt64 / unit.duration); count > limit || count < -limit {
+			return 0, fmt.Errorf("duration %q is out of range", s)
+		}
+		return time.Duration(count) * unit.duration, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, must be a Go duration such as \"2160h\", or a whole number of days (d), weeks (w), months (mo) or years (y) such as \"90d\"", s)
+	}
+	return d, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaller interface.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+
+	pd, err := ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	d.Duration = pd
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// ToUnstructured implements the value.UnstructuredConverter interface.
+func (d Duration) ToUnstructured() interface{} {
+	return d.Duration.String()
+}
+
+// OpenAPISchemaType is used by the kube-openapi generator when constructing
+// the OpenAPI spec of this type.
+func (Duration) OpenAPISchemaType() []string { return []string{"string"} }
+
+// OpenAPISchemaFormat is used by the kube-openapi generator when constructing
+// the OpenAPI spec of this type.
+func (Duration) OpenAPISchemaFormat() string { return "" }
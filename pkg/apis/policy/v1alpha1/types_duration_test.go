@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseDuration(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		exp    time.Duration
+		expErr bool
+	}{
+		"a Go duration should parse": {
+			input: "2160h",
+			exp:   2160 * time.Hour,
+		},
+		"a Go duration in minutes should not be read as months": {
+			input: "90m",
+			exp:   90 * time.Minute,
+		},
+		"days should parse": {
+			input: "90d",
+			exp:   90 * 24 * time.Hour,
+		},
+		"weeks should parse": {
+			input: "2w",
+			exp:   14 * 24 * time.Hour,
+		},
+		"months should parse as 30 days": {
+			input: "3mo",
+			exp:   90 * 24 * time.Hour,
+		},
+		"years should parse as 365 days": {
+			input: "1y",
+			exp:   365 * 24 * time.Hour,
+		},
+		"fractional days should error": {
+			input:  "1.5d",
+			expErr: true,
+		},
+		"mixed units should error": {
+			input:  "1d12h",
+			expErr: true,
+		},
+		"out of range years should error": {
+			input:  "1000y",
+			expErr: true,
+		},
+		"an unknown unit should error": {
+			input:  "90x",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseDuration(test.input)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.exp, d)
+		})
+	}
+}
+
+func Test_DurationJSON(t *testing.T) {
+	var constraints CertificateRequestPolicyConstraints
+	require.NoError(t, json.Unmarshal([]byte(`{"minDuration":"90d","maxDuration":"1y"}`), &constraints))
+	assert.Equal(t, 90*24*time.Hour, constraints.MinDuration.Duration)
+	assert.Equal(t, 365*24*time.Hour, constraints.MaxDuration.Duration)
+
+	b, err := json.Marshal(constraints)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"minDuration":"2160h0m0s","maxDuration":"8760h0m0s"}`, string(b), "durations should be written as Go durations")
+
+	assert.Error(t, json.Unmarshal([]byte(`{"maxDuration":"90x"}`), &constraints))
+}
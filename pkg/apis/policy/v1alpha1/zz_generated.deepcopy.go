@@ -22,10 +22,120 @@ package v1alpha1
 
 import (
 	"github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestDecision) DeepCopyInto(out *CertificateRequestDecision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestDecision.
+func (in *CertificateRequestDecision) DeepCopy() *CertificateRequestDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestDecision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestDecisionList) DeepCopyInto(out *CertificateRequestDecisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateRequestDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestDecisionList.
+func (in *CertificateRequestDecisionList) DeepCopy() *CertificateRequestDecisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestDecisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestDecisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestDecisionPolicy) DeepCopyInto(out *CertificateRequestDecisionPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestDecisionPolicy.
+func (in *CertificateRequestDecisionPolicy) DeepCopy() *CertificateRequestDecisionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestDecisionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestDecisionRequest) DeepCopyInto(out *CertificateRequestDecisionRequest) {
+	*out = *in
+	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestDecisionRequest.
+func (in *CertificateRequestDecisionRequest) DeepCopy() *CertificateRequestDecisionRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestDecisionRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestDecisionSpec) DeepCopyInto(out *CertificateRequestDecisionSpec) {
+	*out = *in
+	in.CertificateRequest.DeepCopyInto(&out.CertificateRequest)
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]CertificateRequestDecisionPolicy, len(*in))
+		copy(*out, *in)
+	}
+	in.DecidedAt.DeepCopyInto(&out.DecidedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestDecisionSpec.
+func (in *CertificateRequestDecisionSpec) DeepCopy() *CertificateRequestDecisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestDecisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateRequestPolicy) DeepCopyInto(out *CertificateRequestPolicy) {
 	*out = *in
@@ -235,6 +345,22 @@ func (in *CertificateRequestPolicyAllowedX509Subject) DeepCopy() *CertificateReq
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestPolicyBreakGlass) DeepCopyInto(out *CertificateRequestPolicyBreakGlass) {
+	*out = *in
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyBreakGlass.
+func (in *CertificateRequestPolicyBreakGlass) DeepCopy() *CertificateRequestPolicyBreakGlass {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestPolicyBreakGlass)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateRequestPolicyCondition) DeepCopyInto(out *CertificateRequestPolicyCondition) {
 	*out = *in
@@ -259,12 +385,12 @@ func (in *CertificateRequestPolicyConstraints) DeepCopyInto(out *CertificateRequ
 	*out = *in
 	if in.MinDuration != nil {
 		in, out := &in.MinDuration, &out.MinDuration
-		*out = new(metav1.Duration)
+		*out = new(Duration)
 		**out = **in
 	}
 	if in.MaxDuration != nil {
 		in, out := &in.MaxDuration, &out.MaxDuration
-		*out = new(metav1.Duration)
+		*out = new(Duration)
 		**out = **in
 	}
 	if in.PrivateKey != nil {
@@ -272,6 +398,21 @@ func (in *CertificateRequestPolicyConstraints) DeepCopyInto(out *CertificateRequ
 		*out = new(CertificateRequestPolicyConstraintsPrivateKey)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MatchOwningCertificate != nil {
+		in, out := &in.MatchOwningCertificate, &out.MatchOwningCertificate
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VerifyOwnerNamespace != nil {
+		in, out := &in.VerifyOwnerNamespace, &out.VerifyOwnerNamespace
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PublicIssuance != nil {
+		in, out := &in.PublicIssuance, &out.PublicIssuance
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyConstraints.
@@ -302,6 +443,16 @@ func (in *CertificateRequestPolicyConstraintsPrivateKey) DeepCopyInto(out *Certi
 		*out = new(int)
 		**out = **in
 	}
+	if in.ECDSACurves != nil {
+		in, out := &in.ECDSACurves, &out.ECDSACurves
+		*out = make([]ECDSACurve, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinRSAPublicExponent != nil {
+		in, out := &in.MinRSAPublicExponent, &out.MinRSAPublicExponent
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyConstraintsPrivateKey.
@@ -314,6 +465,90 @@ func (in *CertificateRequestPolicyConstraintsPrivateKey) DeepCopy() *Certificate
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestPolicyExemption) DeepCopyInto(out *CertificateRequestPolicyExemption) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyExemption.
+func (in *CertificateRequestPolicyExemption) DeepCopy() *CertificateRequestPolicyExemption {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestPolicyExemption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestPolicyExemption) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestPolicyExemptionList) DeepCopyInto(out *CertificateRequestPolicyExemptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateRequestPolicyExemption, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyExemptionList.
+func (in *CertificateRequestPolicyExemptionList) DeepCopy() *CertificateRequestPolicyExemptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestPolicyExemptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestPolicyExemptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestPolicyExemptionSpec) DeepCopyInto(out *CertificateRequestPolicyExemptionSpec) {
+	*out = *in
+	if in.CertificateNames != nil {
+		in, out := &in.CertificateNames, &out.CertificateNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CertificateRequestNames != nil {
+		in, out := &in.CertificateRequestNames, &out.CertificateRequestNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyExemptionSpec.
+func (in *CertificateRequestPolicyExemptionSpec) DeepCopy() *CertificateRequestPolicyExemptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestPolicyExemptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateRequestPolicyList) DeepCopyInto(out *CertificateRequestPolicyList) {
 	*out = *in
@@ -381,6 +616,11 @@ func (in *CertificateRequestPolicySelector) DeepCopyInto(out *CertificateRequest
 		*out = new(CertificateRequestPolicySelectorNamespace)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Mode != nil {
+		in, out := &in.Mode, &out.Mode
+		*out = new(SelectorMode)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicySelector.
@@ -411,6 +651,11 @@ func (in *CertificateRequestPolicySelectorIssuerRef) DeepCopyInto(out *Certifica
 		*out = new(string)
 		**out = **in
 	}
+	if in.Alias != nil {
+		in, out := &in.Alias, &out.Alias
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicySelectorIssuerRef.
@@ -458,6 +703,11 @@ func (in *CertificateRequestPolicySpec) DeepCopyInto(out *CertificateRequestPoli
 		*out = new(CertificateRequestPolicyAllowed)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BreakGlass != nil {
+		in, out := &in.BreakGlass, &out.BreakGlass
+		*out = new(CertificateRequestPolicyBreakGlass)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Constraints != nil {
 		in, out := &in.Constraints, &out.Constraints
 		*out = new(CertificateRequestPolicyConstraints)
@@ -493,6 +743,16 @@ func (in *CertificateRequestPolicyStatus) DeepCopyInto(out *CertificateRequestPo
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.UnreadyPlugins != nil {
+		in, out := &in.UnreadyPlugins, &out.UnreadyPlugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnexercisedFields != nil {
+		in, out := &in.UnexercisedFields, &out.UnexercisedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestPolicyStatus.
@@ -505,6 +765,21 @@ func (in *CertificateRequestPolicyStatus) DeepCopy() *CertificateRequestPolicySt
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Duration) DeepCopyInto(out *Duration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Duration.
+func (in *Duration) DeepCopy() *Duration {
+	if in == nil {
+		return nil
+	}
+	out := new(Duration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValidationRule) DeepCopyInto(out *ValidationRule) {
 	*out = *in
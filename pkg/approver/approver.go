@@ -39,6 +39,10 @@ type Interface interface {
 	// controllers, adding health checks, or other controller-runtime runnables.
 	Prepare(context.Context, logr.Logger, manager.Manager) error
 
+	// Scoped declares the requests this Approver applies to. Approvers which
+	// apply to every request return an empty Scope.
+	Scoped
+
 	// Evaluator is responsible for executing evaluations on whether a request
 	// should be denied or not.
 	Evaluator
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/spf13/pflag"
+)
+
+// RequestDecoder normalizes CertificateRequests of issuers which encode the
+// certificate request differently, such as custom issuer controllers placing
+// a base64url JOSE-wrapped CSR in `spec.request`, into the PEM encoded X.509
+// certificate request which approver-policy reviews. A request is decoded by
+// the RequestDecoder whose Scope includes the group of its issuer, unless its
+// `spec.request` is already PEM encoded. The decoded request is checked for
+// structural problems and reviewed in place of the original, so Evaluators
+// only ever see PEM encoded requests. The original request is kept for
+// audit events, decision records and PostProcessors.
+type RequestDecoder interface {
+	// Name is name of this RequestDecoder. Name must be unique to the
+	// approver-policy instance.
+	Name() string
+
+	// Scope returns the issuer groups whose requests are decoded. The Scope
+	// must name at least one issuer group, and each issuer group may only be
+	// decoded by a single RequestDecoder.
+	Scoped
+
+	// RegisterFlags can be used by RequestDecoders for registering CLI flags
+	// which configure them, such as the issuer groups they decode.
+	RegisterFlags(*pflag.FlagSet)
+
+	// Decode returns the PEM encoded X.509 certificate request carried by the
+	// request. Decode is also given requests with an empty `spec.request`, for
+	// issuers which carry the request elsewhere. Decode must not modify the
+	// request. A returned error means the request is structurally invalid, and
+	// is never retried.
+	Decode(*cmapi.CertificateRequest) ([]byte, error)
+}
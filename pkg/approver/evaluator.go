@@ -25,6 +25,7 @@ import (
 	"context"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 )
@@ -70,3 +71,47 @@ type Evaluator interface {
 	// manager may re-evaluate an evaluation if an error is returned.
 	Evaluate(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (EvaluationResponse, error)
 }
+
+// WarningEvaluator is an optional interface of Evaluators which report
+// warnings: non-fatal observations about the request which don't affect the
+// result, such as a requested duration close to the maximum of the policy, or
+// a key size that is due to be disallowed. Warnings are recorded on requests
+// which the policy approves, giving requesters notice before a policy is
+// tightened. EvaluateWithWarnings is called instead of Evaluate.
+type WarningEvaluator interface {
+	Evaluator
+
+	// EvaluateWithWarnings evaluates the request as Evaluate does, also
+	// returning the warnings of the request given the policy.
+	EvaluateWithWarnings(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (EvaluationResponse, []string, error)
+}
+
+// AdvisoryEvaluator is an optional interface of Evaluators whose results are
+// only reported as metrics, and never affect whether a request is approved or
+// denied. Advisory evaluators, such as expensive external reputation checks,
+// may be configured to only evaluate a sample of requests. Advisory
+// evaluators must also implement `Name() string`, which is used to configure
+// their sample rate.
+type AdvisoryEvaluator interface {
+	Evaluator
+
+	// Advisory returns true if the results of the Evaluator are advisory.
+	Advisory() bool
+}
+
+// EnrichedEvaluator is an optional interface of Evaluators which read objects
+// related to the requests they evaluate, such as their Namespace or owning
+// Certificate. Before the Approver is prepared, its Evaluator is given a
+// reader of approver-policy's shared enrichment cache, which serves owning
+// Certificates for up to a configured TTL after they were read from the API
+// server. This keeps the latency of
+// evaluations predictable when the API server is under pressure, at the cost
+// of bounded staleness. Namespaces, which approver-policy already watches, are
+// served from its informer cache. Other objects are read straight from the API
+// server.
+type EnrichedEvaluator interface {
+	Evaluator
+
+	// SetEnrichmentReader sets the reader of the shared enrichment cache.
+	SetEnrichmentReader(client.Reader)
+}
@@ -31,6 +31,7 @@ var _ approver.Interface = &FakeApprover{}
 // FakeApprover is a testing approver designed to mock approvers with a
 // pre-determined response.
 type FakeApprover struct {
+	scope           approver.Scope
 	registerFlagsFn func(*pflag.FlagSet)
 	prepareFn       func(context.Context, logr.Logger, manager.Manager) error
 	*FakeEvaluator
@@ -56,6 +57,11 @@ func (f *FakeApprover) WithReconciler(reconciler *FakeReconciler) *FakeApprover
 	return f
 }
 
+func (f *FakeApprover) WithScope(scope approver.Scope) *FakeApprover {
+	f.scope = scope
+	return f
+}
+
 func (f *FakeApprover) WithRegisterFlags(fn func(*pflag.FlagSet)) *FakeApprover {
 	f.registerFlagsFn = fn
 	return f
@@ -73,3 +79,7 @@ func (f *FakeApprover) RegisterFlags(pf *pflag.FlagSet) {
 func (f *FakeApprover) Prepare(ctx context.Context, log logr.Logger, mgr manager.Manager) error {
 	return f.prepareFn(ctx, log, mgr)
 }
+
+func (f *FakeApprover) Scope() approver.Scope {
+	return f.scope
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/spf13/pflag"
+
+	"github.com/cert-manager/approver-policy/pkg/approver"
+)
+
+var _ approver.RequestDecoder = &FakeRequestDecoder{}
+
+// FakeRequestDecoder is a testing request decoder designed to mock
+// RequestDecoders with a pre-determined response.
+type FakeRequestDecoder struct {
+	name            string
+	scope           approver.Scope
+	registerFlagsFn func(*pflag.FlagSet)
+	decodeFn        func(*cmapi.CertificateRequest) ([]byte, error)
+}
+
+func NewFakeRequestDecoder() *FakeRequestDecoder {
+	return &FakeRequestDecoder{
+		registerFlagsFn: func(*pflag.FlagSet) {},
+	}
+}
+
+func (f *FakeRequestDecoder) WithName(name string) *FakeRequestDecoder {
+	f.name = name
+	return f
+}
+
+func (f *FakeRequestDecoder) WithScope(scope approver.Scope) *FakeRequestDecoder {
+	f.scope = scope
+	return f
+}
+
+func (f *FakeRequestDecoder) WithRegisterFlags(fn func(*pflag.FlagSet)) *FakeRequestDecoder {
+	f.registerFlagsFn = fn
+	return f
+}
+
+func (f *FakeRequestDecoder) WithDecode(fn func(*cmapi.CertificateRequest) ([]byte, error)) *FakeRequestDecoder {
+	f.decodeFn = fn
+	return f
+}
+
+func (f *FakeRequestDecoder) Name() string {
+	return f.name
+}
+
+func (f *FakeRequestDecoder) Scope() approver.Scope {
+	return f.scope
+}
+
+func (f *FakeRequestDecoder) RegisterFlags(pf *pflag.FlagSet) {
+	f.registerFlagsFn(pf)
+}
+
+func (f *FakeRequestDecoder) Decode(cr *cmapi.CertificateRequest) ([]byte, error) {
+	return f.decodeFn(cr)
+}
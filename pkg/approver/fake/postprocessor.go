@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+)
+
+var _ approver.PostProcessor = &FakePostProcessor{}
+
+// FakePostProcessor is a testing post-processor designed to mock
+// PostProcessors with a pre-determined response.
+type FakePostProcessor struct {
+	name            string
+	registerFlagsFn func(*pflag.FlagSet)
+	prepareFn       func(context.Context, logr.Logger, manager.Manager) error
+	postProcessFn   func(context.Context, *cmapi.CertificateRequest, *policyapi.CertificateRequestDecision) error
+}
+
+func NewFakePostProcessor() *FakePostProcessor {
+	return &FakePostProcessor{
+		registerFlagsFn: func(*pflag.FlagSet) {},
+		prepareFn:       func(context.Context, logr.Logger, manager.Manager) error { return nil },
+	}
+}
+
+func (f *FakePostProcessor) WithName(name string) *FakePostProcessor {
+	f.name = name
+	return f
+}
+
+func (f *FakePostProcessor) WithRegisterFlags(fn func(*pflag.FlagSet)) *FakePostProcessor {
+	f.registerFlagsFn = fn
+	return f
+}
+
+func (f *FakePostProcessor) WithPrepare(fn func(context.Context, logr.Logger, manager.Manager) error) *FakePostProcessor {
+	f.prepareFn = fn
+	return f
+}
+
+func (f *FakePostProcessor) WithPostProcess(fn func(context.Context, *cmapi.CertificateRequest, *policyapi.CertificateRequestDecision) error) *FakePostProcessor {
+	f.postProcessFn = fn
+	return f
+}
+
+func (f *FakePostProcessor) Name() string {
+	return f.name
+}
+
+func (f *FakePostProcessor) RegisterFlags(pf *pflag.FlagSet) {
+	f.registerFlagsFn(pf)
+}
+
+func (f *FakePostProcessor) Prepare(ctx context.Context, log logr.Logger, mgr manager.Manager) error {
+	return f.prepareFn(ctx, log, mgr)
+}
+
+func (f *FakePostProcessor) PostProcess(ctx context.Context, cr *cmapi.CertificateRequest, decision *policyapi.CertificateRequestDecision) error {
+	return f.postProcessFn(ctx, cr, decision)
+}
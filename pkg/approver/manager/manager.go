@@ -20,6 +20,8 @@ import (
 	"context"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 )
 
 // ReviewResult is the result from an approver manager reviewing a
@@ -49,6 +51,71 @@ type ReviewResponse struct {
 	// Message is optional context as to why the manager has given the result it
 	// has.
 	Message string
+
+	// ApprovedBy is the revision of the CertificateRequestPolicy which
+	// approved the request. Only set when Result is ResultApproved. If
+	// multiple policies approved the request, this is the first by name.
+	ApprovedBy *PolicyRevision
+
+	// ApprovedByAll are the revisions of every CertificateRequestPolicy which
+	// approved the request, sorted by name. Only set when Result is
+	// ResultApproved.
+	ApprovedByAll []PolicyRevision
+
+	// DeniedBy are the revisions of every CertificateRequestPolicy which
+	// denied the request, sorted by name. Only set when Result is
+	// ResultDenied.
+	DeniedBy []PolicyRevision
+
+	// BreakGlass is the break glass configuration of the
+	// CertificateRequestPolicy which approved the request. Only set when the
+	// request was approved by a break glass policy.
+	BreakGlass *policyapi.CertificateRequestPolicyBreakGlass
+
+	// Override is the override chain of a request which was approved under a
+	// CertificateRequestPolicyExemption. Only set when the request was
+	// approved under an exemption.
+	Override *OverrideChain
+
+	// Warnings are the warnings of the evaluators of the policies which
+	// approved the request, each prefixed with the name of the policy, sorted
+	// and deduplicated. Only set when Result is ResultApproved.
+	Warnings []string
+
+	// NormalizedRequest is the `spec.request` that was reviewed, once
+	// normalized to a PEM encoded CSR by the RequestDecoder of the request's
+	// issuer group. Nil if the request was reviewed as given.
+	NormalizedRequest []byte
+}
+
+// OverrideChain records how a request which no policy approved came to be
+// approved under a CertificateRequestPolicyExemption.
+type OverrideChain struct {
+	// DeniedBy are the revisions of every CertificateRequestPolicy which
+	// denied the request before the exemption was applied. Empty if no policy
+	// was applicable to the request.
+	DeniedBy []PolicyRevision `json:"deniedBy,omitempty"`
+
+	// Exemption is the name of the CertificateRequestPolicyExemption that the
+	// request was evaluated under.
+	Exemption string `json:"exemption"`
+
+	// Reason is the justification given by the exemption.
+	Reason string `json:"reason"`
+
+	// ApprovedBy is the revision of the CertificateRequestPolicy which
+	// approved the request under the exemption.
+	ApprovedBy PolicyRevision `json:"approvedBy"`
+}
+
+// PolicyRevision identifies a revision of a CertificateRequestPolicy.
+type PolicyRevision struct {
+	// Name is the name of the CertificateRequestPolicy.
+	Name string `json:"name"`
+
+	// Generation is the metadata.generation of the CertificateRequestPolicy
+	// at the time it was evaluated.
+	Generation int64 `json:"generation"`
 }
 
 // Interface is an Approver Manager that responsible for evaluating whether
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"context"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// PostProcessor is invoked after the verdict of a CertificateRequest review
+// has been written to the request. PostProcessors may be used to integrate
+// decisions with other systems, such as labelling the owning Certificate or
+// updating external inventories, without changing how requests are reviewed.
+type PostProcessor interface {
+	// Name is name of this PostProcessor. Name must be unique to the
+	// approver-policy instance.
+	Name() string
+
+	// RegisterFlags can be used by PostProcessors for registering CLI flags
+	// which are required for configuring that PostProcessor on this
+	// approver-policy instance.
+	RegisterFlags(*pflag.FlagSet)
+
+	// Prepare can be used by PostProcessors for registering extra Kubernetes
+	// controllers, adding health checks, or other controller-runtime runnables.
+	Prepare(context.Context, logr.Logger, manager.Manager) error
+
+	// PostProcess is called once for each Approved or Denied verdict of a
+	// review, after the verdict has been written to the CertificateRequest.
+	// Requests denied for being structurally invalid without review are
+	// post-processed too, with a decision naming no policies. The decision is the full record of the verdict,
+	// regardless of whether decision records are persisted as
+	// CertificateRequestDecisions.
+	// PostProcess is called inline with the reconciliation of the request, so
+	// the context is cancelled after a timeout, and PostProcess must return
+	// once it is.
+	// A returned error is logged and raised as an Event on the request. The
+	// verdict is final, so PostProcess is never retried and an error never
+	// changes the verdict.
+	PostProcess(context.Context, *cmapi.CertificateRequest, *policyapi.CertificateRequestDecision) error
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"slices"
+)
+
+// defaultIssuerGroup is the group of requests whose issuerRef omits the group,
+// as defaulted by cert-manager.
+const defaultIssuerGroup = "cert-manager.io"
+
+// Scope is the set of CertificateRequests, by their issuer, that an Approver
+// applies to. The Evaluator of an Approver only evaluates requests within its
+// Scope, and its Reconciler is only consulted on the readiness of
+// CertificateRequestPolicies which may select requests within its Scope. For
+// example, an Approver which only checks requests for Venafi issuers would be
+// scoped to the "venafi.cert-manager.io" issuer group.
+type Scope struct {
+	// IssuerGroups are the API groups of the issuers of requests the Approver
+	// applies to. Requests whose issuerRef omits the group are of the
+	// "cert-manager.io" group. Empty applies to requests of every issuer.
+	IssuerGroups []string
+}
+
+// Scoped is implemented by Evaluators and Reconcilers which only apply to the
+// requests within a Scope. Every Approver is Scoped.
+type Scoped interface {
+	// Scope returns the Scope of requests this applies to.
+	Scope() Scope
+}
+
+// IncludesIssuerGroup returns true if requests for issuers of the API group
+// are within the Scope.
+func (s Scope) IncludesIssuerGroup(group string) bool {
+	if len(s.IssuerGroups) == 0 {
+		return true
+	}
+	if len(group) == 0 {
+		group = defaultIssuerGroup
+	}
+	return slices.Contains(s.IssuerGroups, group)
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/cert-manager/approver-policy/pkg/internal/cmd"
+	"github.com/cert-manager/approver-policy/pkg/internal/cmd/options"
 
 	_ "github.com/cert-manager/approver-policy/pkg/internal/approver/allowed"
 	_ "github.com/cert-manager/approver-policy/pkg/internal/approver/constraints"
@@ -34,6 +37,16 @@ func ExecutePolicyApprover() {
 	cmd := cmd.NewCommand(ctrl.SetupSignalHandler())
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+
+		// Invalid flags are also written as a JSON summary, so that they may be
+		// consumed by tooling.
+		var flagErr *options.FlagValidationError
+		if errors.As(err, &flagErr) {
+			if summary, err := json.Marshal(flagErr); err == nil {
+				fmt.Fprintf(os.Stderr, "%s\n", summary)
+			}
+		}
+
 		os.Exit(1)
 	}
 }
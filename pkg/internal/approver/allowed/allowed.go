@@ -18,9 +18,11 @@ package allowed
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
@@ -38,6 +40,7 @@ func init() {
 func Approver() approver.Interface {
 	return allowed{
 		validators: validation.NewCache(),
+		maxMemory:  new(resource.QuantityValue),
 	}
 }
 
@@ -49,6 +52,10 @@ func Approver() approver.Interface {
 // approver-policy builds.
 type allowed struct {
 	validators validation.Cache
+
+	// maxMemory is the limit of the estimated memory of compiled validators.
+	// Zero is unlimited.
+	maxMemory *resource.QuantityValue
 }
 
 // Name of Approver is "allowed"
@@ -56,11 +63,25 @@ func (a allowed) Name() string {
 	return "allowed"
 }
 
-// RegisterFlags is a no-op, allowed doesn't need any flags.
-func (a allowed) RegisterFlags(_ *pflag.FlagSet) {}
+// Scope of allowed is every request.
+func (a allowed) Scope() approver.Scope {
+	return approver.Scope{}
+}
 
-// Prepare is a no-op, allowed doesn't need to prepare anything.
+// RegisterFlags registers the memory limit of compiled CEL validators.
+func (a allowed) RegisterFlags(fs *pflag.FlagSet) {
+	fs.Var(a.maxMemory, "max-compiled-matchers-memory",
+		"Limit of the estimated memory of compiled CEL validation expressions, e.g. \"256Mi\". Once exceeded, the "+
+			"least recently used expressions are evicted and compiled again when next used, trading CPU for memory. "+
+			"Zero is unlimited.")
+}
+
+// Prepare applies the memory limit of compiled CEL validators.
 func (a allowed) Prepare(_ context.Context, _ logr.Logger, _ manager.Manager) error {
+	if a.maxMemory.Sign() < 0 {
+		return fmt.Errorf("--max-compiled-matchers-memory must not be negative, got %s", a.maxMemory.String())
+	}
+	a.validators.SetMaxBytes(a.maxMemory.Value())
 	return nil
 }
 
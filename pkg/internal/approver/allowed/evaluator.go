@@ -20,17 +20,18 @@ import (
 	"context"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	utilpki "github.com/cert-manager/cert-manager/pkg/util/pki"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
 	"github.com/cert-manager/approver-policy/pkg/internal/util"
 )
 
@@ -54,8 +55,12 @@ func (a allowed) Evaluate(_ context.Context, policy *policyapi.CertificateReques
 		allowed = new(policyapi.CertificateRequestPolicyAllowed)
 	}
 
-	csr, err := utilpki.DecodeX509CertificateRequestBytes(request.Spec.Request)
+	csr, err := internalcsr.Decode(request.Spec.Request)
 	if err != nil {
+		var limitErr *internalcsr.LimitError
+		if errors.As(err, &limitErr) {
+			return approver.EvaluationResponse{Result: approver.ResultDenied, Message: limitErr.Error()}, nil
+		}
 		return approver.EvaluationResponse{}, err
 	}
 
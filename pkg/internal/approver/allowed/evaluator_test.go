@@ -32,6 +32,7 @@ import (
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
 )
 
 func Test_Evaluate(t *testing.T) {
@@ -57,6 +58,24 @@ func Test_Evaluate(t *testing.T) {
 			},
 			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied, Message: ""},
 		},
+		"if request exceeds parser limits, return Denied": {
+			request: gen.CertificateRequest("", gen.SetCertificateRequestCSR(csrFrom(t,
+				noErrModifier(func(csr *x509.CertificateRequest) {
+					for i := 0; i <= internalcsr.MaxSubjectRDNs; i++ {
+						csr.Subject.Country = append(csr.Subject.Country, "GB")
+					}
+				}),
+			))),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Allowed: nil,
+			},
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.TooMany(field.NewPath("spec", "request", "subject"), internalcsr.MaxSubjectRDNs+1, internalcsr.MaxSubjectRDNs),
+				}.ToAggregate().Error(),
+			},
+		},
 		"if no allowed defined, all attributes set in request, return Denied": {
 			request: gen.CertificateRequest("", gen.SetCertificateRequestCSR(csrFrom(t,
 				gen.SetCSRCommonName("hello-world"),
@@ -484,3 +503,44 @@ func csrFrom(t *testing.T, mods ...gen.CSRModifier) []byte {
 	}
 	return csr
 }
+
+// Fuzz_Evaluate ensures that evaluating arbitrary request bytes against a
+// policy which exercises every allowed field never panics, and that requests
+// exceeding parser limits are denied rather than returned as errors.
+func Fuzz_Evaluate(f *testing.F) {
+	seed := func(mods ...gen.CSRModifier) []byte {
+		csr, _, err := gen.CSR(x509.ECDSA, mods...)
+		if err != nil {
+			f.Fatal(err)
+		}
+		return csr
+	}
+
+	f.Add([]byte(""))
+	f.Add(seed(gen.SetCSRCommonName("example.com"), gen.SetCSRDNSNames("example.com", "*.example.com")))
+	f.Add(seed(gen.SetCSRIPAddressesFromStrings("10.0.0.1"), gen.SetCSRURIsFromStrings("spiffe://example.com/foo"), gen.SetCSREmails([]string{"foo@example.com"})))
+
+	policy := &policyapi.CertificateRequestPolicy{
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Allowed: &policyapi.CertificateRequestPolicyAllowed{
+				CommonName:     &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("*")},
+				DNSNames:       &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				IPAddresses:    &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				URIs:           &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				EmailAddresses: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				Subject: &policyapi.CertificateRequestPolicyAllowedX509Subject{
+					Organizations: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+					SerialNumber:  &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("*")},
+				},
+			},
+		},
+	}
+
+	f.Fuzz(func(t *testing.T, request []byte) {
+		cr := gen.CertificateRequest("", gen.SetCertificateRequestCSR(request))
+		response, err := Approver().Evaluate(context.TODO(), policy, cr)
+		if err != nil && response != (approver.EvaluationResponse{}) {
+			t.Fatalf("unexpected non-empty response on error: %v", response)
+		}
+	})
+}
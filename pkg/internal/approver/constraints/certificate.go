@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constraints
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/pkg/util/pki"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// evaluateOwningCertificate returns the differences between the subject and
+// SANs of the request, and those defined on the Certificate which owns the
+// request. Requests which are not owned by an existing Certificate are
+// reported as a violation.
+func (c *constraints) evaluateOwningCertificate(ctx context.Context, fldPath *field.Path, request *cmapi.CertificateRequest, csr *x509.CertificateRequest) (field.ErrorList, error) {
+	owner := metav1.GetControllerOf(request)
+	if owner == nil || owner.Kind != cmapi.CertificateKind || !isCertManagerGroup(owner.APIVersion) {
+		return field.ErrorList{field.Required(fldPath, "request must be owned by a Certificate")}, nil
+	}
+
+	if c.reader == nil {
+		return nil, errors.New("constraints approver has not been prepared with a client to get Certificates")
+	}
+
+	var crt cmapi.Certificate
+	if err := c.reader.Get(ctx, client.ObjectKey{Namespace: request.Namespace, Name: owner.Name}, &crt); apierrors.IsNotFound(err) {
+		return field.ErrorList{field.Invalid(fldPath, owner.Name, "owning Certificate does not exist")}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get owning Certificate %q: %w", owner.Name, err)
+	}
+
+	// Guard against a Certificate which has been re-created with the same
+	// name since the request was created.
+	if crt.UID != owner.UID {
+		return field.ErrorList{field.Invalid(fldPath, owner.Name, "owning Certificate does not exist")}, nil
+	}
+
+	var el field.ErrorList
+
+	expSubject, err := certificateSubject(&crt)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath.Child("subject"), crt.Spec.LiteralSubject, fmt.Sprintf("failed to parse literal subject of owning Certificate: %s", err))}, nil
+	}
+	var subject pkix.RDNSequence
+	if _, err := asn1.Unmarshal(csr.RawSubject, &subject); err != nil {
+		return nil, fmt.Errorf("failed to parse request subject: %w", err)
+	}
+	if subject.String() != expSubject.String() {
+		el = append(el, field.Invalid(fldPath.Child("subject"), subject.String(), fmt.Sprintf("must match owning Certificate subject %q", expSubject.String())))
+	}
+
+	var csrIPs []string
+	for _, ip := range csr.IPAddresses {
+		csrIPs = append(csrIPs, ip.String())
+	}
+	var crtIPs []string
+	for _, ip := range crt.Spec.IPAddresses {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			ip = parsed.String()
+		}
+		crtIPs = append(crtIPs, ip)
+	}
+
+	el = append(el, compareNames(fldPath.Child("dnsNames"), csr.DNSNames, crt.Spec.DNSNames)...)
+	el = append(el, compareNames(fldPath.Child("ipAddresses"), csrIPs, crtIPs)...)
+	el = append(el, compareNames(fldPath.Child("uris"), pki.URLsToString(csr.URIs), crt.Spec.URIs)...)
+	el = append(el, compareNames(fldPath.Child("emailAddresses"), csr.EmailAddresses, crt.Spec.EmailAddresses)...)
+
+	return el, nil
+}
+
+// evaluateOwnerNamespace returns a violation for each owner reference of the
+// request to a Certificate which does not resolve to a Certificate with the
+// same UID in the namespace of the request. Such references either refer to
+// an object in another namespace, or to no object at all, and may have been
+// spoofed to make the request appear to be owned by a Certificate.
+func (c *constraints) evaluateOwnerNamespace(ctx context.Context, fldPath *field.Path, request *cmapi.CertificateRequest) (field.ErrorList, error) {
+	var el field.ErrorList
+	for _, owner := range request.OwnerReferences {
+		if owner.Kind != cmapi.CertificateKind || !isCertManagerGroup(owner.APIVersion) {
+			continue
+		}
+
+		if c.reader == nil {
+			return nil, errors.New("constraints approver has not been prepared with a client to get Certificates")
+		}
+
+		var crt cmapi.Certificate
+		if err := c.reader.Get(ctx, client.ObjectKey{Namespace: request.Namespace, Name: owner.Name}, &crt); apierrors.IsNotFound(err) {
+			el = append(el, field.Invalid(fldPath, owner.Name, fmt.Sprintf("owner Certificate does not exist in namespace %q", request.Namespace)))
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get owner Certificate %q: %w", owner.Name, err)
+		}
+
+		if crt.UID != owner.UID {
+			el = append(el, field.Invalid(fldPath, owner.Name, fmt.Sprintf("owner reference UID %q does not match Certificate in namespace %q", owner.UID, request.Namespace)))
+		}
+	}
+
+	return el, nil
+}
+
+// certificateSubject returns the subject that cert-manager encodes into
+// requests for the given Certificate.
+func certificateSubject(crt *cmapi.Certificate) (pkix.RDNSequence, error) {
+	if len(crt.Spec.LiteralSubject) > 0 {
+		return pki.UnmarshalSubjectStringToRDNSequence(crt.Spec.LiteralSubject)
+	}
+
+	subject := pki.SubjectForCertificate(crt)
+	return pkix.Name{
+		Country:            subject.Countries,
+		Organization:       subject.Organizations,
+		OrganizationalUnit: subject.OrganizationalUnits,
+		Locality:           subject.Localities,
+		Province:           subject.Provinces,
+		StreetAddress:      subject.StreetAddresses,
+		PostalCode:         subject.PostalCodes,
+		SerialNumber:       subject.SerialNumber,
+		CommonName:         crt.Spec.CommonName,
+	}.ToRDNSequence(), nil
+}
+
+// compareNames returns an error if the names in the request are not exactly
+// the names defined on the owning Certificate, reporting the names which are
+// extra and missing in the request.
+func compareNames(fldPath *field.Path, requested, expected []string) field.ErrorList {
+	requestedSet, expectedSet := sets.New(requested...), sets.New(expected...)
+	if requestedSet.Equal(expectedSet) {
+		return nil
+	}
+
+	var details []string
+	if extra := sets.List(requestedSet.Difference(expectedSet)); len(extra) > 0 {
+		details = append(details, fmt.Sprintf("not in owning Certificate %v", extra))
+	}
+	if missing := sets.List(expectedSet.Difference(requestedSet)); len(missing) > 0 {
+		details = append(details, fmt.Sprintf("missing from request %v", missing))
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, sets.List(requestedSet), strings.Join(details, ", "))}
+}
+
+// isCertManagerGroup returns true if the given API version is in the
+// cert-manager.io group.
+func isCertManagerGroup(apiVersion string) bool {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	return err == nil && gv.Group == cmapi.SchemeGroupVersion.Group
+}
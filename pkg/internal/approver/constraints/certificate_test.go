@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constraints
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+)
+
+func Test_EvaluateOwningCertificate(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateNamespace("test-ns"),
+		gen.SetCertificateUID("test-uid"),
+		gen.SetCertificateCommonName("example.com"),
+		gen.SetCertificateDNSNames("example.com", "www.example.com"),
+		gen.SetCertificateIPs("10.0.0.1"),
+	)
+
+	csrFor := func(t *testing.T, crt *cmapi.Certificate) []byte {
+		csr, _, err := gen.CSRForCertificate(crt)
+		require.NoError(t, err)
+		return csr
+	}
+
+	ownedBy := func(uid types.UID) metav1.OwnerReference {
+		return *metav1.NewControllerRef(&metav1.ObjectMeta{Name: "test-crt", UID: uid}, cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateKind))
+	}
+
+	fldPath := field.NewPath("spec", "constraints", "matchOwningCertificate")
+
+	tests := map[string]struct {
+		request     *cmapi.CertificateRequest
+		expResponse approver.EvaluationResponse
+	}{
+		"a request which is not owned by a Certificate should be denied": {
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.SetCertificateRequestCSR(csrFor(t, crt)),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result:  approver.ResultDenied,
+				Message: field.ErrorList{field.Required(fldPath, "request must be owned by a Certificate")}.ToAggregate().Error(),
+			},
+		},
+		"a request owned by a Certificate which no longer exists should be denied": {
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.SetCertificateRequestCSR(csrFor(t, crt)),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("other-uid")),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result:  approver.ResultDenied,
+				Message: field.ErrorList{field.Invalid(fldPath, "test-crt", "owning Certificate does not exist")}.ToAggregate().Error(),
+			},
+		},
+		"a request matching its owning Certificate should not be denied": {
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.SetCertificateRequestCSR(csrFor(t, crt)),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("test-uid")),
+			),
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"a request with a different subject and extra and missing names should be denied": {
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.SetCertificateRequestCSR(csrFor(t, gen.CertificateFrom(crt,
+					gen.SetCertificateCommonName("evil.com"),
+					gen.SetCertificateDNSNames("example.com", "evil.com"),
+				))),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("test-uid")),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.Invalid(fldPath.Child("subject"), "CN=evil.com", `must match owning Certificate subject "CN=example.com"`),
+					field.Invalid(fldPath.Child("dnsNames"), []string{"evil.com", "example.com"}, "not in owning Certificate [evil.com], missing from request [www.example.com]"),
+				}.ToAggregate().Error(),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &constraints{
+				reader: fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(crt).Build(),
+			}
+
+			response, err := c.Evaluate(context.TODO(), &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Constraints: &policyapi.CertificateRequestPolicyConstraints{MatchOwningCertificate: ptr.To(true)},
+				},
+			}, test.request)
+			require.NoError(t, err)
+			assert.Equal(t, test.expResponse, response)
+		})
+	}
+}
+
+func Test_EvaluateOwnerNamespace(t *testing.T) {
+	crt := gen.Certificate("test-crt",
+		gen.SetCertificateNamespace("test-ns"),
+		gen.SetCertificateUID("test-uid"),
+	)
+
+	ownedBy := func(uid types.UID) metav1.OwnerReference {
+		return *metav1.NewControllerRef(&metav1.ObjectMeta{Name: "test-crt", UID: uid}, cmapi.SchemeGroupVersion.WithKind(cmapi.CertificateKind))
+	}
+
+	fldPath := field.NewPath("spec", "constraints", "verifyOwnerNamespace")
+
+	tests := map[string]struct {
+		enforce     bool
+		constraints *policyapi.CertificateRequestPolicyConstraints
+		request     *cmapi.CertificateRequest
+		expResponse approver.EvaluationResponse
+	}{
+		"a request owned by a Certificate in its namespace should not be denied": {
+			constraints: &policyapi.CertificateRequestPolicyConstraints{VerifyOwnerNamespace: ptr.To(true)},
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("test-uid")),
+			),
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"a request without Certificate owners should not be denied": {
+			constraints: &policyapi.CertificateRequestPolicyConstraints{VerifyOwnerNamespace: ptr.To(true)},
+			request:     gen.CertificateRequest("test-cr", gen.SetCertificateRequestNamespace("test-ns")),
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"a request owned by a Certificate in another namespace should be denied": {
+			constraints: &policyapi.CertificateRequestPolicyConstraints{VerifyOwnerNamespace: ptr.To(true)},
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("other-ns"),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("test-uid")),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result:  approver.ResultDenied,
+				Message: field.ErrorList{field.Invalid(fldPath, "test-crt", `owner Certificate does not exist in namespace "other-ns"`)}.ToAggregate().Error(),
+			},
+		},
+		"a request whose owner reference UID does not match the Certificate should be denied": {
+			constraints: &policyapi.CertificateRequestPolicyConstraints{VerifyOwnerNamespace: ptr.To(true)},
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("spoofed-uid")),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result:  approver.ResultDenied,
+				Message: field.ErrorList{field.Invalid(fldPath, "test-crt", `owner reference UID "spoofed-uid" does not match Certificate in namespace "test-ns"`)}.ToAggregate().Error(),
+			},
+		},
+		"if enforced, a policy without constraints should deny a spoofed owner": {
+			enforce: true,
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("spoofed-uid")),
+			),
+			expResponse: approver.EvaluationResponse{
+				Result:  approver.ResultDenied,
+				Message: field.ErrorList{field.Invalid(fldPath, "test-crt", `owner reference UID "spoofed-uid" does not match Certificate in namespace "test-ns"`)}.ToAggregate().Error(),
+			},
+		},
+		"if not enforced, a policy without the constraint should not verify owners": {
+			request: gen.CertificateRequest("test-cr",
+				gen.SetCertificateRequestNamespace("test-ns"),
+				gen.AddCertificateRequestOwnerReferences(ownedBy("spoofed-uid")),
+			),
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &constraints{
+				reader:                fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(crt).Build(),
+				enforceOwnerNamespace: test.enforce,
+			}
+
+			response, err := c.Evaluate(context.TODO(), &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{Constraints: test.constraints},
+			}, test.request)
+			require.NoError(t, err)
+			assert.Equal(t, test.expResponse, response)
+		})
+	}
+}
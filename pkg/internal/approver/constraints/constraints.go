@@ -21,6 +21,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
@@ -35,35 +36,69 @@ func init() {
 
 // Approver returns an instance on the constraints approver.
 func Approver() approver.Interface {
-	return constraints{}
+	return &constraints{}
 }
 
 // constraints is a base approver-policy Approver that is responsible for
 // ensuring incoming requests satisfy the constraints defined on
 // CertificateRequestPolicies. It is expected that constraints must _always_ be
 // registered for all approver-policy builds.
-type constraints struct{}
+type constraints struct {
+	// reader is used to fetch the Certificate owning a request. It is the
+	// shared enrichment cache, or the API reader if no cache was set. The
+	// TTL of Certificates in the cache bounds how stale a Certificate a
+	// request may be compared against.
+	reader client.Reader
+
+	// enrichment is the shared enrichment cache, if set.
+	enrichment client.Reader
+
+	// enforceOwnerNamespace applies the owner namespace constraint to every
+	// policy, whether or not it sets spec.constraints.verifyOwnerNamespace.
+	enforceOwnerNamespace bool
+}
 
 // Name of Approver is "constraints"
-func (c constraints) Name() string {
+func (c *constraints) Name() string {
 	return "constraints"
 }
 
-// RegisterFlags is a no-op, constraints doesn't need any flags.
-func (c constraints) RegisterFlags(_ *pflag.FlagSet) {}
+// Scope of constraints is every request.
+func (c *constraints) Scope() approver.Scope {
+	return approver.Scope{}
+}
+
+// RegisterFlags registers the flag enforcing the owner namespace constraint
+// for all policies.
+func (c *constraints) RegisterFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&c.enforceOwnerNamespace, "enforce-owner-namespace", false,
+		"Require that the owner references of every CertificateRequest to Certificates resolve to a Certificate in the "+
+			"namespace of the request, as if every CertificateRequestPolicy set spec.constraints.verifyOwnerNamespace.")
+}
+
+// SetEnrichmentReader sets the shared enrichment cache, used to fetch the
+// Certificate owning a request once prepared.
+func (c *constraints) SetEnrichmentReader(reader client.Reader) {
+	c.enrichment = reader
+}
 
-// Prepare is a no-op, constraints doesn't need to prepare anything.
-func (c constraints) Prepare(_ context.Context, _ logr.Logger, _ manager.Manager) error {
+// Prepare sets the reader used to fetch the Certificate owning a request to
+// the enrichment cache, or the API reader if it was not set.
+func (c *constraints) Prepare(_ context.Context, _ logr.Logger, mgr manager.Manager) error {
+	c.reader = c.enrichment
+	if c.reader == nil {
+		c.reader = mgr.GetAPIReader()
+	}
 	return nil
 }
 
 // Ready always returns ready, constraints doesn't have any dependencies to
 // block readiness.
-func (c constraints) Ready(_ context.Context, _ *policyapi.CertificateRequestPolicy) (approver.ReconcilerReadyResponse, error) {
+func (c *constraints) Ready(_ context.Context, _ *policyapi.CertificateRequestPolicy) (approver.ReconcilerReadyResponse, error) {
 	return approver.ReconcilerReadyResponse{Ready: true}, nil
 }
 
 // constraints never needs to manually enqueue policies.
-func (c constraints) EnqueueChan() <-chan string {
+func (c *constraints) EnqueueChan() <-chan string {
 	return nil
 }
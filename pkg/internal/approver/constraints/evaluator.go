@@ -21,15 +21,19 @@ import (
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	utilpki "github.com/cert-manager/cert-manager/pkg/util/pki"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
 )
 
 // Evaluate evaluates whether the given CertificateRequest satisfies the
@@ -38,9 +42,12 @@ import (
 // permitted by the passed policy.
 // If the request is denied by the constraints an explanation is returned.
 // An error signals that the policy couldn't be evaluated to completion.
-func (c constraints) Evaluate(_ context.Context, policy *policyapi.CertificateRequestPolicy, request *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+func (c *constraints) Evaluate(ctx context.Context, policy *policyapi.CertificateRequestPolicy, request *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+	verifyOwnerNamespace := c.enforceOwnerNamespace ||
+		(policy.Spec.Constraints != nil && ptr.Deref(policy.Spec.Constraints.VerifyOwnerNamespace, false))
+
 	// If no constraints defined, exit early.
-	if policy.Spec.Constraints == nil {
+	if policy.Spec.Constraints == nil && !verifyOwnerNamespace {
 		return approver.EvaluationResponse{Result: approver.ResultNotDenied, Message: ""}, nil
 	}
 
@@ -51,6 +58,9 @@ func (c constraints) Evaluate(_ context.Context, policy *policyapi.CertificateRe
 		consts  = policy.Spec.Constraints
 		fldPath = field.NewPath("spec", "constraints")
 	)
+	if consts == nil {
+		consts = new(policyapi.CertificateRequestPolicyConstraints)
+	}
 
 	if consts.MaxDuration != nil {
 		// If the request contains no duration or the maxDuration is smaller than requested, append error.
@@ -70,30 +80,43 @@ func (c constraints) Evaluate(_ context.Context, policy *policyapi.CertificateRe
 		}
 	}
 
-	if consts.PrivateKey != nil {
-		fldPath := fldPath.Child("privateKey")
-
-		// Decode CSR from CertificateRequest
-		csr, err := utilpki.DecodeX509CertificateRequestBytes(request.Spec.Request)
+	if verifyOwnerNamespace {
+		ownerErrs, err := c.evaluateOwnerNamespace(ctx, fldPath.Child("verifyOwnerNamespace"), request)
 		if err != nil {
 			return approver.EvaluationResponse{}, err
 		}
+		el = append(el, ownerErrs...)
+	}
 
-		alg, size, err := decodePublicKey(csr.PublicKey)
+	if consts.PrivateKey != nil || ptr.Deref(consts.MatchOwningCertificate, false) || ptr.Deref(consts.PublicIssuance, false) {
+		// Decode CSR from CertificateRequest
+		csr, err := internalcsr.Decode(request.Spec.Request)
 		if err != nil {
+			var limitErr *internalcsr.LimitError
+			if errors.As(err, &limitErr) {
+				return approver.EvaluationResponse{Result: approver.ResultDenied, Message: limitErr.Error()}, nil
+			}
 			return approver.EvaluationResponse{}, err
 		}
 
-		if consts.PrivateKey.Algorithm != nil && *consts.PrivateKey.Algorithm != alg {
-			el = append(el, field.Invalid(fldPath.Child("algorithm"), string(alg), string(*consts.PrivateKey.Algorithm)))
+		if consts.PrivateKey != nil {
+			privateKeyErrs, err := evaluatePrivateKey(fldPath.Child("privateKey"), consts.PrivateKey, csr)
+			if err != nil {
+				return approver.EvaluationResponse{}, err
+			}
+			el = append(el, privateKeyErrs...)
 		}
 
-		if consts.PrivateKey.MaxSize != nil && *consts.PrivateKey.MaxSize < size {
-			el = append(el, field.Invalid(fldPath.Child("maxSize"), strconv.Itoa(size), strconv.Itoa(*consts.PrivateKey.MaxSize)))
+		if ptr.Deref(consts.MatchOwningCertificate, false) {
+			certificateErrs, err := c.evaluateOwningCertificate(ctx, fldPath.Child("matchOwningCertificate"), request, csr)
+			if err != nil {
+				return approver.EvaluationResponse{}, err
+			}
+			el = append(el, certificateErrs...)
 		}
 
-		if consts.PrivateKey.MinSize != nil && *consts.PrivateKey.MinSize > size {
-			el = append(el, field.Invalid(fldPath.Child("minSize"), strconv.Itoa(size), strconv.Itoa(*consts.PrivateKey.MinSize)))
+		if ptr.Deref(consts.PublicIssuance, false) {
+			el = append(el, evaluatePublicIssuance(fldPath.Child("publicIssuance"), request, csr)...)
 		}
 	}
 
@@ -106,6 +129,52 @@ func (c constraints) Evaluate(_ context.Context, policy *policyapi.CertificateRe
 	return approver.EvaluationResponse{Result: approver.ResultNotDenied}, nil
 }
 
+// evaluatePrivateKey returns a list of violations of the private key
+// constraints by the public key of the request.
+func evaluatePrivateKey(fldPath *field.Path, consts *policyapi.CertificateRequestPolicyConstraintsPrivateKey, csr *x509.CertificateRequest) (field.ErrorList, error) {
+	var el field.ErrorList
+
+	alg, size, err := decodePublicKey(csr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if consts.Algorithm != nil && *consts.Algorithm != alg {
+		el = append(el, field.Invalid(fldPath.Child("algorithm"), string(alg), string(*consts.Algorithm)))
+	}
+
+	if consts.MaxSize != nil && *consts.MaxSize < size {
+		el = append(el, field.Invalid(fldPath.Child("maxSize"), strconv.Itoa(size), strconv.Itoa(*consts.MaxSize)))
+	}
+
+	if consts.MinSize != nil && *consts.MinSize > size {
+		el = append(el, field.Invalid(fldPath.Child("minSize"), strconv.Itoa(size), strconv.Itoa(*consts.MinSize)))
+	}
+
+	switch pubKey := csr.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if len(consts.ECDSACurves) > 0 {
+			curve := policyapi.ECDSACurve(pubKey.Curve.Params().Name)
+			if !slices.Contains(consts.ECDSACurves, curve) {
+				el = append(el, field.NotSupported(fldPath.Child("ecdsaCurves"), string(curve), consts.ECDSACurves))
+			}
+		}
+
+	case *rsa.PublicKey:
+		if consts.MinRSAPublicExponent != nil {
+			if pubKey.E < *consts.MinRSAPublicExponent {
+				el = append(el, field.Invalid(fldPath.Child("minRSAPublicExponent"), strconv.Itoa(pubKey.E),
+					fmt.Sprintf("RSA public exponent must be at least %d", *consts.MinRSAPublicExponent)))
+			}
+			if pubKey.E%2 == 0 {
+				el = append(el, field.Invalid(fldPath.Child("minRSAPublicExponent"), strconv.Itoa(pubKey.E), "RSA public exponent must be odd"))
+			}
+		}
+	}
+
+	return el, nil
+}
+
 // decodePublicKey will return the algorithm and size of the given public key.
 // If the public key cannot be decoded, an error is returned.
 func decodePublicKey(pub interface{}) (cmapi.PrivateKeyAlgorithm, int, error) {
@@ -116,7 +185,7 @@ func decodePublicKey(pub interface{}) (cmapi.PrivateKeyAlgorithm, int, error) {
 	case *ecdsa.PublicKey:
 		return cmapi.ECDSAKeyAlgorithm, pubKey.Curve.Params().BitSize, nil
 
-	case *ed25519.PublicKey:
+	case ed25519.PublicKey:
 		return cmapi.Ed25519KeyAlgorithm, -1, nil
 
 	default:
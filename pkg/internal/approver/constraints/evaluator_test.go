@@ -35,8 +35,9 @@ import (
 
 func Test_Evaluate(t *testing.T) {
 	var (
-		ecdsaAlg = cmapi.ECDSAKeyAlgorithm
-		rsaAlg   = cmapi.RSAKeyAlgorithm
+		ecdsaAlg   = cmapi.ECDSAKeyAlgorithm
+		rsaAlg     = cmapi.RSAKeyAlgorithm
+		ed25519Alg = cmapi.Ed25519KeyAlgorithm
 	)
 
 	tests := map[string]struct {
@@ -58,8 +59,8 @@ func Test_Evaluate(t *testing.T) {
 			),
 			policy: policyapi.CertificateRequestPolicySpec{
 				Constraints: &policyapi.CertificateRequestPolicyConstraints{
-					MinDuration: &metav1.Duration{Duration: time.Hour},
-					MaxDuration: &metav1.Duration{Duration: time.Hour * 24},
+					MinDuration: &policyapi.Duration{Duration: time.Hour},
+					MaxDuration: &policyapi.Duration{Duration: time.Hour * 24},
 				},
 			},
 			expResponse: approver.EvaluationResponse{
@@ -76,8 +77,8 @@ func Test_Evaluate(t *testing.T) {
 			),
 			policy: policyapi.CertificateRequestPolicySpec{
 				Constraints: &policyapi.CertificateRequestPolicyConstraints{
-					MinDuration: &metav1.Duration{Duration: time.Hour},
-					MaxDuration: &metav1.Duration{Duration: time.Hour * 24},
+					MinDuration: &policyapi.Duration{Duration: time.Hour},
+					MaxDuration: &policyapi.Duration{Duration: time.Hour * 24},
 				},
 			},
 			expResponse: approver.EvaluationResponse{
@@ -91,8 +92,8 @@ func Test_Evaluate(t *testing.T) {
 			),
 			policy: policyapi.CertificateRequestPolicySpec{
 				Constraints: &policyapi.CertificateRequestPolicyConstraints{
-					MinDuration: &metav1.Duration{Duration: time.Hour},
-					MaxDuration: &metav1.Duration{Duration: time.Hour * 24},
+					MinDuration: &policyapi.Duration{Duration: time.Hour},
+					MaxDuration: &policyapi.Duration{Duration: time.Hour * 24},
 				},
 			},
 			expResponse: approver.EvaluationResponse{
@@ -154,6 +155,157 @@ func Test_Evaluate(t *testing.T) {
 				}.ToAggregate().Error(),
 			},
 		},
+		"if constraints contains an Ed25519 algorithm and CSR uses an Ed25519 key, return NotDenied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.Ed25519)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						Algorithm: &ed25519Alg,
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"if constraints contains ECDSA curves and CSR uses an allowed curve, return NotDenied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						ECDSACurves:          []policyapi.ECDSACurve{policyapi.ECDSACurveP256, policyapi.ECDSACurveP384},
+						MinRSAPublicExponent: ptr.To(65537),
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"if constraints contains ECDSA curves and CSR uses a curve which is not allowed, return Denied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						ECDSACurves: []policyapi.ECDSACurve{policyapi.ECDSACurveP384, policyapi.ECDSACurveP521},
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.NotSupported(field.NewPath("spec.constraints.privateKey.ecdsaCurves"), "P-256", []string{"P-384", "P-521"}),
+				}.ToAggregate().Error(),
+			},
+		},
+		"if constraints contains ECDSA curves and CSR uses an RSA key, return NotDenied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.RSA)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						ECDSACurves: []policyapi.ECDSACurve{policyapi.ECDSACurveP384},
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"if constraints contains a minimum RSA public exponent which the CSR meets, return NotDenied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.RSA)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						MinRSAPublicExponent: ptr.To(65537),
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"if constraints contains a minimum RSA public exponent larger than the CSR's, return Denied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.RSA)),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+						MinRSAPublicExponent: ptr.To(65539),
+					},
+				},
+			},
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.Invalid(field.NewPath("spec.constraints.privateKey.minRSAPublicExponent"), "65537", "RSA public exponent must be at least 65539"),
+				}.ToAggregate().Error(),
+			},
+		},
+		"if public issuance is enabled and the request is publicly issuable, return NotDenied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA,
+					gen.SetCSRDNSNames("example.com", "www.example.org"),
+					gen.SetCSRIPAddressesFromStrings("8.8.8.8", "2606:4700::1111"),
+				)),
+				gen.SetCertificateRequestDuration(&metav1.Duration{Duration: 90 * 24 * time.Hour}),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PublicIssuance: ptr.To(true),
+				},
+			},
+			expResponse: approver.EvaluationResponse{Result: approver.ResultNotDenied},
+		},
+		"if public issuance is enabled and the request has internal names, reserved IPs and a long duration, return Denied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA,
+					gen.SetCSRDNSNames("example.com", "db", "app.corp", "printer.home.arpa", "svc.Cluster.Local."),
+					gen.SetCSRIPAddressesFromStrings("10.0.0.1", "100.64.1.1", "127.0.0.1", "::ffff:192.168.1.1", "fe80::1", "2001:db8::1"),
+				)),
+				gen.SetCertificateRequestDuration(&metav1.Duration{Duration: 399 * 24 * time.Hour}),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PublicIssuance: ptr.To(true),
+				},
+			},
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "9576h0m0s", "publicly trusted certificates must not be valid for more than 398 days"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "db", "internal DNS names must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "app.corp", "internal DNS names must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "printer.home.arpa", "internal DNS names must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "svc.Cluster.Local.", "internal DNS names must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "10.0.0.1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "100.64.1.1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "127.0.0.1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "192.168.1.1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "fe80::1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "2001:db8::1", "reserved IP addresses must not be requested for publicly trusted certificates"),
+				}.ToAggregate().Error(),
+			},
+		},
+		"if public issuance is enabled and the request has no duration, return Denied": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA, gen.SetCSRDNSNames("example.com"))),
+				gen.SetCertificateRequestDuration(nil),
+			),
+			policy: policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					PublicIssuance: ptr.To(true),
+				},
+			},
+			expResponse: approver.EvaluationResponse{
+				Result: approver.ResultDenied,
+				Message: field.ErrorList{
+					field.Invalid(field.NewPath("spec.constraints.publicIssuance"), "nil", "a duration must be requested for publicly trusted certificates"),
+				}.ToAggregate().Error(),
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constraints
+
+import (
+	"crypto/x509"
+	"net/netip"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PublicIssuanceMaxDuration is the maximum validity period of publicly
+// trusted TLS server certificates, as set by the CA/Browser Forum Baseline
+// Requirements section 6.3.2.
+const PublicIssuanceMaxDuration = 398 * 24 * time.Hour
+
+// internalDomainSuffixes are top level domains, and special use domains, which
+// are not publicly resolvable and so cannot be included in publicly trusted
+// certificates. This includes names reserved by RFC 2606, RFC 6761, RFC 6762
+// and RFC 8375, as well as commonly used internal top level domains which the
+// Baseline Requirements treat as Internal Names.
+var internalDomainSuffixes = []string{
+	"corp",
+	"example",
+	"home",
+	"home.arpa",
+	"internal",
+	"intranet",
+	"invalid",
+	"lan",
+	"local",
+	"localdomain",
+	"localhost",
+	"private",
+	"test",
+}
+
+// reservedPrefixes are IP address ranges which are reserved by IANA and are
+// not already covered by the checks of netip.Addr, such as IsPrivate.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),   // Shared address space, RFC 6598
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments, RFC 6890
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1, RFC 5737
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking, RFC 2544
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2, RFC 5737
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3, RFC 5737
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, RFC 1112
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation, RFC 3849
+}
+
+// evaluatePublicIssuance returns a list of violations of the CA/Browser Forum
+// Baseline Requirements by the request which can be detected before issuance.
+func evaluatePublicIssuance(fldPath *field.Path, request *cmapi.CertificateRequest, csr *x509.CertificateRequest) field.ErrorList {
+	var el field.ErrorList
+
+	if request.Spec.Duration == nil {
+		el = append(el, field.Invalid(fldPath, request.Spec.Duration.String(), "a duration must be requested for publicly trusted certificates"))
+	} else if request.Spec.Duration.Duration > PublicIssuanceMaxDuration {
+		el = append(el, field.Invalid(fldPath, request.Spec.Duration.Duration.String(), "publicly trusted certificates must not be valid for more than 398 days"))
+	}
+
+	for _, dnsName := range csr.DNSNames {
+		if isInternalDNSName(dnsName) {
+			el = append(el, field.Invalid(fldPath, dnsName, "internal DNS names must not be requested for publicly trusted certificates"))
+		}
+	}
+
+	for _, ip := range csr.IPAddresses {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok || isReservedAddr(addr.Unmap()) {
+			el = append(el, field.Invalid(fldPath, ip.String(), "reserved IP addresses must not be requested for publicly trusted certificates"))
+		}
+	}
+
+	return el
+}
+
+// isInternalDNSName returns true if the DNS name is a single label, or is
+// under a domain which is not publicly resolvable.
+func isInternalDNSName(dnsName string) bool {
+	name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+	if !strings.Contains(name, ".") {
+		return true
+	}
+	for _, suffix := range internalDomainSuffixes {
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReservedAddr returns true if the IP address is not a publicly routable
+// unicast address.
+func isReservedAddr(addr netip.Addr) bool {
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -19,9 +19,11 @@ package constraints
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
@@ -29,7 +31,7 @@ import (
 
 // Validate validates that the processed CertificateRequestPolicy has valid
 // constraint fields defined and there are no parsing errors in the values.
-func (c constraints) Validate(_ context.Context, policy *policyapi.CertificateRequestPolicy) (approver.WebhookValidationResponse, error) {
+func (c *constraints) Validate(_ context.Context, policy *policyapi.CertificateRequestPolicy) (approver.WebhookValidationResponse, error) {
 	// If no constraints are defined we can exit early
 	if policy.Spec.Constraints == nil {
 		return approver.WebhookValidationResponse{
@@ -78,6 +80,26 @@ func (c constraints) Validate(_ context.Context, policy *policyapi.CertificateRe
 		if maxSize != nil && minSize != nil && *maxSize < *minSize {
 			el = append(el, field.Invalid(fldPath.Child("maxSize"), *maxSize, "maxSize must be the same value as minSize or larger"))
 		}
+
+		supportedCurves := []policyapi.ECDSACurve{policyapi.ECDSACurveP256, policyapi.ECDSACurveP384, policyapi.ECDSACurveP521}
+		for i, curve := range consts.PrivateKey.ECDSACurves {
+			if !slices.Contains(supportedCurves, curve) {
+				el = append(el, field.NotSupported(fldPath.Child("ecdsaCurves").Index(i), curve, supportedCurves))
+			}
+		}
+
+		if alg := consts.PrivateKey.Algorithm; alg != nil {
+			if len(consts.PrivateKey.ECDSACurves) > 0 && *alg != cmapi.ECDSAKeyAlgorithm {
+				el = append(el, field.Invalid(fldPath.Child("ecdsaCurves"), consts.PrivateKey.ECDSACurves, fmt.Sprintf("ecdsaCurves cannot be defined with algorithm constraint %s", *alg)))
+			}
+			if consts.PrivateKey.MinRSAPublicExponent != nil && *alg != cmapi.RSAKeyAlgorithm {
+				el = append(el, field.Invalid(fldPath.Child("minRSAPublicExponent"), *consts.PrivateKey.MinRSAPublicExponent, fmt.Sprintf("minRSAPublicExponent cannot be defined with algorithm constraint %s", *alg)))
+			}
+		}
+
+		if minExp := consts.PrivateKey.MinRSAPublicExponent; minExp != nil && *minExp < 3 {
+			el = append(el, field.Invalid(fldPath.Child("minRSAPublicExponent"), *minExp, "must be 3 or larger"))
+		}
 	}
 
 	if consts.MaxDuration != nil && consts.MinDuration != nil && consts.MaxDuration.Duration < consts.MinDuration.Duration {
@@ -89,6 +111,14 @@ func (c constraints) Validate(_ context.Context, policy *policyapi.CertificateRe
 	if consts.MinDuration != nil && consts.MinDuration.Duration < 0 {
 		el = append(el, field.Invalid(fldPath.Child("minDuration"), consts.MinDuration.Duration.String(), "minDuration must be a value greater or equal to 0"))
 	}
+	if ptr.Deref(consts.PublicIssuance, false) {
+		if consts.MaxDuration != nil && consts.MaxDuration.Duration > PublicIssuanceMaxDuration {
+			el = append(el, field.Invalid(fldPath.Child("maxDuration"), consts.MaxDuration.Duration.String(), "maxDuration must not be larger than 398 days when publicIssuance is enabled"))
+		}
+		if consts.MinDuration != nil && consts.MinDuration.Duration > PublicIssuanceMaxDuration {
+			el = append(el, field.Invalid(fldPath.Child("minDuration"), consts.MinDuration.Duration.String(), "minDuration must not be larger than 398 days when publicIssuance is enabled"))
+		}
+	}
 
 	return approver.WebhookValidationResponse{
 		Allowed: len(el) == 0,
@@ -23,7 +23,6 @@ import (
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/stretchr/testify/assert"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 
@@ -60,8 +59,8 @@ func Test_Validate(t *testing.T) {
 							MinSize:   ptr.To(9999),
 							MaxSize:   ptr.To(-1),
 						},
-						MinDuration: &metav1.Duration{Duration: -time.Minute},
-						MaxDuration: &metav1.Duration{Duration: -2 * time.Minute},
+						MinDuration: &policyapi.Duration{Duration: -time.Minute},
+						MaxDuration: &policyapi.Duration{Duration: -2 * time.Minute},
 					},
 				},
 			},
@@ -98,6 +97,46 @@ func Test_Validate(t *testing.T) {
 				},
 			},
 		},
+		"if policy contains invalid ECDSA curve and RSA exponent constraints, expect a Allowed=false response": {
+			policy: &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Constraints: &policyapi.CertificateRequestPolicyConstraints{
+						PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+							Algorithm:            &edAlg,
+							ECDSACurves:          []policyapi.ECDSACurve{policyapi.ECDSACurveP256, "P-224"},
+							MinRSAPublicExponent: ptr.To(1),
+						},
+					},
+				},
+			},
+			expResponse: approver.WebhookValidationResponse{
+				Allowed: false,
+				Errors: field.ErrorList{
+					field.NotSupported(field.NewPath("spec.constraints.privateKey.ecdsaCurves").Index(1), policyapi.ECDSACurve("P-224"), []string{"P-256", "P-384", "P-521"}),
+					field.Invalid(field.NewPath("spec.constraints.privateKey.ecdsaCurves"), []policyapi.ECDSACurve{"P-256", "P-224"}, "ecdsaCurves cannot be defined with algorithm constraint Ed25519"),
+					field.Invalid(field.NewPath("spec.constraints.privateKey.minRSAPublicExponent"), 1, "minRSAPublicExponent cannot be defined with algorithm constraint Ed25519"),
+					field.Invalid(field.NewPath("spec.constraints.privateKey.minRSAPublicExponent"), 1, "must be 3 or larger"),
+				},
+			},
+		},
+		"if public issuance is enabled with durations larger than 398 days, expect a Allowed=false response": {
+			policy: &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Constraints: &policyapi.CertificateRequestPolicyConstraints{
+						MinDuration:    &policyapi.Duration{Duration: 399 * 24 * time.Hour},
+						MaxDuration:    &policyapi.Duration{Duration: 400 * 24 * time.Hour},
+						PublicIssuance: ptr.To(true),
+					},
+				},
+			},
+			expResponse: approver.WebhookValidationResponse{
+				Allowed: false,
+				Errors: field.ErrorList{
+					field.Invalid(field.NewPath("spec.constraints.maxDuration"), "9600h0m0s", "maxDuration must not be larger than 398 days when publicIssuance is enabled"),
+					field.Invalid(field.NewPath("spec.constraints.minDuration"), "9576h0m0s", "minDuration must not be larger than 398 days when publicIssuance is enabled"),
+				},
+			},
+		},
 		"if policy contains no validation errors, expect a Allowed=true response": {
 			policy: &policyapi.CertificateRequestPolicy{
 				Spec: policyapi.CertificateRequestPolicySpec{
@@ -107,8 +146,8 @@ func Test_Validate(t *testing.T) {
 							MinSize:   ptr.To(100),
 							MaxSize:   ptr.To(500),
 						},
-						MinDuration: &metav1.Duration{Duration: 0},
-						MaxDuration: &metav1.Duration{Duration: 2 * time.Minute},
+						MinDuration: &policyapi.Duration{Duration: 0},
+						MaxDuration: &policyapi.Duration{Duration: 2 * time.Minute},
 					},
 				},
 			},
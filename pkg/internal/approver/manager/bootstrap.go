@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+)
+
+// BootstrapOptions configures approving cert-manager's bootstrap
+// CertificateRequests, such as those issuing the CA of a cluster PKI, without
+// consulting CertificateRequestPolicies. This prevents installing
+// approver-policy with a default deny posture from deadlocking cluster PKI
+// bootstrap.
+// A request is only approved by bootstrap if it matches all configured
+// options.
+type BootstrapOptions struct {
+	// Usernames are the requesters whose requests may be approved by
+	// bootstrap, typically the cert-manager controller ServiceAccount.
+	Usernames []string
+
+	// IssuerRefs are the issuers whose requests may be approved by bootstrap.
+	IssuerRefs []cmmeta.ObjectReference
+
+	// Namespaces restricts bootstrap approval to requests in these
+	// namespaces. cert-manager creates the requests of Certificates in every
+	// namespace under its own ServiceAccount, and issuer names are only unique
+	// within a namespace, so bootstrap is never enabled for all namespaces.
+	Namespaces []string
+}
+
+// Enabled returns true if bootstrap approval has been configured. Requesters,
+// issuers and namespaces must all be given for bootstrap approval to be
+// enabled.
+func (o BootstrapOptions) Enabled() bool {
+	return len(o.Usernames) > 0 && len(o.IssuerRefs) > 0 && len(o.Namespaces) > 0
+}
+
+// bootstrap is an approver Manager which approves bootstrap requests, and
+// passes all other requests to the wrapped Manager for review.
+type bootstrap struct {
+	opts BootstrapOptions
+	next manager.Interface
+}
+
+// NewBootstrap wraps the given Manager, approving requests matching the
+// bootstrap options without review. If bootstrap is not enabled, the given
+// Manager is returned.
+func NewBootstrap(opts BootstrapOptions, next manager.Interface) manager.Interface {
+	if !opts.Enabled() {
+		return next
+	}
+	return &bootstrap{opts: opts, next: next}
+}
+
+// Review approves the request if it is a bootstrap request, otherwise the
+// request is reviewed by the wrapped Manager.
+func (b *bootstrap) Review(ctx context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+	if !slices.Contains(b.opts.Usernames, cr.Spec.Username) {
+		return b.next.Review(ctx, cr)
+	}
+
+	if !slices.Contains(b.opts.Namespaces, cr.Namespace) {
+		return b.next.Review(ctx, cr)
+	}
+
+	issuerRef := cr.Spec.IssuerRef
+	for _, ref := range b.opts.IssuerRefs {
+		if issuerRefMatches(ref, issuerRef) {
+			return manager.ReviewResponse{
+				Result:  manager.ResultApproved,
+				Message: fmt.Sprintf("Approved by bootstrap for issuer %q", FormatIssuerRef(issuerRef)),
+			}, nil
+		}
+	}
+
+	return b.next.Review(ctx, cr)
+}
+
+// issuerRefMatches returns true if the request issuer matches the configured
+// issuer. Empty kind and group on the request default to Issuer and
+// cert-manager.io, as they do in cert-manager.
+func issuerRefMatches(ref, issuerRef cmmeta.ObjectReference) bool {
+	return ref.Name == issuerRef.Name &&
+		ref.Kind == nonEmptyOrDefault(issuerRef.Kind, cmapi.IssuerKind) &&
+		ref.Group == nonEmptyOrDefault(issuerRef.Group, "cert-manager.io")
+}
+
+// ParseIssuerRef parses an issuer reference in the form
+// `<kind>.<group>/<name>`. If the group is omitted, it defaults to
+// cert-manager.io.
+func ParseIssuerRef(s string) (cmmeta.ObjectReference, error) {
+	kindGroup, name, ok := strings.Cut(s, "/")
+	if !ok || len(kindGroup) == 0 || len(name) == 0 {
+		return cmmeta.ObjectReference{}, fmt.Errorf("invalid issuer reference %q, expected <kind>.<group>/<name>", s)
+	}
+
+	kind, group, _ := strings.Cut(kindGroup, ".")
+	if len(kind) == 0 {
+		return cmmeta.ObjectReference{}, fmt.Errorf("invalid issuer reference %q, kind must not be empty", s)
+	}
+
+	return cmmeta.ObjectReference{
+		Name:  name,
+		Kind:  kind,
+		Group: nonEmptyOrDefault(group, "cert-manager.io"),
+	}, nil
+}
+
+// FormatIssuerRef formats an issuer reference in the form
+// `<kind>.<group>/<name>`, defaulting empty kind and group.
+func FormatIssuerRef(ref cmmeta.ObjectReference) string {
+	return fmt.Sprintf("%s.%s/%s", nonEmptyOrDefault(ref.Kind, cmapi.IssuerKind), nonEmptyOrDefault(ref.Group, "cert-manager.io"), ref.Name)
+}
+
+func nonEmptyOrDefault(s, d string) string {
+	if len(s) == 0 {
+		return d
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager/fake"
+)
+
+func Test_Bootstrap(t *testing.T) {
+	const username = "system:serviceaccount:cert-manager:cert-manager"
+	selfsigned := cmmeta.ObjectReference{Name: "selfsigned", Kind: "ClusterIssuer", Group: "cert-manager.io"}
+
+	reviewed := manager.ReviewResponse{Result: manager.ResultUnprocessed, Message: "reviewed"}
+	next := fake.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+		return reviewed, nil
+	})
+
+	request := func(namespace, username string, issuerRef cmmeta.ObjectReference) *cmapi.CertificateRequest {
+		return &cmapi.CertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "test"},
+			Spec:       cmapi.CertificateRequestSpec{Username: username, IssuerRef: issuerRef},
+		}
+	}
+
+	tests := map[string]struct {
+		opts        BootstrapOptions
+		cr          *cmapi.CertificateRequest
+		expResponse manager.ReviewResponse
+	}{
+		"if bootstrap is not enabled, should review the request": {
+			opts:        BootstrapOptions{Usernames: []string{username}},
+			cr:          request("cert-manager", username, selfsigned),
+			expResponse: reviewed,
+		},
+		"if bootstrap namespaces are not given, should review the request": {
+			opts:        BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{selfsigned}},
+			cr:          request("cert-manager", username, selfsigned),
+			expResponse: reviewed,
+		},
+		"if the requester is not a bootstrap username, should review the request": {
+			opts:        BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{selfsigned}, Namespaces: []string{"cert-manager"}},
+			cr:          request("cert-manager", "alice", selfsigned),
+			expResponse: reviewed,
+		},
+		"if the issuer is not a bootstrap issuer, should review the request": {
+			opts:        BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{selfsigned}, Namespaces: []string{"cert-manager"}},
+			cr:          request("cert-manager", username, cmmeta.ObjectReference{Name: "selfsigned", Kind: "Issuer", Group: "cert-manager.io"}),
+			expResponse: reviewed,
+		},
+		"if the request is not in a bootstrap namespace, should review the request": {
+			opts:        BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{selfsigned}, Namespaces: []string{"cert-manager"}},
+			cr:          request("default", username, selfsigned),
+			expResponse: reviewed,
+		},
+		"if the request matches all bootstrap options, should approve the request": {
+			opts: BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{selfsigned}, Namespaces: []string{"cert-manager"}},
+			cr:   request("cert-manager", username, selfsigned),
+			expResponse: manager.ReviewResponse{
+				Result:  manager.ResultApproved,
+				Message: `Approved by bootstrap for issuer "ClusterIssuer.cert-manager.io/selfsigned"`,
+			},
+		},
+		"if the request issuer has an empty kind and group, should default them when matching": {
+			opts: BootstrapOptions{Usernames: []string{username}, IssuerRefs: []cmmeta.ObjectReference{{Name: "ca", Kind: "Issuer", Group: "cert-manager.io"}}, Namespaces: []string{"cert-manager"}},
+			cr:   request("cert-manager", username, cmmeta.ObjectReference{Name: "ca"}),
+			expResponse: manager.ReviewResponse{
+				Result:  manager.ResultApproved,
+				Message: `Approved by bootstrap for issuer "Issuer.cert-manager.io/ca"`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			response, err := NewBootstrap(test.opts, next).Review(context.TODO(), test.cr)
+			require.NoError(t, err)
+			assert.Equal(t, test.expResponse, response)
+		})
+	}
+}
+
+func Test_ParseIssuerRef(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		expRef cmmeta.ObjectReference
+		expErr bool
+	}{
+		"kind, group and name should be parsed": {
+			input:  "AWSPCAClusterIssuer.awspca.cert-manager.io/pca",
+			expRef: cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+		},
+		"an omitted group should default to cert-manager.io": {
+			input:  "ClusterIssuer/selfsigned",
+			expRef: cmmeta.ObjectReference{Name: "selfsigned", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		},
+		"a missing name should error": {
+			input:  "ClusterIssuer.cert-manager.io",
+			expErr: true,
+		},
+		"an empty kind should error": {
+			input:  "/selfsigned",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, err := ParseIssuerRef(test.input)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expRef, ref)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+)
+
+// decoding is an approver Manager which normalizes requests with the
+// RequestDecoder of their issuer group before they are reviewed by the
+// wrapped Manager.
+type decoding struct {
+	decoders internalcsr.Decoders
+	next     manager.Interface
+}
+
+// NewDecoding wraps the given Manager, reviewing a copy of each request
+// whose `spec.request` has been normalized by the decoders. The request
+// itself is left unchanged, so callers keep the original request. If there
+// are no decoders, the given Manager is returned.
+func NewDecoding(decoders internalcsr.Decoders, next manager.Interface) manager.Interface {
+	if len(decoders) == 0 {
+		return next
+	}
+	return &decoding{decoders: decoders, next: next}
+}
+
+// Review normalizes a copy of the request, and reviews it with the wrapped
+// Manager. The normalized request is returned in the response, so that the
+// request can be analysed as it was reviewed. An error is returned if the
+// request cannot be decoded.
+func (d *decoding) Review(ctx context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+	normalized := cr.DeepCopy()
+	if err := d.decoders.Normalize(normalized); err != nil {
+		return manager.ReviewResponse{}, fmt.Errorf("failed to normalize request: %w", err)
+	}
+
+	response, err := d.next.Review(ctx, normalized)
+	if err != nil {
+		return response, err
+	}
+	response.NormalizedRequest = normalized.Spec.Request
+	return response, nil
+}
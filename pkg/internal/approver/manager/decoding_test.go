@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	approverfake "github.com/cert-manager/approver-policy/pkg/approver/fake"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager/fake"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+)
+
+func Test_Decoding(t *testing.T) {
+	decoders, err := internalcsr.NewDecoders([]approver.RequestDecoder{
+		approverfake.NewFakeRequestDecoder().WithName("jose").
+			WithScope(approver.Scope{IssuerGroups: []string{"jose.example.com"}}).
+			WithDecode(func(cr *cmapi.CertificateRequest) ([]byte, error) {
+				if string(cr.Spec.Request) == "invalid" {
+					return nil, errors.New("invalid request")
+				}
+				return []byte("decoded"), nil
+			}),
+	})
+	require.NoError(t, err)
+
+	var reviewed []byte
+	next := fake.NewFakeManager().WithReview(func(_ context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+		reviewed = cr.Spec.Request
+		return manager.ReviewResponse{Result: manager.ResultApproved}, nil
+	})
+
+	assert.Equal(t, next, NewDecoding(nil, next), "without decoders, the Manager should not be wrapped")
+
+	m := NewDecoding(decoders, next)
+	request := func(request string) *cmapi.CertificateRequest {
+		return &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{
+			IssuerRef: cmmeta.ObjectReference{Group: "jose.example.com"},
+			Request:   []byte(request),
+		}}
+	}
+
+	// The decoded request is reviewed, leaving the original unchanged.
+	cr := request("encoded")
+	response, err := m.Review(context.TODO(), cr)
+	require.NoError(t, err)
+	assert.Equal(t, manager.ResultApproved, response.Result)
+	assert.Equal(t, "decoded", string(reviewed))
+	assert.Equal(t, "decoded", string(response.NormalizedRequest), "the normalized request should be returned in the response")
+	assert.Equal(t, "encoded", string(cr.Spec.Request))
+
+	// An empty request is decoded too.
+	_, err = m.Review(context.TODO(), request(""))
+	require.NoError(t, err)
+	assert.Equal(t, "decoded", string(reviewed))
+
+	_, err = m.Review(context.TODO(), request("invalid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+)
+
+// exemptions is an approver Manager which evaluates requests that the wrapped
+// Manager did not approve under the CertificateRequestPolicyExemption named
+// by the request's override denial annotation.
+type exemptions struct {
+	lister client.Reader
+
+	// clock returns time which can be overwritten for testing.
+	clock clock.PassiveClock
+
+	// policies evaluates the exempting policy, without any predicates.
+	policies *mngr
+
+	next manager.Interface
+}
+
+// NewExemptions wraps the given Manager, evaluating requests which it does
+// not approve under the CertificateRequestPolicyExemption named by the
+// request's override denial annotation.
+func NewExemptions(lister client.Reader, evaluators []approver.Evaluator, next manager.Interface) manager.Interface {
+	return &exemptions{
+		lister:   lister,
+		clock:    clock.RealClock{},
+		policies: &mngr{lister: lister, evaluators: evaluators},
+		next:     next,
+	}
+}
+
+// Review reviews the request with the wrapped Manager. If the request is not
+// approved and names an exemption, it is evaluated against the exempting
+// policy. If the exemption does not apply, or the exempting policy does not
+// approve the request either, the response of the wrapped Manager is returned
+// with the reason the override failed appended to its message.
+func (e *exemptions) Review(ctx context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+	response, err := e.next.Review(ctx, cr)
+	if err != nil || response.Result == manager.ResultApproved {
+		return response, err
+	}
+
+	name, ok := cr.Annotations[policy.OverrideDenialAnnotationKey]
+	if !ok {
+		return response, nil
+	}
+
+	overrideFailed := func(format string, args ...any) (manager.ReviewResponse, error) {
+		response.Message = fmt.Sprintf("%s; override by CertificateRequestPolicyExemption %q failed: %s", response.Message, name, fmt.Sprintf(format, args...))
+		return response, nil
+	}
+
+	var exemption policyapi.CertificateRequestPolicyExemption
+	if err := e.lister.Get(ctx, client.ObjectKey{Name: name}, &exemption); apierrors.IsNotFound(err) {
+		return overrideFailed("exemption does not exist")
+	} else if err != nil {
+		return manager.ReviewResponse{}, fmt.Errorf("failed to get CertificateRequestPolicyExemption %q: %w", name, err)
+	}
+
+	if !exemptionApplies(&exemption.Spec, cr) {
+		return overrideFailed("exemption does not apply to this request")
+	}
+	if !e.clock.Now().Before(exemption.Spec.ExpiresAt.Time) {
+		return overrideFailed("exemption expired")
+	}
+
+	var exempting policyapi.CertificateRequestPolicy
+	if err := e.lister.Get(ctx, client.ObjectKey{Name: exemption.Spec.Policy}, &exempting); apierrors.IsNotFound(err) {
+		return overrideFailed("CertificateRequestPolicy %q does not exist", exemption.Spec.Policy)
+	} else if err != nil {
+		return manager.ReviewResponse{}, fmt.Errorf("failed to get CertificateRequestPolicy %q: %w", exemption.Spec.Policy, err)
+	}
+
+	if ready, _ := predicate.Ready(ctx, cr, []policyapi.CertificateRequestPolicy{exempting}); len(ready) == 0 {
+		return overrideFailed("CertificateRequestPolicy %q is not ready", exempting.Name)
+	}
+
+	denied, message, warnings, err := e.policies.evaluate(ctx, &exempting, cr)
+	if err != nil {
+		return manager.ReviewResponse{}, err
+	}
+	if denied {
+		return overrideFailed("[%s: %s]", exempting.Name, message)
+	}
+
+	approvedBy := manager.PolicyRevision{Name: exempting.Name, Generation: exempting.Generation}
+	return manager.ReviewResponse{
+		Result: manager.ResultApproved,
+		Message: fmt.Sprintf("Approved by CertificateRequestPolicy: %q under CertificateRequestPolicyExemption: %q for reason %q",
+			exempting.Name, exemption.Name, exemption.Spec.Reason),
+		ApprovedBy:    &approvedBy,
+		ApprovedByAll: []manager.PolicyRevision{approvedBy},
+		Override: &manager.OverrideChain{
+			DeniedBy:   response.DeniedBy,
+			Exemption:  exemption.Name,
+			Reason:     exemption.Spec.Reason,
+			ApprovedBy: approvedBy,
+		},
+		Warnings: sortedWarnings(prefixWarnings(exempting.Name, warnings)),
+	}, nil
+}
+
+// exemptionApplies returns true if the exemption names the request, or the
+// Certificate which created it, in the request's namespace.
+func exemptionApplies(spec *policyapi.CertificateRequestPolicyExemptionSpec, cr *cmapi.CertificateRequest) bool {
+	if spec.Namespace != cr.Namespace {
+		return false
+	}
+	if slices.Contains(spec.CertificateRequestNames, cr.Name) {
+		return true
+	}
+	certificateName, ok := cr.Annotations[cmapi.CertificateNameKey]
+	return ok && slices.Contains(spec.CertificateNames, certificateName)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclock "k8s.io/utils/clock/testing"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	approverfake "github.com/cert-manager/approver-policy/pkg/approver/fake"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/approver/manager/fake"
+)
+
+func Test_Exemptions(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	denied := manager.ReviewResponse{
+		Result:   manager.ResultDenied,
+		Message:  "No policy approved this request: [other-policy: denied]",
+		DeniedBy: []manager.PolicyRevision{{Name: "other-policy", Generation: 1}},
+	}
+
+	exemption := func(mod func(*policyapi.CertificateRequestPolicyExemptionSpec)) *policyapi.CertificateRequestPolicyExemption {
+		e := &policyapi.CertificateRequestPolicyExemption{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-exemption"},
+			Spec: policyapi.CertificateRequestPolicyExemptionSpec{
+				Namespace:        "test-ns",
+				CertificateNames: []string{"test-cert"},
+				ExpiresAt:        metav1.NewTime(now.Add(time.Hour)),
+				Policy:           "exempting-policy",
+				Reason:           "CHG-42",
+			},
+		}
+		if mod != nil {
+			mod(&e.Spec)
+		}
+		return e
+	}
+
+	policy := func(ready corev1.ConditionStatus) *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "exempting-policy", Generation: 3},
+			Status: policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{{Type: policyapi.CertificateRequestPolicyConditionReady, Status: ready}},
+			},
+		}
+	}
+
+	request := func(annotations map[string]string) *cmapi.CertificateRequest {
+		return &cmapi.CertificateRequest{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-cert-1", Annotations: annotations},
+		}
+	}
+	overridden := map[string]string{
+		"policy.cert-manager.io/override-denial": "test-exemption",
+		"cert-manager.io/certificate-name":       "test-cert",
+	}
+
+	approving := approverfake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+		return approver.EvaluationResponse{Result: approver.ResultNotDenied}, nil
+	})
+	denying := approverfake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+		return approver.EvaluationResponse{Result: approver.ResultDenied, Message: "still denied"}, nil
+	})
+
+	tests := map[string]struct {
+		existingObjects []runtime.Object
+		evaluator       approver.Evaluator
+		cr              *cmapi.CertificateRequest
+		expResponse     manager.ReviewResponse
+	}{
+		"if the request does not name an exemption, should return the review": {
+			existingObjects: []runtime.Object{exemption(nil), policy(corev1.ConditionTrue)},
+			evaluator:       approving,
+			cr:              request(nil),
+			expResponse:     denied,
+		},
+		"if the exemption does not exist, should return the review with the reason": {
+			existingObjects: []runtime.Object{policy(corev1.ConditionTrue)},
+			evaluator:       approving,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:   manager.ResultDenied,
+				Message:  denied.Message + `; override by CertificateRequestPolicyExemption "test-exemption" failed: exemption does not exist`,
+				DeniedBy: denied.DeniedBy,
+			},
+		},
+		"if the exemption is for another namespace, should return the review with the reason": {
+			existingObjects: []runtime.Object{exemption(func(spec *policyapi.CertificateRequestPolicyExemptionSpec) { spec.Namespace = "other-ns" }), policy(corev1.ConditionTrue)},
+			evaluator:       approving,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:   manager.ResultDenied,
+				Message:  denied.Message + `; override by CertificateRequestPolicyExemption "test-exemption" failed: exemption does not apply to this request`,
+				DeniedBy: denied.DeniedBy,
+			},
+		},
+		"if the exemption has expired, should return the review with the reason": {
+			existingObjects: []runtime.Object{exemption(func(spec *policyapi.CertificateRequestPolicyExemptionSpec) { spec.ExpiresAt = metav1.NewTime(now) }), policy(corev1.ConditionTrue)},
+			evaluator:       approving,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:   manager.ResultDenied,
+				Message:  denied.Message + `; override by CertificateRequestPolicyExemption "test-exemption" failed: exemption expired`,
+				DeniedBy: denied.DeniedBy,
+			},
+		},
+		"if the exempting policy is not ready, should return the review with the reason": {
+			existingObjects: []runtime.Object{exemption(nil), policy(corev1.ConditionFalse)},
+			evaluator:       approving,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:   manager.ResultDenied,
+				Message:  denied.Message + `; override by CertificateRequestPolicyExemption "test-exemption" failed: CertificateRequestPolicy "exempting-policy" is not ready`,
+				DeniedBy: denied.DeniedBy,
+			},
+		},
+		"if the exempting policy denies the request, should return the review with the reason": {
+			existingObjects: []runtime.Object{exemption(nil), policy(corev1.ConditionTrue)},
+			evaluator:       denying,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:   manager.ResultDenied,
+				Message:  denied.Message + `; override by CertificateRequestPolicyExemption "test-exemption" failed: [exempting-policy: still denied]`,
+				DeniedBy: denied.DeniedBy,
+			},
+		},
+		"if the exempting policy approves the request by request name, should approve with the override chain": {
+			existingObjects: []runtime.Object{exemption(func(spec *policyapi.CertificateRequestPolicyExemptionSpec) {
+				spec.CertificateNames = nil
+				spec.CertificateRequestNames = []string{"test-cert-1"}
+			}), policy(corev1.ConditionTrue)},
+			evaluator: approving,
+			cr:        request(map[string]string{"policy.cert-manager.io/override-denial": "test-exemption"}),
+			expResponse: manager.ReviewResponse{
+				Result:        manager.ResultApproved,
+				Message:       `Approved by CertificateRequestPolicy: "exempting-policy" under CertificateRequestPolicyExemption: "test-exemption" for reason "CHG-42"`,
+				ApprovedBy:    &manager.PolicyRevision{Name: "exempting-policy", Generation: 3},
+				ApprovedByAll: []manager.PolicyRevision{{Name: "exempting-policy", Generation: 3}},
+				Override: &manager.OverrideChain{
+					DeniedBy:   denied.DeniedBy,
+					Exemption:  "test-exemption",
+					Reason:     "CHG-42",
+					ApprovedBy: manager.PolicyRevision{Name: "exempting-policy", Generation: 3},
+				},
+			},
+		},
+		"if the exempting policy approves the request by certificate name, should approve with the override chain": {
+			existingObjects: []runtime.Object{exemption(nil), policy(corev1.ConditionTrue)},
+			evaluator:       approving,
+			cr:              request(overridden),
+			expResponse: manager.ReviewResponse{
+				Result:        manager.ResultApproved,
+				Message:       `Approved by CertificateRequestPolicy: "exempting-policy" under CertificateRequestPolicyExemption: "test-exemption" for reason "CHG-42"`,
+				ApprovedBy:    &manager.PolicyRevision{Name: "exempting-policy", Generation: 3},
+				ApprovedByAll: []manager.PolicyRevision{{Name: "exempting-policy", Generation: 3}},
+				Override: &manager.OverrideChain{
+					DeniedBy:   denied.DeniedBy,
+					Exemption:  "test-exemption",
+					Reason:     "CHG-42",
+					ApprovedBy: manager.PolicyRevision{Name: "exempting-policy", Generation: 3},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			lister := fakeclient.NewClientBuilder().
+				WithScheme(policyapi.GlobalScheme).
+				WithRuntimeObjects(test.existingObjects...).
+				Build()
+
+			next := fake.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return denied, nil
+			})
+
+			e := NewExemptions(lister, []approver.Evaluator{test.evaluator}, next).(*exemptions)
+			e.clock = fakeclock.NewFakeClock(now)
+
+			response, err := e.Review(context.TODO(), test.cr)
+			require.NoError(t, err)
+			assert.Equal(t, test.expResponse, response)
+		})
+	}
+}
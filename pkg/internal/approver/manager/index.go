@@ -0,0 +1,336 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// numIndexShards is the number of shards of a PolicyIndex. An update to a
+// policy only locks the shard of that policy, so that reviews are not blocked
+// by the churn of unrelated policies.
+const numIndexShards = 32
+
+// PolicyIndex is an index of CertificateRequestPolicies by the name of the
+// issuer their selector matches, which is kept up to date incrementally from
+// the events of the policy informer.
+//
+// Listing every policy for each request copies every policy from the informer
+// cache, which dominates the cost of reviews with tens of thousands of
+// policies. The index instead returns the candidate policies of a request,
+// which are only those selecting the issuer name of the request or any issuer
+// name, and only copies those. The candidates are a superset of the policies
+// whose selector matches the request, so the predicates must still be run on
+// them. The index is designed for 10,000 policies with sub-millisecond
+// candidate selection, which Test_PolicyIndexCandidatesTarget asserts.
+//
+// Policies are not compiled as they are indexed. The CEL expressions of the
+// policies are compiled lazily by the validation cache when a candidate is
+// first evaluated, so policies which never select a request cost no more than
+// their copy in the informer cache.
+type PolicyIndex struct {
+	// aliases resolves the issuer aliases referenced by policy selectors.
+	aliases map[string]cmmeta.ObjectReference
+
+	// maxPolicies is the maximum number of policies which may be candidates.
+	// If the index holds more, only the oldest are, in the same way as the
+	// MaxPolicies predicate. 0 is unlimited.
+	maxPolicies int
+
+	// len is the number of policies in the index.
+	len atomic.Int64
+
+	// version is incremented by every change to the index, so that the
+	// cutoff is only computed again once the index has changed.
+	version atomic.Int64
+
+	cutoffLock    sync.Mutex
+	cutoffAge     policyAge
+	cutoffVersion int64
+
+	// hasSynced returns true once the index has received every policy of the
+	// informer it is registered with. Nil if not registered.
+	hasSynced func() bool
+
+	shards [numIndexShards]indexShard
+}
+
+type indexShard struct {
+	lock sync.RWMutex
+
+	// policies holds every policy of the shard, keyed by name. Policies are
+	// shared with the informer cache, so must not be modified.
+	policies map[string]*policyapi.CertificateRequestPolicy
+
+	// byIssuerName holds the names of policies whose selector only matches a
+	// single issuer name, keyed by that issuer name.
+	byIssuerName map[string]map[string]struct{}
+
+	// anyIssuerName holds the names of policies whose selector may match any
+	// issuer name.
+	anyIssuerName map[string]struct{}
+}
+
+// policyAge orders policies by creation timestamp, then name, in the same way
+// as the MaxPolicies predicate.
+type policyAge struct {
+	created time.Time
+	name    string
+}
+
+func ageOf(policy *policyapi.CertificateRequestPolicy) policyAge {
+	return policyAge{created: policy.CreationTimestamp.Time, name: policy.Name}
+}
+
+func (a policyAge) compare(b policyAge) int {
+	if c := a.created.Compare(b.created); c != 0 {
+		return c
+	}
+	return strings.Compare(a.name, b.name)
+}
+
+// NewPolicyIndex returns an empty PolicyIndex. aliases resolves the issuer
+// aliases referenced by policy selectors, in the same way as the
+// SelectorIssuerRef predicate. maxPolicies is the maximum number of policies
+// which may be candidates, where 0 is unlimited.
+func NewPolicyIndex(aliases map[string]cmmeta.ObjectReference, maxPolicies int) *PolicyIndex {
+	idx := &PolicyIndex{aliases: aliases, maxPolicies: maxPolicies, cutoffVersion: -1}
+	for i := range idx.shards {
+		idx.shards[i] = indexShard{
+			policies:      make(map[string]*policyapi.CertificateRequestPolicy),
+			byIssuerName:  make(map[string]map[string]struct{}),
+			anyIssuerName: make(map[string]struct{}),
+		}
+	}
+	return idx
+}
+
+// Register registers the index with the CertificateRequestPolicy informer,
+// keeping the index up to date with its events.
+func (idx *PolicyIndex) Register(informer interface {
+	AddEventHandler(cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error)
+}) error {
+	registration, err := informer.AddEventHandler(idx)
+	if err != nil {
+		return err
+	}
+	idx.hasSynced = registration.HasSynced
+	return nil
+}
+
+// HasSynced returns true once the index holds every policy of the informer
+// it is registered with. Until then, the index is incomplete and policies
+// must be listed instead.
+func (idx *PolicyIndex) HasSynced() bool {
+	return idx.hasSynced == nil || idx.hasSynced()
+}
+
+// Len returns the number of policies in the index.
+func (idx *PolicyIndex) Len() int {
+	return int(idx.len.Load())
+}
+
+// Candidates returns copies of the policies which may select the issuer of
+// the request, sorted by name. If the index holds more than the maximum
+// number of policies, policies newer than the oldest maximum are never
+// candidates.
+func (idx *PolicyIndex) Candidates(cr *cmapi.CertificateRequest) []policyapi.CertificateRequestPolicy {
+	issuerName := cr.Spec.IssuerRef.Name
+	cutoff, limited := idx.cutoff()
+
+	var candidates []policyapi.CertificateRequestPolicy
+	add := func(policy *policyapi.CertificateRequestPolicy) {
+		if limited && ageOf(policy).compare(cutoff) > 0 {
+			return
+		}
+		candidates = append(candidates, *policy.DeepCopy())
+	}
+	for i := range idx.shards {
+		shard := &idx.shards[i]
+		shard.lock.RLock()
+		for name := range shard.byIssuerName[issuerName] {
+			add(shard.policies[name])
+		}
+		for name := range shard.anyIssuerName {
+			add(shard.policies[name])
+		}
+		shard.lock.RUnlock()
+	}
+
+	slices.SortFunc(candidates, func(a, b policyapi.CertificateRequestPolicy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return candidates
+}
+
+// cutoff returns the age of the newest of the oldest maximum number of
+// policies, and false if the index holds no more than the maximum. It is
+// computed from every policy in the index, but only once per change to the
+// index, so is cheap unless policies are churning.
+func (idx *PolicyIndex) cutoff() (policyAge, bool) {
+	if idx.maxPolicies <= 0 || idx.Len() <= idx.maxPolicies {
+		return policyAge{}, false
+	}
+
+	idx.cutoffLock.Lock()
+	defer idx.cutoffLock.Unlock()
+
+	version := idx.version.Load()
+	if idx.cutoffVersion == version {
+		return idx.cutoffAge, true
+	}
+
+	ages := make([]policyAge, 0, idx.Len())
+	for i := range idx.shards {
+		shard := &idx.shards[i]
+		shard.lock.RLock()
+		for _, policy := range shard.policies {
+			ages = append(ages, ageOf(policy))
+		}
+		shard.lock.RUnlock()
+	}
+	if len(ages) <= idx.maxPolicies {
+		return policyAge{}, false
+	}
+
+	slices.SortFunc(ages, policyAge.compare)
+	idx.cutoffAge, idx.cutoffVersion = ages[idx.maxPolicies-1], version
+	return idx.cutoffAge, true
+}
+
+// Set adds the policy to the index, replacing any existing policy with the
+// same name. The policy must not be modified after being added.
+func (idx *PolicyIndex) Set(policy *policyapi.CertificateRequestPolicy) {
+	shard := idx.shard(policy.Name)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	if !idx.remove(shard, policy.Name) {
+		idx.len.Add(1)
+	}
+	idx.version.Add(1)
+
+	shard.policies[policy.Name] = policy
+	issuerName, anyName, ok := idx.issuerName(policy)
+	switch {
+	case !ok:
+		// The selector matches no issuer, so the policy is never a candidate.
+	case anyName:
+		shard.anyIssuerName[policy.Name] = struct{}{}
+	default:
+		if shard.byIssuerName[issuerName] == nil {
+			shard.byIssuerName[issuerName] = make(map[string]struct{})
+		}
+		shard.byIssuerName[issuerName][policy.Name] = struct{}{}
+	}
+}
+
+// Delete removes the named policy from the index.
+func (idx *PolicyIndex) Delete(name string) {
+	shard := idx.shard(name)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	if idx.remove(shard, name) {
+		idx.len.Add(-1)
+		idx.version.Add(1)
+	}
+}
+
+// OnAdd implements cache.ResourceEventHandler.
+func (idx *PolicyIndex) OnAdd(obj any, _ bool) {
+	if policy, ok := obj.(*policyapi.CertificateRequestPolicy); ok {
+		idx.Set(policy)
+	}
+}
+
+// OnUpdate implements cache.ResourceEventHandler.
+func (idx *PolicyIndex) OnUpdate(_, newObj any) {
+	idx.OnAdd(newObj, false)
+}
+
+// OnDelete implements cache.ResourceEventHandler.
+func (idx *PolicyIndex) OnDelete(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if policy, ok := obj.(*policyapi.CertificateRequestPolicy); ok {
+		idx.Delete(policy.Name)
+	}
+}
+
+// issuerName returns the single issuer name the selector of the policy
+// matches. anyName is true if the selector may match any issuer name, and ok
+// is false if the selector matches no issuer.
+func (idx *PolicyIndex) issuerName(policy *policyapi.CertificateRequestPolicy) (name string, anyName bool, ok bool) {
+	sel := policy.Spec.Selector.IssuerRef
+	if sel == nil {
+		return "", true, true
+	}
+
+	if sel.Alias != nil {
+		ref, ok := idx.aliases[*sel.Alias]
+		if !ok {
+			return "", false, false
+		}
+		return ref.Name, false, true
+	}
+
+	if sel.Name == nil || strings.Contains(*sel.Name, "*") {
+		return "", true, true
+	}
+	return *sel.Name, false, true
+}
+
+func (idx *PolicyIndex) shard(name string) *indexShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return &idx.shards[h.Sum32()%numIndexShards]
+}
+
+// remove removes the named policy from the shard, returning false if it was
+// not in the shard. The shard lock must be held.
+func (idx *PolicyIndex) remove(shard *indexShard, name string) bool {
+	policy, ok := shard.policies[name]
+	if !ok {
+		return false
+	}
+
+	delete(shard.policies, name)
+	delete(shard.anyIssuerName, name)
+	if issuerName, anyName, ok := idx.issuerName(policy); ok && !anyName {
+		delete(shard.byIssuerName[issuerName], name)
+		if len(shard.byIssuerName[issuerName]) == 0 {
+			delete(shard.byIssuerName, issuerName)
+		}
+	}
+	return true
+}
+
+var _ cache.ResourceEventHandler = &PolicyIndex{}
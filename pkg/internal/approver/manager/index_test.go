@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+)
+
+func Test_PolicyIndex(t *testing.T) {
+	aliases := map[string]cmmeta.ObjectReference{
+		"internal-mtls": {Name: "vault-mtls", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+	}
+
+	policy := func(name string, issuerRef *policyapi.CertificateRequestPolicySelectorIssuerRef) *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: issuerRef},
+			},
+		}
+	}
+
+	request := func(issuerName string) *cmapi.CertificateRequest {
+		return &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: issuerName}}}
+	}
+
+	names := func(policies []policyapi.CertificateRequestPolicy) []string {
+		var names []string
+		for _, policy := range policies {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+
+	idx := NewPolicyIndex(aliases, 0)
+	idx.OnAdd(policy("nil-selector", nil), true)
+	idx.OnAdd(policy("empty-selector", &policyapi.CertificateRequestPolicySelectorIssuerRef{}), true)
+	idx.OnAdd(policy("wildcard", &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca-*")}), true)
+	idx.OnAdd(policy("ca-1", &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca-1")}), true)
+	idx.OnAdd(policy("ca-2", &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca-2")}), true)
+	idx.OnAdd(policy("alias", &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("internal-mtls")}), true)
+	idx.OnAdd(policy("unknown-alias", &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("unknown")}), true)
+
+	assert.Equal(t, 7, idx.Len())
+	assert.Equal(t, []string{"ca-1", "empty-selector", "nil-selector", "wildcard"}, names(idx.Candidates(request("ca-1"))))
+	assert.Equal(t, []string{"alias", "empty-selector", "nil-selector", "wildcard"}, names(idx.Candidates(request("vault-mtls"))))
+
+	// Updating a policy to select a different issuer should move it.
+	idx.OnUpdate(policy("ca-1", nil), policy("ca-1", &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca-2")}))
+	assert.Equal(t, 7, idx.Len())
+	assert.Equal(t, []string{"empty-selector", "nil-selector", "wildcard"}, names(idx.Candidates(request("ca-1"))))
+	assert.Equal(t, []string{"ca-1", "ca-2", "empty-selector", "nil-selector", "wildcard"}, names(idx.Candidates(request("ca-2"))))
+
+	idx.OnDelete(policy("ca-1", nil))
+	idx.OnDelete(cache.DeletedFinalStateUnknown{Key: "wildcard", Obj: policy("wildcard", nil)})
+	idx.Delete("does-not-exist")
+	assert.Equal(t, 5, idx.Len())
+	assert.Equal(t, []string{"ca-2", "empty-selector", "nil-selector"}, names(idx.Candidates(request("ca-2"))))
+
+	// Candidates must be copies, so that the policies of the informer cache
+	// are never modified.
+	candidates := idx.Candidates(request("ca-2"))
+	candidates[0].Name = "modified"
+	assert.Equal(t, []string{"ca-2", "empty-selector", "nil-selector"}, names(idx.Candidates(request("ca-2"))))
+}
+
+// Test_PolicyIndexCandidates ensures that the candidates of the index are a
+// superset of the policies that the SelectorIssuerRef predicate selects.
+func Test_PolicyIndexCandidates(t *testing.T) {
+	aliases := map[string]cmmeta.ObjectReference{
+		"alias-1": {Name: "issuer-1", Kind: "Issuer", Group: "cert-manager.io"},
+	}
+
+	policies := generatePolicies(500)
+	idx := NewPolicyIndex(aliases, 0)
+	for i := range policies {
+		idx.Set(&policies[i])
+	}
+
+	selectorIssuerRef := predicate.SelectorIssuerRef(aliases)
+	for i := range 20 {
+		cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: fmt.Sprintf("issuer-%d", i)}}}
+
+		fromList, err := selectorIssuerRef(context.TODO(), cr, policies)
+		require.NoError(t, err)
+		fromIndex, err := selectorIssuerRef(context.TODO(), cr, idx.Candidates(cr))
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, fromList, fromIndex, "issuer-%d", i)
+	}
+}
+
+// Test_PolicyIndexMaxPolicies ensures that an index with a maximum number of
+// policies selects the candidates within the maximum that the MaxPolicies
+// predicate keeps, as policies are added and removed.
+func Test_PolicyIndexMaxPolicies(t *testing.T) {
+	policies := generatePolicies(500)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range policies {
+		// Policies are created in the reverse order of their names, with
+		// some created at the same time so that they are ordered by name.
+		policies[i].CreationTimestamp = metav1.NewTime(created.Add(time.Duration(len(policies)-i/2) * time.Second))
+	}
+
+	idx := NewPolicyIndex(nil, 100)
+	for i := range policies {
+		idx.Set(&policies[i])
+	}
+
+	selectorIssuerRef := predicate.SelectorIssuerRef(nil)
+	assertCandidates := func(policies []policyapi.CertificateRequestPolicy) {
+		t.Helper()
+		limited, err := predicate.MaxPolicies(100)(context.TODO(), nil, policies)
+		require.NoError(t, err)
+		for i := range 20 {
+			cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: fmt.Sprintf("issuer-%d", i)}}}
+
+			fromList, err := selectorIssuerRef(context.TODO(), cr, limited)
+			require.NoError(t, err)
+			fromIndex, err := selectorIssuerRef(context.TODO(), cr, idx.Candidates(cr))
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, fromList, fromIndex, "issuer-%d", i)
+		}
+	}
+
+	assertCandidates(policies)
+
+	// Removing the oldest policies should make newer policies candidates.
+	for i := len(policies) - 50; i < len(policies); i++ {
+		idx.Delete(policies[i].Name)
+	}
+	assertCandidates(policies[:len(policies)-50])
+}
+
+// Test_PolicyIndexCandidatesTarget asserts the target of selecting the
+// candidates of a request from an index of 10,000 policies in under a
+// millisecond.
+func Test_PolicyIndexCandidatesTarget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping benchmark of candidate selection in short mode")
+	}
+
+	result := testing.Benchmark(BenchmarkPolicyIndexCandidates)
+	assert.Less(t, time.Duration(result.NsPerOp()), time.Millisecond,
+		"selecting candidates from 10,000 policies should take under a millisecond, took %s", time.Duration(result.NsPerOp()))
+}
+
+// BenchmarkPolicyIndexCandidates benchmarks the selection of the candidate
+// policies of a request from an index of 10,000 policies. The target is for
+// candidate selection to take well under a millisecond, which
+// Test_PolicyIndexCandidatesTarget asserts.
+func BenchmarkPolicyIndexCandidates(b *testing.B) {
+	policies := generatePolicies(10_000)
+	idx := NewPolicyIndex(nil, 0)
+	for i := range policies {
+		idx.Set(&policies[i])
+	}
+	cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "issuer-1"}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		idx.Candidates(cr)
+	}
+}
+
+// BenchmarkPolicyIndexSet benchmarks concurrent updates to an index of 10,000
+// policies, such as when the status of every policy is updated on start.
+func BenchmarkPolicyIndexSet(b *testing.B) {
+	policies := generatePolicies(10_000)
+	idx := NewPolicyIndex(nil, 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			idx.Set(&policies[i%len(policies)])
+			i++
+		}
+	})
+}
+
+// BenchmarkListCandidates is the baseline of BenchmarkPolicyIndexCandidates,
+// copying every policy as listing them from the informer cache does, before
+// filtering them by issuer.
+func BenchmarkListCandidates(b *testing.B) {
+	policies := generatePolicies(10_000)
+	selectorIssuerRef := predicate.SelectorIssuerRef(nil)
+	cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "issuer-1"}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		list := make([]policyapi.CertificateRequestPolicy, len(policies))
+		for i := range policies {
+			policies[i].DeepCopyInto(&list[i])
+		}
+		if _, err := selectorIssuerRef(context.TODO(), cr, list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// generatePolicies returns n policies, most of which select one of n/10
+// issuers by name, as is typical of clusters with many tenants. Every 100th
+// policy selects issuers with a wildcard, and every 250th by alias.
+func generatePolicies(n int) []policyapi.CertificateRequestPolicy {
+	policies := make([]policyapi.CertificateRequestPolicy, n)
+	for i := range policies {
+		issuerRef := &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To(fmt.Sprintf("issuer-%d", i%max(n/10, 1)))}
+		switch {
+		case i%250 == 0:
+			issuerRef = &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To(fmt.Sprintf("alias-%d", i%2))}
+		case i%100 == 0:
+			issuerRef = &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("issuer-1*"), Kind: ptr.To("Issuer")}
+		}
+
+		policies[i] = policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("policy-%d", i)},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{
+					DNSNames: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{fmt.Sprintf("*.tenant-%d.example.com", i)}},
+				},
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: issuerRef,
+					Namespace: &policyapi.CertificateRequestPolicySelectorNamespace{MatchNames: []string{fmt.Sprintf("tenant-%d", i)}},
+				},
+			},
+		}
+	}
+	return policies
+}
@@ -19,15 +19,22 @@ package predicate
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
 	authzv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 	"github.com/cert-manager/approver-policy/pkg/internal/util"
 )
 
@@ -37,6 +44,71 @@ import (
 // predicate or filter.
 type Predicate func(context.Context, *cmapi.CertificateRequest, []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error)
 
+// MaxPolicies is a Predicate that returns at most maxPolicies of the given
+// policies, keeping the oldest by creation timestamp. This bounds the work of
+// reviewing requests if a runaway generator creates policies faster than they
+// can be removed, while the policies that existing issuance relies on continue
+// to be evaluated. The number of ignored policies is reported by the
+// PoliciesIgnoredCount metric, and logged whenever it changes. A maxPolicies
+// of 0 or less returns all policies.
+func MaxPolicies(maxPolicies int) Predicate {
+	return NewPolicyLimit(maxPolicies).Predicate
+}
+
+// PolicyLimit is the maximum number of policies evaluated, of which the
+// MaxPolicies predicate is built.
+type PolicyLimit struct {
+	maxPolicies int
+
+	// ignored is the number of policies ignored by the previous review, so
+	// that changes are logged once rather than on every review.
+	ignored atomic.Int64
+}
+
+// NewPolicyLimit returns a PolicyLimit of maxPolicies. A maxPolicies of 0 or
+// less, like a nil PolicyLimit, is unlimited.
+func NewPolicyLimit(maxPolicies int) *PolicyLimit {
+	return &PolicyLimit{maxPolicies: maxPolicies}
+}
+
+// Predicate is the MaxPolicies Predicate of the limit.
+func (l *PolicyLimit) Predicate(ctx context.Context, _ *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+	if l.Report(ctx, len(policies)) == 0 {
+		return policies, nil
+	}
+
+	policies = slices.Clone(policies)
+	slices.SortStableFunc(policies, func(a, b policyapi.CertificateRequestPolicy) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return policies[:l.maxPolicies], nil
+}
+
+// Report reports the number of the given number of policies which are
+// ignored, and returns it. It is called by the Predicate, and by callers which
+// only select the oldest policies themselves, such as the PolicyIndex.
+func (l *PolicyLimit) Report(ctx context.Context, policies int) int {
+	if l == nil || l.maxPolicies <= 0 {
+		return 0
+	}
+
+	n := max(policies-l.maxPolicies, 0)
+	metrics.PoliciesIgnoredCount.Set(float64(n))
+	if previous := l.ignored.Swap(int64(n)); previous != int64(n) {
+		log := logr.FromContextOrDiscard(ctx)
+		if n > 0 {
+			log.Error(nil, "too many CertificateRequestPolicies exist, ignoring the newest policies", "policies", policies, "max_policies", l.maxPolicies, "ignored", n)
+		} else {
+			log.Info("CertificateRequestPolicies are within the maximum, no policies are ignored", "policies", policies, "max_policies", l.maxPolicies)
+		}
+	}
+	return n
+}
+
 // Ready is a Predicate that returns the subset of given policies that have a
 // Ready condition set to True.
 func Ready(_ context.Context, _ *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
@@ -53,44 +125,120 @@ func Ready(_ context.Context, _ *cmapi.CertificateRequest, policies []policyapi.
 	return readyPolicies, nil
 }
 
-// SelectorIssuerRef is a Predicate that returns the subset of given policies
-// that have an `spec.selector.issuerRef` matching the `spec.issuerRef` in the
-// request. PredicateSelectorIssuerRef will match on strings using wilcards
-// "*". Empty selector is equivalent to "*" and will match on anything.
-func SelectorIssuerRef(_ context.Context, cr *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
-	var matchingPolicies []policyapi.CertificateRequestPolicy
-
-	// cert-manager applies controller defaults for issuer Kind and Group,
-	// which means that default values are NOT materialized in resources
-	// if omitted.
-	// So in order to make policies addressing these default values effective,
-	// we must apply cert-manager defaults on request when matching policies.
-	issKind := nonEmptyOrDefault(cr.Spec.IssuerRef.Kind, cmapi.IssuerKind)
-	issGroup := nonEmptyOrDefault(cr.Spec.IssuerRef.Group, "cert-manager.io")
-	issName := cr.Spec.IssuerRef.Name
+// BreakGlassUnexpired is a Predicate that returns the subset of given policies
+// that are not break glass policies whose `spec.breakGlass.expiresAt` has
+// passed. Expired break glass policies are deleted by the
+// CertificateRequestPolicy controller, but must not approve requests in the
+// meantime.
+func BreakGlassUnexpired(clock clock.PassiveClock) Predicate {
+	return func(_ context.Context, _ *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+		var unexpiredPolicies []policyapi.CertificateRequestPolicy
 
-	for _, policy := range policies {
-		issRefSel := policy.Spec.Selector.IssuerRef
-		// If the issuerRef selector is nil, we match the policy and continue
-		// early.
-		if issRefSel == nil {
-			matchingPolicies = append(matchingPolicies, policy)
-			continue
+		now := clock.Now()
+		for _, policy := range policies {
+			if breakGlass := policy.Spec.BreakGlass; breakGlass != nil && !breakGlass.ExpiresAt.After(now) {
+				continue
+			}
+			unexpiredPolicies = append(unexpiredPolicies, policy)
 		}
 
-		if issRefSel.Name != nil && !util.WildcardMatches(*issRefSel.Name, issName) {
-			continue
+		return unexpiredPolicies, nil
+	}
+}
+
+// SelectorMode is a Predicate that returns the subset of given policies that
+// do not have an empty selector with a `spec.selector.mode` of None. Policies
+// with an empty selector which do not set a mode use the given default mode.
+// The IssuerRef and Namespace selector predicates match empty selectors
+// against all requests, so this predicate is responsible for removing them.
+func SelectorMode(defaultMode policyapi.SelectorMode) Predicate {
+	return func(_ context.Context, _ *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+		var matchingPolicies []policyapi.CertificateRequestPolicy
+
+		for _, policy := range policies {
+			if SelectorIsEmpty(policy.Spec.Selector) {
+				mode := defaultMode
+				if policy.Spec.Selector.Mode != nil {
+					mode = *policy.Spec.Selector.Mode
+				}
+				if mode == policyapi.SelectorModeNone {
+					continue
+				}
+			}
+			matchingPolicies = append(matchingPolicies, policy)
 		}
-		if issRefSel.Kind != nil && !util.WildcardMatches(*issRefSel.Kind, issKind) {
-			continue
+
+		return matchingPolicies, nil
+	}
+}
+
+// SelectorIsEmpty returns true if the selector places no constraint on the
+// issuer or namespace of requests, for example `issuerRef: {}`.
+func SelectorIsEmpty(selector policyapi.CertificateRequestPolicySelector) bool {
+	if issRefSel := selector.IssuerRef; issRefSel != nil {
+		if issRefSel.Name != nil || issRefSel.Kind != nil || issRefSel.Group != nil || issRefSel.Alias != nil {
+			return false
 		}
-		if issRefSel.Group != nil && !util.WildcardMatches(*issRefSel.Group, issGroup) {
-			continue
+	}
+	if nsSel := selector.Namespace; nsSel != nil {
+		if len(nsSel.MatchNames) > 0 || len(nsSel.MatchLabels) > 0 {
+			return false
 		}
-		matchingPolicies = append(matchingPolicies, policy)
 	}
+	return true
+}
+
+// SelectorIssuerRef returns a Predicate that returns the subset of given
+// policies that have an `spec.selector.issuerRef` matching the
+// `spec.issuerRef` in the request. PredicateSelectorIssuerRef will match on
+// strings using wilcards "*". Empty selector is equivalent to "*" and will
+// match on anything.
+// aliases maps the logical issuer names which selectors may reference with
+// `spec.selector.issuerRef.alias` to the concrete issuer of this cluster. A
+// selector referencing an alias which is not in aliases matches nothing.
+func SelectorIssuerRef(aliases map[string]cmmeta.ObjectReference) Predicate {
+	return func(_ context.Context, cr *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+		var matchingPolicies []policyapi.CertificateRequestPolicy
+
+		// cert-manager applies controller defaults for issuer Kind and Group,
+		// which means that default values are NOT materialized in resources
+		// if omitted.
+		// So in order to make policies addressing these default values effective,
+		// we must apply cert-manager defaults on request when matching policies.
+		issKind := nonEmptyOrDefault(cr.Spec.IssuerRef.Kind, cmapi.IssuerKind)
+		issGroup := nonEmptyOrDefault(cr.Spec.IssuerRef.Group, "cert-manager.io")
+		issName := cr.Spec.IssuerRef.Name
+
+		for _, policy := range policies {
+			issRefSel := policy.Spec.Selector.IssuerRef
+			// If the issuerRef selector is nil, we match the policy and continue
+			// early.
+			if issRefSel == nil {
+				matchingPolicies = append(matchingPolicies, policy)
+				continue
+			}
+
+			if issRefSel.Alias != nil {
+				ref, ok := aliases[*issRefSel.Alias]
+				if !ok || ref.Name != issName || ref.Kind != issKind || ref.Group != issGroup {
+					continue
+				}
+			}
+
+			if issRefSel.Name != nil && !util.WildcardMatches(*issRefSel.Name, issName) {
+				continue
+			}
+			if issRefSel.Kind != nil && !util.WildcardMatches(*issRefSel.Kind, issKind) {
+				continue
+			}
+			if issRefSel.Group != nil && !util.WildcardMatches(*issRefSel.Group, issGroup) {
+				continue
+			}
+			matchingPolicies = append(matchingPolicies, policy)
+		}
 
-	return matchingPolicies, nil
+		return matchingPolicies, nil
+	}
 }
 
 // SelectorNamespace is a Predicate that returns the subset of given policies
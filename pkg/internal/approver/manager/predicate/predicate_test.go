@@ -19,20 +19,24 @@ package predicate
 import (
 	"context"
 	"testing"
+	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	fakeclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 	testenv "github.com/cert-manager/approver-policy/test/env"
 )
 
@@ -507,6 +511,176 @@ func Test_Ready(t *testing.T) {
 	}
 }
 
+func Test_BreakGlassUnexpired(t *testing.T) {
+	fixedTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	breakGlassPolicy := func(name string, expiresAt time.Time) policyapi.CertificateRequestPolicy {
+		return policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(expiresAt), IncidentRef: "INC-123"},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		policies    []policyapi.CertificateRequestPolicy
+		expPolicies []policyapi.CertificateRequestPolicy
+	}{
+		"no given policies should return no policies": {
+			policies:    nil,
+			expPolicies: nil,
+		},
+		"policy which is not break glass should be returned": {
+			policies:    []policyapi.CertificateRequestPolicy{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+			expPolicies: []policyapi.CertificateRequestPolicy{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}},
+		},
+		"break glass policies which have expired, or expire now, should not be returned": {
+			policies: []policyapi.CertificateRequestPolicy{
+				breakGlassPolicy("a", fixedTime.Add(-time.Hour)),
+				breakGlassPolicy("b", fixedTime),
+				breakGlassPolicy("c", fixedTime.Add(time.Hour)),
+			},
+			expPolicies: []policyapi.CertificateRequestPolicy{
+				breakGlassPolicy("c", fixedTime.Add(time.Hour)),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policies, err := BreakGlassUnexpired(fakeclock.NewFakePassiveClock(fixedTime))(context.TODO(), nil, test.policies)
+			assert.NoError(t, err)
+			if !apiequality.Semantic.DeepEqual(test.expPolicies, policies) {
+				t.Errorf("unexpected policies returned:\nexp=%#+v\ngot=%#+v", test.expPolicies, policies)
+			}
+		})
+	}
+}
+
+func Test_MaxPolicies(t *testing.T) {
+	baseTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	policyCreatedAt := func(name string, offset time.Duration) policyapi.CertificateRequestPolicy {
+		return policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(baseTime.Add(offset))},
+		}
+	}
+
+	var (
+		oldest = policyCreatedAt("oldest", 0)
+		middle = policyCreatedAt("middle", time.Minute)
+		sameA  = policyCreatedAt("same-a", time.Hour)
+		sameB  = policyCreatedAt("same-b", time.Hour)
+	)
+
+	tests := map[string]struct {
+		maxPolicies int
+		policies    []policyapi.CertificateRequestPolicy
+		expPolicies []policyapi.CertificateRequestPolicy
+		expIgnored  float64
+	}{
+		"a maximum of 0 should return all policies": {
+			maxPolicies: 0,
+			policies:    []policyapi.CertificateRequestPolicy{sameB, oldest, sameA, middle},
+			expPolicies: []policyapi.CertificateRequestPolicy{sameB, oldest, sameA, middle},
+			expIgnored:  0,
+		},
+		"policies within the maximum should all be returned": {
+			maxPolicies: 4,
+			policies:    []policyapi.CertificateRequestPolicy{sameB, oldest, sameA, middle},
+			expPolicies: []policyapi.CertificateRequestPolicy{sameB, oldest, sameA, middle},
+			expIgnored:  0,
+		},
+		"policies beyond the maximum should return the oldest, ordered by name when created at the same time": {
+			maxPolicies: 3,
+			policies:    []policyapi.CertificateRequestPolicy{sameB, oldest, sameA, middle},
+			expPolicies: []policyapi.CertificateRequestPolicy{oldest, middle, sameA},
+			expIgnored:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policies, err := MaxPolicies(test.maxPolicies)(context.TODO(), nil, test.policies)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expPolicies, policies)
+			if test.maxPolicies > 0 {
+				assert.Equal(t, test.expIgnored, testutil.ToFloat64(metrics.PoliciesIgnoredCount))
+			}
+		})
+	}
+}
+
+func Test_SelectorMode(t *testing.T) {
+	policyWithSelector := func(name string, selector policyapi.CertificateRequestPolicySelector) policyapi.CertificateRequestPolicy {
+		return policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       policyapi.CertificateRequestPolicySpec{Selector: selector},
+		}
+	}
+
+	var (
+		emptyIssuerRef = policyWithSelector("empty-issuer-ref", policyapi.CertificateRequestPolicySelector{
+			IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+		})
+		emptyNamespace = policyWithSelector("empty-namespace", policyapi.CertificateRequestPolicySelector{
+			Namespace: &policyapi.CertificateRequestPolicySelectorNamespace{MatchLabels: map[string]string{}},
+		})
+		emptyAll = policyWithSelector("empty-all", policyapi.CertificateRequestPolicySelector{
+			IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+			Mode:      ptr.To(policyapi.SelectorModeAll),
+		})
+		emptyNone = policyWithSelector("empty-none", policyapi.CertificateRequestPolicySelector{
+			IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+			Mode:      ptr.To(policyapi.SelectorModeNone),
+		})
+		issuerName = policyWithSelector("issuer-name", policyapi.CertificateRequestPolicySelector{
+			IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("*")},
+			Mode:      ptr.To(policyapi.SelectorModeNone),
+		})
+		namespaceNames = policyWithSelector("namespace-names", policyapi.CertificateRequestPolicySelector{
+			IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+			Namespace: &policyapi.CertificateRequestPolicySelectorNamespace{MatchNames: []string{"*"}},
+		})
+	)
+
+	tests := map[string]struct {
+		defaultMode policyapi.SelectorMode
+		policies    []policyapi.CertificateRequestPolicy
+		expPolicies []policyapi.CertificateRequestPolicy
+	}{
+		"no given policies should return no policies": {
+			defaultMode: policyapi.SelectorModeAll,
+			policies:    nil,
+			expPolicies: nil,
+		},
+		"default mode All should return empty selectors unless they set mode None": {
+			defaultMode: policyapi.SelectorModeAll,
+			policies:    []policyapi.CertificateRequestPolicy{emptyIssuerRef, emptyNamespace, emptyAll, emptyNone},
+			expPolicies: []policyapi.CertificateRequestPolicy{emptyIssuerRef, emptyNamespace, emptyAll},
+		},
+		"default mode None should only return empty selectors which set mode All": {
+			defaultMode: policyapi.SelectorModeNone,
+			policies:    []policyapi.CertificateRequestPolicy{emptyIssuerRef, emptyNamespace, emptyAll, emptyNone},
+			expPolicies: []policyapi.CertificateRequestPolicy{emptyAll},
+		},
+		"selectors which are not empty should always be returned, regardless of mode": {
+			defaultMode: policyapi.SelectorModeNone,
+			policies:    []policyapi.CertificateRequestPolicy{issuerName, namespaceNames},
+			expPolicies: []policyapi.CertificateRequestPolicy{issuerName, namespaceNames},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policies, err := SelectorMode(test.defaultMode)(context.TODO(), nil, test.policies)
+			assert.NoError(t, err)
+			if !apiequality.Semantic.DeepEqual(test.expPolicies, policies) {
+				t.Errorf("unexpected policies returned:\nexp=%#+v\ngot=%#+v", test.expPolicies, policies)
+			}
+		})
+	}
+}
+
 func Test_SelectorIssuerRef(t *testing.T) {
 	baseRequest := &cmapi.CertificateRequest{
 		Spec: cmapi.CertificateRequestSpec{
@@ -518,6 +692,12 @@ func Test_SelectorIssuerRef(t *testing.T) {
 		},
 	}
 
+	aliases := map[string]cmmeta.ObjectReference{
+		"test-alias":    {Name: "test-name", Kind: "test-kind", Group: "test-group"},
+		"other-alias":   {Name: "other-name", Kind: "test-kind", Group: "test-group"},
+		"default-alias": {Name: "my-issuer", Kind: "Issuer", Group: "cert-manager.io"},
+	}
+
 	tests := map[string]struct {
 		request     *cmapi.CertificateRequest
 		policies    []policyapi.CertificateRequestPolicy
@@ -715,6 +895,39 @@ func Test_SelectorIssuerRef(t *testing.T) {
 				}},
 			},
 		},
+		"if policy selects an alias which resolves to the request's issuer, return policy": {
+			policies: []policyapi.CertificateRequestPolicy{
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("test-alias")}},
+				}},
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("other-alias")}},
+				}},
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("unknown-alias")}},
+				}},
+			},
+			expPolicies: []policyapi.CertificateRequestPolicy{
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("test-alias")}},
+				}},
+			},
+		},
+		"if policy selects an alias which resolves to cert-manager defaults and request omits defaults, return policy": {
+			request: &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{
+				Name: "my-issuer",
+			}}},
+			policies: []policyapi.CertificateRequestPolicy{
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("default-alias")}},
+				}},
+			},
+			expPolicies: []policyapi.CertificateRequestPolicy{
+				{Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("default-alias")}},
+				}},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -722,7 +935,7 @@ func Test_SelectorIssuerRef(t *testing.T) {
 			if test.request == nil {
 				test.request = baseRequest
 			}
-			policies, err := SelectorIssuerRef(context.TODO(), test.request, test.policies)
+			policies, err := SelectorIssuerRef(aliases)(context.TODO(), test.request, test.policies)
 			assert.NoError(t, err)
 			if !apiequality.Semantic.DeepEqual(test.expPolicies, policies) {
 				t.Errorf("unexpected policies returned:\nexp=%#+v\ngot=%#+v", test.expPolicies, policies)
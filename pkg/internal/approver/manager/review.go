@@ -19,20 +19,39 @@ package manager
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
 	"github.com/cert-manager/approver-policy/pkg/approver/manager"
 	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 var _ manager.Interface = &mngr{}
 
+const (
+	// maxAdvisoryEvaluations is the maximum number of requests evaluated by
+	// advisory evaluators at once. Sampled requests are skipped while the
+	// limit is reached.
+	maxAdvisoryEvaluations = 16
+
+	// advisoryEvaluationTimeout bounds the evaluation of a request by an
+	// advisory evaluator.
+	advisoryEvaluationTimeout = 30 * time.Second
+)
+
 // mngr is an implementation of an Approver Manager. It will manage
 // filtering CertificiateRequestPolicies based on predicates, and evaluating
 // CertificateRequests using the registered evaluators.
@@ -40,6 +59,25 @@ type mngr struct {
 	lister     client.Reader
 	predicates []predicate.Predicate
 	evaluators []approver.Evaluator
+
+	// limit is the maximum number of policies evaluated, applied to the
+	// candidate policies before the predicates.
+	limit *predicate.PolicyLimit
+
+	// index, if not nil, is used to select the candidate policies of a
+	// request instead of listing every policy.
+	index *PolicyIndex
+
+	// sampling configures the evaluation of advisory evaluators.
+	sampling AdvisorySampling
+
+	// advisory holds a token for each advisory evaluation in progress,
+	// bounding them to its capacity.
+	advisory chan struct{}
+
+	// random returns a random number in [0, 100) which can be overwritten for
+	// testing.
+	random func() float64
 }
 
 // policyMessage holds the name of the CertificateRequestPolicy and aggregated
@@ -49,6 +87,10 @@ type policyMessage struct {
 	// response by the evaluators.
 	name string
 
+	// generation is the metadata.generation of the CertificateRequestPolicy
+	// at the time it was evaluated.
+	generation int64
+
 	// message is the aggregated messages returned from the evaluators for this
 	// policy.
 	message string
@@ -58,22 +100,78 @@ type policyMessage struct {
 // CertificateRequests should be approved or denied, managing registered
 // evaluators.
 // CertificateRequestPolicies will be filtered on Review for evaluation with the predicates:
+//   - CertificateRequestPolicy is within the maximum number of policies
 //   - CertificateRequestPolicy is ready
+//   - CertificateRequestPolicy is not an expired break glass policy
+//   - CertificateRequestPolicy does not have an empty selector with mode None
 //   - CertificateRequestPolicy Selector.IssuerRef matches the CertificateRequest
 //
 // IssuerRef
 //   - CertificateRequestPolicy is bound to the user that appears in the
 //     CertificateRequest
-func New(lister client.Reader, client client.Client, evaluators []approver.Evaluator) manager.Interface {
-	return &mngr{
-		lister: lister,
-		predicates: []predicate.Predicate{
-			predicate.Ready,
-			predicate.SelectorIssuerRef,
-			predicate.SelectorNamespace(lister),
-			predicate.RBACBound(client),
-		},
+//
+// opts configures the predicates, and sampling configures the evaluation of
+// advisory evaluators.
+func New(lister client.Reader, client client.Client, evaluators []approver.Evaluator, opts PredicateOptions, sampling AdvisorySampling) manager.Interface {
+	m := &mngr{
+		lister:     lister,
+		predicates: filterPredicates(lister, client, opts),
 		evaluators: evaluators,
+		limit:      predicate.NewPolicyLimit(opts.MaxPolicies),
+		index:      opts.Index,
+		sampling:   sampling,
+		advisory:   make(chan struct{}, maxAdvisoryEvaluations),
+		random:     func() float64 { return rand.Float64() * 100 },
+	}
+	return m
+}
+
+// AdvisorySampling configures the evaluation of advisory evaluators, whose
+// results are only reported as metrics.
+type AdvisorySampling struct {
+	// Rates are the percentage, from 0 to 100, of requests evaluated by each
+	// advisory evaluator, keyed by evaluator name. Advisory evaluators without
+	// a rate evaluate every request.
+	Rates map[string]int
+}
+
+// PredicateOptions configure the predicates that filter the
+// CertificateRequestPolicies evaluated for a request.
+type PredicateOptions struct {
+	// DefaultSelectorMode is the mode of policies with an empty selector
+	// which do not set a mode.
+	DefaultSelectorMode policyapi.SelectorMode
+
+	// IssuerAliases resolves the issuer aliases referenced by policy
+	// selectors.
+	IssuerAliases map[string]cmmeta.ObjectReference
+
+	// MaxPolicies is the maximum number of policies that are evaluated. If
+	// more policies exist, the newest are ignored. 0 is unlimited.
+	MaxPolicies int
+
+	// Index, if not nil, selects the candidate policies of a request by the
+	// issuer they select, rather than listing every policy. It must be built
+	// with the same MaxPolicies.
+	Index *PolicyIndex
+}
+
+// Predicates returns the predicates that the approver Manager uses to filter
+// the CertificateRequestPolicies that are evaluated for a request.
+func Predicates(lister client.Reader, client client.Client, opts PredicateOptions) []predicate.Predicate {
+	return append([]predicate.Predicate{predicate.MaxPolicies(opts.MaxPolicies)}, filterPredicates(lister, client, opts)...)
+}
+
+// filterPredicates returns the predicates which filter the policies within
+// the maximum number of policies.
+func filterPredicates(lister client.Reader, client client.Client, opts PredicateOptions) []predicate.Predicate {
+	return []predicate.Predicate{
+		predicate.Ready,
+		predicate.BreakGlassUnexpired(clock.RealClock{}),
+		predicate.SelectorMode(opts.DefaultSelectorMode),
+		predicate.SelectorIssuerRef(opts.IssuerAliases),
+		predicate.SelectorNamespace(lister),
+		predicate.RBACBound(client),
 	}
 }
 
@@ -81,22 +179,18 @@ func New(lister client.Reader, client client.Client, evaluators []approver.Evalu
 // approved. All evaluators will be called with CertificateRequestPolicys that
 // have passed all of the predicates.
 func (m *mngr) Review(ctx context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
-	policyList := new(policyapi.CertificateRequestPolicyList)
-	if err := m.lister.List(ctx, policyList); err != nil {
+	policies, exist, err := m.candidates(ctx, cr)
+	if err != nil {
 		return manager.ReviewResponse{}, err
 	}
 
 	// If no CertificateRequestPolicies exist in the cluster, return
 	// ResultUnprocessed. A CertificateRequest may be re-evaluated at a later
 	// time if a CertificateRequestPolicy is created.
-	if len(policyList.Items) == 0 {
+	if !exist {
 		return manager.ReviewResponse{Result: manager.ResultUnprocessed, Message: "No CertificateRequestPolicies exist"}, nil
 	}
 
-	var (
-		policies = policyList.Items
-		err      error
-	)
 	for _, predicate := range m.predicates {
 		policies, err = predicate(ctx, cr, policies)
 		if err != nil {
@@ -112,64 +206,329 @@ func (m *mngr) Review(ctx context.Context, cr *cmapi.CertificateRequest) (manage
 		}, nil
 	}
 
+	// Advisory evaluators never affect the review, so are evaluated in the
+	// background.
+	m.evaluateAdvisory(cr, policies)
+
 	// policyMessages hold the aggregated messages of each evaluator response,
 	// keyed by the policy name that was executed.
 	var policyMessages []policyMessage
 
+	// Break glass policies are only consulted if no other policy approves the
+	// request, so that approvals are not attributed to them needlessly.
+	var breakGlassPolicies []policyapi.CertificateRequestPolicy
+
+	// approvedBy holds the revisions of the policies which approved the
+	// request.
+	var approvedBy []manager.PolicyRevision
+
+	// warnings holds the warnings of the evaluators of the policies which
+	// approved the request.
+	var warnings []string
+
 	// Run every evaluators against ever policy which is bound to the requesting
 	// user.
 	for _, policy := range policies {
-		var (
-			evaluatorDenied   bool
-			evaluatorMessages []string
-		)
-
-		for _, evaluator := range m.evaluators {
-			// #nosec G601 -- False positive. The function does not keep this pointer past its scope.
-			response, err := evaluator.Evaluate(ctx, &policy, cr)
-			if err != nil {
-				// if a single evaluator errors, then return early without trying
-				// others.
-				return manager.ReviewResponse{}, err
-			}
-
-			if len(response.Message) > 0 {
-				evaluatorMessages = append(evaluatorMessages, response.Message)
-			}
+		if policy.Spec.BreakGlass != nil {
+			breakGlassPolicies = append(breakGlassPolicies, policy)
+			continue
+		}
 
-			// evaluatorDenied will be set to true if any evaluator denies. We don't
-			// break early so that we can capture the responses from _all_
-			// evaluators.
-			if response.Result == approver.ResultDenied {
-				evaluatorDenied = true
-			}
+		// #nosec G601 -- False positive. The function does not keep this pointer past its scope.
+		evaluatorDenied, message, policyWarnings, err := m.evaluate(ctx, &policy, cr)
+		if err != nil {
+			return manager.ReviewResponse{}, err
 		}
 
-		// If no evaluator denied the request, return with approved response.
+		// If no evaluator denied the request, the policy approves it. Continue
+		// evaluating so that every approving policy is recorded.
 		if !evaluatorDenied {
-			return manager.ReviewResponse{
-				Result:  manager.ResultApproved,
-				Message: fmt.Sprintf("Approved by CertificateRequestPolicy: %q", policy.Name),
-			}, nil
+			approvedBy = append(approvedBy, manager.PolicyRevision{
+				Name:       policy.Name,
+				Generation: policy.Generation,
+			})
+			warnings = append(warnings, prefixWarnings(policy.Name, policyWarnings)...)
+			continue
 		}
 
 		// Collect evaluator messages that were executed for this policy.
-		policyMessages = append(policyMessages, policyMessage{name: policy.Name, message: strings.Join(evaluatorMessages, ", ")})
+		policyMessages = append(policyMessages, policyMessage{name: policy.Name, generation: policy.Generation, message: message})
+	}
+
+	if len(approvedBy) > 0 {
+		sort.SliceStable(approvedBy, func(i, j int) bool {
+			return approvedBy[i].Name < approvedBy[j].Name
+		})
+
+		message := fmt.Sprintf("Approved by CertificateRequestPolicy: %q", approvedBy[0].Name)
+		if len(approvedBy) > 1 {
+			names := make([]string, 0, len(approvedBy))
+			for _, revision := range approvedBy {
+				names = append(names, strconv.Quote(revision.Name))
+			}
+			message = fmt.Sprintf("Approved by CertificateRequestPolicies: %s", strings.Join(names, ", "))
+		}
+
+		return manager.ReviewResponse{
+			Result:        manager.ResultApproved,
+			Message:       message,
+			ApprovedBy:    &approvedBy[0],
+			ApprovedByAll: approvedBy,
+			Warnings:      sortedWarnings(warnings),
+		}, nil
+	}
+
+	if len(breakGlassPolicies) > 0 {
+		sort.SliceStable(breakGlassPolicies, func(i, j int) bool {
+			return breakGlassPolicies[i].Name < breakGlassPolicies[j].Name
+		})
+		policy := breakGlassPolicies[0]
+		return manager.ReviewResponse{
+			Result: manager.ResultApproved,
+			Message: fmt.Sprintf("Approved by break glass CertificateRequestPolicy: %q for incident %q, expires at %s",
+				policy.Name, policy.Spec.BreakGlass.IncidentRef, policy.Spec.BreakGlass.ExpiresAt.UTC().Format(time.RFC3339)),
+			ApprovedBy: &manager.PolicyRevision{
+				Name:       policy.Name,
+				Generation: policy.Generation,
+			},
+			ApprovedByAll: []manager.PolicyRevision{{
+				Name:       policy.Name,
+				Generation: policy.Generation,
+			}},
+			BreakGlass: policy.Spec.BreakGlass.DeepCopy(),
+		}, nil
 	}
 
 	// Sort messages by policy name and build message string.
 	sort.SliceStable(policyMessages, func(i, j int) bool {
 		return policyMessages[i].name < policyMessages[j].name
 	})
-	var messages []string
+	var (
+		messages []string
+		deniedBy []manager.PolicyRevision
+	)
 	for _, policyMessage := range policyMessages {
 		messages = append(messages, fmt.Sprintf("[%s: %s]", policyMessage.name, policyMessage.message))
+		deniedBy = append(deniedBy, manager.PolicyRevision{Name: policyMessage.name, Generation: policyMessage.generation})
 	}
 
 	// Return with all policies that we consulted, and their errors to why the
 	// request was denied.
 	return manager.ReviewResponse{
-		Result:  manager.ResultDenied,
-		Message: fmt.Sprintf("No policy approved this request: %s", strings.Join(messages, " ")),
+		Result:   manager.ResultDenied,
+		Message:  fmt.Sprintf("No policy approved this request: %s", strings.Join(messages, " ")),
+		DeniedBy: deniedBy,
 	}, nil
 }
+
+// candidates returns the policies within the maximum number of policies
+// which the predicates filter for the request, and whether any policies exist
+// in the cluster.
+func (m *mngr) candidates(ctx context.Context, cr *cmapi.CertificateRequest) ([]policyapi.CertificateRequestPolicy, bool, error) {
+	if m.index != nil && m.index.HasSynced() {
+		// The index only returns candidates within the maximum, so the
+		// ignored policies are only reported.
+		m.limit.Report(ctx, m.index.Len())
+		return m.index.Candidates(cr), m.index.Len() > 0, nil
+	}
+
+	policyList := new(policyapi.CertificateRequestPolicyList)
+	if err := m.lister.List(ctx, policyList); err != nil {
+		return nil, false, err
+	}
+	policies, err := m.limit.Predicate(ctx, cr, policyList.Items)
+	return policies, len(policyList.Items) > 0, err
+}
+
+// evaluate runs every evaluator against the policy, returning whether any
+// evaluator denied the request, the aggregated messages of the evaluators and
+// their warnings.
+// An error is returned if an evaluator fails and its failure policy is Block.
+func (m *mngr) evaluate(ctx context.Context, policy *policyapi.CertificateRequestPolicy, cr *cmapi.CertificateRequest) (bool, string, []string, error) {
+	var (
+		evaluatorDenied   bool
+		evaluatorMessages []string
+		evaluatorWarnings []string
+	)
+
+	for _, evaluator := range m.evaluators {
+		// Evaluators scoped to other issuers do not evaluate the request.
+		if scoped, ok := evaluator.(approver.Scoped); ok && !scoped.Scope().IncludesIssuerGroup(cr.Spec.IssuerRef.Group) {
+			continue
+		}
+
+		// Advisory evaluators are evaluated once per request by
+		// evaluateAdvisory.
+		if advisory, ok := evaluator.(approver.AdvisoryEvaluator); ok && advisory.Advisory() {
+			continue
+		}
+
+		plugin, failurePolicy := pluginFailurePolicy(policy, evaluator)
+
+		var (
+			response approver.EvaluationResponse
+			warnings []string
+			err      error
+		)
+		if slices.Contains(policy.Status.UnreadyPlugins, plugin) {
+			err = fmt.Errorf("plugin %q is not ready", plugin)
+		} else {
+			response, warnings, err = evaluateWithWarnings(ctx, evaluator, policy, cr)
+		}
+
+		if err != nil {
+			if failurePolicy != policyapi.PluginFailurePolicyBlock {
+				logr.FromContextOrDiscard(ctx).V(2).Info("plugin failed to evaluate request, applying failure policy",
+					"plugin", plugin, "policy", policy.Name, "failurePolicy", failurePolicy, "error", err)
+				metrics.PluginFailures.WithLabelValues(plugin, string(failurePolicy)).Inc()
+			}
+
+			switch failurePolicy {
+			case policyapi.PluginFailurePolicyDeny:
+				// Don't expose the error to the client, only that the plugin
+				// failed.
+				response = approver.EvaluationResponse{
+					Result:  approver.ResultDenied,
+					Message: fmt.Sprintf("plugin %q failed to evaluate request", plugin),
+				}
+
+			case policyapi.PluginFailurePolicySkip:
+				continue
+
+			default:
+				// if a single evaluator errors, then return early without trying
+				// others.
+				return false, "", nil, err
+			}
+		}
+
+		if len(response.Message) > 0 {
+			evaluatorMessages = append(evaluatorMessages, response.Message)
+		}
+		evaluatorWarnings = append(evaluatorWarnings, warnings...)
+
+		// evaluatorDenied will be set to true if any evaluator denies. We don't
+		// break early so that we can capture the responses from _all_
+		// evaluators.
+		if response.Result == approver.ResultDenied {
+			evaluatorDenied = true
+		}
+	}
+
+	return evaluatorDenied, strings.Join(evaluatorMessages, ", "), evaluatorWarnings, nil
+}
+
+// evaluateWithWarnings runs the evaluator against the policy, returning its
+// warnings if it is a WarningEvaluator.
+func evaluateWithWarnings(ctx context.Context, evaluator approver.Evaluator, policy *policyapi.CertificateRequestPolicy, cr *cmapi.CertificateRequest) (approver.EvaluationResponse, []string, error) {
+	if warning, ok := evaluator.(approver.WarningEvaluator); ok {
+		return warning.EvaluateWithWarnings(ctx, policy, cr)
+	}
+	response, err := evaluator.Evaluate(ctx, policy, cr)
+	return response, nil, err
+}
+
+// prefixWarnings prefixes each evaluator warning with the name of the policy
+// it was raised for.
+func prefixWarnings(policyName string, warnings []string) []string {
+	prefixed := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		prefixed = append(prefixed, fmt.Sprintf("%s: %s", policyName, warning))
+	}
+	return prefixed
+}
+
+// sortedWarnings returns the warnings sorted and deduplicated, or nil if
+// there are none.
+func sortedWarnings(warnings []string) []string {
+	if len(warnings) == 0 {
+		return nil
+	}
+	slices.Sort(warnings)
+	return slices.Compact(warnings)
+}
+
+// evaluateAdvisory evaluates a sample of requests with each advisory
+// evaluator in the background, recording the result as a metric. Each
+// evaluator is sampled once per request, and evaluates it against every policy
+// which is not a break glass policy. The request is reported as denied if it
+// is denied under any policy. The result never affects the review of the
+// request.
+func (m *mngr) evaluateAdvisory(cr *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) {
+	var evaluated []policyapi.CertificateRequestPolicy
+	for _, policy := range policies {
+		if policy.Spec.BreakGlass == nil {
+			evaluated = append(evaluated, *policy.DeepCopy())
+		}
+	}
+	if len(evaluated) == 0 {
+		return
+	}
+	cr = cr.DeepCopy()
+
+	for _, evaluator := range m.evaluators {
+		advisory, ok := evaluator.(approver.AdvisoryEvaluator)
+		if !ok || !advisory.Advisory() {
+			continue
+		}
+		if scoped, ok := evaluator.(approver.Scoped); ok && !scoped.Scope().IncludesIssuerGroup(cr.Spec.IssuerRef.Group) {
+			continue
+		}
+
+		var name string
+		if named, ok := evaluator.(interface{ Name() string }); ok {
+			name = named.Name()
+		}
+
+		if rate, ok := m.sampling.Rates[name]; ok && m.random() >= float64(rate) {
+			metrics.AdvisoryEvaluations.WithLabelValues(name, metrics.AdvisorySkipped).Inc()
+			continue
+		}
+
+		select {
+		case m.advisory <- struct{}{}:
+		default:
+			metrics.AdvisoryEvaluations.WithLabelValues(name, metrics.AdvisorySkipped).Inc()
+			continue
+		}
+
+		go func() {
+			defer func() { <-m.advisory }()
+
+			// The review, and so its context, ends before the evaluation.
+			ctx, cancel := context.WithTimeout(context.Background(), advisoryEvaluationTimeout)
+			defer cancel()
+
+			result := metrics.AdvisoryNotDenied
+			for i := range evaluated {
+				response, err := advisory.Evaluate(ctx, &evaluated[i], cr)
+				if err != nil {
+					result = metrics.AdvisoryError
+					break
+				}
+				if response.Result == approver.ResultDenied {
+					result = metrics.AdvisoryDenied
+				}
+			}
+			metrics.AdvisoryEvaluations.WithLabelValues(name, result).Inc()
+		}()
+	}
+}
+
+// pluginFailurePolicy returns the plugin name and failure policy of the
+// evaluator if it is a plugin configured on the policy. Evaluators which are
+// not configured as a plugin on the policy return an empty name and the Block
+// failure policy.
+func pluginFailurePolicy(policy *policyapi.CertificateRequestPolicy, evaluator approver.Evaluator) (string, policyapi.PluginFailurePolicy) {
+	named, ok := evaluator.(interface{ Name() string })
+	if !ok {
+		return "", policyapi.PluginFailurePolicyBlock
+	}
+
+	plugin, ok := policy.Spec.Plugins[named.Name()]
+	if !ok || len(plugin.FailurePolicy) == 0 {
+		return "", policyapi.PluginFailurePolicyBlock
+	}
+
+	return named.Name(), plugin.FailurePolicy
+}
@@ -20,17 +20,22 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/ktesting"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
 	"github.com/cert-manager/approver-policy/pkg/approver/fake"
 	"github.com/cert-manager/approver-policy/pkg/approver/manager"
 	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 	testenv "github.com/cert-manager/approver-policy/test/env"
 )
 
@@ -52,6 +57,17 @@ func Test_Review(t *testing.T) {
 		})
 	}
 
+	fixedTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	breakGlassPolicy := func(expiresAt time.Time) policyapi.CertificateRequestPolicy {
+		return policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-policy-break-glass"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(expiresAt), IncidentRef: "INC-123"},
+				Selector:   policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+			},
+		}
+	}
+
 	tests := map[string]struct {
 		evaluator   func(t *testing.T) approver.Evaluator
 		predicate   func(t *testing.T) predicate.Predicate
@@ -117,8 +133,9 @@ func Test_Review(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
 				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
 			}},
-			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: "No policy approved this request: [test-policy-a: this is a denied response]"},
-			expErr:      false,
+			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: "No policy approved this request: [test-policy-a: this is a denied response]",
+				DeniedBy: []manager.PolicyRevision{{Name: "test-policy-a"}}},
+			expErr: false,
 		},
 		"if single policy returns and evaluator returns not-denied, return ResultApproved": {
 			evaluator: func(t *testing.T) approver.Evaluator {
@@ -138,7 +155,7 @@ func Test_Review(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
 				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
 			}},
-			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-a"`},
+			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-a"`, ApprovedBy: &manager.PolicyRevision{Name: "test-policy-a"}, ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-a"}}},
 			expErr:      false,
 		},
 		"if two policies returned and evaluator returns one not-denied, return ResultApproved": {
@@ -174,9 +191,43 @@ func Test_Review(t *testing.T) {
 					Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
 				},
 			},
-			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-b"`},
+			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-b"`, ApprovedBy: &manager.PolicyRevision{Name: "test-policy-b"}, ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-b"}}},
 			expErr:      false,
 		},
+		"if two policies returned and both return not-denied, return ResultApproved by both": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, _ *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+					return approver.EvaluationResponse{Result: approver.ResultNotDenied, Message: "this is an approved response"}, nil
+				})
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "test-policy-b", Generation: 2},
+							Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a", Generation: 1},
+							Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+						},
+					}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+					Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+				},
+			},
+			expResponse: manager.ReviewResponse{
+				Result:        manager.ResultApproved,
+				Message:       `Approved by CertificateRequestPolicies: "test-policy-a", "test-policy-b"`,
+				ApprovedBy:    &manager.PolicyRevision{Name: "test-policy-a", Generation: 1},
+				ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-a", Generation: 1}, {Name: "test-policy-b", Generation: 2}},
+			},
+			expErr: false,
+		},
 		"if two policies returned and both return denied, return ResultDenied": {
 			evaluator: func(t *testing.T) approver.Evaluator {
 				return fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, policy *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
@@ -207,9 +258,161 @@ func Test_Review(t *testing.T) {
 					Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
 				},
 			},
-			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: "No policy approved this request: [test-policy-a: this is a denied response] [test-policy-b: this is a denied response]"},
+			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: "No policy approved this request: [test-policy-a: this is a denied response] [test-policy-b: this is a denied response]",
+				DeniedBy: []manager.PolicyRevision{{Name: "test-policy-a"}, {Name: "test-policy-b"}}},
+			expErr: false,
+		},
+		"if plugin errors with failure policy Block, return an error": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return pluginEvaluator{name: "test-plugin", Evaluator: fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, _ *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+					return approver.EvaluationResponse{}, errors.New("this is an error")
+				})}
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+						Spec: policyapi.CertificateRequestPolicySpec{
+							Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicyBlock}},
+							Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+						},
+					}}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+			}},
+			expResponse: manager.ReviewResponse{},
+			expErr:      true,
+		},
+		"if plugin errors with failure policy Deny, return ResultDenied": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return pluginEvaluator{name: "test-plugin", Evaluator: fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, _ *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+					return approver.EvaluationResponse{}, errors.New("this is an error")
+				})}
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+						Spec: policyapi.CertificateRequestPolicySpec{
+							Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicyDeny}},
+							Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+						},
+					}}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+			}},
+			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: `No policy approved this request: [test-policy-a: plugin "test-plugin" failed to evaluate request]`,
+				DeniedBy: []manager.PolicyRevision{{Name: "test-policy-a"}}},
+			expErr: false,
+		},
+		"if plugin errors with failure policy Skip, return ResultApproved": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return pluginEvaluator{name: "test-plugin", Evaluator: fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, _ *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+					return approver.EvaluationResponse{}, errors.New("this is an error")
+				})}
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+						Spec: policyapi.CertificateRequestPolicySpec{
+							Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicySkip}},
+							Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+						},
+					}}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+			}},
+			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-a"`, ApprovedBy: &manager.PolicyRevision{Name: "test-policy-a"}, ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-a"}}},
 			expErr:      false,
 		},
+		"if plugin is unready with failure policy Deny, return ResultDenied without evaluating": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return pluginEvaluator{name: "test-plugin", Evaluator: expNoEvaluation(t)}
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+						Spec: policyapi.CertificateRequestPolicySpec{
+							Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicyDeny}},
+							Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+						},
+						Status: policyapi.CertificateRequestPolicyStatus{UnreadyPlugins: []string{"test-plugin"}},
+					}}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+			}},
+			expResponse: manager.ReviewResponse{Result: manager.ResultDenied, Message: `No policy approved this request: [test-policy-a: plugin "test-plugin" failed to evaluate request]`,
+				DeniedBy: []manager.PolicyRevision{{Name: "test-policy-a"}}},
+			expErr: false,
+		},
+		"if plugin is unready with failure policy Skip, return ResultApproved without evaluating": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return pluginEvaluator{name: "test-plugin", Evaluator: expNoEvaluation(t)}
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, _ []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return []policyapi.CertificateRequestPolicy{{
+						ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+						Spec: policyapi.CertificateRequestPolicySpec{
+							Plugins:  map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicySkip}},
+							Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+						},
+						Status: policyapi.CertificateRequestPolicyStatus{UnreadyPlugins: []string{"test-plugin"}},
+					}}, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+				Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+			}},
+			expResponse: manager.ReviewResponse{Result: manager.ResultApproved, Message: `Approved by CertificateRequestPolicy: "test-policy-a"`, ApprovedBy: &manager.PolicyRevision{Name: "test-policy-a"}, ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-a"}}},
+			expErr:      false,
+		},
+		"if no policy approves but a break glass policy is applicable, return ResultApproved by the break glass policy without evaluating it": {
+			evaluator: func(t *testing.T) approver.Evaluator {
+				return fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, policy *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+					if policy.Spec.BreakGlass != nil {
+						t.Fatal("unexpected evaluation of break glass policy")
+					}
+					return approver.EvaluationResponse{Result: approver.ResultDenied, Message: "this is a denied response"}, nil
+				})
+			},
+			predicate: func(t *testing.T) predicate.Predicate {
+				return func(_ context.Context, _ *cmapi.CertificateRequest, policies []policyapi.CertificateRequestPolicy) ([]policyapi.CertificateRequestPolicy, error) {
+					return policies, nil
+				}
+			},
+			policies: []policyapi.CertificateRequestPolicy{
+				breakGlassPolicy(fixedTime.Add(time.Hour)),
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy-a"},
+					Spec:       policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}}},
+				},
+			},
+			expResponse: manager.ReviewResponse{
+				Result:        manager.ResultApproved,
+				Message:       `Approved by break glass CertificateRequestPolicy: "test-policy-break-glass" for incident "INC-123", expires at 2024-03-01T13:00:00Z`,
+				ApprovedBy:    &manager.PolicyRevision{Name: "test-policy-break-glass", Generation: 1},
+				ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy-break-glass", Generation: 1}},
+				// Times are decoded from the API server in the local time zone.
+				BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(fixedTime.Add(time.Hour).Local()), IncidentRef: "INC-123"},
+			},
+			expErr: false,
+		},
 	}
 
 	for name, test := range tests {
@@ -253,3 +456,253 @@ func Test_Review(t *testing.T) {
 		})
 	}
 }
+
+func Test_evaluateAdvisory(t *testing.T) {
+	denied := fake.NewFakeEvaluator().WithEvaluate(func(_ context.Context, policy *policyapi.CertificateRequestPolicy, _ *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+		if policy.Name == "policy-b" {
+			return approver.EvaluationResponse{Result: approver.ResultDenied, Message: "bad reputation"}, nil
+		}
+		return approver.EvaluationResponse{Result: approver.ResultNotDenied}, nil
+	})
+	failing := fake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+		return approver.EvaluationResponse{}, errors.New("this is an error")
+	})
+
+	tests := map[string]struct {
+		evaluator approver.Evaluator
+		rates     map[string]int
+		random    float64
+		busy      bool
+
+		expResult string
+	}{
+		"an advisory evaluator without a sample rate should evaluate every request": {
+			evaluator: denied,
+			random:    99,
+			expResult: metrics.AdvisoryDenied,
+		},
+		"an advisory evaluator should evaluate requests within its sample rate": {
+			evaluator: denied,
+			rates:     map[string]int{"reputation": 10},
+			random:    9.5,
+			expResult: metrics.AdvisoryDenied,
+		},
+		"an advisory evaluator should skip requests outside of its sample rate": {
+			evaluator: denied,
+			rates:     map[string]int{"reputation": 10},
+			random:    10,
+			expResult: metrics.AdvisorySkipped,
+		},
+		"an advisory evaluator with a sample rate of 0 should skip every request": {
+			evaluator: denied,
+			rates:     map[string]int{"reputation": 0},
+			random:    0,
+			expResult: metrics.AdvisorySkipped,
+		},
+		"an advisory evaluator which errors should not fail the review": {
+			evaluator: failing,
+			random:    0,
+			expResult: metrics.AdvisoryError,
+		},
+		"a sampled request should be skipped while the maximum number of evaluations are in progress": {
+			evaluator: denied,
+			random:    0,
+			busy:      true,
+			expResult: metrics.AdvisorySkipped,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			counter := metrics.AdvisoryEvaluations.WithLabelValues("reputation", test.expResult)
+			before := testutil.ToFloat64(counter)
+
+			mngr := &mngr{
+				evaluators: []approver.Evaluator{
+					advisoryEvaluator{pluginEvaluator{name: "reputation", Evaluator: test.evaluator}},
+				},
+				sampling: AdvisorySampling{Rates: test.rates},
+				advisory: make(chan struct{}, 1),
+				random:   func() float64 { return test.random },
+			}
+			if test.busy {
+				mngr.advisory <- struct{}{}
+			}
+
+			// Advisory evaluators never affect the evaluation of a policy.
+			denied, message, _, err := mngr.evaluate(context.TODO(), &policyapi.CertificateRequestPolicy{}, &cmapi.CertificateRequest{})
+			assert.NoError(t, err)
+			assert.False(t, denied)
+			assert.Empty(t, message)
+
+			// The request is evaluated once against every policy.
+			mngr.evaluateAdvisory(&cmapi.CertificateRequest{}, []policyapi.CertificateRequestPolicy{
+				{ObjectMeta: metav1.ObjectMeta{Name: "policy-a"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "policy-b"}},
+			})
+			assert.Eventually(t, func() bool {
+				return testutil.ToFloat64(counter) == before+1
+			}, time.Second*5, time.Millisecond*10)
+		})
+	}
+}
+
+func Test_evaluateWarnings(t *testing.T) {
+	mngr := &mngr{
+		evaluators: []approver.Evaluator{
+			warningEvaluator{warnings: []string{"duration close to maximum"}},
+			warningEvaluator{},
+			fake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+				return approver.EvaluationResponse{Result: approver.ResultNotDenied}, nil
+			}),
+			warningEvaluator{warnings: []string{"deprecated key size", "duration close to maximum"}},
+		},
+	}
+
+	denied, _, warnings, err := mngr.evaluate(context.TODO(), &policyapi.CertificateRequestPolicy{}, &cmapi.CertificateRequest{})
+	assert.NoError(t, err)
+	assert.False(t, denied)
+	assert.Equal(t, []string{"duration close to maximum", "deprecated key size", "duration close to maximum"}, warnings)
+
+	assert.Equal(t, []string{"test-policy: deprecated key size", "test-policy: duration close to maximum"},
+		sortedWarnings(prefixWarnings("test-policy", warnings)))
+	assert.Nil(t, sortedWarnings(prefixWarnings("test-policy", nil)))
+}
+
+func Test_evaluatePluginFailure(t *testing.T) {
+	failing := pluginEvaluator{name: "test-plugin", Evaluator: fake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+		return approver.EvaluationResponse{}, errors.New("this is an error")
+	})}
+
+	tests := map[string]struct {
+		failurePolicy policyapi.PluginFailurePolicy
+		expDenied     bool
+		expErr        bool
+		expLogged     bool
+	}{
+		"failures of plugins with failure policy Deny should be logged and counted": {
+			failurePolicy: policyapi.PluginFailurePolicyDeny,
+			expDenied:     true,
+			expLogged:     true,
+		},
+		"failures of plugins with failure policy Skip should be logged and counted": {
+			failurePolicy: policyapi.PluginFailurePolicySkip,
+			expLogged:     true,
+		},
+		"failures of plugins with failure policy Block should be returned": {
+			failurePolicy: policyapi.PluginFailurePolicyBlock,
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			metrics.PluginFailures.Reset()
+
+			log := ktesting.NewLogger(t, ktesting.NewConfig(ktesting.BufferLogs(true), ktesting.Verbosity(2)))
+			ctx := logr.NewContext(context.TODO(), log)
+
+			mngr := &mngr{evaluators: []approver.Evaluator{failing}}
+			policy := &policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Plugins: map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: test.failurePolicy}},
+				},
+			}
+
+			denied, _, _, err := mngr.evaluate(ctx, policy, &cmapi.CertificateRequest{})
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expDenied, denied)
+
+			expCount := 0.0
+			if test.expLogged {
+				expCount = 1
+			}
+			assert.Equal(t, expCount, testutil.ToFloat64(metrics.PluginFailures.WithLabelValues("test-plugin", string(test.failurePolicy))))
+
+			logs := log.GetSink().(ktesting.Underlier).GetBuffer().String()
+			if test.expLogged {
+				assert.Contains(t, logs, "plugin failed to evaluate request")
+				assert.Contains(t, logs, `plugin="test-plugin" policy="test-policy"`)
+				assert.Contains(t, logs, "this is an error")
+			} else {
+				assert.Empty(t, logs)
+			}
+		})
+	}
+}
+
+func Test_evaluateScope(t *testing.T) {
+	deny := func(message string) *fake.FakeEvaluator {
+		return fake.NewFakeEvaluator().WithEvaluate(func(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+			return approver.EvaluationResponse{Result: approver.ResultDenied, Message: message}, nil
+		})
+	}
+
+	mngr := &mngr{
+		evaluators: []approver.Evaluator{
+			fake.NewFakeApprover().WithScope(approver.Scope{IssuerGroups: []string{"venafi.cert-manager.io"}}).WithEvaluator(deny("venafi")),
+			fake.NewFakeApprover().WithEvaluator(deny("every issuer")),
+		},
+	}
+
+	tests := map[string]struct {
+		issuerGroup string
+		expMessage  string
+	}{
+		"requests for issuers within the scope should be evaluated by the scoped evaluator": {
+			issuerGroup: "venafi.cert-manager.io",
+			expMessage:  "venafi, every issuer",
+		},
+		"requests for other issuers should not be evaluated by the scoped evaluator": {
+			issuerGroup: "awspca.cert-manager.io",
+			expMessage:  "every issuer",
+		},
+		"requests omitting the issuer group should be of the cert-manager.io group": {
+			expMessage: "every issuer",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Group: test.issuerGroup}}}
+			denied, message, _, err := mngr.evaluate(context.TODO(), &policyapi.CertificateRequestPolicy{}, cr)
+			assert.NoError(t, err)
+			assert.True(t, denied)
+			assert.Equal(t, test.expMessage, message)
+		})
+	}
+}
+
+// warningEvaluator is an Evaluator which never denies, reporting warnings.
+type warningEvaluator struct {
+	warnings []string
+}
+
+func (w warningEvaluator) Evaluate(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, error) {
+	return approver.EvaluationResponse{Result: approver.ResultNotDenied}, nil
+}
+
+func (w warningEvaluator) EvaluateWithWarnings(context.Context, *policyapi.CertificateRequestPolicy, *cmapi.CertificateRequest) (approver.EvaluationResponse, []string, error) {
+	return approver.EvaluationResponse{Result: approver.ResultNotDenied}, w.warnings, nil
+}
+
+// advisoryEvaluator is a named Evaluator whose results are advisory.
+type advisoryEvaluator struct {
+	pluginEvaluator
+}
+
+func (advisoryEvaluator) Advisory() bool {
+	return true
+}
+
+// pluginEvaluator is an Evaluator which is named, in the same way as
+// registered plugin approvers.
+type pluginEvaluator struct {
+	name string
+	approver.Evaluator
+}
+
+func (p pluginEvaluator) Name() string {
+	return p.name
+}
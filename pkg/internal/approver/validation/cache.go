@@ -16,7 +16,23 @@ limitations under the License.
 
 package validation
 
-import "sync"
+import (
+	"container/list"
+	"sync"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+const (
+	// validatorBaseSize is the estimated memory, in bytes, of a compiled
+	// validator regardless of its expression. Each validator holds its own CEL
+	// environment and program, which dominate its size.
+	validatorBaseSize = 40 << 10
+
+	// validatorBytesPerChar is the estimated memory, in bytes, of each
+	// character of a compiled validator's expression.
+	validatorBytesPerChar = 16
+)
 
 // Cache maintains a cache of compiled validators.
 // The current implementation is a simple lazy cache meaning:
@@ -24,46 +40,126 @@ import "sync"
 // 1. Whenever a validator is requested, it first checks the cache.
 // 2. If a compiled validator exists for the supplied CEL expression, it is returned.
 // 3. If the validator doesn't exist in the cache, a new validator is created, compiled, added to the cache, and returned.
+//
+// If a memory limit is set, the least recently used validators are evicted
+// once the estimated memory of the cached validators exceeds it, and are
+// compiled again when next requested.
 type Cache interface {
 	// Get returns a compiled validator for the supplied CEL expression.
 	// Any compilation errors will be returned to the caller.
 	//
 	// The supplied CEL expression must output a bool.
 	Get(expr string) (Validator, error)
+
+	// SetMaxBytes sets the limit of the estimated memory of the cached
+	// validators. A limit of 0 or less is unlimited.
+	SetMaxBytes(maxBytes int64)
 }
 
 type cache struct {
-	m sync.Map
+	lock sync.Mutex
+
+	// maxBytes is the limit of the estimated memory of the cached validators.
+	maxBytes int64
+
+	// bytes is the estimated memory of the cached validators.
+	bytes int64
+
+	// entries holds the elements of lru, keyed by expression.
+	entries map[string]*list.Element
+
+	// lru holds the cached entries, most recently used first.
+	lru *list.List
 }
 
 type cacheEntry struct {
+	expr      string
 	validator *validator
 	err       error
 }
 
 func (c *cache) Get(expr string) (Validator, error) {
 	// First check if cache contains validator for expression
-	o, ok := c.m.Load(expr)
-	if ok {
-		ce := o.(*cacheEntry)
+	if ce, ok := c.load(expr); ok {
 		return ce.validator, ce.err
 	}
 
 	// Expression did not exist in cache. Create a new validator, compile it
 	// and add the result to cache.
 	// Theoretically this could lead to the same expression being compiled multiple times,
-	// but guarding against that would require locking and increase complexity.
+	// but guarding against that would require holding the lock while compiling.
 	v := &validator{expression: expr}
 	err := v.compile()
 	if err != nil {
 		v = nil
 	}
-	o, _ = c.m.LoadOrStore(expr, &cacheEntry{validator: v, err: err})
-	ce := o.(*cacheEntry)
+	ce := c.store(&cacheEntry{expr: expr, validator: v, err: err})
 	return ce.validator, ce.err
 }
 
+func (c *cache) SetMaxBytes(maxBytes int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.maxBytes = maxBytes
+	c.evict()
+}
+
+// load returns the cached entry of the expression, marking it as most
+// recently used.
+func (c *cache) load(expr string) (*cacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[expr]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry), true
+}
+
+// store adds the entry to the cache, returning the entry already cached for
+// the expression if it was stored concurrently.
+func (c *cache) store(ce *cacheEntry) *cacheEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[ce.expr]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry)
+	}
+
+	c.entries[ce.expr] = c.lru.PushFront(ce)
+	c.bytes += estimateSize(ce.expr)
+	c.evict()
+
+	return ce
+}
+
+// evict removes the least recently used entries until the estimated memory
+// is within the limit. The most recently used entry is never evicted, so that
+// an expression larger than the limit can still be evaluated.
+func (c *cache) evict() {
+	for c.maxBytes > 0 && c.bytes > c.maxBytes && c.lru.Len() > 1 {
+		ce := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, ce.expr)
+		c.bytes -= estimateSize(ce.expr)
+		metrics.CompiledMatchersEvictions.Inc()
+	}
+	metrics.CompiledMatchersMemory.Set(float64(c.bytes))
+}
+
+// estimateSize returns the estimated memory, in bytes, of the compiled
+// validator of the expression.
+func estimateSize(expr string) int64 {
+	return validatorBaseSize + int64(len(expr))*validatorBytesPerChar
+}
+
 // NewCache is a constructor for cache of compiled CEL expression validators.
 func NewCache() Cache {
-	return &cache{}
+	return &cache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
 }
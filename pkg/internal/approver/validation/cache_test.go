@@ -17,9 +17,14 @@ limitations under the License.
 package validation
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 func Test_Cache_Get(t *testing.T) {
@@ -54,3 +59,41 @@ func Test_Cache_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_Cache_MaxBytes(t *testing.T) {
+	c := NewCache()
+	exprA := "self.endsWith('a')"
+	exprB := "self.endsWith('b')"
+	exprC := "self.endsWith('c')"
+
+	// Allow two of the three expressions to be cached.
+	c.SetMaxBytes(estimateSize(exprA) * 2)
+	evictions := testutil.ToFloat64(metrics.CompiledMatchersEvictions)
+
+	a, err := c.Get(exprA)
+	require.NoError(t, err)
+	_, err = c.Get(exprB)
+	require.NoError(t, err)
+
+	// Using A makes B the least recently used expression, which is evicted
+	// when C is added.
+	same, _ := c.Get(exprA)
+	assert.Same(t, a, same)
+	_, err = c.Get(exprC)
+	require.NoError(t, err)
+
+	assert.Equal(t, evictions+1, testutil.ToFloat64(metrics.CompiledMatchersEvictions))
+	assert.Equal(t, float64(estimateSize(exprA)*2), testutil.ToFloat64(metrics.CompiledMatchersMemory))
+	same, _ = c.Get(exprA)
+	assert.Same(t, a, same, "recently used expressions should not be evicted")
+
+	// An expression larger than the limit is still cached, evicting all
+	// others.
+	c.SetMaxBytes(1)
+	assert.Equal(t, evictions+2, testutil.ToFloat64(metrics.CompiledMatchersEvictions))
+	large := "self.endsWith('" + strings.Repeat("d", 100) + "')"
+	v, err := c.Get(large)
+	require.NoError(t, err)
+	same, _ = c.Get(large)
+	assert.Same(t, v, same)
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/utils/clock"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+)
+
+const (
+	// AnnotationDecision is the audit event annotation key holding the
+	// decision made on the CertificateRequest, either "Approved" or "Denied".
+	AnnotationDecision = "policy.cert-manager.io/decision"
+
+	// AnnotationMessage is the audit event annotation key holding the message
+	// of the review, which includes the policies consulted.
+	AnnotationMessage = "policy.cert-manager.io/message"
+
+	// AnnotationApprovedBy is the audit event annotation key holding a JSON
+	// list of the name and generation of every policy which approved the
+	// request. Only set on approved decisions.
+	AnnotationApprovedBy = "policy.cert-manager.io/approved-by-policies"
+
+	// bufferSize is the number of events that may be waiting to be written
+	// before new events are dropped.
+	bufferSize = 1024
+
+	// maxBatchSize is the maximum number of events written to sinks in a
+	// single call.
+	maxBatchSize = 100
+)
+
+// Sink is a destination that audit events are written to.
+type Sink interface {
+	// Write writes the batch of audit events to the sink.
+	Write(context.Context, []auditv1.Event) error
+}
+
+// Exporter exports approval decisions as Kubernetes audit events
+// (audit.k8s.io/v1), so that existing audit pipelines can ingest decisions
+// alongside API server audit logs without custom parsing. Exported events are
+// buffered and written to sinks by the Exporter runnable, so that slow sinks
+// do not block reconciliation.
+type Exporter struct {
+	log     logr.Logger
+	clock   clock.PassiveClock
+	encoder *Encoder
+	sinks   []Sink
+
+	events chan auditv1.Event
+}
+
+// NewExporter returns a new Exporter which builds events with the given
+// encoder and writes them to the given sinks. The Exporter must be started for
+// events to be written.
+func NewExporter(log logr.Logger, encoder *Encoder, sinks ...Sink) *Exporter {
+	return &Exporter{
+		log:     log.WithName("audit"),
+		clock:   clock.RealClock{},
+		encoder: encoder,
+		sinks:   sinks,
+		events:  make(chan auditv1.Event, bufferSize),
+	}
+}
+
+// Export queues an audit event for the decision made on the given
+// CertificateRequest. Only Approved and Denied decisions are exported. If the
+// buffer is full the event is dropped and an error is logged.
+func (e *Exporter) Export(cr *cmapi.CertificateRequest, response manager.ReviewResponse) {
+	event, err := e.encoder.Event(cr, response, e.clock.Now())
+	if err != nil {
+		e.log.Error(err, "failed to build audit event", "namespace", cr.Namespace, "name", cr.Name)
+		return
+	}
+	if event == nil {
+		return
+	}
+
+	select {
+	case e.events <- *event:
+	default:
+		e.log.Error(nil, "audit event buffer full, dropping event", "namespace", cr.Namespace, "name", cr.Name)
+	}
+}
+
+// Start writes queued audit events to all sinks until the context is
+// cancelled.
+func (e *Exporter) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-e.events:
+			batch := []auditv1.Event{event}
+		drain:
+			for len(batch) < maxBatchSize {
+				select {
+				case event := <-e.events:
+					batch = append(batch, event)
+				default:
+					break drain
+				}
+			}
+
+			for _, sink := range e.sinks {
+				if err := sink.Write(ctx, batch); err != nil {
+					e.log.Error(err, "failed to write audit events", "count", len(batch))
+				}
+			}
+		}
+	}
+}
+
+// Encoder builds audit events from decisions, redacting requester values
+// according to its redaction rules. Every sink receives the same redacted
+// events.
+type Encoder struct {
+	redactor redactor
+}
+
+// NewEncoder returns an Encoder which applies the given redaction rules, in
+// order, to the requester of every event.
+func NewEncoder(rules ...RedactionRule) *Encoder {
+	return &Encoder{redactor: rules}
+}
+
+// Username returns the username redacted by the rules of the Encoder. A
+// dropped username is returned empty.
+func (e *Encoder) Username(username string) string {
+	if username, ok := e.redactor.value(username); ok {
+		return username
+	}
+	return ""
+}
+
+// NewEvent builds an audit event for the decision made on the given
+// CertificateRequest without redacting any requester values. See
+// Encoder.Event.
+func NewEvent(cr *cmapi.CertificateRequest, response manager.ReviewResponse, now time.Time) (*auditv1.Event, error) {
+	return NewEncoder().Event(cr, response, now)
+}
+
+// Event builds an audit event at stage ResponseComplete for the decision made
+// on the given CertificateRequest. The requesting user of the
+// CertificateRequest, including their groups and extra values, is recorded as
+// the event user, and the request itself is recorded as the request object.
+// Redaction rules are applied to both. A nil event is returned for decisions
+// which are neither Approved nor Denied.
+func (e *Encoder) Event(cr *cmapi.CertificateRequest, response manager.ReviewResponse, now time.Time) (*auditv1.Event, error) {
+	var decision string
+	switch response.Result {
+	case manager.ResultApproved:
+		decision = string(cmapi.CertificateRequestConditionApproved)
+	case manager.ResultDenied:
+		decision = string(cmapi.CertificateRequestConditionDenied)
+	default:
+		return nil, nil
+	}
+
+	cr = cr.DeepCopy()
+	cr.APIVersion = cmapi.SchemeGroupVersion.String()
+	cr.Kind = cmapi.CertificateRequestKind
+	cr.ManagedFields = nil
+	cr.Spec.Username = e.Username(cr.Spec.Username)
+	cr.Spec.Groups = e.redactor.values(cr.Spec.Groups)
+	cr.Spec.Extra = e.redactor.extra(cr.Spec.Extra)
+	raw, err := json.Marshal(cr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CertificateRequest: %w", err)
+	}
+
+	annotations := map[string]string{
+		AnnotationDecision: decision,
+		AnnotationMessage:  response.Message,
+	}
+	if len(response.ApprovedByAll) > 0 {
+		approvedBy, err := json.Marshal(response.ApprovedByAll)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode approving policies: %w", err)
+		}
+		annotations[AnnotationApprovedBy] = string(approvedBy)
+	}
+
+	var extra map[string]authnv1.ExtraValue
+	if cr.Spec.Extra != nil {
+		extra = make(map[string]authnv1.ExtraValue, len(cr.Spec.Extra))
+		for key, values := range cr.Spec.Extra {
+			extra[key] = values
+		}
+	}
+
+	timestamp := metav1.NewMicroTime(now)
+
+	return &auditv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: auditv1.SchemeGroupVersion.String(),
+			Kind:       "Event",
+		},
+		Level:      auditv1.LevelRequest,
+		AuditID:    uuid.NewUUID(),
+		Stage:      auditv1.StageResponseComplete,
+		RequestURI: fmt.Sprintf("/apis/%s/namespaces/%s/certificaterequests/%s/status", cmapi.SchemeGroupVersion, cr.Namespace, cr.Name),
+		Verb:       "patch",
+		User: authnv1.UserInfo{
+			Username: cr.Spec.Username,
+			UID:      cr.Spec.UID,
+			Groups:   cr.Spec.Groups,
+			Extra:    extra,
+		},
+		UserAgent: "approver-policy",
+		ObjectRef: &auditv1.ObjectReference{
+			Resource:        "certificaterequests",
+			Namespace:       cr.Namespace,
+			Name:            cr.Name,
+			UID:             cr.UID,
+			APIGroup:        cmapi.SchemeGroupVersion.Group,
+			APIVersion:      cmapi.SchemeGroupVersion.Version,
+			ResourceVersion: cr.ResourceVersion,
+			Subresource:     "status",
+		},
+		ResponseStatus: &metav1.Status{
+			Status: metav1.StatusSuccess,
+			Code:   200,
+		},
+		RequestObject: &runtime.Unknown{
+			Raw:         raw,
+			ContentType: runtime.ContentTypeJSON,
+		},
+		RequestReceivedTimestamp: timestamp,
+		StageTimestamp:           timestamp,
+		Annotations:              annotations,
+	}, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+)
+
+func Test_NewEvent(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "test-ns",
+			Name:            "test-cr",
+			UID:             "cr-uid",
+			ResourceVersion: "10",
+		},
+		Spec: cmapi.CertificateRequestSpec{
+			Username: "user-1",
+			UID:      "user-uid",
+		},
+	}
+
+	tests := map[string]struct {
+		response      manager.ReviewResponse
+		expDecision   string
+		expApprovedBy string
+		expNil        bool
+	}{
+		"an approved response should return an Approved event": {
+			response:    manager.ReviewResponse{Result: manager.ResultApproved, Message: "Approved by CertificateRequestPolicy: \"test-policy\""},
+			expDecision: "Approved",
+		},
+		"an approved response by multiple policies should record every policy": {
+			response: manager.ReviewResponse{
+				Result:        manager.ResultApproved,
+				Message:       `Approved by CertificateRequestPolicies: "policy-a", "policy-b"`,
+				ApprovedByAll: []manager.PolicyRevision{{Name: "policy-a", Generation: 1}, {Name: "policy-b", Generation: 4}},
+			},
+			expDecision:   "Approved",
+			expApprovedBy: `[{"name":"policy-a","generation":1},{"name":"policy-b","generation":4}]`,
+		},
+		"a denied response should return a Denied event": {
+			response:    manager.ReviewResponse{Result: manager.ResultDenied, Message: "No policy approved this request"},
+			expDecision: "Denied",
+		},
+		"an unprocessed response should return no event": {
+			response: manager.ReviewResponse{Result: manager.ResultUnprocessed},
+			expNil:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			event, err := NewEvent(cr, test.response, now)
+			require.NoError(t, err)
+			if test.expNil {
+				assert.Nil(t, event)
+				return
+			}
+
+			require.NotNil(t, event)
+			assert.Equal(t, auditv1.StageResponseComplete, event.Stage)
+			assert.Equal(t, "/apis/cert-manager.io/v1/namespaces/test-ns/certificaterequests/test-cr/status", event.RequestURI)
+			assert.Equal(t, authnv1.UserInfo{Username: "user-1", UID: "user-uid"}, event.User)
+			assert.Equal(t, &auditv1.ObjectReference{
+				Resource:        "certificaterequests",
+				Namespace:       "test-ns",
+				Name:            "test-cr",
+				UID:             "cr-uid",
+				APIGroup:        "cert-manager.io",
+				APIVersion:      "v1",
+				ResourceVersion: "10",
+				Subresource:     "status",
+			}, event.ObjectRef)
+			assert.Equal(t, metav1.NewMicroTime(now), event.StageTimestamp)
+			expAnnotations := map[string]string{
+				AnnotationDecision: test.expDecision,
+				AnnotationMessage:  test.response.Message,
+			}
+			if len(test.expApprovedBy) > 0 {
+				expAnnotations[AnnotationApprovedBy] = test.expApprovedBy
+			}
+			assert.Equal(t, expAnnotations, event.Annotations)
+
+			var got cmapi.CertificateRequest
+			require.NoError(t, json.Unmarshal(event.RequestObject.Raw, &got))
+			assert.Equal(t, "CertificateRequest", got.Kind)
+			assert.Equal(t, cr.Spec, got.Spec)
+		})
+	}
+}
+
+func Test_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.TODO(), []auditv1.Event{{AuditID: "1"}, {AuditID: "2"}}))
+	require.NoError(t, sink.Write(context.TODO(), []auditv1.Event{{AuditID: "3"}}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event auditv1.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		ids = append(ids, string(event.AuditID))
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
+
+func Test_WebhookSink(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		expErr     bool
+	}{
+		"a successful response should not error": {
+			statusCode: http.StatusOK,
+		},
+		"an unsuccessful response should error": {
+			statusCode: http.StatusInternalServerError,
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got auditv1.EventList
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				w.WriteHeader(test.statusCode)
+			}))
+			defer server.Close()
+
+			err := NewWebhookSink(server.URL, time.Second).Write(context.TODO(), []auditv1.Event{{AuditID: "1"}})
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, "EventList", got.Kind)
+			assert.Equal(t, []auditv1.Event{{AuditID: "1"}}, got.Items)
+		})
+	}
+}
+
+type fakeSink chan []auditv1.Event
+
+func (f fakeSink) Write(_ context.Context, events []auditv1.Event) error {
+	f <- events
+	return nil
+}
+
+func Test_Exporter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	sink := make(fakeSink, 1)
+	exporter := NewExporter(logr.Discard(), NewEncoder(), sink)
+
+	cr := &cmapi.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-cr"}}
+	exporter.Export(cr, manager.ReviewResponse{Result: manager.ResultUnprocessed})
+	exporter.Export(cr, manager.ReviewResponse{Result: manager.ResultApproved, Message: "approved"})
+
+	go func() { _ = exporter.Start(ctx) }()
+
+	select {
+	case events := <-sink:
+		require.Len(t, events, 1)
+		assert.Equal(t, "Approved", events[0].Annotations[AnnotationDecision])
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for audit events")
+	}
+}
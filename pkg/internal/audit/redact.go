@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactionAction is the action taken on requester values which match a
+// RedactionRule.
+type RedactionAction string
+
+const (
+	// RedactionActionDrop removes matching values from audit events. A
+	// matching username is replaced with an empty string.
+	RedactionActionDrop RedactionAction = "drop"
+
+	// RedactionActionHash replaces matching values with the hex encoded
+	// SHA-256 hash of the value, prefixed with "sha256:". Hashed values can
+	// still be correlated across events without being recorded.
+	RedactionActionHash RedactionAction = "hash"
+)
+
+// RedactionRule redacts the requester's username, groups and extra values
+// which match Pattern from audit events. An extra key which matches Pattern
+// has the action applied to all of its values, or is removed entirely if the
+// action is drop.
+type RedactionRule struct {
+	Action  RedactionAction
+	Pattern *regexp.Regexp
+}
+
+// ParseRedactionRule parses a redaction rule of the form "<action>=<regex>",
+// for example "drop=^authentication.kubernetes.io/credential-id$" or
+// "hash=@".
+func ParseRedactionRule(rule string) (RedactionRule, error) {
+	action, pattern, ok := strings.Cut(rule, "=")
+	if !ok {
+		return RedactionRule{}, fmt.Errorf("invalid audit redaction rule %q, must be of the form <action>=<regex>", rule)
+	}
+
+	switch RedactionAction(action) {
+	case RedactionActionDrop, RedactionActionHash:
+	default:
+		return RedactionRule{}, fmt.Errorf("invalid audit redaction rule %q, action must be one of %q or %q", rule, RedactionActionDrop, RedactionActionHash)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return RedactionRule{}, fmt.Errorf("invalid audit redaction rule %q: %w", rule, err)
+	}
+
+	return RedactionRule{Action: RedactionAction(action), Pattern: re}, nil
+}
+
+// redactor applies redaction rules to requester values. The first matching
+// rule is applied.
+type redactor []RedactionRule
+
+// value returns the redacted value, and false if the value should be
+// dropped.
+func (r redactor) value(v string) (string, bool) {
+	for _, rule := range r {
+		if !rule.Pattern.MatchString(v) {
+			continue
+		}
+		if rule.Action == RedactionActionDrop {
+			return "", false
+		}
+		return hashValue(v), true
+	}
+	return v, true
+}
+
+// values returns the redacted values, with dropped values removed.
+func (r redactor) values(vs []string) []string {
+	if vs == nil {
+		return nil
+	}
+	redacted := make([]string, 0, len(vs))
+	for _, v := range vs {
+		if v, ok := r.value(v); ok {
+			redacted = append(redacted, v)
+		}
+	}
+	return redacted
+}
+
+// extra returns the redacted extra values. Rules matching a key are applied
+// to all of its values, otherwise rules are applied to each value.
+func (r redactor) extra(extra map[string][]string) map[string][]string {
+	if extra == nil {
+		return nil
+	}
+	redacted := make(map[string][]string, len(extra))
+extra:
+	for key, vs := range extra {
+		for _, rule := range r {
+			if !rule.Pattern.MatchString(key) {
+				continue
+			}
+			if rule.Action == RedactionActionHash {
+				hashed := make([]string, len(vs))
+				for i, v := range vs {
+					hashed[i] = hashValue(v)
+				}
+				redacted[key] = hashed
+			}
+			continue extra
+		}
+		redacted[key] = r.values(vs)
+	}
+	return redacted
+}
+
+// hashValue returns the hex encoded SHA-256 hash of the value.
+func hashValue(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
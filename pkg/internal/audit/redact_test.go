@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+)
+
+func Test_ParseRedactionRule(t *testing.T) {
+	tests := map[string]struct {
+		rule      string
+		expAction RedactionAction
+		expErr    bool
+	}{
+		"a drop rule should parse": {
+			rule:      "drop=^authentication.kubernetes.io/credential-id$",
+			expAction: RedactionActionDrop,
+		},
+		"a hash rule should parse": {
+			rule:      "hash=@",
+			expAction: RedactionActionHash,
+		},
+		"a rule without an action should error": {
+			rule:   "@",
+			expErr: true,
+		},
+		"an unknown action should error": {
+			rule:   "mask=@",
+			expErr: true,
+		},
+		"an invalid regex should error": {
+			rule:   "drop=(",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule, err := ParseRedactionRule(test.rule)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expAction, rule.Action)
+		})
+	}
+}
+
+func Test_EncoderRedaction(t *testing.T) {
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "test-cr"},
+		Spec: cmapi.CertificateRequestSpec{
+			Username: "jane@example.com",
+			UID:      "user-uid",
+			Groups:   []string{"system:authenticated", "team@example.com", "secret-group"},
+			Extra: map[string][]string{
+				"authentication.kubernetes.io/credential-id": {"JTI=1234"},
+				"scopes": {"read", "mail:bob@example.com"},
+			},
+		},
+	}
+
+	var rules []RedactionRule
+	for _, rule := range []string{"drop=^authentication.kubernetes.io/credential-id$", "drop=^secret-", "hash=@"} {
+		parsed, err := ParseRedactionRule(rule)
+		require.NoError(t, err)
+		rules = append(rules, parsed)
+	}
+
+	event, err := NewEncoder(rules...).Event(cr, manager.ReviewResponse{Result: manager.ResultDenied}, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, event)
+
+	expUser := authnv1.UserInfo{
+		Username: hashValue("jane@example.com"),
+		UID:      "user-uid",
+		Groups:   []string{"system:authenticated", hashValue("team@example.com")},
+		Extra: map[string]authnv1.ExtraValue{
+			"scopes": {"read", hashValue("mail:bob@example.com")},
+		},
+	}
+	assert.Equal(t, expUser, event.User)
+
+	var got cmapi.CertificateRequest
+	require.NoError(t, json.Unmarshal(event.RequestObject.Raw, &got))
+	assert.Equal(t, expUser.Username, got.Spec.Username)
+	assert.Equal(t, expUser.Groups, got.Spec.Groups)
+	assert.Equal(t, map[string][]string{"scopes": {"read", hashValue("mail:bob@example.com")}}, got.Spec.Extra)
+
+	assert.Equal(t, "jane@example.com", cr.Spec.Username, "the CertificateRequest should not be modified")
+
+	event, err = NewEvent(cr, manager.ReviewResponse{Result: manager.ResultDenied}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, cr.Spec.Groups, event.User.Groups, "groups should be recorded without redaction rules")
+	assert.Equal(t, authnv1.ExtraValue{"JTI=1234"}, event.User.Extra["authentication.kubernetes.io/credential-id"])
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// fileSink writes audit events as JSON lines, matching the format of the
+// Kubernetes API server audit log backend.
+type fileSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewFileSink returns a Sink which appends audit events as JSON lines to the
+// file at the given path. If path is "-", events are written to stdout.
+func NewFileSink(path string) (Sink, error) {
+	if path == "-" {
+		return &fileSink{w: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file %q: %w", path, err)
+	}
+
+	return &fileSink{w: f}, nil
+}
+
+func (f *fileSink) Write(_ context.Context, events []auditv1.Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return fmt.Errorf("failed to encode audit event: %w", err)
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, err := f.w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write audit events: %w", err)
+	}
+
+	return nil
+}
+
+// webhookSink POSTs batches of audit events as an audit EventList, matching
+// the format of the Kubernetes API server audit webhook backend.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a Sink which POSTs batches of audit events to the
+// given URL.
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *webhookSink) Write(ctx context.Context, events []auditv1.Event) error {
+	body, err := json.Marshal(&auditv1.EventList{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: auditv1.SchemeGroupVersion.String(),
+			Kind:       "EventList",
+		},
+		Items: events,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build audit webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
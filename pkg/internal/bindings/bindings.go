@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
+)
+
+const (
+	// resource is the RBAC resource of CertificateRequestPolicies.
+	resource = "certificaterequestpolicies"
+
+	// verb is the RBAC verb that binds a CertificateRequestPolicy to a
+	// subject.
+	verb = "use"
+)
+
+// Binding is a subject which has been granted the "use" verb on a
+// CertificateRequestPolicy.
+type Binding struct {
+	// Policy is the name of the CertificateRequestPolicy.
+	Policy string `json:"policy"`
+
+	// Subject is the user, group or ServiceAccount that is bound.
+	Subject rbacv1.Subject `json:"subject"`
+
+	// Namespace is the namespace the binding is scoped to. Empty when bound
+	// with a ClusterRoleBinding, meaning requests in all namespaces.
+	Namespace string `json:"namespace,omitempty"`
+
+	// BindingKind and BindingName identify the RoleBinding or
+	// ClusterRoleBinding that grants the binding.
+	BindingKind string `json:"bindingKind"`
+	BindingName string `json:"bindingName"`
+
+	// RoleKind and RoleName identify the Role or ClusterRole referenced by
+	// the binding.
+	RoleKind string `json:"roleKind"`
+	RoleName string `json:"roleName"`
+}
+
+// Scope returns a human readable description of the namespaces the binding
+// applies to.
+func (b Binding) Scope() string {
+	if len(b.Namespace) > 0 {
+		return "namespace " + b.Namespace
+	}
+	return "all namespaces"
+}
+
+// BindingRef returns the name of the RoleBinding or ClusterRoleBinding that
+// grants the binding, prefixed with its namespace for RoleBindings.
+func (b Binding) BindingRef() string {
+	if len(b.Namespace) > 0 {
+		return b.Namespace + "/" + b.BindingName
+	}
+	return b.BindingName
+}
+
+// RBAC is a snapshot of the RBAC resources of a cluster.
+type RBAC struct {
+	Roles               []rbacv1.Role
+	ClusterRoles        []rbacv1.ClusterRole
+	RoleBindings        []rbacv1.RoleBinding
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding
+
+	// rules caches the rules of every Role and ClusterRole, keyed by
+	// roleKey. Built on the first call to Resolve.
+	rules map[roleKey][]rbacv1.PolicyRule
+}
+
+// roleKey identifies a Role or ClusterRole. Namespace is empty for
+// ClusterRoles.
+type roleKey struct {
+	kind, namespace, name string
+}
+
+// List lists all RBAC resources using the given reader.
+func List(ctx context.Context, reader client.Reader) (*RBAC, error) {
+	var (
+		roles               rbacv1.RoleList
+		clusterRoles        rbacv1.ClusterRoleList
+		roleBindings        rbacv1.RoleBindingList
+		clusterRoleBindings rbacv1.ClusterRoleBindingList
+	)
+
+	for _, list := range []client.ObjectList{&roles, &clusterRoles, &roleBindings, &clusterRoleBindings} {
+		if err := reader.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list %T: %w", list, err)
+		}
+	}
+
+	return &RBAC{
+		Roles:               roles.Items,
+		ClusterRoles:        clusterRoles.Items,
+		RoleBindings:        roleBindings.Items,
+		ClusterRoleBindings: clusterRoleBindings.Items,
+	}, nil
+}
+
+// Resolve returns every Binding of a subject to one of the given
+// CertificateRequestPolicy names.
+func (r *RBAC) Resolve(policies []string) []Binding {
+	var bindings []Binding
+
+	for _, crb := range r.ClusterRoleBindings {
+		_, rules, ok := r.roleRefRules("", crb.RoleRef)
+		if !ok {
+			continue
+		}
+		bindings = append(bindings, bind(policies, rules, crb.Subjects, "", "ClusterRoleBinding", crb.Name, crb.RoleRef)...)
+	}
+
+	for _, rb := range r.RoleBindings {
+		_, rules, ok := r.roleRefRules(rb.Namespace, rb.RoleRef)
+		if !ok {
+			continue
+		}
+		bindings = append(bindings, bind(policies, rules, rb.Subjects, rb.Namespace, "RoleBinding", rb.Name, rb.RoleRef)...)
+	}
+
+	return bindings
+}
+
+// roleRefRules returns the key and rules of the role referenced by a binding
+// in the given namespace, which is empty for ClusterRoleBindings. ok is false
+// if the role is not in the snapshot, or cannot be referenced by the binding.
+func (r *RBAC) roleRefRules(namespace string, roleRef rbacv1.RoleRef) (key roleKey, rules []rbacv1.PolicyRule, ok bool) {
+	switch roleRef.Kind {
+	case "ClusterRole":
+		key = roleKey{kind: "ClusterRole", name: roleRef.Name}
+	case "Role":
+		// Roles can only be referenced by RoleBindings in their namespace.
+		if len(namespace) == 0 {
+			return roleKey{}, nil, false
+		}
+		key = roleKey{kind: "Role", namespace: namespace, name: roleRef.Name}
+	default:
+		return roleKey{}, nil, false
+	}
+	rules, ok = r.roleIndex()[key]
+	return key, rules, ok
+}
+
+// roleIndex returns the cached rules of every role, building the cache if
+// needed, so that resolving many bindings doesn't scan every role for each.
+func (r *RBAC) roleIndex() map[roleKey][]rbacv1.PolicyRule {
+	if r.rules != nil {
+		return r.rules
+	}
+
+	r.rules = make(map[roleKey][]rbacv1.PolicyRule, len(r.ClusterRoles)+len(r.Roles))
+	for _, cr := range r.ClusterRoles {
+		r.rules[roleKey{kind: "ClusterRole", name: cr.Name}] = cr.Rules
+	}
+	for _, role := range r.Roles {
+		r.rules[roleKey{kind: "Role", namespace: role.Namespace, name: role.Name}] = role.Rules
+	}
+	return r.rules
+}
+
+func bind(policies []string, rules []rbacv1.PolicyRule, subjects []rbacv1.Subject, namespace, bindingKind, bindingName string, roleRef rbacv1.RoleRef) []Binding {
+	var bindings []Binding
+	for _, policyName := range policies {
+		if !slices.ContainsFunc(rules, func(rule rbacv1.PolicyRule) bool { return RuleGrantsUse(rule, policyName) }) {
+			continue
+		}
+
+		for _, subject := range subjects {
+			// ServiceAccount subjects in RoleBindings default to the namespace
+			// of the binding.
+			if subject.Kind == rbacv1.ServiceAccountKind && len(subject.Namespace) == 0 {
+				subject.Namespace = namespace
+			}
+
+			bindings = append(bindings, Binding{
+				Policy:      policyName,
+				Subject:     subject,
+				Namespace:   namespace,
+				BindingKind: bindingKind,
+				BindingName: bindingName,
+				RoleKind:    roleRef.Kind,
+				RoleName:    roleRef.Name,
+			})
+		}
+	}
+	return bindings
+}
+
+// RuleGrantsUse returns true if the RBAC rule grants the "use" verb on the
+// named CertificateRequestPolicy.
+func RuleGrantsUse(rule rbacv1.PolicyRule, policyName string) bool {
+	return matches(rule.APIGroups, policy.GroupName) &&
+		matches(rule.Resources, resource) &&
+		matches(rule.Verbs, verb) &&
+		(len(rule.ResourceNames) == 0 || slices.Contains(rule.ResourceNames, policyName))
+}
+
+// RuleGrantsAnyUse returns true if the RBAC rule grants the "use" verb on
+// any CertificateRequestPolicy.
+func RuleGrantsAnyUse(rule rbacv1.PolicyRule) bool {
+	return matches(rule.APIGroups, policy.GroupName) &&
+		matches(rule.Resources, resource) &&
+		matches(rule.Verbs, verb)
+}
+
+func matches(values []string, value string) bool {
+	return slices.Contains(values, rbacv1.ResourceAll) || slices.Contains(values, value)
+}
+
+// SubjectString returns a human readable, unique representation of the
+// subject.
+func SubjectString(subject rbacv1.Subject) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return fmt.Sprintf("%s:%s/%s", subject.Kind, subject.Namespace, subject.Name)
+	}
+	return fmt.Sprintf("%s:%s", subject.Kind, subject.Name)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_RuleGrantsUse(t *testing.T) {
+	tests := map[string]struct {
+		rule   rbacv1.PolicyRule
+		expUse bool
+	}{
+		"a rule for the policy should grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}, ResourceNames: []string{"test-policy"}},
+			expUse: true,
+		},
+		"a rule for all policies should grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}},
+			expUse: true,
+		},
+		"a wildcard rule should grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			expUse: true,
+		},
+		"a rule for a different policy should not grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}, ResourceNames: []string{"other-policy"}},
+			expUse: false,
+		},
+		"a rule with a different verb should not grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"get"}},
+			expUse: false,
+		},
+		"a rule for a different group should not grant use": {
+			rule:   rbacv1.PolicyRule{APIGroups: []string{"cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}},
+			expUse: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expUse, RuleGrantsUse(test.rule, "test-policy"))
+		})
+	}
+}
+
+func Test_Resolve(t *testing.T) {
+	useRule := rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}, ResourceNames: []string{"test-policy"}}
+
+	rbac := &RBAC{
+		ClusterRoles: []rbacv1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "unrelated"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}},
+		},
+		Roles: []rbacv1.Role{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule}},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "crb"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "unrelated"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "unrelated"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "missing-role"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "does-not-exist"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "carol"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "role"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "use-policy"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dave"}},
+			},
+		},
+		RoleBindings: []rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "rb"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "use-policy"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "sa"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns-2", Name: "rb"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "use-policy"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "group"}},
+			},
+		},
+	}
+
+	assert.Equal(t, []Binding{
+		{
+			Policy:      "test-policy",
+			Subject:     rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"},
+			BindingKind: "ClusterRoleBinding",
+			BindingName: "crb",
+			RoleKind:    "ClusterRole",
+			RoleName:    "use-policy",
+		},
+		{
+			Policy:      "test-policy",
+			Subject:     rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "ns-1", Name: "sa"},
+			Namespace:   "ns-1",
+			BindingKind: "RoleBinding",
+			BindingName: "rb",
+			RoleKind:    "Role",
+			RoleName:    "use-policy",
+		},
+	}, rbac.Resolve([]string{"test-policy", "other-policy"}))
+}
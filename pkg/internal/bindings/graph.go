@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// NodeKind is the kind of a node in a binding Graph.
+type NodeKind string
+
+const (
+	// NodeSubject is a user, group or ServiceAccount.
+	NodeSubject NodeKind = "Subject"
+
+	// NodeBinding is a RoleBinding or ClusterRoleBinding.
+	NodeBinding NodeKind = "Binding"
+
+	// NodePolicy is a CertificateRequestPolicy.
+	NodePolicy NodeKind = "CertificateRequestPolicy"
+
+	// NodeIssuer is the issuer selector of a CertificateRequestPolicy.
+	NodeIssuer NodeKind = "Issuer"
+)
+
+// Node is a node in a binding Graph.
+type Node struct {
+	// ID uniquely identifies the node in the graph.
+	ID string `json:"id"`
+
+	// Kind is the kind of the node.
+	Kind NodeKind `json:"kind"`
+
+	// Label is the human readable name of the node.
+	Label string `json:"label"`
+}
+
+// Edge is a directed edge between two nodes in a binding Graph.
+type Edge struct {
+	// From and To are the IDs of the nodes the edge connects.
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Label optionally describes the edge, such as the namespace a binding
+	// is scoped to.
+	Label string `json:"label,omitempty"`
+}
+
+// Graph is the graph of subjects, the bindings which bind them to
+// CertificateRequestPolicies, and the issuers the policies select. It shows
+// who is able to obtain certificates from which issuers.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// NewGraph builds the Graph of the given policies and the bindings to them,
+// as returned by RBAC.Resolve, so the graph shows the same subjects as the
+// bindings which grant use of the policies. Nodes and edges are sorted by ID.
+func NewGraph(policies []policyapi.CertificateRequestPolicy, bindings []Binding) *Graph {
+	nodes := make(map[string]Node)
+	edges := make(map[Edge]struct{})
+
+	addNode := func(kind NodeKind, id, label string) string {
+		id = fmt.Sprintf("%s:%s", kind, id)
+		nodes[id] = Node{ID: id, Kind: kind, Label: label}
+		return id
+	}
+
+	for _, policy := range policies {
+		policyID := addNode(NodePolicy, policy.Name, policy.Name)
+		issuer := issuerSelector(policy.Spec.Selector.IssuerRef)
+		edges[Edge{From: policyID, To: addNode(NodeIssuer, issuer, issuer)}] = struct{}{}
+	}
+
+	for _, binding := range bindings {
+		policyID := fmt.Sprintf("%s:%s", NodePolicy, binding.Policy)
+		if _, ok := nodes[policyID]; !ok {
+			continue
+		}
+
+		subject := SubjectString(binding.Subject)
+		subjectID := addNode(NodeSubject, subject, subject)
+
+		bindingRef := binding.BindingRef()
+		bindingID := addNode(NodeBinding, binding.BindingKind+":"+bindingRef,
+			fmt.Sprintf("%s %s (%s %s)", binding.BindingKind, bindingRef, binding.RoleKind, binding.RoleName))
+
+		edges[Edge{From: subjectID, To: bindingID}] = struct{}{}
+		edges[Edge{From: bindingID, To: policyID, Label: binding.Scope()}] = struct{}{}
+	}
+
+	graph := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	slices.SortFunc(graph.Nodes, func(a, b Node) int { return strings.Compare(a.ID, b.ID) })
+	slices.SortFunc(graph.Edges, func(a, b Edge) int {
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To, b.To)
+	})
+
+	return graph
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph approverpolicy {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s];\n", dotQuote(node.ID), dotQuote(node.Label), dotShape(node.Kind))
+	}
+	for _, edge := range g.Edges {
+		if len(edge.Label) > 0 {
+			fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Label))
+		} else {
+			fmt.Fprintf(bw, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// issuerSelector returns a human readable representation of the issuer
+// selector of a policy in the form `<kind>.<group>/<name>`, where omitted
+// fields match all values.
+func issuerSelector(ref *policyapi.CertificateRequestPolicySelectorIssuerRef) string {
+	if ref == nil {
+		return "*.*/*"
+	}
+	if ref.Alias != nil {
+		return "alias:" + *ref.Alias
+	}
+
+	orWildcard := func(s *string) string {
+		if s == nil {
+			return "*"
+		}
+		return *s
+	}
+	return fmt.Sprintf("%s.%s/%s", orWildcard(ref.Kind), orWildcard(ref.Group), orWildcard(ref.Name))
+}
+
+func dotShape(kind NodeKind) string {
+	switch kind {
+	case NodeBinding:
+		return "box"
+	case NodePolicy:
+		return "note"
+	case NodeIssuer:
+		return "hexagon"
+	default:
+		return "ellipse"
+	}
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
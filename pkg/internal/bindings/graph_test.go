@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_NewGraph(t *testing.T) {
+	policies := []policyapi.CertificateRequestPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Kind: ptr.To("ClusterIssuer"), Name: ptr.To("internal-ca")},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "mtls"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("internal-mtls")},
+				},
+			},
+		},
+	}
+
+	graph := NewGraph(policies, []Binding{
+		{Policy: "internal", Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}, BindingKind: "ClusterRoleBinding", BindingName: "crb", RoleKind: "ClusterRole", RoleName: "use-internal"},
+		{Policy: "mtls", Subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "ns-1", Name: "sa"}, Namespace: "ns-1", BindingKind: "RoleBinding", BindingName: "rb", RoleKind: "Role", RoleName: "use-mtls"},
+		{Policy: "deleted", Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "bob"}, BindingKind: "ClusterRoleBinding", BindingName: "crb", RoleKind: "ClusterRole", RoleName: "use-deleted"},
+	})
+
+	assert.Equal(t, &Graph{
+		Nodes: []Node{
+			{ID: "Binding:ClusterRoleBinding:crb", Kind: NodeBinding, Label: "ClusterRoleBinding crb (ClusterRole use-internal)"},
+			{ID: "Binding:RoleBinding:ns-1/rb", Kind: NodeBinding, Label: "RoleBinding ns-1/rb (Role use-mtls)"},
+			{ID: "CertificateRequestPolicy:internal", Kind: NodePolicy, Label: "internal"},
+			{ID: "CertificateRequestPolicy:mtls", Kind: NodePolicy, Label: "mtls"},
+			{ID: "Issuer:ClusterIssuer.*/internal-ca", Kind: NodeIssuer, Label: "ClusterIssuer.*/internal-ca"},
+			{ID: "Issuer:alias:internal-mtls", Kind: NodeIssuer, Label: "alias:internal-mtls"},
+			{ID: "Subject:ServiceAccount:ns-1/sa", Kind: NodeSubject, Label: "ServiceAccount:ns-1/sa"},
+			{ID: "Subject:User:alice", Kind: NodeSubject, Label: "User:alice"},
+		},
+		Edges: []Edge{
+			{From: "Binding:ClusterRoleBinding:crb", To: "CertificateRequestPolicy:internal", Label: "all namespaces"},
+			{From: "Binding:RoleBinding:ns-1/rb", To: "CertificateRequestPolicy:mtls", Label: "namespace ns-1"},
+			{From: "CertificateRequestPolicy:internal", To: "Issuer:ClusterIssuer.*/internal-ca"},
+			{From: "CertificateRequestPolicy:mtls", To: "Issuer:alias:internal-mtls"},
+			{From: "Subject:ServiceAccount:ns-1/sa", To: "Binding:RoleBinding:ns-1/rb"},
+			{From: "Subject:User:alice", To: "Binding:ClusterRoleBinding:crb"},
+		},
+	}, graph)
+}
+
+func Test_WriteDOT(t *testing.T) {
+	graph := &Graph{
+		Nodes: []Node{
+			{ID: "Subject:User:alice", Kind: NodeSubject, Label: `User:"alice"`},
+			{ID: "CertificateRequestPolicy:internal", Kind: NodePolicy, Label: "internal"},
+		},
+		Edges: []Edge{
+			{From: "Subject:User:alice", To: "CertificateRequestPolicy:internal", Label: "all namespaces"},
+			{From: "CertificateRequestPolicy:internal", To: "Subject:User:alice"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, graph.WriteDOT(&buf))
+	assert.Equal(t, `digraph approverpolicy {
+  rankdir=LR;
+  "Subject:User:alice" [label="User:\"alice\"", shape=ellipse];
+  "CertificateRequestPolicy:internal" [label="internal", shape=note];
+  "Subject:User:alice" -> "CertificateRequestPolicy:internal" [label="all namespaces"];
+  "CertificateRequestPolicy:internal" -> "Subject:User:alice";
+}
+`, buf.String())
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Problem is an inconsistency between the CertificateRequestPolicies and RBAC
+// resources of a bundle of manifests.
+type Problem struct {
+	// Kind, Namespace and Name identify the object with the problem.
+	// Namespace is empty for cluster scoped objects.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// String returns the problem as a single human readable line.
+func (p Problem) String() string {
+	if len(p.Namespace) > 0 {
+		return fmt.Sprintf("%s %s/%s: %s", p.Kind, p.Namespace, p.Name, p.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", p.Kind, p.Name, p.Message)
+}
+
+// Verify statically checks that the RBAC resources are consistent with the
+// named CertificateRequestPolicies, treating them as a self-contained bundle.
+// Problems are returned for:
+//   - policies which are not bound to any subject;
+//   - rules granting use of named policies which are not in the bundle;
+//   - roles granting use of policies which no binding references;
+//   - bindings with no subjects which reference roles granting use of
+//     policies.
+//
+// Bindings referencing roles which are not in the bundle, such as the
+// built-in view ClusterRole, or roles which grant no use of policies are not
+// checked.
+func (r *RBAC) Verify(policies []string) []Problem {
+	var problems []Problem
+	problem := func(kind, namespace, name, message string, args ...any) {
+		problems = append(problems, Problem{Kind: kind, Namespace: namespace, Name: name, Message: fmt.Sprintf(message, args...)})
+	}
+
+	bound := make(map[string]bool)
+	for _, binding := range r.Resolve(policies) {
+		bound[binding.Policy] = true
+	}
+	for _, policyName := range policies {
+		if !bound[policyName] {
+			problem("CertificateRequestPolicy", "", policyName, "is not bound to any subject by a RoleBinding or ClusterRoleBinding")
+		}
+	}
+
+	referenced := make(map[roleKey]bool)
+	verifyBinding := func(kind, namespace, name string, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject) {
+		key, rules, ok := r.roleRefRules(namespace, roleRef)
+		if !ok {
+			return
+		}
+		referenced[key] = true
+
+		if !slices.ContainsFunc(rules, RuleGrantsAnyUse) {
+			return
+		}
+		if len(subjects) == 0 {
+			problem(kind, namespace, name, "has no subjects")
+		}
+	}
+	for _, crb := range r.ClusterRoleBindings {
+		verifyBinding("ClusterRoleBinding", "", crb.Name, crb.RoleRef, crb.Subjects)
+	}
+	for _, rb := range r.RoleBindings {
+		verifyBinding("RoleBinding", rb.Namespace, rb.Name, rb.RoleRef, rb.Subjects)
+	}
+
+	verifyRole := func(key roleKey, rules []rbacv1.PolicyRule) {
+		grantsUse := false
+		for i, rule := range rules {
+			if !RuleGrantsAnyUse(rule) {
+				continue
+			}
+			grantsUse = true
+			for _, resourceName := range rule.ResourceNames {
+				if !slices.Contains(policies, resourceName) {
+					problem(key.kind, key.namespace, key.name, "rule %d grants use of CertificateRequestPolicy %q, which is not in the bundle", i, resourceName)
+				}
+			}
+		}
+		if grantsUse && !referenced[key] {
+			problem(key.kind, key.namespace, key.name, "grants use of CertificateRequestPolicies but is not referenced by any RoleBinding or ClusterRoleBinding")
+		}
+	}
+	for _, cr := range r.ClusterRoles {
+		verifyRole(roleKey{kind: "ClusterRole", name: cr.Name}, cr.Rules)
+	}
+	for _, role := range r.Roles {
+		verifyRole(roleKey{kind: "Role", namespace: role.Namespace, name: role.Name}, role.Rules)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Kind != problems[j].Kind {
+			return problems[i].Kind < problems[j].Kind
+		}
+		if problems[i].Namespace != problems[j].Namespace {
+			return problems[i].Namespace < problems[j].Namespace
+		}
+		return problems[i].Name < problems[j].Name
+	})
+
+	return problems
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Verify(t *testing.T) {
+	useRule := func(resourceNames ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}, ResourceNames: resourceNames}
+	}
+	alice := []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}}
+
+	tests := map[string]struct {
+		rbac        *RBAC
+		policies    []string
+		expProblems []Problem
+	}{
+		"bundle with every policy bound should have no problems": {
+			rbac: &RBAC{
+				ClusterRoles:        []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}}},
+				Roles:               []rbacv1.Role{{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-b")}}},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice}},
+				RoleBindings:        []rbacv1.RoleBinding{{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "rb"}, RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "use-policy"}, Subjects: alice}},
+			},
+			policies: []string{"policy-a", "policy-b"},
+		},
+		"unbound policy should be a problem": {
+			rbac: &RBAC{
+				ClusterRoles:        []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}}},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice}},
+			},
+			policies: []string{"policy-a", "policy-b"},
+			expProblems: []Problem{
+				{Kind: "CertificateRequestPolicy", Name: "policy-b", Message: "is not bound to any subject by a RoleBinding or ClusterRoleBinding"},
+			},
+		},
+		"rule naming a policy not in the bundle should be a problem": {
+			rbac: &RBAC{
+				ClusterRoles:        []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a", "policy-typo")}}},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice}},
+			},
+			policies: []string{"policy-a"},
+			expProblems: []Problem{
+				{Kind: "ClusterRole", Name: "use-policy", Message: `rule 0 grants use of CertificateRequestPolicy "policy-typo", which is not in the bundle`},
+			},
+		},
+		"role granting use which is not bound should be a problem": {
+			rbac: &RBAC{
+				ClusterRoles:        []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}}},
+				Roles:               []rbacv1.Role{{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "unbound"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}}},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice}},
+			},
+			policies: []string{"policy-a"},
+			expProblems: []Problem{
+				{Kind: "Role", Namespace: "ns-1", Name: "unbound", Message: "grants use of CertificateRequestPolicies but is not referenced by any RoleBinding or ClusterRoleBinding"},
+			},
+		},
+		"orphan bindings should be problems": {
+			rbac: &RBAC{
+				ClusterRoles: []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}}},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+					{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice},
+					{ObjectMeta: metav1.ObjectMeta{Name: "no-subjects"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}},
+				},
+			},
+			policies: []string{"policy-a"},
+			expProblems: []Problem{
+				{Kind: "ClusterRoleBinding", Name: "no-subjects", Message: "has no subjects"},
+			},
+		},
+		"bindings to roles not granting use of policies should not be checked": {
+			rbac: &RBAC{
+				ClusterRoles: []rbacv1.ClusterRole{
+					{ObjectMeta: metav1.ObjectMeta{Name: "use-policy"}, Rules: []rbacv1.PolicyRule{useRule("policy-a")}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}}},
+				},
+				ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+					{ObjectMeta: metav1.ObjectMeta{Name: "crb"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "use-policy"}, Subjects: alice},
+					{ObjectMeta: metav1.ObjectMeta{Name: "read-secrets"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "read-secrets"}},
+				},
+				RoleBindings: []rbacv1.RoleBinding{
+					{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "view"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}, Subjects: alice},
+				},
+			},
+			policies: []string{"policy-a"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expProblems, test.rbac.Verify(test.policies))
+		})
+	}
+}
@@ -20,19 +20,30 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"os"
+	"strings"
 
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	logf "github.com/cert-manager/cert-manager/pkg/logs"
 	servertls "github.com/cert-manager/cert-manager/pkg/server/tls"
 	"github.com/cert-manager/cert-manager/pkg/server/tls/authority"
 	"github.com/spf13/cobra"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	approverapi "github.com/cert-manager/approver-policy/pkg/approver"
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
 	"github.com/cert-manager/approver-policy/pkg/internal/cmd/options"
 	"github.com/cert-manager/approver-policy/pkg/internal/controllers"
+	"github.com/cert-manager/approver-policy/pkg/internal/enrichment"
+	"github.com/cert-manager/approver-policy/pkg/internal/httpserver"
+	"github.com/cert-manager/approver-policy/pkg/internal/kubeclient"
 	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+	"github.com/cert-manager/approver-policy/pkg/internal/policyreview"
 	"github.com/cert-manager/approver-policy/pkg/internal/webhook"
 	"github.com/cert-manager/approver-policy/pkg/registry"
 )
@@ -50,6 +61,9 @@ func NewCommand(ctx context.Context) *cobra.Command {
 		Short: helpOutput,
 		Long:  helpOutput,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(cmd.Flags()); err != nil {
+				return err
+			}
 			return opts.Complete()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -60,15 +74,46 @@ func NewCommand(ctx context.Context) *cobra.Command {
 
 			ctrl.SetLogger(mlog)
 
+			defaultSelectorMode, requireSelectorMode, err := emptySelectorMode(opts.EmptySelectorMode)
+			if err != nil {
+				return err
+			}
+
+			aliases, err := issuerAliases(opts.IssuerAliases)
+			if err != nil {
+				return err
+			}
+
+			opts.RestConfig.Wrap(kubeclient.WrapTransport(opts.KubeClientTimeout))
+
+			var policyDefaults *policyapi.CertificateRequestPolicySpec
+			if len(opts.Webhook.PolicyDefaultsFile) > 0 {
+				policyDefaults, err = webhook.LoadPolicyDefaults(opts.Webhook.PolicyDefaultsFile)
+				if err != nil {
+					return fmt.Errorf("invalid --webhook-policy-defaults-file: %w", err)
+				}
+			}
+
+			if err := validateWebhookListeners(opts.Webhook); err != nil {
+				return err
+			}
+
+			predicateOptions := internalmanager.PredicateOptions{
+				DefaultSelectorMode: defaultSelectorMode,
+				IssuerAliases:       aliases,
+				MaxPolicies:         opts.MaxPolicies,
+			}
+
+			webhookAuthority := &authority.DynamicAuthority{
+				SecretNamespace: opts.Webhook.CASecretNamespace,
+				SecretName:      opts.Webhook.CASecretName,
+				RESTConfig:      opts.RestConfig,
+				CADuration:      opts.Webhook.CADuration,
+				LeafDuration:    opts.Webhook.LeafDuration,
+			}
 			certificateSource := &servertls.DynamicSource{
-				DNSNames: []string{fmt.Sprintf("%s.%s.svc", opts.Webhook.ServiceName, opts.Webhook.CASecretNamespace)},
-				Authority: &authority.DynamicAuthority{
-					SecretNamespace: opts.Webhook.CASecretNamespace,
-					SecretName:      opts.Webhook.CASecretName,
-					RESTConfig:      opts.RestConfig,
-					CADuration:      opts.Webhook.CADuration,
-					LeafDuration:    opts.Webhook.LeafDuration,
-				},
+				DNSNames:  []string{fmt.Sprintf("%s.%s.svc", opts.Webhook.ServiceName, opts.Webhook.CASecretNamespace)},
+				Authority: webhookAuthority,
 			}
 
 			mgr, err := ctrl.NewManager(opts.RestConfig, ctrl.Options{
@@ -80,6 +125,9 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				LeaderElectionNamespace:       opts.LeaderElectionNamespace,
 				ReadinessEndpointName:         "/readyz",
 				HealthProbeBindAddress:        opts.ReadyzAddress,
+				Cache: cache.Options{
+					ByObject: controllers.CacheByObject(),
+				},
 				Metrics: server.Options{
 					BindAddress: opts.MetricsAddress,
 				},
@@ -102,30 +150,144 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				return err
 			}
 
-			metrics.RegisterMetrics(ctx, opts.Logr.WithName("metrics"), mgr.GetCache())
+			enrichmentCache := enrichment.New(mgr.GetAPIReader(), mgr.GetCache(), enrichment.TTLs{
+				enrichment.TypeCertificates: opts.Enrichment.CertificateTTL,
+			})
+
+			mutatingServer, err := webhookListenerServer(mgr, opts.Webhook, opts.Webhook.Mutating, webhookAuthority)
+			if err != nil {
+				return fmt.Errorf("failed to add mutating webhook listener: %w", err)
+			}
+			policyReviewServer, err := webhookListenerServer(mgr, opts.Webhook, opts.Webhook.PolicyReview, webhookAuthority)
+			if err != nil {
+				return fmt.Errorf("failed to add policy review listener: %w", err)
+			}
+
+			policyDecisions, err := metrics.NewPolicyDecisions(opts.Metrics.PolicyLabels, opts.Metrics.PolicyLabelMaxValues)
+			if err != nil {
+				return fmt.Errorf("invalid --metrics-policy-labels: %w", err)
+			}
+			var fieldUsage *metrics.FieldUsage
+			if opts.Metrics.FieldUsageMinReviews != 0 {
+				fieldUsage, err = metrics.NewFieldUsage(opts.Metrics.FieldUsageMinReviews)
+				if err != nil {
+					return fmt.Errorf("invalid --metrics-field-usage-min-reviews: %w", err)
+				}
+			}
+			metrics.RegisterMetrics(ctx, opts.Logr.WithName("metrics"), mgr.GetCache(), policyDecisions, fieldUsage)
 
 			if err := webhook.Register(ctx, webhook.Options{
-				Log:      opts.Logr,
-				Webhooks: registry.Shared.Webhooks(),
-				Manager:  mgr,
+				Log:                   opts.Logr,
+				Webhooks:              registry.Shared.Webhooks(),
+				Manager:               mgr,
+				RequireSelectorMode:   requireSelectorMode,
+				StrictPolicyMode:      opts.StrictPolicyMode,
+				IssuerAliases:         aliases,
+				MaxPolicies:           opts.MaxPolicies,
+				MaxBreakGlassDuration: opts.Webhook.MaxBreakGlassDuration,
+				ResponseCacheTTL:      opts.Webhook.ResponseCacheTTL,
+				ResponseCacheSize:     opts.Webhook.ResponseCacheSize,
+				PolicyDefaults:        policyDefaults,
+				MutatingServer:        mutatingServer,
 			}); err != nil {
 				return fmt.Errorf("failed to register webhook: %w", err)
 			}
 
+			authorizer, err := httpserver.NewAuthorizer(httpserver.AuthorizationMode(opts.HTTPAuthorization.Mode),
+				mgr.GetClient(), opts.HTTPAuthorization.TokenFile)
+			if err != nil {
+				return fmt.Errorf("failed to build http authorizer: %w", err)
+			}
+
+			if opts.Webhook.EnablePolicyReview {
+				reviewer := policyreview.NewReviewer(opts.Logr, mgr.GetCache(), internalmanager.Predicates(mgr.GetCache(), mgr.GetClient(), predicateOptions))
+				server := mgr.GetWebhookServer()
+				if policyReviewServer != nil {
+					server = policyReviewServer
+					if err := mgr.AddReadyzCheck("policy-review", server.StartedChecker()); err != nil {
+						return fmt.Errorf("failed to add policy review readyz check: %w", err)
+					}
+				}
+				server.Register(policyreview.Path, httpserver.WithAuthorization(opts.Logr, authorizer, reviewer))
+			}
+
+			// The encoder also redacts the requester of decision records.
+			encoder, err := auditEncoder(opts.Audit)
+			if err != nil {
+				return err
+			}
+
+			var auditor *audit.Exporter
+			if sinks, err := auditSinks(opts.Audit); err != nil {
+				return err
+			} else if len(sinks) > 0 {
+				auditor = audit.NewExporter(opts.Logr, encoder, sinks...)
+				if err := mgr.Add(auditor); err != nil {
+					return fmt.Errorf("failed to add audit exporter: %w", err)
+				}
+			}
+
+			bootstrap, err := bootstrapOptions(opts.Bootstrap)
+			if err != nil {
+				return err
+			}
+			if bootstrap.Enabled() {
+				log.Info("approving bootstrap requests without policy review", "usernames", bootstrap.Usernames,
+					"issuer_refs", opts.Bootstrap.IssuerRefs, "namespaces", bootstrap.Namespaces)
+			}
+
+			handoffNamespace, err := leaderElectionNamespace(opts.LeaderElectionNamespace)
+			if err != nil {
+				return err
+			}
+
 			log.Info("preparing approvers...")
 			for _, approver := range registry.Shared.Approvers() {
 				log.Info("preparing approver...", "approver", approver.Name())
+				if enriched, ok := approver.(approverapi.EnrichedEvaluator); ok {
+					enriched.SetEnrichmentReader(enrichmentCache)
+				}
 				if err := approver.Prepare(ctx, opts.Logr, mgr); err != nil {
 					return fmt.Errorf("failed to prepare approver %q: %w", approver.Name(), err)
 				}
 			}
 			log.Info("all approvers ready...")
 
+			for _, postProcessor := range registry.Shared.PostProcessors() {
+				log.Info("preparing post-processor...", "post-processor", postProcessor.Name())
+				if err := postProcessor.Prepare(ctx, opts.Logr, mgr); err != nil {
+					return fmt.Errorf("failed to prepare post-processor %q: %w", postProcessor.Name(), err)
+				}
+			}
+
 			if err := controllers.AddControllers(ctx, controllers.Options{
 				Log:         opts.Logr.WithName("controller"),
 				Manager:     mgr,
 				Evaluators:  registry.Shared.Evaluators(),
 				Reconcilers: registry.Shared.Reconcilers(),
+				Auditor:     auditor,
+				Bootstrap:   bootstrap,
+				DenialBackoff: controllers.DenialBackoffOptions{
+					Threshold: opts.DenialBackoff.Threshold,
+					BaseDelay: opts.DenialBackoff.BaseDelay,
+					MaxDelay:  opts.DenialBackoff.MaxDelay,
+				},
+				Predicates:           predicateOptions,
+				PolicyDecisions:      policyDecisions,
+				FieldUsage:           fieldUsage,
+				AdvisorySampling:     internalmanager.AdvisorySampling{Rates: opts.AdvisorySampleRates},
+				InvalidRequestAction: controllers.InvalidRequestAction(opts.InvalidRequestAction),
+				VerifyIssuers:        opts.VerifyIssuers,
+				DecisionRecords: controllers.DecisionRecordOptions{
+					Enabled:   opts.RecordDecisions,
+					Retention: opts.DecisionRetention,
+					Encoder:   encoder,
+				},
+				PostProcessors:  registry.Shared.PostProcessors(),
+				RequestDecoders: registry.Shared.RequestDecoders(),
+				Handoff: controllers.HandoffOptions{
+					Namespace: handoffNamespace,
+				},
 			}); err != nil {
 				return fmt.Errorf("failed to add controllers: %w", err)
 			}
@@ -135,7 +297,139 @@ func NewCommand(ctx context.Context) *cobra.Command {
 		},
 	}
 
-	opts.Prepare(cmd, registry.Shared.Approvers()...)
+	opts.Prepare(cmd, registry.Shared.Approvers(), registry.Shared.PostProcessors(), registry.Shared.RequestDecoders())
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return options.UnknownFlagError(cmd.Flags(), err)
+	})
+
+	for _, subcommand := range []*cobra.Command{
+		newDiffCommand(ctx),
+		newGraphCommand(ctx),
+		newConvertCommand(ctx),
+		newReplayCommand(ctx),
+		newVerifyBundleCommand(),
+		newObservabilityCommand(),
+	} {
+		setSubcommandUsage(subcommand)
+		cmd.AddCommand(subcommand)
+	}
 
 	return cmd
 }
+
+// setSubcommandUsage sets the usage and help output of a subcommand to only
+// print its own flags, rather than inheriting the named flag sets of the root
+// command.
+func setSubcommandUsage(cmd *cobra.Command) {
+	usageFmt := "Usage:\n  %s\n\nFlags:\n%s"
+	cmd.SetUsageFunc(func(cmd *cobra.Command) error {
+		fmt.Fprintf(cmd.OutOrStderr(), usageFmt, cmd.UseLine(), cmd.LocalFlags().FlagUsages())
+		return nil
+	})
+
+	cmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n"+usageFmt, cmd.Long, cmd.UseLine(), cmd.LocalFlags().FlagUsages())
+	})
+}
+
+// auditSinks builds the audit sinks that have been configured. No sinks are
+// returned if audit export is disabled.
+func auditSinks(opts options.Audit) ([]audit.Sink, error) {
+	var sinks []audit.Sink
+
+	if len(opts.LogPath) > 0 {
+		sink, err := audit.NewFileSink(opts.LogPath)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if len(opts.WebhookURL) > 0 {
+		sinks = append(sinks, audit.NewWebhookSink(opts.WebhookURL, opts.WebhookTimeout))
+	}
+
+	return sinks, nil
+}
+
+// auditEncoder builds the audit encoder with the configured redaction rules.
+func auditEncoder(opts options.Audit) (*audit.Encoder, error) {
+	rules := make([]audit.RedactionRule, 0, len(opts.RedactionRules))
+	for _, rule := range opts.RedactionRules {
+		parsed, err := audit.ParseRedactionRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parsed)
+	}
+	return audit.NewEncoder(rules...), nil
+}
+
+// inClusterNamespaceFile holds the namespace of the pod when running in a
+// cluster.
+const inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// leaderElectionNamespace returns the leader election namespace, defaulting
+// to the namespace of the pod as controller-runtime does.
+func leaderElectionNamespace(namespace string) (string, error) {
+	if len(namespace) > 0 {
+		return namespace, nil
+	}
+
+	data, err := os.ReadFile(inClusterNamespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the leader election namespace, --leader-election-namespace must be given when not running in a cluster: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// emptySelectorMode parses the configured empty selector mode, returning the
+// mode of policies with an empty selector which do not set a mode, and whether
+// the webhook should require that such policies set a mode. Policies which
+// predate requiring a mode match no requests.
+func emptySelectorMode(mode string) (policyapi.SelectorMode, bool, error) {
+	switch mode {
+	case string(policyapi.SelectorModeAll):
+		return policyapi.SelectorModeAll, false, nil
+	case string(policyapi.SelectorModeNone):
+		return policyapi.SelectorModeNone, false, nil
+	case "Explicit":
+		return policyapi.SelectorModeNone, true, nil
+	default:
+		return "", false, fmt.Errorf(`--empty-selector-mode must be one of "All", "None" or "Explicit", got %q`, mode)
+	}
+}
+
+// issuerAliases parses the issuer references of the --issuer-aliases flag.
+func issuerAliases(aliases map[string]string) (map[string]cmmeta.ObjectReference, error) {
+	refs := make(map[string]cmmeta.ObjectReference, len(aliases))
+	for alias, s := range aliases {
+		if len(alias) == 0 {
+			return nil, fmt.Errorf("invalid --issuer-aliases: alias of %q must not be empty", s)
+		}
+		ref, err := internalmanager.ParseIssuerRef(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --issuer-aliases %q: %w", alias, err)
+		}
+		refs[alias] = ref
+	}
+	return refs, nil
+}
+
+// bootstrapOptions parses the configured bootstrap options, which have been
+// validated by options.Validate.
+func bootstrapOptions(opts options.Bootstrap) (internalmanager.BootstrapOptions, error) {
+	bootstrap := internalmanager.BootstrapOptions{
+		Usernames:  opts.Usernames,
+		Namespaces: opts.Namespaces,
+	}
+	for _, s := range opts.IssuerRefs {
+		ref, err := internalmanager.ParseIssuerRef(s)
+		if err != nil {
+			return internalmanager.BootstrapOptions{}, fmt.Errorf("invalid --bootstrap-issuer-refs: %w", err)
+		}
+		bootstrap.IssuerRefs = append(bootstrap.IssuerRefs, ref)
+	}
+
+	return bootstrap, nil
+}
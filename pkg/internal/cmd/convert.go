@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/convert"
+)
+
+const (
+	convertHelpOutput = `Check that all live CertificateRequestPolicies convert to another API version without loss.
+Each policy is read as stored, converted to the target version and back, and any policy which fails to convert or
+loses spec fields is reported. Fields unknown to this version of approver-policy are also reported as lost.
+Run this before upgrading to find policies which need changing. Only --dry-run is supported; the conversion of stored
+policies is performed by the API server during the upgrade.`
+)
+
+// newConvertCommand returns the convert subcommand which checks the conversion
+// of live policies to another API version.
+func newConvertCommand(ctx context.Context) *cobra.Command {
+	var (
+		kubeconfig  string
+		kubeContext string
+		to          string
+		dryRun      bool
+		output      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert --dry-run [--to <version>]",
+		Short: "Check that live policies convert to another API version without loss",
+		Long:  convertHelpOutput,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !dryRun {
+				return errors.New("only --dry-run is supported")
+			}
+			if output != "text" && output != "json" {
+				return fmt.Errorf(`--output must be one of "text" or "json", got %q`, output)
+			}
+
+			converter, err := convert.Lookup(to)
+			if err != nil {
+				return err
+			}
+
+			restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig, Precedence: clientcmd.NewDefaultClientConfigLoadingRules().Precedence},
+				&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+			).ClientConfig()
+			if err != nil {
+				return fmt.Errorf("failed to build rest config: %w", err)
+			}
+
+			cl, err := client.New(restConfig, client.Options{Scheme: policyapi.GlobalScheme})
+			if err != nil {
+				return fmt.Errorf("failed to build client: %w", err)
+			}
+
+			// Policies are listed as unstructured so that fields unknown to this
+			// version of approver-policy are not dropped before being checked.
+			policies := new(unstructured.UnstructuredList)
+			policies.SetGroupVersionKind(policyapi.SchemeGroupVersion.WithKind("CertificateRequestPolicyList"))
+			if err := cl.List(ctx, policies); err != nil {
+				return fmt.Errorf("failed to list policies: %w", err)
+			}
+
+			results := []convert.Result{}
+			failed := 0
+			for i := range policies.Items {
+				result := convert.Check(&policies.Items[i], converter)
+				if !result.OK() {
+					failed++
+				}
+				results = append(results, result)
+			}
+
+			out := cmd.OutOrStdout()
+			switch output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					return fmt.Errorf("failed to encode results: %w", err)
+				}
+			default:
+				for _, result := range results {
+					switch {
+					case len(result.Error) > 0:
+						fmt.Fprintf(out, "policy %q: FAILED: %s\n", result.Policy, result.Error)
+					case len(result.LostFields) > 0:
+						fmt.Fprintf(out, "policy %q: LOSSY: %s\n", result.Policy, strings.Join(result.LostFields, ", "))
+					default:
+						fmt.Fprintf(out, "policy %q: OK\n", result.Policy)
+					}
+				}
+				fmt.Fprintf(out, "%d of %d policies convert to %s without loss.\n", len(results)-failed, len(results), converter.Version())
+			}
+
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d policies fail to convert or lose fields", failed)
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the standard kubeconfig loading rules.")
+	fs.StringVar(&kubeContext, "context", "", "Name of the kubeconfig context to use. Defaults to the current context.")
+	fs.StringVar(&to, "to", policyapi.SchemeGroupVersion.Version, fmt.Sprintf("API version to check conversion to, one of %q.", convert.Versions()))
+	fs.BoolVar(&dryRun, "dry-run", false, "Only report the result of converting live policies. Required.")
+	fs.StringVarP(&output, "output", "o", "text", `Output format, one of "text" or "json".`)
+
+	return cmd
+}
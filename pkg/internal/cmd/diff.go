@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/diff"
+)
+
+const (
+	diffHelpOutput = `Compare the effective CertificateRequestPolicies, and the RBAC bindings to them, of two clusters.
+Reports the semantic differences in what each cluster would approve: policies only present in one cluster,
+policy spec fields with different values, differing Ready state, and subjects only bound in one cluster.`
+)
+
+// newDiffCommand returns the diff subcommand which compares the policy sets
+// of two clusters.
+func newDiffCommand(ctx context.Context) *cobra.Command {
+	var (
+		kubeconfig string
+		contexts   []string
+		output     string
+		exitCode   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff --context <a> --context <b>",
+		Short: "Compare the effective policy sets of two clusters",
+		Long:  diffHelpOutput,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(contexts) != 2 {
+				return fmt.Errorf("exactly two --context flags must be given, got %d", len(contexts))
+			}
+			if output != "text" && output != "json" {
+				return fmt.Errorf(`--output must be one of "text" or "json", got %q`, output)
+			}
+
+			var clusters []*diff.Cluster
+			for _, kubeContext := range contexts {
+				cl, err := newContextClient(kubeconfig, kubeContext)
+				if err != nil {
+					return err
+				}
+
+				cluster, err := diff.Load(ctx, kubeContext, cl)
+				if err != nil {
+					return fmt.Errorf("failed to load policies from context %q: %w", kubeContext, err)
+				}
+				clusters = append(clusters, cluster)
+			}
+
+			diffs, err := diff.Compare(clusters[0], clusters[1])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if diffs == nil {
+					diffs = []diff.Difference{}
+				}
+				if err := enc.Encode(diffs); err != nil {
+					return fmt.Errorf("failed to encode differences: %w", err)
+				}
+			default:
+				if len(diffs) == 0 {
+					fmt.Fprintln(out, "No differences found.")
+				}
+				for _, d := range diffs {
+					fmt.Fprintln(out, d.String(contexts[0], contexts[1]))
+				}
+			}
+
+			if exitCode && len(diffs) > 0 {
+				cmd.SilenceUsage = true
+				return errors.New("clusters have differences")
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the standard kubeconfig loading rules.")
+	fs.StringArrayVar(&contexts, "context", nil, "Name of a kubeconfig context to compare. Must be given exactly twice.")
+	fs.StringVarP(&output, "output", "o", "text", `Output format, one of "text" or "json".`)
+	fs.BoolVar(&exitCode, "exit-code", false, "Exit with an error if any differences are found.")
+
+	return cmd
+}
+
+// newContextClient returns a client for the given kubeconfig context. An
+// empty kubeconfig path uses the standard kubeconfig loading rules, and an
+// empty context uses the current context.
+func newContextClient(kubeconfig, kubeContext string) (client.Client, error) {
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig, Precedence: clientcmd.NewDefaultClientConfigLoadingRules().Precedence},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rest config for context %q: %w", kubeContext, err)
+	}
+
+	cl, err := client.New(restConfig, client.Options{Scheme: policyapi.GlobalScheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client for context %q: %w", kubeContext, err)
+	}
+
+	return cl, nil
+}
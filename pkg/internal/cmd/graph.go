@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+)
+
+const (
+	graphHelpOutput = `Output the graph of subjects, the RoleBindings and ClusterRoleBindings which bind them to
+CertificateRequestPolicies, and the issuers those policies select. Shows who is able to obtain certificates
+from which issuers. The graph is resolved from the RBAC resources of the cluster, so bindings granted by other
+authorizers, such as webhooks, are not shown.
+
+Render the DOT output with Graphviz, e.g. "approver-policy graph | dot -Tsvg > graph.svg".`
+)
+
+// newGraphCommand returns the graph subcommand which outputs the graph of
+// subjects bound to policies in a cluster.
+func newGraphCommand(ctx context.Context) *cobra.Command {
+	var (
+		kubeconfig  string
+		kubeContext string
+		output      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph [--output dot|json]",
+		Short: "Output the graph of subjects bound to policies and the issuers they select",
+		Long:  graphHelpOutput,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "dot" && output != "json" {
+				return fmt.Errorf(`--output must be one of "dot" or "json", got %q`, output)
+			}
+
+			cl, err := newContextClient(kubeconfig, kubeContext)
+			if err != nil {
+				return err
+			}
+
+			var policyList policyapi.CertificateRequestPolicyList
+			if err := cl.List(ctx, &policyList); err != nil {
+				return fmt.Errorf("failed to list CertificateRequestPolicies: %w", err)
+			}
+
+			rbac, err := bindings.List(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(policyList.Items))
+			for _, policy := range policyList.Items {
+				names = append(names, policy.Name)
+			}
+
+			graph := bindings.NewGraph(policyList.Items, rbac.Resolve(names))
+
+			out := cmd.OutOrStdout()
+			switch output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(graph); err != nil {
+					return fmt.Errorf("failed to encode graph: %w", err)
+				}
+			default:
+				if err := graph.WriteDOT(out); err != nil {
+					return fmt.Errorf("failed to write graph: %w", err)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to the standard kubeconfig loading rules.")
+	fs.StringVar(&kubeContext, "context", "", "Name of the kubeconfig context to use. Defaults to the current context.")
+	fs.StringVarP(&output, "output", "o", "dot", `Output format, one of "dot" or "json".`)
+
+	return cmd
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	servertls "github.com/cert-manager/cert-manager/pkg/server/tls"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/cmd/options"
+)
+
+// validateWebhookListeners returns an error if the webhook listeners are
+// configured to serve on the same port.
+func validateWebhookListeners(opts options.Webhook) error {
+	ports := map[int]string{opts.Port: "--webhook-port"}
+	for flag, listener := range map[string]options.WebhookListener{
+		"--webhook-mutating-port":      opts.Mutating,
+		"--webhook-policy-review-port": opts.PolicyReview,
+	} {
+		if listener.Port == 0 {
+			continue
+		}
+		if listener.Port < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", flag, listener.Port)
+		}
+		if other, ok := ports[listener.Port]; ok {
+			return fmt.Errorf("%s and %s must not be the same port, got %d", flag, other, listener.Port)
+		}
+		ports[listener.Port] = flag
+	}
+	return nil
+}
+
+// webhookListenerServer returns the webhook server of the listener, which is
+// added to the Manager along with its certificate source. Returns nil if the
+// listener is served by the shared webhook server.
+func webhookListenerServer(mgr manager.Manager, opts options.Webhook, listener options.WebhookListener, authority servertls.Authority) (ctrlwebhook.Server, error) {
+	if listener.Port == 0 {
+		return nil, nil
+	}
+
+	serverOpts := ctrlwebhook.Options{
+		Host:    listener.Host,
+		Port:    listener.Port,
+		CertDir: listener.CertDir,
+	}
+	if len(serverOpts.Host) == 0 {
+		serverOpts.Host = opts.Host
+	}
+
+	if len(listener.CertDir) == 0 {
+		serviceName := listener.ServiceName
+		if len(serviceName) == 0 {
+			serviceName = opts.ServiceName
+		}
+
+		certificateSource := &servertls.DynamicSource{
+			DNSNames:  []string{fmt.Sprintf("%s.%s.svc", serviceName, opts.CASecretNamespace)},
+			Authority: sharedAuthority{authority},
+		}
+		if err := mgr.Add(certificateSource); err != nil {
+			return nil, err
+		}
+		serverOpts.TLSOpts = []func(*tls.Config){
+			func(cfg *tls.Config) {
+				cfg.GetCertificate = certificateSource.GetCertificate
+			},
+		}
+	}
+
+	server := ctrlwebhook.NewServer(serverOpts)
+	if err := mgr.Add(server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// sharedAuthority signs certificates with an Authority which is run by the
+// certificate source of the shared webhook listener, so that every listener
+// is served a certificate of the same webhook CA.
+type sharedAuthority struct {
+	servertls.Authority
+}
+
+// Run blocks until the context is cancelled, as the Authority is already run
+// by the certificate source of the shared webhook listener.
+func (sharedAuthority) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
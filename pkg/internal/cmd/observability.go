@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+	"github.com/cert-manager/approver-policy/pkg/internal/observability"
+)
+
+const (
+	observabilityExportHelpOutput = `Export a Grafana dashboard or Prometheus alerting rules for the metrics of this version of approver-policy.
+The dashboard is written as JSON which can be imported into Grafana, and contains a panel for every metric.
+The alerting rules are written as a Prometheus rules file in YAML.`
+)
+
+// newObservabilityCommand returns the observability subcommand, which groups
+// commands for generating observability configuration.
+func newObservabilityCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "observability",
+		Short: "Generate observability configuration for approver-policy",
+		Long:  "Generate observability configuration for approver-policy.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newObservabilityExportCommand())
+
+	return cmd
+}
+
+// newObservabilityExportCommand returns the observability export subcommand,
+// which writes a Grafana dashboard or Prometheus alerting rules generated from
+// the metric catalog to stdout.
+func newObservabilityExportCommand() *cobra.Command {
+	var kind string
+
+	cmd := &cobra.Command{
+		Use:   "export [--type dashboard|alerts]",
+		Short: "Export a Grafana dashboard or Prometheus alerting rules",
+		Long:  observabilityExportHelpOutput,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				out []byte
+				err error
+			)
+
+			switch kind {
+			case "dashboard":
+				out, err = observability.Dashboard(metrics.Catalog())
+			case "alerts":
+				out, err = observability.AlertRules(metrics.Catalog())
+			default:
+				return fmt.Errorf(`--type must be one of "dashboard" or "alerts", got %q`, kind)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to generate %s: %w", kind, err)
+			}
+
+			if len(out) > 0 && out[len(out)-1] != '\n' {
+				out = append(out, '\n')
+			}
+
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "type", "dashboard", `Type of configuration to export, one of "dashboard" or "alerts".`)
+
+	return cmd
+}
@@ -31,7 +31,9 @@ import (
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
 
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -45,6 +47,10 @@ type Options struct {
 	// flags.
 	kubeConfigFlags *genericclioptions.ConfigFlags
 
+	// approvers are the names of the registered approvers, which
+	// --advisory-sample-rates may be given for.
+	approvers []string
+
 	// MetricsAddress is the TCP address for exposing HTTP Prometheus metrics
 	// which will be served on the HTTP path '/metrics'. The value "0" will
 	// disable exposing metrics.
@@ -58,6 +64,52 @@ type Options struct {
 	// which will be served on the HTTP path '/readyz'.
 	ReadyzAddress string
 
+	// EmptySelectorMode is the mode of CertificateRequestPolicies with an empty
+	// selector which do not set `spec.selector.mode`. One of "All", "None" or
+	// "Explicit". "Explicit" rejects such policies, and matches no requests
+	// with existing ones.
+	EmptySelectorMode string
+
+	// StrictPolicyMode rejects CertificateRequestPolicies which do not
+	// explicitly allow or deny every category of request attribute, and
+	// constrain the duration of requests.
+	StrictPolicyMode bool
+
+	// IssuerAliases maps logical issuer names, which policies may select with
+	// `spec.selector.issuerRef.alias`, to issuers in the form
+	// `<kind>.<group>/<name>`.
+	IssuerAliases map[string]string
+
+	// MaxPolicies is the maximum number of CertificateRequestPolicies in the
+	// cluster. 0 is unlimited.
+	MaxPolicies int
+
+	// InvalidRequestAction is the action taken on structurally invalid
+	// CertificateRequests. One of "Deny" or "Ignore".
+	InvalidRequestAction string
+
+	// VerifyIssuers sets the IssuersFound condition on
+	// CertificateRequestPolicies, reporting whether the issuers named by their
+	// selector exist.
+	VerifyIssuers bool
+
+	// AdvisorySampleRates is the percentage of requests which advisory-only
+	// evaluators, keyed by approver name, evaluate. Advisory evaluators not
+	// listed evaluate every request.
+	AdvisorySampleRates map[string]int
+
+	// KubeClientTimeout is the timeout of each request to the Kubernetes API
+	// server, other than watches and lists. 0 disables the timeout.
+	KubeClientTimeout time.Duration
+
+	// RecordDecisions mirrors the decision made on every CertificateRequest
+	// into a CertificateRequestDecision.
+	RecordDecisions bool
+
+	// DecisionRetention is how long CertificateRequestDecisions are kept. 0
+	// keeps them indefinitely.
+	DecisionRetention time.Duration
+
 	// RestConfig is the shared base rest config to connect to the Kubernetes
 	// API.
 	RestConfig *rest.Config
@@ -68,6 +120,30 @@ type Options struct {
 	// Webhook are options specific to the Kubernetes Webhook.
 	Webhook
 
+	// Audit are options controlling the export of approval decisions as
+	// Kubernetes audit events.
+	Audit
+
+	// Bootstrap are options controlling the approval of cert-manager's
+	// bootstrap CertificateRequests without policy review.
+	Bootstrap
+
+	// DenialBackoff are options controlling delaying the review of requests
+	// from requesters which have repeatedly had requests denied.
+	DenialBackoff
+
+	// HTTPAuthorization are options controlling the authorization of requests
+	// to approver-policy's HTTP endpoints, such as policy reviews.
+	HTTPAuthorization
+
+	// Metrics are options controlling the Prometheus metrics of
+	// approver-policy.
+	Metrics
+
+	// Enrichment are options controlling the cache of objects related to
+	// requests which evaluators read.
+	Enrichment
+
 	// Logr is the shared base logger.
 	Logr logr.Logger
 }
@@ -136,14 +212,152 @@ type Webhook struct {
 	// LeafDuration for webhook server TLS certificates.
 	// Defaults to 7 days.
 	LeafDuration time.Duration
+
+	// EnablePolicyReview serves CertificateRequestPolicyReviews on the Webhook
+	// server, allowing clients to discover which policies would be used to
+	// evaluate requests from a given subject.
+	EnablePolicyReview bool
+
+	// MaxBreakGlassDuration is the furthest in the future that the expiry of
+	// a break glass CertificateRequestPolicy may be set.
+	MaxBreakGlassDuration time.Duration
+
+	// ResponseCacheTTL is how long the results of validating
+	// CertificateRequestPolicies are cached for. Zero disables caching.
+	ResponseCacheTTL time.Duration
+
+	// ResponseCacheSize is the maximum number of cached validation results.
+	ResponseCacheSize int
+
+	// PolicyDefaultsFile is the path of a YAML file of CertificateRequestPolicy
+	// spec defaults, set on policies as they are created. Empty disables
+	// defaulting.
+	PolicyDefaultsFile string
+
+	// Mutating is the listener of the mutating webhook.
+	Mutating WebhookListener
+
+	// PolicyReview is the listener of CertificateRequestPolicyReviews.
+	PolicyReview WebhookListener
+}
+
+// WebhookListener holds options for serving a webhook on its own listener,
+// rather than the shared listener of the Webhook, so that it can be reached
+// through a different network path.
+type WebhookListener struct {
+	// Port is the TCP port that the webhook is served on. 0 serves the
+	// webhook on the shared listener.
+	Port int
+
+	// Host is the host that the webhook is served on. Defaults to the host of
+	// the shared listener.
+	Host string
+
+	// CertDir is the directory holding the "tls.crt" and "tls.key" serving
+	// certificate of the listener, which are reloaded when they change. Empty
+	// serves a certificate signed by the webhook CA.
+	CertDir string
+
+	// ServiceName is the service that exposes the listener, which names the
+	// certificate signed by the webhook CA. Defaults to the service of the
+	// shared listener.
+	ServiceName string
+}
+
+// Audit holds options for exporting approval decisions as Kubernetes audit
+// events.
+type Audit struct {
+	// LogPath is the path of the file that audit events are appended to as
+	// JSON lines. The value "-" writes to stdout. Empty disables the file
+	// export.
+	LogPath string
+
+	// WebhookURL is the URL that batches of audit events are POSTed to as an
+	// audit EventList. Empty disables the webhook export.
+	WebhookURL string
+
+	// WebhookTimeout is the timeout of requests to the audit webhook.
+	WebhookTimeout time.Duration
+
+	// RedactionRules are rules of the form "<action>=<regex>" redacting
+	// requester values from audit events. See audit.ParseRedactionRule.
+	RedactionRules []string
+}
+
+// Bootstrap holds options for approving cert-manager's bootstrap
+// CertificateRequests, for example those issuing a cluster's root CA, without
+// reviewing them against CertificateRequestPolicies.
+type Bootstrap struct {
+	// Usernames are the requesters whose requests may be approved by
+	// bootstrap.
+	Usernames []string
+
+	// IssuerRefs are the issuers, in the form `<kind>.<group>/<name>`, whose
+	// requests may be approved by bootstrap.
+	IssuerRefs []string
+
+	// Namespaces restricts bootstrap approval to requests in these
+	// namespaces. Required if IssuerRefs are given.
+	Namespaces []string
+}
+
+// Enrichment holds options for the enrichment cache shared by evaluators.
+type Enrichment struct {
+	// CertificateTTL is how long the Certificates owning requests are served
+	// from the cache after they were read. 0 reads them from the API server
+	// on every evaluation.
+	CertificateTTL time.Duration
+}
+
+// DenialBackoff holds options for delaying the review of requests from
+// requesters which have repeatedly had requests denied.
+type DenialBackoff struct {
+	// Threshold is the number of denials of a requester after which reviews
+	// of its requests are delayed. 0 disables denial backoff.
+	Threshold int
+
+	// BaseDelay is the delay applied once a requester reaches the threshold,
+	// doubling for every further denial.
+	BaseDelay time.Duration
+
+	// MaxDelay is the maximum delay applied to a requester.
+	MaxDelay time.Duration
+}
+
+// HTTPAuthorization holds options for authorizing requests to
+// approver-policy's HTTP endpoints.
+type HTTPAuthorization struct {
+	// Mode is the authorization mode, one of "Delegated", "StaticToken" or
+	// "None".
+	Mode string
+
+	// TokenFile is the path to the file holding the bearer token used by the
+	// "StaticToken" mode.
+	TokenFile string
+}
+
+// Metrics holds options for the Prometheus metrics of approver-policy.
+type Metrics struct {
+	// PolicyLabels are the names of the custom labels that policies may add
+	// to their decision metrics with annotations.
+	PolicyLabels []string
+
+	// PolicyLabelMaxValues is the maximum number of distinct values of each
+	// custom policy label.
+	PolicyLabelMaxValues int
+
+	// FieldUsageMinReviews is the number of requests which must be reviewed
+	// against a CertificateRequestPolicy before the fields of its spec which
+	// were never exercised are reported. 0 disables field usage analysis.
+	FieldUsageMinReviews int
 }
 
 func New() *Options {
 	return new(Options)
 }
 
-func (o *Options) Prepare(cmd *cobra.Command, approvers ...approver.Interface) *Options {
-	o.addFlags(cmd, approvers...)
+func (o *Options) Prepare(cmd *cobra.Command, approvers []approver.Interface, postProcessors []approver.PostProcessor, requestDecoders []approver.RequestDecoder) *Options {
+	o.addFlags(cmd, approvers, postProcessors, requestDecoders)
 	return o
 }
 
@@ -173,17 +387,30 @@ func (o *Options) Complete() error {
 	return nil
 }
 
-func (o *Options) addFlags(cmd *cobra.Command, approvers ...approver.Interface) {
+func (o *Options) addFlags(cmd *cobra.Command, approvers []approver.Interface, postProcessors []approver.PostProcessor, requestDecoders []approver.RequestDecoder) {
 	var nfs cliflag.NamedFlagSets
 
 	o.addAppFlags(nfs.FlagSet("App"))
 	o.addLoggingFlags(nfs.FlagSet("Logging"))
 	o.addWebhookFlags(nfs.FlagSet("Webhook"))
+	o.addAuditFlags(nfs.FlagSet("Audit"))
+	o.addBootstrapFlags(nfs.FlagSet("Bootstrap"))
+	o.addDenialBackoffFlags(nfs.FlagSet("Denial Backoff"))
+	o.addHTTPAuthorizationFlags(nfs.FlagSet("HTTP Authorization"))
+	o.addMetricsFlags(nfs.FlagSet("Metrics"))
+	o.addEnrichmentFlags(nfs.FlagSet("Enrichment"))
 	o.kubeConfigFlags = genericclioptions.NewConfigFlags(true)
 	o.kubeConfigFlags.AddFlags(nfs.FlagSet("Kubernetes"))
 
 	for _, approver := range approvers {
 		approver.RegisterFlags(nfs.FlagSet(approver.Name()))
+		o.approvers = append(o.approvers, approver.Name())
+	}
+	for _, postProcessor := range postProcessors {
+		postProcessor.RegisterFlags(nfs.FlagSet(postProcessor.Name()))
+	}
+	for _, requestDecoder := range requestDecoders {
+		requestDecoder.RegisterFlags(nfs.FlagSet(requestDecoder.Name()))
 	}
 
 	usageFmt := "Usage:\n  %s\n"
@@ -214,6 +441,56 @@ func (o *Options) addAppFlags(fs *pflag.FlagSet) {
 
 	fs.StringVar(&o.ReadyzAddress, "readiness-probe-bind-address", ":6060",
 		"TCP address for exposing the HTTP readiness probe which will be served on the HTTP path '/readyz'.")
+
+	fs.StringVar(&o.EmptySelectorMode, "empty-selector-mode", "All",
+		"Whether CertificateRequestPolicies with an empty selector, such as `issuerRef: {}`, which do not set "+
+			"spec.selector.mode match all requests or none. One of \"All\", \"None\" or \"Explicit\". \"Explicit\" "+
+			"rejects new policies with an empty selector which do not set a mode, and matches no requests with existing "+
+			"ones.")
+
+	fs.BoolVar(&o.StrictPolicyMode, "strict-policy-mode", false,
+		"If true, reject CertificateRequestPolicies which do not explicitly allow or deny each of the dnsNames, "+
+			"ipAddresses, uris, emailAddresses, subject, usages and isCA request attributes, and constrain the "+
+			"duration of requests with minDuration or maxDuration, rather than relying on unset fields denying them. "+
+			"Existing policies which don't are reported when approver-policy becomes leader.")
+
+	fs.StringToStringVar(&o.IssuerAliases, "issuer-aliases", nil,
+		"Logical issuer names which CertificateRequestPolicies may select with spec.selector.issuerRef.alias, "+
+			"mapped to the issuer of this cluster in the form <kind>.<group>/<name>, e.g. "+
+			"\"internal-mtls=ClusterIssuer.cert-manager.io/vault-mtls\".")
+
+	fs.IntVar(&o.MaxPolicies, "max-policies", 0,
+		"Maximum number of CertificateRequestPolicies in the cluster. Once reached, the creation of new policies is "+
+			"rejected, and if more policies exist only the oldest are used to review requests. 0 is unlimited.")
+
+	fs.StringVar(&o.InvalidRequestAction, "invalid-request-action", "Deny",
+		"Action taken on structurally invalid CertificateRequests, such as those with an empty CSR, invalid PEM or a "+
+			"CSR not signed by its own key, which can never be signed. One of \"Deny\" or \"Ignore\", which leaves "+
+			"them unreviewed for another approver to decide. Denied invalid requests are audited, recorded and "+
+			"post-processed like any other denial, but do not count towards the requester's denial backoff.")
+
+	fs.BoolVar(&o.VerifyIssuers, "verify-issuers", false,
+		"If true, verify that the Issuers and ClusterIssuers named by the selector of each CertificateRequestPolicy "+
+			"exist, reporting the result in the informational IssuersFound condition of the policy. Requires "+
+			"permission to list and watch Issuers and ClusterIssuers.")
+
+	fs.StringToIntVar(&o.AdvisorySampleRates, "advisory-sample-rates", nil,
+		"Percentage of requests, between 0 and 100, which advisory-only evaluators evaluate, keyed by approver name, "+
+			"e.g. \"reputation=10\". Advisory results are only reported as metrics and never affect whether a request "+
+			"is approved. Advisory evaluators not listed evaluate every request.")
+
+	fs.DurationVar(&o.KubeClientTimeout, "kube-client-timeout", 10*time.Second,
+		"Timeout of each request to the Kubernetes API server made while reviewing CertificateRequests, such as "+
+			"SubjectAccessReviews and owner lookups. Watches and lists, made by informers, are not subject to the "+
+			"timeout. 0 disables the timeout.")
+
+	fs.BoolVar(&o.RecordDecisions, "record-decisions", false,
+		"If true, record the decision made on every CertificateRequest in a CertificateRequestDecision in the "+
+			"namespace of the request, which is kept after the request is deleted.")
+
+	fs.DurationVar(&o.DecisionRetention, "decision-retention", 30*24*time.Hour,
+		"How long CertificateRequestDecisions recorded with --record-decisions are kept after the decision was "+
+			"made. 0 keeps them indefinitely.")
 }
 
 func (o *Options) addLoggingFlags(fs *pflag.FlagSet) {
@@ -255,6 +532,35 @@ func (o *Options) addWebhookFlags(fs *pflag.FlagSet) {
 		"webhook-leaf-cert-duration", time.Hour*24*7,
 		"Duration for webhook server TLS certificates. Defaults to 7 days.")
 
+	fs.BoolVar(&o.Webhook.EnablePolicyReview,
+		"webhook-enable-policy-review", false,
+		"Serve CertificateRequestPolicyReviews on the webhook server, which report the CertificateRequestPolicies "+
+			"that would be used to evaluate requests from a given subject, issuer and namespace.")
+
+	fs.DurationVar(&o.Webhook.MaxBreakGlassDuration,
+		"webhook-max-break-glass-duration", 72*time.Hour,
+		"Maximum duration from now that the spec.breakGlass.expiresAt of a break glass CertificateRequestPolicy "+
+			"may be set to, so that break glass policies cannot approve requests indefinitely.")
+
+	fs.DurationVar(&o.Webhook.ResponseCacheTTL,
+		"webhook-response-cache-ttl", 0,
+		"Duration that the results of validating CertificateRequestPolicies are cached for, so that identical "+
+			"policies re-applied by GitOps reconcilers are not validated again. Break glass policies are never cached. "+
+			"0 disables caching.")
+
+	fs.IntVar(&o.Webhook.ResponseCacheSize,
+		"webhook-response-cache-size", 1024,
+		"Maximum number of cached CertificateRequestPolicy validation results.")
+
+	fs.StringVar(&o.Webhook.PolicyDefaultsFile,
+		"webhook-policy-defaults-file", "",
+		"Path of a YAML file of CertificateRequestPolicy spec fields, such as a default constraints.maxDuration, that "+
+			"are set on CertificateRequestPolicies as they are created if the policy does not set them. Requires the "+
+			"mutating webhook to be registered with the API server. Empty disables defaulting.")
+
+	o.Webhook.Mutating.addFlags(fs, "mutating", "the mutating webhook")
+	o.Webhook.PolicyReview.addFlags(fs, "policy-review", "CertificateRequestPolicyReviews")
+
 	var deprecatedCertDir string
 	fs.StringVar(&deprecatedCertDir,
 		"webhook-certificate-dir", "/tmp",
@@ -266,3 +572,126 @@ func (o *Options) addWebhookFlags(fs *pflag.FlagSet) {
 		panic(err)
 	}
 }
+
+func (o *Options) addAuditFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Audit.LogPath,
+		"audit-log-path", "",
+		"Path of the file that approval decisions are appended to as Kubernetes audit events (audit.k8s.io/v1), one "+
+			"JSON object per line. The value \"-\" writes to stdout. Empty disables the file export.")
+
+	fs.StringVar(&o.Audit.WebhookURL,
+		"audit-webhook-url", "",
+		"URL that batches of approval decisions are POSTed to as a Kubernetes audit EventList (audit.k8s.io/v1). "+
+			"Empty disables the webhook export.")
+
+	fs.DurationVar(&o.Audit.WebhookTimeout,
+		"audit-webhook-timeout", time.Second*10,
+		"Timeout of requests to the audit webhook.")
+
+	fs.StringArrayVar(&o.Audit.RedactionRules,
+		"audit-redact", nil,
+		"Rule of the form <action>=<regex> redacting the requester's username, groups and extra values from audit "+
+			"events, and the requester's username from CertificateRequestDecisions, for example \"drop=^authentication.kubernetes.io/credential-id$\" or \"hash=@\". The action "+
+			"\"drop\" removes matching values, or the whole extra value if its key matches. The action \"hash\" "+
+			"replaces matching values with their SHA-256 hash. The first matching rule applies. May be given multiple "+
+			"times.")
+}
+
+func (o *Options) addBootstrapFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&o.Bootstrap.Usernames,
+		"bootstrap-usernames", nil,
+		"Requesters whose CertificateRequests may be approved without policy review, e.g. "+
+			"\"system:serviceaccount:cert-manager:cert-manager\". cert-manager creates the requests of Certificates "+
+			"in every namespace under its own ServiceAccount, so bootstrap is scoped with "+
+			"--bootstrap-namespaces. Must be used with --bootstrap-issuer-refs.")
+
+	fs.StringSliceVar(&o.Bootstrap.IssuerRefs,
+		"bootstrap-issuer-refs", nil,
+		"Issuers, in the form <kind>.<group>/<name>, whose CertificateRequests may be approved without policy "+
+			"review, e.g. \"Issuer.cert-manager.io/bootstrap-ca\". Must be used with --bootstrap-usernames "+
+			"and --bootstrap-namespaces.")
+
+	fs.StringSliceVar(&o.Bootstrap.Namespaces,
+		"bootstrap-namespaces", nil,
+		"Namespaces that CertificateRequests must be in to be approved without policy review, typically only the "+
+			"namespaces of cert-manager and approver-policy, e.g. \"cert-manager\". Required if "+
+			"--bootstrap-issuer-refs are given, since issuers of the same name may be created in any namespace.")
+}
+
+func (o *Options) addDenialBackoffFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.DenialBackoff.Threshold,
+		"denial-backoff-threshold", 0,
+		"Number of denied CertificateRequests from a requester in a namespace after which the review of its "+
+			"further requests in that namespace is delayed. Denials are forgotten when policies or RBAC change, or an hour after the last denial. 0 disables "+
+			"denial backoff.")
+
+	fs.DurationVar(&o.DenialBackoff.BaseDelay,
+		"denial-backoff-base-delay", time.Second*10,
+		"Delay applied to the review of requests once a requester reaches the denial backoff threshold. The delay "+
+			"doubles with every further denial.")
+
+	fs.DurationVar(&o.DenialBackoff.MaxDelay,
+		"denial-backoff-max-delay", time.Minute*5,
+		"Maximum delay applied to the review of requests from a requester which is repeatedly denied.")
+}
+
+func (o *Options) addHTTPAuthorizationFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.HTTPAuthorization.Mode,
+		"http-authorization-mode", "Delegated",
+		"Authorization mode of requests to approver-policy's HTTP endpoints, such as policy reviews. One of "+
+			"\"Delegated\", which authenticates bearer tokens with a TokenReview and authorizes the request path and "+
+			"method with a non-resource SubjectAccessReview, \"StaticToken\", which requires the bearer token in "+
+			"--http-authorization-token-file, or \"None\".")
+
+	fs.StringVar(&o.HTTPAuthorization.TokenFile,
+		"http-authorization-token-file", "",
+		"Path to the file holding the bearer token required by the \"StaticToken\" authorization mode.")
+}
+
+func (o *Options) addMetricsFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&o.Metrics.PolicyLabels,
+		"metrics-policy-labels", nil,
+		fmt.Sprintf("Names of the custom labels, at most %d, that CertificateRequestPolicies may add to their decision "+
+			"metrics with \"%s<label>\" annotations, e.g. \"team,tier\".", metrics.MaxPolicyLabels, policy.MetricLabelAnnotationPrefix))
+
+	fs.IntVar(&o.Metrics.PolicyLabelMaxValues,
+		"metrics-policy-label-max-values", 50,
+		fmt.Sprintf("Maximum number of distinct values of each custom policy label. Further values are reported as %q.",
+			metrics.OverflowLabelValue))
+
+	fs.IntVar(&o.Metrics.FieldUsageMinReviews,
+		"metrics-field-usage-min-reviews", 0,
+		"Number of requests which must be reviewed against a CertificateRequestPolicy before the allowed and "+
+			"constraints fields of its spec which no request exercised are reported, in the policy status and as "+
+			"metrics. Usage is tracked per policy generation since approver-policy started. 0 disables field usage "+
+			"analysis.")
+}
+
+func (o *Options) addEnrichmentFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&o.Enrichment.CertificateTTL,
+		"enrichment-certificate-ttl", 0,
+		"How long the Certificates owning requests, read to evaluate owner constraints, are cached. A request may "+
+			"be compared against a Certificate up to this old. 0 disables caching.")
+}
+
+// addFlags registers the flags of the named webhook listener.
+func (l *WebhookListener) addFlags(fs *pflag.FlagSet, name, served string) {
+	fs.IntVar(&l.Port,
+		"webhook-"+name+"-port", 0,
+		fmt.Sprintf("Port to serve %s on, separately from the shared webhook listener. 0 serves %s on the shared "+
+			"webhook listener.", served, served))
+
+	fs.StringVar(&l.Host,
+		"webhook-"+name+"-host", "",
+		fmt.Sprintf("Host to serve %s on. Defaults to --webhook-host.", served))
+
+	fs.StringVar(&l.CertDir,
+		"webhook-"+name+"-cert-dir", "",
+		fmt.Sprintf("Directory holding the tls.crt and tls.key serving certificate of the %s listener, which are "+
+			"reloaded when they change. Empty serves a certificate signed by the webhook CA.", name))
+
+	fs.StringVar(&l.ServiceName,
+		"webhook-"+name+"-service-name", "",
+		fmt.Sprintf("Name of the Kubernetes Service that exposes the %s listener, naming the certificate signed by "+
+			"the webhook CA. Defaults to --webhook-service-name.", name))
+}
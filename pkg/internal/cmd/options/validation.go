@@ -0,0 +1,301 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/controllers"
+	"github.com/cert-manager/approver-policy/pkg/internal/httpserver"
+)
+
+// FlagProblemType is the type of a problem found with the given flags.
+type FlagProblemType string
+
+const (
+	// FlagProblemUnknown is the type of problems with flags which are not
+	// defined, which are often misspelled.
+	FlagProblemUnknown FlagProblemType = "Unknown"
+
+	// FlagProblemConflict is the type of problems with flags which conflict
+	// with, or have no effect given, other flags.
+	FlagProblemConflict FlagProblemType = "Conflict"
+
+	// FlagProblemInvalid is the type of problems with flags given values
+	// which are not valid.
+	FlagProblemInvalid FlagProblemType = "Invalid"
+)
+
+// maxSuggestions is the maximum number of flags suggested for an unknown
+// flag.
+const maxSuggestions = 3
+
+// FlagProblem is a problem found with a given flag.
+type FlagProblem struct {
+	// Flag is the name of the flag, including its leading dashes.
+	Flag string `json:"flag"`
+
+	// Type is the type of the problem.
+	Type FlagProblemType `json:"type"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Suggestions are the names of defined flags which are similar to an
+	// unknown flag, including their leading dashes.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// String returns the problem as a single human readable line.
+func (p FlagProblem) String() string {
+	s := fmt.Sprintf("%s: %s", p.Flag, p.Message)
+	if len(p.Suggestions) > 0 {
+		s += fmt.Sprintf(", did you mean %s?", strings.Join(p.Suggestions, " or "))
+	}
+	return s
+}
+
+// FlagValidationError is returned when approver-policy is started with
+// unknown, conflicting or invalid flags. It is encoded as a JSON summary of the
+// problems, so that they may be consumed by tooling.
+type FlagValidationError struct {
+	// Problems are the problems found with the given flags.
+	Problems []FlagProblem `json:"problems"`
+}
+
+func (e *FlagValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, problem.String())
+	}
+	return "invalid flags: " + strings.Join(lines, "; ")
+}
+
+// UnknownFlagError returns a FlagValidationError suggesting similarly named
+// flags if the flag parse error is of an unknown flag. Other errors are
+// returned unchanged.
+func UnknownFlagError(fs *pflag.FlagSet, err error) error {
+	name, ok := strings.CutPrefix(err.Error(), "unknown flag: --")
+	if !ok {
+		return err
+	}
+
+	return &FlagValidationError{Problems: []FlagProblem{{
+		Flag:        "--" + name,
+		Type:        FlagProblemUnknown,
+		Message:     "unknown flag",
+		Suggestions: suggestFlags(fs, name),
+	}}}
+}
+
+// suggestFlags returns the names of visible flags similar to the unknown
+// name, closest first. Flags are similar if they are within a few edits of
+// the name, or contain it.
+func suggestFlags(fs *pflag.FlagSet, name string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	maxDistance := max(2, len(name)/4)
+	var candidates []candidate
+	fs.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || len(flag.Deprecated) > 0 {
+			return
+		}
+		distance := editDistance(name, flag.Name)
+		if distance > maxDistance && !strings.Contains(flag.Name, name) {
+			return
+		}
+		candidates = append(candidates, candidate{flag.Name, distance})
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for _, candidate := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, "--"+candidate.name)
+	}
+	return suggestions
+}
+
+// sortedKeys returns the keys of m in order, so that problems found
+// iterating over it are stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// Validate returns a FlagValidationError if the given flags have invalid
+// values, conflict with each other, or would have no effect given the other
+// flags. fs is the flag set the Options were parsed from, used to tell which
+// flags were given.
+func (o *Options) Validate(fs *pflag.FlagSet) error {
+	var problems []FlagProblem
+	problem := func(problemType FlagProblemType) func(flag, message string, args ...any) {
+		return func(flag, message string, args ...any) {
+			problems = append(problems, FlagProblem{
+				Flag:    "--" + flag,
+				Type:    problemType,
+				Message: fmt.Sprintf(message, args...),
+			})
+		}
+	}
+	conflict, invalid := problem(FlagProblemConflict), problem(FlagProblemInvalid)
+
+	if o.MaxPolicies < 0 {
+		invalid("max-policies", "must not be negative, got %d", o.MaxPolicies)
+	}
+
+	switch controllers.InvalidRequestAction(o.InvalidRequestAction) {
+	case controllers.InvalidRequestActionDeny, controllers.InvalidRequestActionIgnore:
+	default:
+		invalid("invalid-request-action", "must be one of %q or %q, got %q",
+			controllers.InvalidRequestActionDeny, controllers.InvalidRequestActionIgnore, o.InvalidRequestAction)
+	}
+
+	for _, name := range sortedKeys(o.AdvisorySampleRates) {
+		if rate := o.AdvisorySampleRates[name]; !slices.Contains(o.approvers, name) {
+			invalid("advisory-sample-rates", "unknown approver %q, must be one of %v", name, o.approvers)
+		} else if rate < 0 || rate > 100 {
+			invalid("advisory-sample-rates", "rate of %q must be between 0 and 100, got %d", name, rate)
+		}
+	}
+
+	if o.Webhook.MaxBreakGlassDuration <= 0 {
+		invalid("webhook-max-break-glass-duration", "must be positive, got %s", o.Webhook.MaxBreakGlassDuration)
+	}
+
+	for flag, d := range map[string]time.Duration{
+		"kube-client-timeout":        o.KubeClientTimeout,
+		"decision-retention":         o.DecisionRetention,
+		"enrichment-certificate-ttl": o.Enrichment.CertificateTTL,
+	} {
+		if d < 0 {
+			invalid(flag, "must not be negative, got %s", d)
+		}
+	}
+
+	for name, listener := range map[string]WebhookListener{
+		"mutating":      o.Webhook.Mutating,
+		"policy-review": o.Webhook.PolicyReview,
+	} {
+		prefix := "webhook-" + name + "-"
+		if listener.Port == 0 {
+			for _, flag := range []string{"host", "cert-dir", "service-name"} {
+				if fs.Changed(prefix + flag) {
+					conflict(prefix+flag, "has no effect unless --%sport is given", prefix)
+				}
+			}
+			continue
+		}
+		if len(listener.CertDir) > 0 && fs.Changed(prefix+"service-name") {
+			conflict(prefix+"service-name", "must not be given with --%scert-dir, as the listener serves the certificate in the directory rather than one signed by the webhook CA", prefix)
+		}
+	}
+
+	if len(o.HTTPAuthorization.TokenFile) > 0 && httpserver.AuthorizationMode(o.HTTPAuthorization.Mode) != httpserver.AuthorizationModeStaticToken {
+		conflict("http-authorization-token-file", "is only used by the %q --http-authorization-mode, got %q", httpserver.AuthorizationModeStaticToken, o.HTTPAuthorization.Mode)
+	}
+
+	if len(o.Audit.WebhookURL) == 0 && fs.Changed("audit-webhook-timeout") {
+		conflict("audit-webhook-timeout", "has no effect unless --audit-webhook-url is given")
+	}
+
+	if (len(o.Bootstrap.Usernames) == 0) != (len(o.Bootstrap.IssuerRefs) == 0) {
+		flag, other := "bootstrap-usernames", "bootstrap-issuer-refs"
+		if len(o.Bootstrap.Usernames) == 0 {
+			flag, other = other, flag
+		}
+		conflict(flag, "must be used with --%s", other)
+	}
+	for _, s := range o.Bootstrap.IssuerRefs {
+		if _, err := internalmanager.ParseIssuerRef(s); err != nil {
+			invalid("bootstrap-issuer-refs", "%s", err)
+		}
+	}
+	if len(o.Bootstrap.Usernames) == 0 && len(o.Bootstrap.Namespaces) > 0 {
+		conflict("bootstrap-namespaces", "has no effect unless --bootstrap-usernames is given")
+	}
+	if len(o.Bootstrap.IssuerRefs) > 0 && len(o.Bootstrap.Namespaces) == 0 {
+		// cert-manager creates the requests of Certificates in every namespace
+		// under its own ServiceAccount, so bootstrapping from any issuer in all
+		// namespaces would let anyone who can create an Issuer of that name
+		// and a Certificate bypass policy review.
+		conflict("bootstrap-issuer-refs", "must be used with --bootstrap-namespaces")
+	}
+
+	if o.DenialBackoff.Threshold == 0 {
+		for _, flag := range []string{"denial-backoff-base-delay", "denial-backoff-max-delay"} {
+			if fs.Changed(flag) {
+				conflict(flag, "has no effect unless --denial-backoff-threshold is given")
+			}
+		}
+	}
+
+	if len(o.Metrics.PolicyLabels) == 0 && fs.Changed("metrics-policy-label-max-values") {
+		conflict("metrics-policy-label-max-values", "has no effect unless --metrics-policy-labels is given")
+	}
+
+	if !o.RecordDecisions && fs.Changed("decision-retention") {
+		conflict("decision-retention", "has no effect unless --record-decisions is given")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Flag < problems[j].Flag })
+	return &FlagValidationError{Problems: problems}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Validate(t *testing.T) {
+	tests := map[string]struct {
+		args        []string
+		expProblems []FlagProblem
+	}{
+		"no flags should be valid": {},
+		"related flags given together should be valid": {
+			args: []string{
+				"--record-decisions", "--decision-retention=1h",
+				"--denial-backoff-threshold=3", "--denial-backoff-max-delay=1m",
+				"--webhook-mutating-port=6444", "--webhook-mutating-cert-dir=/certs",
+				"--http-authorization-mode=StaticToken", "--http-authorization-token-file=/token",
+			},
+		},
+		"flags without effect should conflict": {
+			args: []string{"--decision-retention=1h", "--denial-backoff-base-delay=1s", "--webhook-policy-review-host=0.0.0.0"},
+			expProblems: []FlagProblem{
+				{Flag: "--decision-retention", Type: FlagProblemConflict, Message: "has no effect unless --record-decisions is given"},
+				{Flag: "--denial-backoff-base-delay", Type: FlagProblemConflict, Message: "has no effect unless --denial-backoff-threshold is given"},
+				{Flag: "--webhook-policy-review-host", Type: FlagProblemConflict, Message: "has no effect unless --webhook-policy-review-port is given"},
+			},
+		},
+		"listener with both a certificate directory and a webhook CA service name should conflict": {
+			args: []string{"--webhook-mutating-port=6444", "--webhook-mutating-cert-dir=/certs", "--webhook-mutating-service-name=foo"},
+			expProblems: []FlagProblem{
+				{Flag: "--webhook-mutating-service-name", Type: FlagProblemConflict, Message: "must not be given with --webhook-mutating-cert-dir, as the listener serves the certificate in the directory rather than one signed by the webhook CA"},
+			},
+		},
+		"bootstrap scoped to namespaces should be valid": {
+			args: []string{"--bootstrap-usernames=system:serviceaccount:cert-manager:cert-manager", "--bootstrap-issuer-refs=Issuer.cert-manager.io/bootstrap-ca,ClusterIssuer.cert-manager.io/selfsigned", "--bootstrap-namespaces=cert-manager"},
+		},
+		"bootstrap from an Issuer in all namespaces should conflict": {
+			args: []string{"--bootstrap-usernames=system:serviceaccount:cert-manager:cert-manager", "--bootstrap-issuer-refs=Issuer.cert-manager.io/bootstrap-ca"},
+			expProblems: []FlagProblem{
+				{Flag: "--bootstrap-issuer-refs", Type: FlagProblemConflict, Message: "must be used with --bootstrap-namespaces"},
+			},
+		},
+		"bootstrap from a ClusterIssuer in all namespaces should conflict": {
+			args: []string{"--bootstrap-usernames=system:serviceaccount:cert-manager:cert-manager", "--bootstrap-issuer-refs=ClusterIssuer/selfsigned"},
+			expProblems: []FlagProblem{
+				{Flag: "--bootstrap-issuer-refs", Type: FlagProblemConflict, Message: "must be used with --bootstrap-namespaces"},
+			},
+		},
+		"bootstrap usernames without issuer refs should conflict": {
+			args: []string{"--bootstrap-usernames=system:serviceaccount:cert-manager:cert-manager"},
+			expProblems: []FlagProblem{
+				{Flag: "--bootstrap-usernames", Type: FlagProblemConflict, Message: "must be used with --bootstrap-issuer-refs"},
+			},
+		},
+		"invalid values should be invalid": {
+			args: []string{
+				"--max-policies=-1", "--invalid-request-action=Allow", "--kube-client-timeout=-1s",
+				"--bootstrap-usernames=system:serviceaccount:cert-manager:cert-manager", "--bootstrap-issuer-refs=bootstrap-ca", "--bootstrap-namespaces=cert-manager",
+			},
+			expProblems: []FlagProblem{
+				{Flag: "--bootstrap-issuer-refs", Type: FlagProblemInvalid, Message: `invalid issuer reference "bootstrap-ca", expected <kind>.<group>/<name>`},
+				{Flag: "--invalid-request-action", Type: FlagProblemInvalid, Message: `must be one of "Deny" or "Ignore", got "Allow"`},
+				{Flag: "--kube-client-timeout", Type: FlagProblemInvalid, Message: "must not be negative, got -1s"},
+				{Flag: "--max-policies", Type: FlagProblemInvalid, Message: "must not be negative, got -1"},
+			},
+		},
+		"advisory sample rates of unknown approvers should be invalid": {
+			args: []string{"--advisory-sample-rates=foo=50"},
+			expProblems: []FlagProblem{
+				{Flag: "--advisory-sample-rates", Type: FlagProblemInvalid, Message: `unknown approver "foo", must be one of []`},
+			},
+		},
+		"token file without the StaticToken mode should conflict": {
+			args: []string{"--http-authorization-token-file=/token"},
+			expProblems: []FlagProblem{
+				{Flag: "--http-authorization-token-file", Type: FlagProblemConflict, Message: `is only used by the "StaticToken" --http-authorization-mode, got "Delegated"`},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			opts := New().Prepare(cmd, nil, nil, nil)
+			require.NoError(t, cmd.ParseFlags(test.args))
+
+			err := opts.Validate(cmd.Flags())
+			if len(test.expProblems) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var flagErr *FlagValidationError
+			require.ErrorAs(t, err, &flagErr)
+			assert.Equal(t, test.expProblems, flagErr.Problems)
+		})
+	}
+}
+
+func Test_UnknownFlagError(t *testing.T) {
+	tests := map[string]struct {
+		arg            string
+		expFlag        string
+		expSuggestions []string
+	}{
+		"misspelled flag should suggest the closest flags": {
+			arg:            "--webhok-port=6443",
+			expFlag:        "--webhok-port",
+			expSuggestions: []string{"--webhook-port"},
+		},
+		"partial flag should suggest flags containing it": {
+			arg:            "--retention",
+			expFlag:        "--retention",
+			expSuggestions: []string{"--decision-retention"},
+		},
+		"unrelated flag should have no suggestions": {
+			arg:     "--zzzzzzzz",
+			expFlag: "--zzzzzzzz",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			New().Prepare(cmd, nil, nil, nil)
+			parseErr := cmd.ParseFlags([]string{test.arg})
+			require.Error(t, parseErr)
+
+			var flagErr *FlagValidationError
+			require.ErrorAs(t, UnknownFlagError(cmd.Flags(), parseErr), &flagErr)
+			require.Len(t, flagErr.Problems, 1)
+			assert.Equal(t, test.expFlag, flagErr.Problems[0].Flag)
+			assert.Equal(t, FlagProblemUnknown, flagErr.Problems[0].Type)
+			assert.Equal(t, test.expSuggestions, flagErr.Problems[0].Suggestions)
+		})
+	}
+}
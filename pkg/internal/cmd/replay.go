@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+	"github.com/cert-manager/approver-policy/pkg/internal/replay"
+)
+
+const (
+	replayHelpOutput = `Re-evaluate historical decisions recorded in an audit log against a new set of CertificateRequestPolicies.
+The audit file holds the JSON lines of audit events written by approver-policy. The policies directory holds the
+CertificateRequestPolicies, and the RBAC and Namespaces their evaluation depends on, as YAML manifests. Each
+recorded request is reviewed again, and every request whose verdict would change is reported. Requesters are
+replayed as recorded, so requests whose requester was redacted in the audit log may not be bound to any policy.`
+)
+
+// newReplayCommand returns the replay subcommand which re-evaluates audited
+// decisions against a policy set.
+func newReplayCommand(ctx context.Context) *cobra.Command {
+	var (
+		auditFile         string
+		policiesDir       string
+		emptySelector     string
+		issuerAliasValues map[string]string
+		output            string
+		exitCode          bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay --audit-file <decisions.jsonl> --policies <dir>",
+		Short: "Re-evaluate audited decisions against a new policy set",
+		Long:  replayHelpOutput,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(auditFile) == 0 || len(policiesDir) == 0 {
+				return errors.New("--audit-file and --policies must be given")
+			}
+			if output != "text" && output != "json" {
+				return fmt.Errorf(`--output must be one of "text" or "json", got %q`, output)
+			}
+
+			defaultSelectorMode, _, err := emptySelectorMode(emptySelector)
+			if err != nil {
+				return err
+			}
+			aliases, err := issuerAliases(issuerAliasValues)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(auditFile)
+			if err != nil {
+				return fmt.Errorf("failed to open --audit-file: %w", err)
+			}
+			defer f.Close()
+
+			decisions, err := replay.ReadDecisions(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %q: %w", auditFile, err)
+			}
+
+			objects, err := fixtures.LoadObjects(policiesDir)
+			if err != nil {
+				return err
+			}
+
+			reviewer, err := fixtures.NewReviewer(ctx, objects, replay.Namespaces(decisions), fixtures.Options{
+				DefaultSelectorMode: defaultSelectorMode,
+				IssuerAliases:       aliases,
+			})
+			if err != nil {
+				return err
+			}
+
+			report, err := replay.Replay(ctx, reviewer, decisions)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return fmt.Errorf("failed to encode report: %w", err)
+				}
+			default:
+				for _, change := range report.Changes {
+					fmt.Fprintln(out, change.String())
+				}
+				fmt.Fprintf(out, "Replayed %d decisions, %d changed verdict.\n", report.Replayed, len(report.Changes))
+			}
+
+			if exitCode && len(report.Changes) > 0 {
+				cmd.SilenceUsage = true
+				return errors.New("verdicts changed")
+			}
+
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&auditFile, "audit-file", "", "Path to the audit log of decisions, as JSON lines of audit events.")
+	fs.StringVar(&policiesDir, "policies", "", "Directory of YAML manifests of the policy set to replay the decisions against.")
+	fs.StringVar(&emptySelector, "empty-selector-mode", "All", `Selector mode of policies with an empty selector, as configured for approver-policy.`)
+	fs.StringToStringVar(&issuerAliasValues, "issuer-aliases", nil, "Issuer aliases, as configured for approver-policy.")
+	fs.StringVarP(&output, "output", "o", "text", `Output format, one of "text" or "json".`)
+	fs.BoolVar(&exitCode, "exit-code", false, "Exit with an error if any verdict changes.")
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+)
+
+const (
+	verifyBundleHelpOutput = `Statically check that a directory of YAML manifests holding CertificateRequestPolicies, Roles,
+ClusterRoles, RoleBindings and ClusterRoleBindings is consistent, without access to a cluster. The bundle is
+expected to be self-contained, and problems are reported for:
+  - policies which are not bound to any subject;
+  - rules granting use of named policies which are not in the bundle;
+  - roles granting use of policies which no binding references;
+  - bindings with no subjects which reference roles granting use of policies.
+
+Bindings referencing roles which are not in the bundle, such as the built-in view ClusterRole, are not checked.
+
+Exits with an error if any problem is found, so may gate changes to a GitOps repository.`
+)
+
+// verifyBundleReport is the result of verifying a bundle.
+type verifyBundleReport struct {
+	Policies int                `json:"policies"`
+	Roles    int                `json:"roles"`
+	Bindings int                `json:"bindings"`
+	Problems []bindings.Problem `json:"problems"`
+}
+
+// newVerifyBundleCommand returns the verify-bundle subcommand which checks a
+// directory of policies and RBAC for consistency.
+func newVerifyBundleCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "verify-bundle <dir> [--output text|json]",
+		Short: "Check a directory of policies and RBAC manifests for consistency",
+		Long:  verifyBundleHelpOutput,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf(`--output must be one of "text" or "json", got %q`, output)
+			}
+
+			objects, err := fixtures.LoadObjects(args[0])
+			if err != nil {
+				return err
+			}
+
+			var (
+				rbac     bindings.RBAC
+				policies []string
+			)
+			for _, obj := range objects {
+				switch obj := obj.(type) {
+				case *policyapi.CertificateRequestPolicy:
+					policies = append(policies, obj.Name)
+				case *rbacv1.Role:
+					rbac.Roles = append(rbac.Roles, *obj)
+				case *rbacv1.ClusterRole:
+					rbac.ClusterRoles = append(rbac.ClusterRoles, *obj)
+				case *rbacv1.RoleBinding:
+					rbac.RoleBindings = append(rbac.RoleBindings, *obj)
+				case *rbacv1.ClusterRoleBinding:
+					rbac.ClusterRoleBindings = append(rbac.ClusterRoleBindings, *obj)
+				}
+			}
+
+			report := verifyBundleReport{
+				Policies: len(policies),
+				Roles:    len(rbac.Roles) + len(rbac.ClusterRoles),
+				Bindings: len(rbac.RoleBindings) + len(rbac.ClusterRoleBindings),
+				Problems: rbac.Verify(policies),
+			}
+
+			out := cmd.OutOrStdout()
+			switch output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return fmt.Errorf("failed to encode report: %w", err)
+				}
+			default:
+				for _, problem := range report.Problems {
+					fmt.Fprintln(out, problem.String())
+				}
+				fmt.Fprintf(out, "Verified %d policies, %d roles and %d bindings, found %d problems.\n",
+					report.Policies, report.Roles, report.Bindings, len(report.Problems))
+			}
+
+			if len(report.Problems) > 0 {
+				cmd.SilenceUsage = true
+				return errors.New("bundle is inconsistent")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", `Output format, one of "text" or "json".`)
+
+	return cmd
+}
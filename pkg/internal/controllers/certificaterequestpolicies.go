@@ -20,9 +20,14 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -31,16 +36,20 @@ import (
 	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
 	"github.com/cert-manager/approver-policy/pkg/internal/controllers/ssa_client"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+	"github.com/cert-manager/approver-policy/pkg/internal/util"
 )
 
 // certificaterequestpolicies is a controller-runtime Reconciler which handles
@@ -69,11 +78,23 @@ type certificaterequestpolicies struct {
 	// CertificateRequestPolicies that are not in a Ready state will not be used
 	// to evaluate.
 	reconcilers []approver.Reconciler
+
+	// verifyIssuers sets the IssuersFound condition, reporting whether the
+	// issuers named by the selector of the policy exist.
+	verifyIssuers bool
+
+	// issuerAliases resolves the issuer aliases referenced by policy
+	// selectors.
+	issuerAliases map[string]cmmeta.ObjectReference
+
+	// fieldUsage reports the fields of policies which reviewed requests have
+	// not exercised. May be nil if field usage analysis is not enabled.
+	fieldUsage *metrics.FieldUsage
 }
 
 // addCertificateRequestPolicyController will register the
 // certificaterequestpolicies controller with the controller-runtime Manager.
-func addCertificateRequestPolicyController(_ context.Context, opts Options) error {
+func addCertificateRequestPolicyController(ctx context.Context, opts Options) error {
 	log := opts.Log.WithName("certificaterequestpolicies")
 	genericChan := make(chan event.GenericEvent)
 
@@ -85,6 +106,11 @@ func addCertificateRequestPolicyController(_ context.Context, opts Options) erro
 			enqueueListSelect = append(enqueueListSelect, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(enqueueChan)})
 		}
 	}
+	// Policies are resynced when their unexercised fields change, so that
+	// their status is kept up to date.
+	if opts.FieldUsage != nil {
+		enqueueListSelect = append(enqueueListSelect, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(opts.FieldUsage.EnqueueChan())})
+	}
 
 	// Only setup generic event triggers if at least one Reconciler gave an
 	// enqueue channel.
@@ -118,22 +144,45 @@ func addCertificateRequestPolicyController(_ context.Context, opts Options) erro
 		}
 	}
 
-	return ctrl.NewControllerManagedBy(opts.Manager).
+	c := &certificaterequestpolicies{
+		log:           log,
+		clock:         clock.RealClock{},
+		recorder:      opts.Manager.GetEventRecorderFor("policy.cert-manager.io"),
+		client:        opts.Manager.GetClient(),
+		lister:        opts.Manager.GetCache(),
+		reconcilers:   opts.Reconcilers,
+		verifyIssuers: opts.VerifyIssuers,
+		issuerAliases: opts.Predicates.IssuerAliases,
+		fieldUsage:    opts.FieldUsage,
+	}
+
+	builder := ctrl.NewControllerManagedBy(opts.Manager).
 		For(new(policyapi.CertificateRequestPolicy)).
 		WatchesRawSource(source.Channel(genericChan, handler.EnqueueRequestsFromMapFunc(
 			func(_ context.Context, obj client.Object) []reconcile.Request {
 				log.Info("reconciling certificaterequestpolicy after receiving event message", "name", obj.GetName())
 				return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: obj.GetName()}}}
 			},
-		))).
-		Complete(&certificaterequestpolicies{
-			log:         log,
-			clock:       clock.RealClock{},
-			recorder:    opts.Manager.GetEventRecorderFor("policy.cert-manager.io"),
-			client:      opts.Manager.GetClient(),
-			lister:      opts.Manager.GetCache(),
-			reconcilers: opts.Reconcilers,
-		})
+		)))
+
+	// Resync the policies naming an issuer when it is created or deleted, so
+	// that their IssuersFound condition is kept up to date. Updates never
+	// change whether an issuer exists, so are skipped.
+	if opts.VerifyIssuers {
+		if err := opts.Manager.GetFieldIndexer().IndexField(ctx, new(cmapi.Issuer), issuerNameField, issuerName); err != nil {
+			return fmt.Errorf("failed to index Issuers by name: %w", err)
+		}
+
+		createdOrDeleted := predicate.Funcs{
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		}
+		builder = builder.
+			Watches(new(cmapi.Issuer), handler.EnqueueRequestsFromMapFunc(c.enqueuePoliciesNamingIssuer), ctrlbuilder.WithPredicates(createdOrDeleted)).
+			Watches(new(cmapi.ClusterIssuer), handler.EnqueueRequestsFromMapFunc(c.enqueuePoliciesNamingIssuer), ctrlbuilder.WithPredicates(createdOrDeleted))
+	}
+
+	return builder.Complete(c)
 }
 
 // Reconcile is the top level function for reconciling over synced
@@ -164,12 +213,41 @@ func (c *certificaterequestpolicies) Reconcile(ctx context.Context, req ctrl.Req
 	return result, resultErr
 }
 
+// PolicyStatus returns the status the certificaterequestpolicies controller
+// would set on the named CertificateRequestPolicy, as built by the given
+// Reconcilers, without patching it. Returns nil if the policy does not exist
+// or is an expired break glass policy, which the controller deletes.
+func PolicyStatus(ctx context.Context, cl client.Client, reconcilers []approver.Reconciler, name string) (*policyapi.CertificateRequestPolicyStatus, error) {
+	c := &certificaterequestpolicies{
+		log:         logr.Discard(),
+		clock:       clock.RealClock{},
+		recorder:    &record.FakeRecorder{},
+		client:      dryRunClient{cl},
+		lister:      cl,
+		reconcilers: reconcilers,
+	}
+	_, status, err := c.reconcileStatusPatch(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+	return status, err
+}
+
+// dryRunClient is a client which does not delete objects.
+type dryRunClient struct {
+	client.Client
+}
+
+func (dryRunClient) Delete(context.Context, client.Object, ...client.DeleteOption) error {
+	return nil
+}
+
 func (c *certificaterequestpolicies) reconcileStatusPatch(ctx context.Context, req ctrl.Request) (ctrl.Result, *policyapi.CertificateRequestPolicyStatus, error) {
 	log := c.log.WithValues("name", req.NamespacedName.Name)
 	log.V(2).Info("syncing")
 
 	policy := new(policyapi.CertificateRequestPolicy)
 	if err := c.lister.Get(ctx, req.NamespacedName, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			c.fieldUsage.Forget(req.NamespacedName.Name)
+		}
 		return reconcile.Result{}, nil, client.IgnoreNotFound(err)
 	}
 
@@ -179,18 +257,64 @@ func (c *certificaterequestpolicies) reconcileStatusPatch(ctx context.Context, r
 
 		ready = true
 		el    field.ErrorList
+
+		// unreadyPlugins are plugins which are not ready, but whose failure
+		// policy does not block the policy from being Ready.
+		unreadyPlugins []string
+		pluginErrs     field.ErrorList
 	)
 
+	if breakGlass := policy.Spec.BreakGlass; breakGlass != nil {
+		remaining := breakGlass.ExpiresAt.Sub(c.clock.Now())
+		if remaining <= 0 {
+			log.Info("deleting expired break glass policy", "incident", breakGlass.IncidentRef, "expired-at", breakGlass.ExpiresAt.UTC())
+			c.recorder.Eventf(policy, corev1.EventTypeWarning, "BreakGlassExpired", "Deleting break glass policy for incident %q which expired at %s",
+				breakGlass.IncidentRef, breakGlass.ExpiresAt.UTC().Format(time.RFC3339))
+			if err := c.client.Delete(ctx, policy, client.Preconditions{UID: &policy.UID}); client.IgnoreNotFound(err) != nil {
+				return reconcile.Result{}, nil, fmt.Errorf("failed to delete expired break glass CertificateRequestPolicy %q: %w", req.NamespacedName.Name, err)
+			}
+			return reconcile.Result{}, nil, nil
+		}
+
+		// Resync at expiry so that the policy is deleted.
+		result.Requeue = true
+		result.RequeueAfter = remaining
+	}
+
+	policyPatch := &policyapi.CertificateRequestPolicyStatus{}
+
+	// The IssuersFound condition is informational, so is set regardless of
+	// whether the policy is Ready.
+	if c.verifyIssuers {
+		if err := c.setIssuersFoundCondition(ctx, policy, policyPatch); err != nil {
+			return reconcile.Result{}, nil, fmt.Errorf("failed to verify issuers of CertificateRequestPolicy %q: %w", req.NamespacedName.Name, err)
+		}
+	}
+
 	// Capture the ready response from each Reconciler.
 	for _, reconciler := range c.reconcilers {
+		// Reconcilers scoped to issuers the policy can never select do not
+		// affect whether it is ready.
+		if !reconcilerInScope(policy, reconciler) {
+			continue
+		}
+
 		response, err := reconciler.Ready(ctx, policy)
 		if err != nil {
 			return reconcile.Result{}, nil, fmt.Errorf("failed to evaluate ready state of CertificateRequestPolicy %q: %w", req.NamespacedName.Name, err)
 		}
 
-		// If any response is not ready, set ready to false.
+		// If any response is not ready, set ready to false, unless the
+		// Reconciler is a plugin whose failure policy is to deny or skip while
+		// it is not ready.
 		if !response.Ready {
-			ready = false
+			if name, ok := pluginFailureTolerated(policy, reconciler); ok {
+				unreadyPlugins = append(unreadyPlugins, name)
+				pluginErrs = append(pluginErrs, response.Errors...)
+			} else {
+				ready = false
+				el = append(el, response.Errors...)
+			}
 		}
 
 		// Capture requeue. If requeue is not currently set or the given
@@ -201,14 +325,10 @@ func (c *certificaterequestpolicies) reconcileStatusPatch(ctx context.Context, r
 			}
 			result.Requeue = true
 		}
-
-		el = append(el, response.Errors...)
 	}
 
 	log = log.WithValues("ready", ready)
 
-	policyPatch := &policyapi.CertificateRequestPolicyStatus{}
-
 	if !ready {
 		log.V(2).Info("NOT ready for approval evaluation", "errors", el.ToAggregate())
 
@@ -230,9 +350,15 @@ func (c *certificaterequestpolicies) reconcileStatusPatch(ctx context.Context, r
 		return result, policyPatch, nil
 	}
 
-	log.V(2).Info("ready for approval evaluation")
+	log.V(2).Info("ready for approval evaluation", "unready-plugins", unreadyPlugins)
 
 	message := "CertificateRequestPolicy is ready for approval evaluation"
+	if len(unreadyPlugins) > 0 {
+		sort.Strings(unreadyPlugins)
+		message = fmt.Sprintf("%s, applying failure policy of unready plugins %v: %s", message, unreadyPlugins, pluginErrs.ToAggregate())
+		policyPatch.UnreadyPlugins = unreadyPlugins
+	}
+	policyPatch.UnexercisedFields = c.fieldUsage.Unexercised(policy)
 	c.recorder.Event(policy, corev1.EventTypeNormal, "Ready", message)
 
 	c.setCertificateRequestPolicyCondition(
@@ -304,3 +430,48 @@ func (c *certificaterequestpolicies) setCertificateRequestPolicyCondition(
 	// the new condition into the slice.
 	*patchConditions = append(*patchConditions, newCondition)
 }
+
+// reconcilerInScope returns true if the policy may select requests within the
+// Scope of the Reconciler. Policies which don't select issuers by group may
+// select requests of any issuer.
+func reconcilerInScope(policy *policyapi.CertificateRequestPolicy, reconciler approver.Reconciler) bool {
+	scoped, ok := reconciler.(approver.Scoped)
+	if !ok {
+		return true
+	}
+	scope := scoped.Scope()
+	issuerRef := policy.Spec.Selector.IssuerRef
+	if len(scope.IssuerGroups) == 0 || issuerRef == nil || issuerRef.Group == nil {
+		return true
+	}
+	for _, group := range scope.IssuerGroups {
+		if util.WildcardMatches(*issuerRef.Group, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginFailureTolerated returns the name of the plugin Reconciler, and true,
+// if the Reconciler is a plugin configured on the policy with a failure policy
+// of Deny or Skip. Plugins with these failure policies don't block the policy
+// from becoming Ready, and instead have their failure policy applied during
+// evaluation.
+func pluginFailureTolerated(policy *policyapi.CertificateRequestPolicy, reconciler approver.Reconciler) (string, bool) {
+	named, ok := reconciler.(interface{ Name() string })
+	if !ok {
+		return "", false
+	}
+
+	plugin, ok := policy.Spec.Plugins[named.Name()]
+	if !ok {
+		return "", false
+	}
+
+	switch plugin.FailurePolicy {
+	case policyapi.PluginFailurePolicyDeny, policyapi.PluginFailurePolicySkip:
+		return named.Name(), true
+	default:
+		return "", false
+	}
+}
@@ -22,8 +22,10 @@ import (
 	"testing"
 	"time"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,12 +33,14 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2/ktesting"
 	fakeclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
 	fakeapprover "github.com/cert-manager/approver-policy/pkg/approver/fake"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
@@ -54,11 +58,15 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 	tests := map[string]struct {
 		existingObjects []runtime.Object
 		reconcilers     []approver.Reconciler
+		verifyIssuers   bool
+		fieldUsage      func(t *testing.T) *metrics.FieldUsage
 
-		expResult      ctrl.Result
-		expError       bool
-		expStatusPatch *policyapi.CertificateRequestPolicyStatus
-		expEvent       string
+		expResult       ctrl.Result
+		expError        bool
+		expStatusPatch  *policyapi.CertificateRequestPolicyStatus
+		expIssuersEvent string
+		expEvent        string
+		expDeleted      bool
 	}{
 		"if policy doesn't exist, no nothing": {
 			existingObjects: nil,
@@ -86,6 +94,153 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 			},
 			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
 		},
+		"if verifying issuers and all named issuers exist, set IssuersFound true": {
+			verifyIssuers: true,
+			existingObjects: []runtime.Object{
+				&policyapi.CertificateRequestPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+					TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+					Spec: policyapi.CertificateRequestPolicySpec{
+						Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("my-issuer")}},
+					},
+				},
+				&cmapi.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "my-issuer"}},
+			},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionIssuersFound,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "IssuersFound",
+						Message:            "All issuers named by the selector exist",
+						ObservedGeneration: policyGeneration},
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation",
+						ObservedGeneration: policyGeneration},
+				},
+			},
+			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
+		},
+		"if verifying issuers and a named issuer is missing, set IssuersFound false but remain ready": {
+			verifyIssuers: true,
+			existingObjects: []runtime.Object{
+				&policyapi.CertificateRequestPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+					TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+					Spec: policyapi.CertificateRequestPolicySpec{
+						Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{
+							Name: ptr.To("my-issuer"), Kind: ptr.To("ClusterIssuer"),
+						}},
+					},
+				},
+				&cmapi.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "my-issuer"}},
+			},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionIssuersFound,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "IssuerNotFound",
+						Message:            "Issuers named by the selector do not exist: ClusterIssuer.cert-manager.io/my-issuer",
+						ObservedGeneration: policyGeneration},
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation",
+						ObservedGeneration: policyGeneration},
+				},
+			},
+			expIssuersEvent: "Warning IssuerNotFound Issuers named by the selector do not exist: ClusterIssuer.cert-manager.io/my-issuer",
+			expEvent:        "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
+		},
+		"if verifying issuers and a named issuer is still missing, should not raise the event again": {
+			verifyIssuers: true,
+			existingObjects: []runtime.Object{
+				&policyapi.CertificateRequestPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+					TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+					Spec: policyapi.CertificateRequestPolicySpec{
+						Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{
+							Name: ptr.To("my-issuer"), Kind: ptr.To("Issuer"),
+						}},
+					},
+					Status: policyapi.CertificateRequestPolicyStatus{
+						Conditions: []policyapi.CertificateRequestPolicyCondition{
+							{Type: policyapi.CertificateRequestPolicyConditionIssuersFound,
+								Status:             corev1.ConditionFalse,
+								LastTransitionTime: fixedmetatime,
+								Reason:             "IssuerNotFound",
+								Message:            "Issuers named by the selector do not exist: Issuer.cert-manager.io/my-issuer",
+								ObservedGeneration: policyGeneration},
+						},
+					},
+				},
+				&cmapi.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "other-issuer"}},
+			},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionIssuersFound,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "IssuerNotFound",
+						Message:            "Issuers named by the selector do not exist: Issuer.cert-manager.io/my-issuer",
+						ObservedGeneration: policyGeneration},
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation",
+						ObservedGeneration: policyGeneration},
+				},
+			},
+			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
+		},
+		"if break glass policy has expired, delete it": {
+			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+				TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(fixedTime.Add(-time.Second)), IncidentRef: "INC-123"},
+				},
+			}},
+			expResult:      ctrl.Result{},
+			expError:       false,
+			expStatusPatch: nil,
+			expEvent:       `Warning BreakGlassExpired Deleting break glass policy for incident "INC-123" which expired at 2021-01-01T00:59:59Z`,
+			expDeleted:     true,
+		},
+		"if break glass policy has not expired, update to ready and requeue at expiry": {
+			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+				TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(fixedTime.Add(time.Hour)), IncidentRef: "INC-123"},
+				},
+			}},
+			expResult: ctrl.Result{Requeue: true, RequeueAfter: time.Hour},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation",
+						ObservedGeneration: policyGeneration},
+				},
+			},
+			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
+		},
 		"if reconciler returns ready response, update to ready": {
 			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
@@ -130,6 +285,96 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 			},
 			expEvent: "Warning NotReady CertificateRequestPolicy is not ready for approval evaluation: foo: Forbidden: not allowed",
 		},
+		"if plugin reconciler with failure policy Deny returns not ready response, update to ready with unready plugin": {
+			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+				TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Plugins: map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicyDeny}},
+				},
+			}},
+			reconcilers: []approver.Reconciler{pluginReconciler{name: "test-plugin", Reconciler: fakeapprover.NewFakeReconciler().WithReady(func(_ context.Context, _ *policyapi.CertificateRequestPolicy) (approver.ReconcilerReadyResponse, error) {
+				return approver.ReconcilerReadyResponse{Ready: false, Errors: field.ErrorList{field.Forbidden(field.NewPath("foo"), "not allowed")}}, nil
+			})}},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation, applying failure policy of unready plugins [test-plugin]: foo: Forbidden: not allowed",
+						ObservedGeneration: policyGeneration},
+				},
+				UnreadyPlugins: []string{"test-plugin"},
+			},
+			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation, applying failure policy of unready plugins [test-plugin]: foo: Forbidden: not allowed",
+		},
+		"if fields of ready policy have not been exercised, report them": {
+			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+				TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Allowed: &policyapi.CertificateRequestPolicyAllowed{IsCA: ptr.To(false)},
+				},
+			}},
+			reconcilers: []approver.Reconciler{fakeapprover.NewFakeReconciler().WithReady(func(_ context.Context, _ *policyapi.CertificateRequestPolicy) (approver.ReconcilerReadyResponse, error) {
+				return approver.ReconcilerReadyResponse{Ready: true}, nil
+			})},
+			fieldUsage: func(t *testing.T) *metrics.FieldUsage {
+				usage, err := metrics.NewFieldUsage(1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				usage.Observe(&cmapi.CertificateRequest{}, &policyapi.CertificateRequestPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration},
+					Spec: policyapi.CertificateRequestPolicySpec{
+						Allowed: &policyapi.CertificateRequestPolicyAllowed{IsCA: ptr.To(false)},
+					},
+				})
+				return usage
+			},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "Ready",
+						Message:            "CertificateRequestPolicy is ready for approval evaluation",
+						ObservedGeneration: policyGeneration},
+				},
+				UnexercisedFields: []string{"spec.allowed.isCA"},
+			},
+			expEvent: "Normal Ready CertificateRequestPolicy is ready for approval evaluation",
+		},
+		"if plugin reconciler with failure policy Block returns not ready response, update to not ready": {
+			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
+				TypeMeta:   metav1.TypeMeta{Kind: "CertificateRequestPolicy", APIVersion: "policy.cert-manager.io/v1alpha1"},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Plugins: map[string]policyapi.CertificateRequestPolicyPluginData{"test-plugin": {FailurePolicy: policyapi.PluginFailurePolicyBlock}},
+				},
+			}},
+			reconcilers: []approver.Reconciler{pluginReconciler{name: "test-plugin", Reconciler: fakeapprover.NewFakeReconciler().WithReady(func(_ context.Context, _ *policyapi.CertificateRequestPolicy) (approver.ReconcilerReadyResponse, error) {
+				return approver.ReconcilerReadyResponse{Ready: false, Errors: field.ErrorList{field.Forbidden(field.NewPath("foo"), "not allowed")}}, nil
+			})}},
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "NotReady",
+						Message:            "CertificateRequestPolicy is not ready for approval evaluation: foo: Forbidden: not allowed",
+						ObservedGeneration: policyGeneration},
+				},
+			},
+			expEvent: "Warning NotReady CertificateRequestPolicy is not ready for approval evaluation: foo: Forbidden: not allowed",
+		},
 		"if reconciler returns error, return error": {
 			existingObjects: []runtime.Object{&policyapi.CertificateRequestPolicy{
 				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: policyGeneration, ResourceVersion: "3"},
@@ -434,17 +679,22 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 			fakeclient := fakeclient.NewClientBuilder().
 				WithScheme(policyapi.GlobalScheme).
 				WithRuntimeObjects(test.existingObjects...).
+				WithIndex(new(cmapi.Issuer), issuerNameField, issuerName).
 				Build()
 
-			fakerecorder := record.NewFakeRecorder(1)
+			fakerecorder := record.NewFakeRecorder(2)
 
 			c := &certificaterequestpolicies{
-				log:         ktesting.NewLogger(t, ktesting.DefaultConfig),
-				clock:       fixedclock,
-				client:      fakeclient,
-				lister:      fakeclient,
-				recorder:    fakerecorder,
-				reconcilers: test.reconcilers,
+				log:           ktesting.NewLogger(t, ktesting.DefaultConfig),
+				clock:         fixedclock,
+				client:        fakeclient,
+				lister:        fakeclient,
+				recorder:      fakerecorder,
+				reconcilers:   test.reconcilers,
+				verifyIssuers: test.verifyIssuers,
+			}
+			if test.fieldUsage != nil {
+				c.fieldUsage = test.fieldUsage(t)
 			}
 
 			resp, statusPatch, err := c.reconcileStatusPatch(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: policyName}})
@@ -456,6 +706,12 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 				t.Errorf("unexpected Reconcile response, exp=%v got=%v", test.expResult, resp)
 			}
 
+			if len(test.expIssuersEvent) > 0 {
+				if event := <-fakerecorder.Events; event != test.expIssuersEvent {
+					t.Errorf("unexpected issuers event, exp=%q got=%q", test.expIssuersEvent, event)
+				}
+			}
+
 			var event string
 			select {
 			case event = <-fakerecorder.Events:
@@ -468,6 +724,11 @@ func Test_certificaterequestpolicies_Reconcile(t *testing.T) {
 			if !apiequality.Semantic.DeepEqual(statusPatch, test.expStatusPatch) {
 				t.Errorf("unexpected Reconcile response, exp=%v got=%v", test.expStatusPatch, statusPatch)
 			}
+
+			err = fakeclient.Get(context.TODO(), types.NamespacedName{Name: policyName}, new(policyapi.CertificateRequestPolicy))
+			if deleted := apierrors.IsNotFound(err); len(test.existingObjects) > 0 && deleted != test.expDeleted {
+				t.Errorf("unexpected policy deletion, exp=%t got=%t", test.expDeleted, deleted)
+			}
 		})
 	}
 }
@@ -602,3 +863,60 @@ func Test_certificaterequestpolicies_setCertificateRequestPolicyCondition(t *tes
 		})
 	}
 }
+
+// pluginReconciler is a Reconciler which is named, in the same way as
+// registered plugin approvers.
+type pluginReconciler struct {
+	name string
+	approver.Reconciler
+}
+
+func (p pluginReconciler) Name() string {
+	return p.name
+}
+
+func Test_reconcilerInScope(t *testing.T) {
+	venafi := fakeapprover.NewFakeApprover().WithScope(approver.Scope{IssuerGroups: []string{"venafi.cert-manager.io"}})
+
+	tests := map[string]struct {
+		reconciler approver.Reconciler
+		issuerRef  *policyapi.CertificateRequestPolicySelectorIssuerRef
+		expInScope bool
+	}{
+		"unscoped reconcilers should be in scope of every policy": {
+			reconciler: fakeapprover.NewFakeApprover(),
+			issuerRef:  &policyapi.CertificateRequestPolicySelectorIssuerRef{Group: ptr.To("awspca.cert-manager.io")},
+			expInScope: true,
+		},
+		"policies without an issuerRef selector should be in scope": {
+			reconciler: venafi,
+			expInScope: true,
+		},
+		"policies not selecting by issuer group should be in scope": {
+			reconciler: venafi,
+			issuerRef:  &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("my-issuer")},
+			expInScope: true,
+		},
+		"policies selecting a matching issuer group wildcard should be in scope": {
+			reconciler: venafi,
+			issuerRef:  &policyapi.CertificateRequestPolicySelectorIssuerRef{Group: ptr.To("*.cert-manager.io")},
+			expInScope: true,
+		},
+		"policies selecting another issuer group should not be in scope": {
+			reconciler: venafi,
+			issuerRef:  &policyapi.CertificateRequestPolicySelectorIssuerRef{Group: ptr.To("awspca.cert-manager.io")},
+			expInScope: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy := &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: test.issuerRef}},
+			}
+			if inScope := reconcilerInScope(policy, test.reconciler); inScope != test.expInScope {
+				t.Errorf("unexpected in scope, exp=%t got=%t", test.expInScope, inScope)
+			}
+		})
+	}
+}
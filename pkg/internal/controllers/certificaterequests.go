@@ -18,9 +18,12 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	apiutil "github.com/cert-manager/cert-manager/pkg/api/util"
@@ -38,14 +41,20 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
 	"github.com/cert-manager/approver-policy/pkg/approver/manager"
 	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
 	"github.com/cert-manager/approver-policy/pkg/internal/controllers/ssa_client"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 // certificaterequests is a controller-runtime Reconciler which evaluates
@@ -61,6 +70,10 @@ type certificaterequests struct {
 	// recorder is used for creating Kubernetes events on resources.
 	recorder record.EventRecorder
 
+	// auditor exports approval decisions as Kubernetes audit events. May be
+	// nil if audit export is not enabled.
+	auditor *audit.Exporter
+
 	// client is a Kubernetes REST client to interact with objects in the API
 	// server.
 	client client.Client
@@ -69,6 +82,39 @@ type certificaterequests struct {
 	// objects.
 	lister client.Reader
 
+	// denials tracks denials of requesters, to delay the review of requests
+	// from requesters which are repeatedly denied. May be nil if denial
+	// backoff is not enabled.
+	denials *denialTracker
+
+	// invalidRequestAction is the action taken on structurally invalid
+	// requests.
+	invalidRequestAction InvalidRequestAction
+
+	// decisions counts the decisions of each policy. May be nil if policy
+	// decision metrics are not enabled.
+	decisions *metrics.PolicyDecisions
+
+	// fieldUsage tracks the fields of each policy exercised by reviewed
+	// requests. May be nil if field usage analysis is not enabled.
+	fieldUsage *metrics.FieldUsage
+
+	// decisionRecords configures mirroring decisions into
+	// CertificateRequestDecisions.
+	decisionRecords DecisionRecordOptions
+
+	// postProcessors are invoked with each verdict once it has been written to
+	// the request.
+	postProcessors []approver.PostProcessor
+
+	// postProcessTimeout bounds each call to a post-processor. Defaults to
+	// defaultPostProcessTimeout.
+	postProcessTimeout time.Duration
+
+	// decoders normalize requests of issuer groups which encode them
+	// differently from PEM before they are checked and reviewed.
+	decoders internalcsr.Decoders
+
 	// manager is a Manager that is responsible for reviewing whether a
 	// CertificateRequest should be approved or denied. This manager is expected
 	// to manage all approvers which have been registered and active for this
@@ -79,13 +125,39 @@ type certificaterequests struct {
 // addCertificateRequestController will register the certificaterequests
 // controller with the controller-runtime Manager.
 func addCertificateRequestController(ctx context.Context, opts Options) error {
+	predicates := opts.Predicates
+	predicates.Index = internalmanager.NewPolicyIndex(predicates.IssuerAliases, predicates.MaxPolicies)
+	informer, err := opts.Manager.GetCache().GetInformer(ctx, &policyapi.CertificateRequestPolicy{})
+	if err != nil {
+		return fmt.Errorf("failed to get CertificateRequestPolicy informer: %w", err)
+	}
+	if err := predicates.Index.Register(informer); err != nil {
+		return fmt.Errorf("failed to index CertificateRequestPolicies: %w", err)
+	}
+
+	decoders, err := internalcsr.NewDecoders(opts.RequestDecoders)
+	if err != nil {
+		return fmt.Errorf("failed to configure request decoders: %w", err)
+	}
+
 	c := &certificaterequests{
-		log:      opts.Log.WithName("certificaterequests"),
-		clock:    clock.RealClock{},
-		recorder: opts.Manager.GetEventRecorderFor("policy.cert-manager.io"),
-		client:   opts.Manager.GetClient(),
-		lister:   opts.Manager.GetCache(),
-		manager:  internalmanager.New(opts.Manager.GetCache(), opts.Manager.GetClient(), opts.Evaluators),
+		log:                  opts.Log.WithName("certificaterequests"),
+		clock:                clock.RealClock{},
+		recorder:             opts.Manager.GetEventRecorderFor("policy.cert-manager.io"),
+		auditor:              opts.Auditor,
+		denials:              newDenialTracker(clock.RealClock{}, opts.DenialBackoff),
+		decisions:            opts.PolicyDecisions,
+		fieldUsage:           opts.FieldUsage,
+		decisionRecords:      opts.DecisionRecords,
+		postProcessors:       opts.PostProcessors,
+		decoders:             decoders,
+		invalidRequestAction: opts.InvalidRequestAction,
+		client:               opts.Manager.GetClient(),
+		lister:               opts.Manager.GetCache(),
+		manager: internalmanager.NewBootstrap(opts.Bootstrap,
+			internalmanager.NewDecoding(decoders,
+				internalmanager.NewExemptions(opts.Manager.GetCache(), opts.Evaluators,
+					internalmanager.New(opts.Manager.GetCache(), opts.Manager.GetClient(), opts.Evaluators, predicates, opts.AdvisorySampling)))),
 	}
 
 	enqueueRequestFromMapFunc := func(_ context.Context, _ client.Object) []reconcile.Request {
@@ -115,7 +187,26 @@ func addCertificateRequestController(ctx context.Context, opts Options) error {
 		return requests
 	}
 
+	// Policy, exemption and RBAC changes may permit requesters which have
+	// previously been denied, so their denials are forgotten.
+	resetDenialsAndEnqueue := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		c.denials.Reset()
+		return enqueueRequestFromMapFunc(ctx, obj)
+	}
+
+	rbacChanged := rbacBindingChanged(ctx, c.log, opts.Manager.GetCache())
+
+	var controllerOptions controller.Options
+	handoff, err := addHandoff(opts)
+	if err != nil {
+		return fmt.Errorf("failed to add handoff of in-flight requests: %w", err)
+	}
+	if handoff != nil {
+		controllerOptions.NewQueue = handoff.newQueue
+	}
+
 	return ctrl.NewControllerManagedBy(opts.Manager).
+		WithOptions(controllerOptions).
 		For(&cmapi.CertificateRequest{}, builder.WithPredicates(
 			// Only process CertificateRequests which have not yet got an approval
 			// status.
@@ -128,18 +219,27 @@ func addCertificateRequestController(ctx context.Context, opts Options) error {
 		// Watch CertificateRequestPolicies. If a policy is created or updated,
 		// then we need to process all CertificateRequests that do not yet have an
 		// approved or denied condition since they may be relevant for the policy.
-		Watches(&policyapi.CertificateRequestPolicy{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
+		// Updates which do not change how requests are reviewed, such as most
+		// status updates, are skipped.
+		Watches(&policyapi.CertificateRequestPolicy{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue),
+			builder.WithPredicates(policyReviewChanged(c.log))).
+
+		// Watch CertificateRequestPolicyExemptions, since a request which is
+		// not yet approved or denied may be approved under a new exemption.
+		Watches(&policyapi.CertificateRequestPolicyExemption{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue)).
 
 		// Watch Roles, RoleBindings, ClusterRoles, and ClusterRoleBindings. If
 		// RBAC changes in the cluster then CertificateRequestPolicies may become
-		// appropriate for a CertificateRequest. On RBAC events, Reconcile all
-		// CertificateRequests that are neither Approved or Denied.
-		// Only need to cache metadata for RBAC resources since we do not need any
-		// information in the spec.
-		WatchesMetadata(&rbacv1.Role{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
-		WatchesMetadata(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
-		WatchesMetadata(&rbacv1.ClusterRole{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
-		WatchesMetadata(&rbacv1.ClusterRoleBinding{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
+		// appropriate for a CertificateRequest. On RBAC events which grant or
+		// revoke the use of policies, Reconcile all CertificateRequests that are
+		// neither Approved or Denied, and forget the denials of requesters,
+		// which are the only state derived from bindings. The rules and
+		// subjects are needed to tell whether a change is relevant, so objects
+		// transformed by the cache options of CacheByObject are cached.
+		Watches(&rbacv1.Role{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue), builder.WithPredicates(rbacChanged)).
+		Watches(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue), builder.WithPredicates(rbacChanged)).
+		Watches(&rbacv1.ClusterRole{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue), builder.WithPredicates(rbacChanged)).
+		Watches(&rbacv1.ClusterRoleBinding{}, handler.EnqueueRequestsFromMapFunc(resetDenialsAndEnqueue), builder.WithPredicates(rbacChanged)).
 		WatchesMetadata(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(enqueueRequestFromMapFunc)).
 
 		// Complete the controller builder.
@@ -152,7 +252,26 @@ func addCertificateRequestController(ctx context.Context, opts Options) error {
 // function will call the approver manager to evaluate whether a
 // CertificateRequest should be approved, denied, or left alone.
 func (c *certificaterequests) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	result, patch, resultErr := c.reconcileStatusPatch(ctx, req)
+	result, patch, annotations, verdict, resultErr := c.reconcileStatusPatch(ctx, req)
+
+	// Annotations are applied before the status, since once the request is
+	// approved or denied it will not be reconciled again.
+	if len(annotations) > 0 {
+		cr, patch, err := ssa_client.GenerateCertificateRequestAnnotationsPatch(req.Name, req.Namespace, annotations)
+		if err != nil {
+			err = fmt.Errorf("failed to generate CertificateRequest annotations patch: %w", err)
+			return ctrl.Result{}, utilerrors.NewAggregate([]error{resultErr, err})
+		}
+
+		if err := c.client.Patch(ctx, cr, patch, &client.PatchOptions{
+			FieldManager: "approver-policy",
+			Force:        ptr.To(true),
+		}); err != nil {
+			err = fmt.Errorf("failed to apply CertificateRequest annotations patch: %w", err)
+			return ctrl.Result{}, utilerrors.NewAggregate([]error{resultErr, err})
+		}
+	}
+
 	if patch != nil {
 		cr, patch, err := ssa_client.GenerateCertificateRequestStatusPatch(req.Name, req.Namespace, patch)
 		if err != nil {
@@ -171,25 +290,117 @@ func (c *certificaterequests) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	// The verdict is only counted, audited, recorded and post-processed once
+	// written, so that a failed patch which is retried is not reported as a
+	// decision.
+	if verdict != nil {
+		c.recordDecision(ctx, c.log.WithValues("namespace", req.NamespacedName.Namespace, "name", req.NamespacedName.Name), verdict.record)
+		switch verdict.response.Result {
+		case manager.ResultApproved:
+			c.observeDecisions(ctx, verdict.reviewed(), metrics.DecisionApproved, verdict.response.ApprovedByAll)
+		case manager.ResultDenied:
+			if !verdict.invalid {
+				c.denials.Denied(verdict.request.Namespace, verdict.request.Spec.Username)
+			}
+			c.observeDecisions(ctx, verdict.reviewed(), metrics.DecisionDenied, verdict.response.DeniedBy)
+		}
+		if c.auditor != nil {
+			c.auditor.Export(verdict.request, verdict.response)
+		}
+		c.postProcess(ctx, verdict)
+	}
+
 	return result, resultErr
 }
 
-func (c *certificaterequests) reconcileStatusPatch(ctx context.Context, req ctrl.Request) (ctrl.Result, *cmapi.CertificateRequestStatus, error) {
+// verdict is an Approved or Denied verdict of a review, which is counted,
+// audited, recorded and passed to the post-processors once it has been written
+// to the request.
+type verdict struct {
+	request  *cmapi.CertificateRequest
+	response manager.ReviewResponse
+	record   *policyapi.CertificateRequestDecision
+
+	// invalid is true for structurally invalid requests denied without
+	// review, which do not count towards the denial backoff of the requester.
+	invalid bool
+}
+
+// reviewed returns the request as it was reviewed. Requests of issuer groups
+// with a RequestDecoder are reviewed with their normalized `spec.request`.
+func (v *verdict) reviewed() *cmapi.CertificateRequest {
+	if v.response.NormalizedRequest == nil {
+		return v.request
+	}
+	reviewed := v.request.DeepCopy()
+	reviewed.Spec.Request = v.response.NormalizedRequest
+	return reviewed
+}
+
+// defaultPostProcessTimeout bounds each call to a post-processor, so that a
+// slow external system cannot stall the reconciliation of requests.
+const defaultPostProcessTimeout = 10 * time.Second
+
+// postProcess invokes each post-processor with the written verdict, each
+// bounded by the post-process timeout. Errors, including timeouts, are logged
+// and raised as an Event, but never requeue the request since the verdict is
+// final.
+func (c *certificaterequests) postProcess(ctx context.Context, verdict *verdict) {
+	timeout := c.postProcessTimeout
+	if timeout == 0 {
+		timeout = defaultPostProcessTimeout
+	}
+
+	for _, postProcessor := range c.postProcessors {
+		if err := c.postProcessOne(ctx, timeout, postProcessor, verdict); err != nil {
+			c.log.Error(err, "post-processor failed", "post-processor", postProcessor.Name(),
+				"namespace", verdict.request.Namespace, "name", verdict.request.Name)
+			c.recorder.Eventf(verdict.request, corev1.EventTypeWarning, "PostProcessError", "Post-processor %q failed to process the %s verdict", postProcessor.Name(), verdict.record.Spec.Verdict)
+			metrics.PostProcesses.WithLabelValues(postProcessor.Name(), metrics.PostProcessError).Inc()
+			continue
+		}
+		metrics.PostProcesses.WithLabelValues(postProcessor.Name(), metrics.PostProcessSuccess).Inc()
+	}
+}
+
+// postProcessOne invokes the post-processor with the verdict, with a context
+// which is cancelled after the timeout.
+func (c *certificaterequests) postProcessOne(ctx context.Context, timeout time.Duration, postProcessor approver.PostProcessor, verdict *verdict) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return postProcessor.PostProcess(ctx, verdict.request, verdict.record)
+}
+
+func (c *certificaterequests) reconcileStatusPatch(ctx context.Context, req ctrl.Request) (ctrl.Result, *cmapi.CertificateRequestStatus, map[string]string, *verdict, error) {
 	log := c.log.WithValues("namespace", req.NamespacedName.Namespace, "name", req.NamespacedName.Name)
 	log.V(2).Info("syncing certificaterequest")
 
 	cr := new(cmapi.CertificateRequest)
 	if err := c.lister.Get(ctx, req.NamespacedName, cr); err != nil {
-		return ctrl.Result{}, nil, client.IgnoreNotFound(err)
+		return ctrl.Result{}, nil, nil, nil, client.IgnoreNotFound(err)
 	}
 
 	if apiutil.CertificateRequestIsApproved(cr) || apiutil.CertificateRequestIsDenied(cr) {
 		// Return early if already approved/denied as this is decision is final for requests.
-		return ctrl.Result{}, nil, nil
+		return ctrl.Result{}, nil, nil, nil, nil
 	}
 
-	// Query review on the approver manager.
-	response, err := c.manager.Review(ctx, cr)
+	// Structurally invalid requests can never be signed, so are handled
+	// before review rather than failing every evaluator.
+	if patch, verdict, ok := c.checkStructure(log, cr); !ok {
+		return ctrl.Result{}, patch, nil, verdict, nil
+	}
+
+	if delay := c.denials.Delay(cr.Namespace, cr.Spec.Username); delay > 0 {
+		log.V(2).Info("delaying review of request as requester has repeatedly been denied", "requester", cr.Spec.Username, "delay", delay)
+		c.recorder.Eventf(cr, corev1.EventTypeWarning, "DenialBackoff", "Review delayed by %s as requester has repeatedly had requests denied", delay.Round(time.Second))
+		metrics.DenialBackoffCount.WithLabelValues(cr.Namespace).Inc()
+		return ctrl.Result{RequeueAfter: delay}, nil, nil, nil, nil
+	}
+
+	// Query review on the approver manager, which logs with the logger of
+	// the request.
+	response, err := c.manager.Review(logr.NewContext(ctx, log), cr)
 	if err != nil {
 		// If an error occurs when evaluating, we fire an event on the
 		// CertificateRequest and return err to try again.
@@ -197,15 +408,35 @@ func (c *certificaterequests) reconcileStatusPatch(ctx context.Context, req ctrl
 		// information about the approver configuration being exposed to the
 		// client.
 		c.recorder.Eventf(cr, corev1.EventTypeWarning, "EvaluationError", "approver-policy failed to review the request and will retry")
-		return ctrl.Result{}, nil, err
+		return ctrl.Result{}, nil, nil, nil, err
 	}
 
 	crPatch := &cmapi.CertificateRequestStatus{}
 
 	switch response.Result {
 	case manager.ResultApproved:
-		log.V(2).Info("approving request")
-		c.recorder.Event(cr, corev1.EventTypeNormal, "Approved", response.Message)
+		if bg := response.BreakGlass; bg != nil {
+			// Break glass approvals bypass all policy evaluation, so are always
+			// logged and raised as a Warning for visibility.
+			log.Info("approving request with break glass policy", "policy", response.ApprovedBy.Name,
+				"incident", bg.IncidentRef, "expires-at", bg.ExpiresAt.UTC(), "username", cr.Spec.Username)
+			c.recorder.Event(cr, corev1.EventTypeWarning, "BreakGlassApproved", response.Message)
+		} else if override := response.Override; override != nil {
+			// Approvals under an exemption override the decision of every
+			// policy, so are always logged and raised as a Warning for
+			// visibility.
+			log.Info("approving request under exemption", "exemption", override.Exemption, "policy", override.ApprovedBy.Name,
+				"reason", override.Reason, "username", cr.Spec.Username)
+			c.recorder.Event(cr, corev1.EventTypeWarning, "ApprovedUnderExemption", response.Message)
+		} else {
+			log.V(2).Info("approving request")
+			c.recorder.Event(cr, corev1.EventTypeNormal, "Approved", response.Message)
+		}
+		if len(response.Warnings) > 0 {
+			log.V(2).Info("approved request has warnings", "warnings", response.Warnings)
+			c.recorder.Eventf(cr, corev1.EventTypeWarning, "ApprovedWithWarnings", "Request approved with warnings: %s", strings.Join(response.Warnings, "; "))
+		}
+		record := c.decisionRecord(cr, response)
 
 		setCertificateRequestStatusCondition(
 			c.clock,
@@ -217,11 +448,50 @@ func (c *certificaterequests) reconcileStatusPatch(ctx context.Context, req ctrl
 			response.Message,
 		)
 
-		return ctrl.Result{}, crPatch, nil
+		var annotations map[string]string
+		if response.ApprovedBy != nil {
+			annotations = map[string]string{
+				policy.ApprovedByPolicyAnnotationKey:           response.ApprovedBy.Name,
+				policy.ApprovedByPolicyGenerationAnnotationKey: strconv.FormatInt(response.ApprovedBy.Generation, 10),
+			}
+		}
+		if len(response.ApprovedByAll) > 0 {
+			approvedBy, err := json.Marshal(response.ApprovedByAll)
+			if err != nil {
+				return ctrl.Result{}, nil, nil, nil, fmt.Errorf("failed to encode approving policies: %w", err)
+			}
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[policy.ApprovedByPoliciesAnnotationKey] = string(approvedBy)
+		}
+		if response.Override != nil {
+			override, err := json.Marshal(response.Override)
+			if err != nil {
+				return ctrl.Result{}, nil, nil, nil, fmt.Errorf("failed to encode override chain: %w", err)
+			}
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[policy.OverrideChainAnnotationKey] = string(override)
+		}
+		if len(response.Warnings) > 0 {
+			warnings, err := json.Marshal(response.Warnings)
+			if err != nil {
+				return ctrl.Result{}, nil, nil, nil, fmt.Errorf("failed to encode warnings: %w", err)
+			}
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[policy.WarningsAnnotationKey] = string(warnings)
+		}
+
+		return ctrl.Result{}, crPatch, annotations, &verdict{request: cr, response: response, record: record}, nil
 
 	case manager.ResultDenied:
 		log.V(2).Info("denying request")
 		c.recorder.Event(cr, corev1.EventTypeWarning, "Denied", response.Message)
+		record := c.decisionRecord(cr, response)
 
 		setCertificateRequestStatusCondition(
 			c.clock,
@@ -233,24 +503,48 @@ func (c *certificaterequests) reconcileStatusPatch(ctx context.Context, req ctrl
 			response.Message,
 		)
 
-		return ctrl.Result{}, crPatch, nil
+		return ctrl.Result{}, crPatch, nil, &verdict{request: cr, response: response, record: record}, nil
 
 	case manager.ResultUnprocessed:
 		log.V(2).Info("request was unprocessed")
 		c.recorder.Event(cr, corev1.EventTypeNormal, "Unprocessed", "Request is not applicable for any policy so ignoring")
 
-		return ctrl.Result{}, nil, nil
+		return ctrl.Result{}, nil, nil, nil, nil
 
 	default:
 		log.Error(errors.New(response.Message), "manager responded with an unknown result", "result", response.Result)
 		c.recorder.Event(cr, corev1.EventTypeWarning, "UnknownResponse", "Policy returned an unknown result. This is a bug. Please check the approver-policy logs and file an issue")
 
 		// We can do nothing but keep retrying the review here.
-		return ctrl.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: time.Second * 5}, nil, nil, nil, nil
 
 	}
 }
 
+// observeDecisions counts the decision of each of the given policies, labelled
+// with the custom metric labels from the policy's annotations, and records the
+// fields of the policies exercised by the request.
+func (c *certificaterequests) observeDecisions(ctx context.Context, cr *cmapi.CertificateRequest, decision string, policies []manager.PolicyRevision) {
+	if c.decisions == nil && c.fieldUsage == nil {
+		return
+	}
+
+	var current []*policyapi.CertificateRequestPolicy
+	for _, revision := range policies {
+		var policy policyapi.CertificateRequestPolicy
+		if err := c.lister.Get(ctx, client.ObjectKey{Name: revision.Name}, &policy); err != nil {
+			// The decision is still counted, without the custom labels.
+			c.log.Error(err, "failed to get policy to label decision metrics", "policy", revision.Name)
+		} else if policy.Generation == revision.Generation {
+			// Field usage is tracked per generation, so is only recorded if
+			// the reviewed generation is still current.
+			current = append(current, &policy)
+		}
+		c.decisions.Observe(cr.Namespace, revision.Name, decision, policy.Annotations)
+	}
+	c.fieldUsage.Observe(cr, current...)
+}
+
 // Update the status with the provided condition details & return
 // the added condition.
 // This function is copied from https://github.com/cert-manager/issuer-lib/blob/main/conditions/certificaterequest.go
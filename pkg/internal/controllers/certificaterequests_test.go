@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"testing"
 	"time"
@@ -25,19 +26,28 @@ import (
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2/ktesting"
 	fakeclock "k8s.io/utils/clock/testing"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	fakeapprover "github.com/cert-manager/approver-policy/pkg/approver/fake"
 	"github.com/cert-manager/approver-policy/pkg/approver/manager"
 	fakemanager "github.com/cert-manager/approver-policy/pkg/approver/manager/fake"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 func Test_certificaterequests_Reconcile(t *testing.T) {
@@ -46,6 +56,15 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 		requestGeneration int64 = 2
 	)
 
+	csr, _, err := gen.CSR(x509.ECDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noReview := fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+		return manager.ReviewResponse{}, errors.New("unexpected review")
+	})
+
 	var (
 		fixedTime                 = time.Date(2021, 01, 01, 01, 0, 0, 0, time.UTC)
 		fixedmetatime             = &metav1.Time{Time: fixedTime}
@@ -67,17 +86,26 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 				cr.ResourceVersion = "999"
 			},
 			gen.SetCertificateRequestNamespace(gen.DefaultTestNamespace),
+			gen.SetCertificateRequestCSR(csr),
 		)
 	)
 
 	tests := map[string]struct {
 		existingObjects []runtime.Object
 		manager         manager.Interface
+		denials         *denialTracker
+
+		invalidRequestAction InvalidRequestAction
 
 		expResult      ctrl.Result
 		expError       bool
 		expStatusPatch *cmapi.CertificateRequestStatus
+		expAnnotations map[string]string
 		expEvent       string
+
+		// expWarningsEvent is the event expected after expEvent, raised for
+		// requests approved with warnings.
+		expWarningsEvent string
 	}{
 		"if request doesn't exist, no nothing": {
 			existingObjects: nil,
@@ -94,6 +122,33 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 			expStatusPatch: nil,
 			expEvent:       "",
 		},
+		"if request has no CSR, deny it without review": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest, gen.SetCertificateRequestCSR(nil))},
+			manager:         noReview,
+			expResult:       ctrl.Result{},
+			expError:        false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionDenied,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            "Request is structurally invalid (EmptyRequest): request does not contain a certificate request",
+					},
+				},
+			},
+			expEvent: "Warning InvalidRequest Request is structurally invalid (EmptyRequest): request does not contain a certificate request",
+		},
+		"if request has invalid PEM and invalid requests are ignored, do nothing without review": {
+			existingObjects:      []runtime.Object{gen.CertificateRequestFrom(baseRequest, gen.SetCertificateRequestCSR([]byte("not a certificate request")))},
+			manager:              noReview,
+			invalidRequestAction: InvalidRequestActionIgnore,
+			expResult:            ctrl.Result{},
+			expError:             false,
+			expStatusPatch:       nil,
+			expEvent:             "Warning InvalidRequest Request is structurally invalid (InvalidPEM): error decoding certificate request PEM block",
+		},
 		"if manager review returns an error, fire event and return an error": {
 			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
 			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
@@ -154,6 +209,35 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 			},
 			expEvent: "Warning Denied denied due to some violation",
 		},
+		"if requester has repeatedly been denied, fire event and delay review": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest, gen.SetCertificateRequestUsername("noisy"))},
+			denials: func() *denialTracker {
+				denials := newDenialTracker(fixedclock, DenialBackoffOptions{Threshold: 2, BaseDelay: time.Second * 10, MaxDelay: time.Minute})
+				denials.Denied(gen.DefaultTestNamespace, "noisy")
+				denials.Denied(gen.DefaultTestNamespace, "noisy")
+				return denials
+			}(),
+			expResult:      ctrl.Result{RequeueAfter: time.Second * 10},
+			expError:       false,
+			expStatusPatch: nil,
+			expEvent:       "Warning DenialBackoff Review delayed by 10s as requester has repeatedly had requests denied",
+		},
+		"if requester has repeatedly been denied in another namespace, should not delay review": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest, gen.SetCertificateRequestUsername("noisy"))},
+			denials: func() *denialTracker {
+				denials := newDenialTracker(fixedclock, DenialBackoffOptions{Threshold: 2, BaseDelay: time.Second * 10, MaxDelay: time.Minute})
+				denials.Denied("other", "noisy")
+				denials.Denied("other", "noisy")
+				return denials
+			}(),
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{Result: manager.ResultUnprocessed}, nil
+			}),
+			expResult:      ctrl.Result{},
+			expError:       false,
+			expStatusPatch: nil,
+			expEvent:       "Normal Unprocessed Request is not applicable for any policy so ignoring",
+		},
 		"if manager review returns true, fire event and update request with approved": {
 			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
 			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
@@ -174,6 +258,166 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 			},
 			expEvent: "Normal Approved policy is happy :)",
 		},
+		"if manager review returns approved by a policy, annotate request with the policy revision": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{
+					Result:        manager.ResultApproved,
+					Message:       `Approved by CertificateRequestPolicy: "test-policy"`,
+					ApprovedBy:    &manager.PolicyRevision{Name: "test-policy", Generation: 7},
+					ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy", Generation: 7}},
+				}, nil
+			}),
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionApproved,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            `Approved by CertificateRequestPolicy: "test-policy"`,
+					},
+				},
+			},
+			expAnnotations: map[string]string{
+				"policy.cert-manager.io/approved-by-policy":            "test-policy",
+				"policy.cert-manager.io/approved-by-policy-generation": "7",
+				"policy.cert-manager.io/approved-by-policies":          `[{"name":"test-policy","generation":7}]`,
+			},
+			expEvent: `Normal Approved Approved by CertificateRequestPolicy: "test-policy"`,
+		},
+		"if manager review returns approved by a break glass policy, fire a warning event": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{
+					Result:     manager.ResultApproved,
+					Message:    `Approved by break glass CertificateRequestPolicy: "test-policy" for incident "INC-123"`,
+					ApprovedBy: &manager.PolicyRevision{Name: "test-policy", Generation: 1},
+					BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: *fixedmetatime, IncidentRef: "INC-123"},
+				}, nil
+			}),
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionApproved,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            `Approved by break glass CertificateRequestPolicy: "test-policy" for incident "INC-123"`,
+					},
+				},
+			},
+			expAnnotations: map[string]string{
+				"policy.cert-manager.io/approved-by-policy":            "test-policy",
+				"policy.cert-manager.io/approved-by-policy-generation": "1",
+			},
+			expEvent: `Warning BreakGlassApproved Approved by break glass CertificateRequestPolicy: "test-policy" for incident "INC-123"`,
+		},
+		"if manager review returns approved under an exemption, fire a warning event and record the override chain": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{
+					Result:        manager.ResultApproved,
+					Message:       `Approved by CertificateRequestPolicy: "test-policy" under CertificateRequestPolicyExemption: "test-exemption" for reason "CHG-42"`,
+					ApprovedBy:    &manager.PolicyRevision{Name: "test-policy", Generation: 2},
+					ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy", Generation: 2}},
+					Override: &manager.OverrideChain{
+						DeniedBy:   []manager.PolicyRevision{{Name: "other-policy", Generation: 1}},
+						Exemption:  "test-exemption",
+						Reason:     "CHG-42",
+						ApprovedBy: manager.PolicyRevision{Name: "test-policy", Generation: 2},
+					},
+				}, nil
+			}),
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionApproved,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            `Approved by CertificateRequestPolicy: "test-policy" under CertificateRequestPolicyExemption: "test-exemption" for reason "CHG-42"`,
+					},
+				},
+			},
+			expAnnotations: map[string]string{
+				"policy.cert-manager.io/approved-by-policy":            "test-policy",
+				"policy.cert-manager.io/approved-by-policy-generation": "2",
+				"policy.cert-manager.io/approved-by-policies":          `[{"name":"test-policy","generation":2}]`,
+				"policy.cert-manager.io/override-chain":                `{"deniedBy":[{"name":"other-policy","generation":1}],"exemption":"test-exemption","reason":"CHG-42","approvedBy":{"name":"test-policy","generation":2}}`,
+			},
+			expEvent: `Warning ApprovedUnderExemption Approved by CertificateRequestPolicy: "test-policy" under CertificateRequestPolicyExemption: "test-exemption" for reason "CHG-42"`,
+		},
+		"if manager review returns approved with only an override chain, record the override chain": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{
+					Result:  manager.ResultApproved,
+					Message: "Approved under CertificateRequestPolicyExemption",
+					Override: &manager.OverrideChain{
+						Exemption:  "test-exemption",
+						Reason:     "CHG-42",
+						ApprovedBy: manager.PolicyRevision{Name: "test-policy", Generation: 2},
+					},
+				}, nil
+			}),
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionApproved,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            "Approved under CertificateRequestPolicyExemption",
+					},
+				},
+			},
+			expAnnotations: map[string]string{
+				"policy.cert-manager.io/override-chain": `{"exemption":"test-exemption","reason":"CHG-42","approvedBy":{"name":"test-policy","generation":2}}`,
+			},
+			expEvent: `Warning ApprovedUnderExemption Approved under CertificateRequestPolicyExemption`,
+		},
+		"if manager review returns approved with warnings, fire a warning event and record the warnings": {
+			existingObjects: []runtime.Object{gen.CertificateRequestFrom(baseRequest)},
+			manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+				return manager.ReviewResponse{
+					Result:        manager.ResultApproved,
+					Message:       `Approved by CertificateRequestPolicy: "test-policy"`,
+					ApprovedBy:    &manager.PolicyRevision{Name: "test-policy", Generation: 1},
+					ApprovedByAll: []manager.PolicyRevision{{Name: "test-policy", Generation: 1}},
+					Warnings:      []string{"test-policy: deprecated key size", "test-policy: duration close to maximum"},
+				}, nil
+			}),
+			expResult: ctrl.Result{},
+			expError:  false,
+			expStatusPatch: &cmapi.CertificateRequestStatus{
+				Conditions: []cmapi.CertificateRequestCondition{
+					{
+						Type:               cmapi.CertificateRequestConditionApproved,
+						Status:             cmmeta.ConditionTrue,
+						LastTransitionTime: fixedmetatime,
+						Reason:             "policy.cert-manager.io",
+						Message:            `Approved by CertificateRequestPolicy: "test-policy"`,
+					},
+				},
+			},
+			expAnnotations: map[string]string{
+				"policy.cert-manager.io/approved-by-policy":            "test-policy",
+				"policy.cert-manager.io/approved-by-policy-generation": "1",
+				"policy.cert-manager.io/approved-by-policies":          `[{"name":"test-policy","generation":1}]`,
+				"policy.cert-manager.io/warnings":                      `["test-policy: deprecated key size","test-policy: duration close to maximum"]`,
+			},
+			expEvent:         `Normal Approved Approved by CertificateRequestPolicy: "test-policy"`,
+			expWarningsEvent: `Warning ApprovedWithWarnings Request approved with warnings: test-policy: deprecated key size; test-policy: duration close to maximum`,
+		},
 	}
 
 	for name, test := range tests {
@@ -183,18 +427,21 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 				WithRuntimeObjects(test.existingObjects...).
 				Build()
 
-			fakerecorder := record.NewFakeRecorder(1)
+			fakerecorder := record.NewFakeRecorder(2)
 
 			c := &certificaterequests{
 				client:   fakeclient,
 				lister:   fakeclient,
 				recorder: fakerecorder,
 				manager:  test.manager,
-				log:      ktesting.NewLogger(t, ktesting.DefaultConfig),
-				clock:    fixedclock,
+				denials:  test.denials,
+
+				invalidRequestAction: test.invalidRequestAction,
+				log:                  ktesting.NewLogger(t, ktesting.DefaultConfig),
+				clock:                fixedclock,
 			}
 
-			resp, statusPatch, err := c.reconcileStatusPatch(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: gen.DefaultTestNamespace, Name: requestName}})
+			resp, statusPatch, annotations, _, err := c.reconcileStatusPatch(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: gen.DefaultTestNamespace, Name: requestName}})
 			if (err != nil) != test.expError {
 				t.Errorf("unexpected error, exp=%t got=%v", test.expError, err)
 			}
@@ -212,9 +459,263 @@ func Test_certificaterequests_Reconcile(t *testing.T) {
 				t.Errorf("unexpected event, exp=%q got=%q", test.expEvent, event)
 			}
 
+			var warningsEvent string
+			select {
+			case warningsEvent = <-fakerecorder.Events:
+			default:
+			}
+			if warningsEvent != test.expWarningsEvent {
+				t.Errorf("unexpected warnings event, exp=%q got=%q", test.expWarningsEvent, warningsEvent)
+			}
+
 			if !apiequality.Semantic.DeepEqual(statusPatch, test.expStatusPatch) {
 				t.Errorf("unexpected Reconcile response, exp=%v got=%v", test.expStatusPatch, statusPatch)
 			}
+
+			if !apiequality.Semantic.DeepEqual(annotations, test.expAnnotations) {
+				t.Errorf("unexpected annotations, exp=%v got=%v", test.expAnnotations, annotations)
+			}
 		})
 	}
 }
+
+func Test_certificaterequests_postProcess(t *testing.T) {
+	cr := gen.CertificateRequest("test-request", gen.SetCertificateRequestNamespace(gen.DefaultTestNamespace))
+	decision := &policyapi.CertificateRequestDecision{
+		Spec: policyapi.CertificateRequestDecisionSpec{Verdict: policyapi.CertificateRequestDecisionApproved},
+	}
+
+	var processed []string
+	postProcessor := func(name string, err error) *fakeapprover.FakePostProcessor {
+		return fakeapprover.NewFakePostProcessor().WithName(name).WithPostProcess(func(_ context.Context, gotCR *cmapi.CertificateRequest, gotRecord *policyapi.CertificateRequestDecision) error {
+			if gotCR != cr || gotRecord != decision {
+				t.Errorf("unexpected verdict passed to post-processor %q", name)
+			}
+			processed = append(processed, name)
+			return err
+		})
+	}
+	hanging := fakeapprover.NewFakePostProcessor().WithName("hanging").WithPostProcess(func(ctx context.Context, _ *cmapi.CertificateRequest, _ *policyapi.CertificateRequestDecision) error {
+		<-ctx.Done()
+		processed = append(processed, "hanging")
+		return ctx.Err()
+	})
+
+	fakerecorder := record.NewFakeRecorder(3)
+	c := &certificaterequests{
+		log:      ktesting.NewLogger(t, ktesting.DefaultConfig),
+		recorder: fakerecorder,
+		postProcessors: []approver.PostProcessor{
+			postProcessor("failing", errors.New("cmdb unavailable")),
+			hanging,
+			postProcessor("labeller", nil),
+		},
+		postProcessTimeout: time.Millisecond * 10,
+	}
+
+	c.postProcess(context.TODO(), &verdict{request: cr, record: decision})
+
+	// A failing or timed out post-processor should not prevent later
+	// post-processors from running.
+	if expProcessed := []string{"failing", "hanging", "labeller"}; !apiequality.Semantic.DeepEqual(processed, expProcessed) {
+		t.Errorf("unexpected post-processors run, exp=%v got=%v", expProcessed, processed)
+	}
+
+	for _, expEvent := range []string{
+		`Warning PostProcessError Post-processor "failing" failed to process the Approved verdict`,
+		`Warning PostProcessError Post-processor "hanging" failed to process the Approved verdict`,
+	} {
+		var event string
+		select {
+		case event = <-fakerecorder.Events:
+		default:
+		}
+		if event != expEvent {
+			t.Errorf("unexpected event, exp=%q got=%q", expEvent, event)
+		}
+	}
+	if len(fakerecorder.Events) > 0 {
+		t.Errorf("unexpected events: %d", len(fakerecorder.Events))
+	}
+}
+
+func Test_certificaterequests_observeDecisions(t *testing.T) {
+	csr, _, err := gen.CSR(x509.ECDSA, gen.SetCSRDNSNames("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Generation: 1},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Allowed: &policyapi.CertificateRequestPolicyAllowed{
+				DNSNames: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				URIs:     &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+			},
+		},
+	}
+	fieldUsage, err := metrics.NewFieldUsage(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &certificaterequests{
+		log:        ktesting.NewLogger(t, ktesting.DefaultConfig),
+		lister:     fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(policy).Build(),
+		fieldUsage: fieldUsage,
+	}
+
+	// The request of an issuer group with a RequestDecoder is not PEM, so its
+	// fields are only exercised by the normalized request which was reviewed.
+	cr := gen.CertificateRequest("test-request",
+		gen.SetCertificateRequestNamespace(gen.DefaultTestNamespace),
+		gen.SetCertificateRequestIssuer(cmmeta.ObjectReference{Name: "jose", Group: "jose.example.com"}),
+		gen.SetCertificateRequestCSR([]byte("eyJhbGciOiJFUzI1NiJ9.e30.c2lnbmF0dXJl")),
+	)
+	v := &verdict{request: cr, response: manager.ReviewResponse{
+		Result:            manager.ResultApproved,
+		ApprovedByAll:     []manager.PolicyRevision{{Name: "test-policy", Generation: 1}},
+		NormalizedRequest: csr,
+	}}
+
+	c.observeDecisions(context.TODO(), v.reviewed(), metrics.DecisionApproved, v.response.ApprovedByAll)
+
+	if expUnexercised, got := []string{"spec.allowed.uris"}, fieldUsage.Unexercised(policy); !apiequality.Semantic.DeepEqual(got, expUnexercised) {
+		t.Errorf("unexpected unexercised fields, exp=%v got=%v", expUnexercised, got)
+	}
+	if string(cr.Spec.Request) != "eyJhbGciOiJFUzI1NiJ9.e30.c2lnbmF0dXJl" {
+		t.Errorf("the original request should not be modified, got %q", cr.Spec.Request)
+	}
+}
+
+func Test_certificaterequests_Reconcile_retriedPatch(t *testing.T) {
+	csr, _, err := gen.CSR(x509.ECDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		csr                []byte
+		expPolicyDecisions float64
+	}{
+		"a denied request should only be reported once the denial is written": {
+			csr:                csr,
+			expPolicyDecisions: 1,
+		},
+		"a structurally invalid request should only be reported once the denial is written": {
+			csr:                nil,
+			expPolicyDecisions: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := gen.CertificateRequest("test-request",
+				gen.SetCertificateRequestNamespace(gen.DefaultTestNamespace),
+				gen.SetCertificateRequestCSR(test.csr),
+			)
+
+			// The first status patch fails, and the retry succeeds.
+			var patches, recordWrites, postProcessed int
+			fakeclient := fakeclient.NewClientBuilder().
+				WithScheme(policyapi.GlobalScheme).
+				WithRuntimeObjects(cr).
+				WithInterceptorFuncs(interceptor.Funcs{
+					SubResourcePatch: func(context.Context, client.Client, string, client.Object, client.Patch, ...client.SubResourcePatchOption) error {
+						patches++
+						if patches == 1 {
+							return errors.New("conflict")
+						}
+						return nil
+					},
+					Create: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+						if _, ok := obj.(*policyapi.CertificateRequestDecision); ok {
+							recordWrites++
+						}
+						return client.Create(ctx, obj, opts...)
+					},
+					Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+						if _, ok := obj.(*policyapi.CertificateRequestDecision); ok {
+							recordWrites++
+						}
+						return client.Update(ctx, obj, opts...)
+					},
+				}).
+				Build()
+
+			decisions, err := metrics.NewPolicyDecisions(nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sink := make(fakeAuditSink, 1)
+			clock := fakeclock.NewFakeClock(time.Date(2021, 01, 01, 01, 0, 0, 0, time.UTC))
+			c := &certificaterequests{
+				client:   fakeclient,
+				lister:   fakeclient,
+				recorder: record.NewFakeRecorder(10),
+				auditor:  audit.NewExporter(logr.Discard(), audit.NewEncoder(), sink),
+				manager: fakemanager.NewFakeManager().WithReview(func(context.Context, *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+					return manager.ReviewResponse{Result: manager.ResultDenied, Message: "denied", DeniedBy: []manager.PolicyRevision{{Name: "test-policy", Generation: 1}}}, nil
+				}),
+				decisions:       decisions,
+				decisionRecords: DecisionRecordOptions{Enabled: true},
+				postProcessors: []approver.PostProcessor{fakeapprover.NewFakePostProcessor().WithName("counter").WithPostProcess(
+					func(context.Context, *cmapi.CertificateRequest, *policyapi.CertificateRequestDecision) error {
+						postProcessed++
+						return nil
+					})},
+				denials: newDenialTracker(clock, DenialBackoffOptions{Threshold: 2, BaseDelay: time.Second * 10, MaxDelay: time.Minute}),
+				log:     ktesting.NewLogger(t, ktesting.DefaultConfig),
+				clock:   clock,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: gen.DefaultTestNamespace, Name: "test-request"}}
+			if _, err := c.Reconcile(context.TODO(), req); err == nil {
+				t.Fatal("expected error from failed status patch")
+			}
+			if _, err := c.Reconcile(context.TODO(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if delay := c.denials.Delay(gen.DefaultTestNamespace, cr.Spec.Username); delay != 0 {
+				t.Errorf("expected the denial to be counted once, got delay %s", delay)
+			}
+			var policyDecisions float64
+			if testutil.CollectAndCount(decisions.Collector()) > 0 {
+				policyDecisions = testutil.ToFloat64(decisions.Collector())
+			}
+			if policyDecisions != test.expPolicyDecisions {
+				t.Errorf("expected %v policy decisions to be counted, got %v", test.expPolicyDecisions, policyDecisions)
+			}
+			if recordWrites != 1 {
+				t.Errorf("expected the decision to be recorded once, got %d writes", recordWrites)
+			}
+			if postProcessed != 1 {
+				t.Errorf("expected the decision to be post-processed once, got %d", postProcessed)
+			}
+
+			// Events queued by both reconciles are written in a single batch
+			// once the exporter is started.
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go func() { _ = c.auditor.Start(ctx) }()
+
+			select {
+			case events := <-sink:
+				if len(events) != 1 {
+					t.Errorf("expected only the written denial to be audited, got %d events", len(events))
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out waiting for audit events")
+			}
+		})
+	}
+}
+
+type fakeAuditSink chan []auditv1.Event
+
+func (f fakeAuditSink) Write(_ context.Context, events []auditv1.Event) error {
+	f <- events
+	return nil
+}
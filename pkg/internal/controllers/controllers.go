@@ -24,6 +24,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 // Options hold options for the internal approver-policy controllers.
@@ -42,6 +45,59 @@ type Options struct {
 	// Reconcilers is the list of registered Approver Reconcilers that  will be
 	// used to manager CertificateRequestPolicy Ready conditions.
 	Reconcilers []approver.Reconciler
+
+	// Auditor optionally exports approval decisions as Kubernetes audit
+	// events. Nil disables audit export.
+	Auditor *audit.Exporter
+
+	// Bootstrap configures approving cert-manager's bootstrap
+	// CertificateRequests without reviewing them against policies.
+	Bootstrap internalmanager.BootstrapOptions
+
+	// DenialBackoff configures delaying the review of requests from
+	// requesters which have repeatedly had requests denied.
+	DenialBackoff DenialBackoffOptions
+
+	// Predicates configures the predicates that filter the
+	// CertificateRequestPolicies evaluated for a request.
+	Predicates internalmanager.PredicateOptions
+
+	// InvalidRequestAction is the action taken on structurally invalid
+	// CertificateRequests.
+	InvalidRequestAction InvalidRequestAction
+
+	// VerifyIssuers sets the informational IssuersFound condition on
+	// CertificateRequestPolicies, reporting whether the issuers named by their
+	// selector exist.
+	VerifyIssuers bool
+
+	// AdvisorySampling configures the evaluation of advisory evaluators.
+	AdvisorySampling internalmanager.AdvisorySampling
+
+	// PolicyDecisions optionally counts the decisions of each policy. Nil
+	// disables policy decision metrics.
+	PolicyDecisions *metrics.PolicyDecisions
+
+	// FieldUsage optionally tracks the fields of each policy exercised by
+	// reviewed requests, reporting those never exercised in the policy
+	// status. Nil disables field usage analysis.
+	FieldUsage *metrics.FieldUsage
+
+	// DecisionRecords configures mirroring decisions into
+	// CertificateRequestDecisions.
+	DecisionRecords DecisionRecordOptions
+
+	// PostProcessors is the list of registered PostProcessors that will be
+	// invoked with each verdict once written to the CertificateRequest.
+	PostProcessors []approver.PostProcessor
+
+	// RequestDecoders is the list of registered RequestDecoders that will
+	// normalize the requests of their issuer groups before review.
+	RequestDecoders []approver.RequestDecoder
+
+	// Handoff configures handing off the requests in flight to the next
+	// leader.
+	Handoff HandoffOptions
 }
 
 // AddControllers adds all internal controllers.
@@ -54,5 +110,13 @@ func AddControllers(ctx context.Context, opts Options) error {
 		return fmt.Errorf("failed to add certificaterequestpolicy controller: %w", err)
 	}
 
+	if err := addDecisionRecordCollector(opts); err != nil {
+		return fmt.Errorf("failed to add certificaterequestdecision collector: %w", err)
+	}
+
+	if err := addDefaultApproverCheck(opts); err != nil {
+		return fmt.Errorf("failed to add default approver check: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	approvermanager "github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+)
+
+// DecisionRecordOptions configures mirroring the decisions made on
+// CertificateRequests into CertificateRequestDecisions.
+type DecisionRecordOptions struct {
+	// Enabled records a CertificateRequestDecision for every request which is
+	// approved or denied.
+	Enabled bool
+
+	// Retention is how long CertificateRequestDecisions are kept after the
+	// decision was made. A value of 0 keeps them until they are deleted by
+	// another means.
+	Retention time.Duration
+
+	// Encoder redacts the requester of recorded decisions with the same rules
+	// as audit events. If nil, the requester is recorded as is.
+	Encoder *audit.Encoder
+}
+
+// decisionCollectionInterval is the interval at which expired
+// CertificateRequestDecisions are deleted.
+const decisionCollectionInterval = time.Hour
+
+// decisionRecord returns the decision record of the request, with the
+// requester redacted by the configured encoder.
+func (c *certificaterequests) decisionRecord(cr *cmapi.CertificateRequest, response approvermanager.ReviewResponse) *policyapi.CertificateRequestDecision {
+	decision := newDecisionRecord(cr, response, c.clock.Now())
+	if c.decisionRecords.Encoder != nil {
+		decision.Spec.CertificateRequest.Username = c.decisionRecords.Encoder.Username(decision.Spec.CertificateRequest.Username)
+	}
+	return decision
+}
+
+// recordDecision creates or updates the decision record as a
+// CertificateRequestDecision if decision records are enabled. It is only
+// called once the decision has been written to the request.
+// Decisions are named after their request, so the decision of a request which
+// is recreated with the same name replaces the previous one.
+// Failing to record a decision does not fail the review, since the decision
+// is still recorded on the request itself.
+func (c *certificaterequests) recordDecision(ctx context.Context, log logr.Logger, decision *policyapi.CertificateRequestDecision) {
+	if !c.decisionRecords.Enabled || decision == nil {
+		return
+	}
+
+	err := c.client.Create(ctx, decision.DeepCopy())
+	if apierrors.IsAlreadyExists(err) {
+		var existing policyapi.CertificateRequestDecision
+		if err = c.client.Get(ctx, client.ObjectKeyFromObject(decision), &existing); err == nil {
+			existing.Spec = decision.Spec
+			err = c.client.Update(ctx, &existing)
+		}
+	}
+	if err != nil {
+		log.Error(err, "failed to record CertificateRequestDecision")
+	}
+}
+
+// newDecisionRecord returns the CertificateRequestDecision recording the
+// response of the review of the request.
+func newDecisionRecord(cr *cmapi.CertificateRequest, response approvermanager.ReviewResponse, now time.Time) *policyapi.CertificateRequestDecision {
+	verdict, revisions := policyapi.CertificateRequestDecisionApproved, response.ApprovedByAll
+	if response.Result == approvermanager.ResultDenied {
+		verdict, revisions = policyapi.CertificateRequestDecisionDenied, response.DeniedBy
+	}
+
+	var policies []policyapi.CertificateRequestDecisionPolicy
+	for _, revision := range revisions {
+		policies = append(policies, policyapi.CertificateRequestDecisionPolicy{Name: revision.Name, Generation: revision.Generation})
+	}
+
+	return &policyapi.CertificateRequestDecision{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cr.Namespace, Name: cr.Name},
+		Spec: policyapi.CertificateRequestDecisionSpec{
+			CertificateRequest: policyapi.CertificateRequestDecisionRequest{
+				Name:      cr.Name,
+				UID:       cr.UID,
+				Username:  cr.Spec.Username,
+				CreatedAt: cr.CreationTimestamp,
+			},
+			Verdict:   verdict,
+			Policies:  policies,
+			Message:   response.Message,
+			DecidedAt: metav1.NewTime(now),
+		},
+	}
+}
+
+// addDecisionRecordCollector registers a Runnable with the manager which
+// periodically deletes CertificateRequestDecisions older than the retention.
+// Decisions are listed directly from the API server, rather than cached, as
+// there may be many more decisions than requests.
+func addDecisionRecordCollector(opts Options) error {
+	if !opts.DecisionRecords.Enabled || opts.DecisionRecords.Retention == 0 {
+		return nil
+	}
+
+	log := opts.Log.WithName("decisionrecords")
+	reader, writer := opts.Manager.GetAPIReader(), opts.Manager.GetClient()
+	return opts.Manager.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		ticker := time.NewTicker(decisionCollectionInterval)
+		defer ticker.Stop()
+
+		for {
+			deleted, err := collectDecisionRecords(ctx, reader, writer, clock.RealClock{}, opts.DecisionRecords.Retention)
+			if err != nil {
+				log.Error(err, "failed to delete expired CertificateRequestDecisions")
+			} else if deleted > 0 {
+				log.V(2).Info("deleted expired CertificateRequestDecisions", "count", deleted)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}))
+}
+
+// collectDecisionRecords deletes the CertificateRequestDecisions which were
+// decided longer than the retention ago, returning the number deleted.
+func collectDecisionRecords(ctx context.Context, reader client.Reader, writer client.Writer, clock clock.PassiveClock, retention time.Duration) (int, error) {
+	cutoff := clock.Now().Add(-retention)
+
+	var deleted int
+	var list policyapi.CertificateRequestDecisionList
+	for {
+		if err := reader.List(ctx, &list, client.Limit(500), client.Continue(list.Continue)); err != nil {
+			return deleted, fmt.Errorf("failed to list CertificateRequestDecisions: %w", err)
+		}
+
+		for i := range list.Items {
+			decision := &list.Items[i]
+			if !decision.Spec.DecidedAt.Time.Before(cutoff) {
+				continue
+			}
+			if err := writer.Delete(ctx, decision, client.Preconditions{UID: &decision.UID}); client.IgnoreNotFound(err) != nil {
+				return deleted, fmt.Errorf("failed to delete CertificateRequestDecision %s/%s: %w", decision.Namespace, decision.Name, err)
+			}
+			deleted++
+		}
+
+		if len(list.Continue) == 0 {
+			return deleted, nil
+		}
+	}
+}
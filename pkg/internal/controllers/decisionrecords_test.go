@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/ktesting"
+	fakeclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	approvermanager "github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+)
+
+func Test_recordDecision(t *testing.T) {
+	fixedTime := time.Date(2021, 01, 01, 01, 0, 0, 0, time.UTC)
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "my-cert-1", UID: "uid-1", CreationTimestamp: metav1.NewTime(fixedTime.Add(-time.Minute))},
+		Spec:       cmapi.CertificateRequestSpec{Username: "alice"},
+	}
+
+	fakeclient := fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).Build()
+	c := &certificaterequests{
+		log:             ktesting.NewLogger(t, ktesting.DefaultConfig),
+		clock:           fakeclock.NewFakeClock(fixedTime),
+		client:          fakeclient,
+		decisionRecords: DecisionRecordOptions{Enabled: true},
+	}
+
+	c.recordDecision(context.TODO(), c.log, c.decisionRecord(cr, approvermanager.ReviewResponse{
+		Result:  approvermanager.ResultDenied,
+		Message: "No policy approved this request: [policy-a: spec.allowed.dnsNames.values: Invalid value]",
+		DeniedBy: []approvermanager.PolicyRevision{
+			{Name: "policy-a", Generation: 2},
+		},
+	}))
+
+	var decision policyapi.CertificateRequestDecision
+	require.NoError(t, fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "team-a", Name: "my-cert-1"}, &decision))
+	expSpec := policyapi.CertificateRequestDecisionSpec{
+		CertificateRequest: policyapi.CertificateRequestDecisionRequest{
+			Name:      "my-cert-1",
+			UID:       "uid-1",
+			Username:  "alice",
+			CreatedAt: metav1.NewTime(fixedTime.Add(-time.Minute)),
+		},
+		Verdict:   policyapi.CertificateRequestDecisionDenied,
+		Policies:  []policyapi.CertificateRequestDecisionPolicy{{Name: "policy-a", Generation: 2}},
+		Message:   "No policy approved this request: [policy-a: spec.allowed.dnsNames.values: Invalid value]",
+		DecidedAt: metav1.NewTime(fixedTime),
+	}
+	if !apiequality.Semantic.DeepEqual(expSpec, decision.Spec) {
+		t.Errorf("unexpected decision, exp=%v got=%v", expSpec, decision.Spec)
+	}
+
+	// A request recreated with the same name should replace the decision.
+	cr.UID = "uid-2"
+	c.recordDecision(context.TODO(), c.log, c.decisionRecord(cr, approvermanager.ReviewResponse{
+		Result:        approvermanager.ResultApproved,
+		Message:       `Approved by CertificateRequestPolicy: "policy-b"`,
+		ApprovedByAll: []approvermanager.PolicyRevision{{Name: "policy-b", Generation: 1}},
+	}))
+
+	require.NoError(t, fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "team-a", Name: "my-cert-1"}, &decision))
+	assert.Equal(t, policyapi.CertificateRequestDecisionApproved, decision.Spec.Verdict)
+	assert.Equal(t, "uid-2", string(decision.Spec.CertificateRequest.UID))
+	assert.Equal(t, []policyapi.CertificateRequestDecisionPolicy{{Name: "policy-b", Generation: 1}}, decision.Spec.Policies)
+}
+
+func Test_decisionRecord_redacted(t *testing.T) {
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "my-cert-1"},
+		Spec:       cmapi.CertificateRequestSpec{Username: "system:serviceaccount:team-a:deployer"},
+	}
+
+	c := &certificaterequests{
+		clock:           fakeclock.NewFakeClock(time.Now()),
+		decisionRecords: DecisionRecordOptions{Enabled: true, Encoder: audit.NewEncoder(audit.RedactionRule{Action: audit.RedactionActionDrop, Pattern: regexp.MustCompile("^system:serviceaccount:")})},
+	}
+
+	decision := c.decisionRecord(cr, approvermanager.ReviewResponse{Result: approvermanager.ResultDenied})
+	assert.Empty(t, decision.Spec.CertificateRequest.Username)
+	assert.Equal(t, "system:serviceaccount:team-a:deployer", cr.Spec.Username)
+}
+
+func Test_collectDecisionRecords(t *testing.T) {
+	fixedTime := time.Date(2021, 01, 01, 01, 0, 0, 0, time.UTC)
+	decision := func(name string, age time.Duration) client.Object {
+		return &policyapi.CertificateRequestDecision{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name},
+			Spec:       policyapi.CertificateRequestDecisionSpec{DecidedAt: metav1.NewTime(fixedTime.Add(-age))},
+		}
+	}
+
+	fakeclient := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithObjects(decision("new", time.Hour), decision("old", 48*time.Hour), decision("older", 72*time.Hour)).
+		Build()
+
+	deleted, err := collectDecisionRecords(context.TODO(), fakeclient, fakeclient, fakeclock.NewFakeClock(fixedTime), 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	var list policyapi.CertificateRequestDecisionList
+	require.NoError(t, fakeclient.List(context.TODO(), &list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "new", list.Items[0].Name)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// maxDefaultApproverExamples is the maximum number of requests approved by
+// cert-manager's built-in approver which are named in the startup warning.
+const maxDefaultApproverExamples = 5
+
+// errDefaultApproverEnabled is logged when cert-manager's built-in approver is
+// found to have approved requests.
+var errDefaultApproverEnabled = errors.New("cert-manager's built-in approver is enabled")
+
+// defaultApproverCheck checks, once approver-policy becomes leader, whether
+// cert-manager's built-in approver has approved any existing
+// CertificateRequest. The built-in approver approves every request, so
+// silently bypasses all CertificateRequestPolicies unless it is disabled.
+// Requests it approves later are reported by the
+// approverpolicy_certificaterequest_default_approver_approved_count metric.
+type defaultApproverCheck struct {
+	log    logr.Logger
+	lister client.Reader
+}
+
+// addDefaultApproverCheck registers the default approver check with the
+// manager.
+func addDefaultApproverCheck(opts Options) error {
+	return opts.Manager.Add(&defaultApproverCheck{
+		log:    opts.Log.WithName("default-approver-check"),
+		lister: opts.Manager.GetCache(),
+	})
+}
+
+// Start checks all existing requests. It does not implement
+// LeaderElectionRunnable, so is only started on the leader, once the caches
+// have synced.
+func (d *defaultApproverCheck) Start(ctx context.Context) error {
+	var crList cmapi.CertificateRequestList
+	if err := d.lister.List(ctx, &crList); err != nil {
+		return fmt.Errorf("failed to list CertificateRequests to check for cert-manager's built-in approver: %w", err)
+	}
+
+	var approved []string
+	for i := range crList.Items {
+		if metrics.ApprovedByDefaultApprover(&crList.Items[i]) {
+			approved = append(approved, client.ObjectKeyFromObject(&crList.Items[i]).String())
+		}
+	}
+
+	if len(approved) == 0 {
+		d.log.V(2).Info("no CertificateRequests approved by cert-manager's built-in approver found", "total", len(crList.Items))
+		return nil
+	}
+
+	d.log.Error(errDefaultApproverEnabled, "CertificateRequests have been approved by cert-manager's built-in approver, "+
+		"bypassing all CertificateRequestPolicies. Disable it by running the cert-manager controller with "+
+		"--controllers=*,-certificaterequests-approver",
+		"approved", len(approved), "requests", approved[:min(len(approved), maxDefaultApproverExamples)])
+
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/ktesting"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_defaultApproverCheck(t *testing.T) {
+	approvedBy := func(name, reason string) runtime.Object {
+		return gen.CertificateRequest(name,
+			gen.SetCertificateRequestNamespace("team-a"),
+			gen.AddCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+				Type:   cmapi.CertificateRequestConditionApproved,
+				Status: cmmeta.ConditionTrue,
+				Reason: reason,
+			}),
+		)
+	}
+
+	tests := map[string]struct {
+		existingObjects []runtime.Object
+		expError        bool
+	}{
+		"no requests should not error": {},
+		"requests approved by approver-policy should not error": {
+			existingObjects: []runtime.Object{approvedBy("policy", "policy.cert-manager.io"), gen.CertificateRequest("pending", gen.SetCertificateRequestNamespace("team-a"))},
+		},
+		"requests approved by the built-in approver should log an error": {
+			existingObjects: []runtime.Object{approvedBy("policy", "policy.cert-manager.io"), approvedBy("built-in", "cert-manager.io")},
+			expError:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fakeclient := fakeclient.NewClientBuilder().
+				WithScheme(policyapi.GlobalScheme).
+				WithRuntimeObjects(test.existingObjects...).
+				Build()
+
+			log := ktesting.NewLogger(t, ktesting.NewConfig(ktesting.BufferLogs(true)))
+			d := &defaultApproverCheck{log: log, lister: fakeclient}
+			require.NoError(t, d.Start(context.TODO()))
+
+			logs := log.GetSink().(ktesting.Underlier).GetBuffer().String()
+			if !test.expError {
+				assert.NotContains(t, logs, errDefaultApproverEnabled.Error())
+				return
+			}
+			assert.Contains(t, logs, errDefaultApproverEnabled.Error())
+			assert.Contains(t, logs, "team-a/built-in")
+			assert.NotContains(t, logs, "team-a/policy")
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// denialExpiry is the duration after the last denial of a requester that its
+// denials are forgotten.
+const denialExpiry = time.Hour
+
+// DenialBackoffOptions configures delaying the review of requests from
+// requesters which have repeatedly had requests denied.
+type DenialBackoffOptions struct {
+	// Threshold is the number of denials of a requester after which reviews of
+	// its requests are delayed. A value of 0 disables denial backoff.
+	Threshold int
+
+	// BaseDelay is the delay applied once a requester reaches the threshold.
+	// The delay doubles for every further denial.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay applied to a requester.
+	MaxDelay time.Duration
+}
+
+// denialTracker tracks the number of denials of each requester, so that
+// reviews of requests from noisy requesters can be delayed rather than
+// consuming evaluation capacity. A nil denialTracker tracks nothing.
+// Requesters are tracked per namespace, since most requests are created by
+// the cert-manager controller ServiceAccount on behalf of every namespace, and
+// the denials of one namespace must not delay the reviews of another.
+type denialTracker struct {
+	clock clock.PassiveClock
+	opts  DenialBackoffOptions
+
+	lock       sync.Mutex
+	requesters map[requesterKey]requesterDenials
+}
+
+// requesterKey identifies a requester within the namespace of its requests.
+type requesterKey struct {
+	namespace string
+	username  string
+}
+
+// requesterDenials holds the denial count and time of last denial of a
+// requester.
+type requesterDenials struct {
+	count int
+	last  time.Time
+}
+
+// newDenialTracker returns a new denialTracker, or nil if denial backoff is
+// disabled.
+func newDenialTracker(clock clock.PassiveClock, opts DenialBackoffOptions) *denialTracker {
+	if opts.Threshold <= 0 {
+		return nil
+	}
+	return &denialTracker{
+		clock:      clock,
+		opts:       opts,
+		requesters: make(map[requesterKey]requesterDenials),
+	}
+}
+
+// Denied records a denial of a request in the given namespace from the given
+// requester.
+func (d *denialTracker) Denied(namespace, requester string) {
+	if d == nil {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.clock.Now()
+	for key, denials := range d.requesters {
+		if now.Sub(denials.last) > denialExpiry {
+			delete(d.requesters, key)
+		}
+	}
+
+	key := requesterKey{namespace: namespace, username: requester}
+	denials := d.requesters[key]
+	d.requesters[key] = requesterDenials{count: denials.count + 1, last: now}
+}
+
+// Delay returns the remaining duration that reviews of requests in the given
+// namespace from the given requester should be delayed for. Returns 0 if the
+// requester has not reached the denial threshold in the namespace, or the
+// delay has passed.
+func (d *denialTracker) Delay(namespace, requester string) time.Duration {
+	if d == nil {
+		return 0
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	denials, ok := d.requesters[requesterKey{namespace: namespace, username: requester}]
+	if !ok || denials.count < d.opts.Threshold {
+		return 0
+	}
+
+	delay := d.opts.BaseDelay
+	for i := d.opts.Threshold; i < denials.count && delay < d.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, d.opts.MaxDelay)
+
+	return max(denials.last.Add(delay).Sub(d.clock.Now()), 0)
+}
+
+// Reset forgets all denials. Called when configuration changes, since
+// previously denied requesters may now be permitted.
+func (d *denialTracker) Reset() {
+	if d == nil {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	clear(d.requesters)
+}
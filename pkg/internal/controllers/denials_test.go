@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	fakeclock "k8s.io/utils/clock/testing"
+)
+
+func Test_denialTracker(t *testing.T) {
+	clock := fakeclock.NewFakeClock(time.Date(2021, 01, 01, 01, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, newDenialTracker(clock, DenialBackoffOptions{}), "tracker should be disabled with no threshold")
+
+	denials := newDenialTracker(clock, DenialBackoffOptions{Threshold: 2, BaseDelay: time.Second * 10, MaxDelay: time.Second * 30})
+
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Duration(0), denials.Delay("default", "noisy"), "requester below threshold should not be delayed")
+
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Second*10, denials.Delay("default", "noisy"), "requester at threshold should be delayed by base delay")
+	assert.Equal(t, time.Duration(0), denials.Delay("default", "quiet"), "other requesters should not be delayed")
+	assert.Equal(t, time.Duration(0), denials.Delay("other", "noisy"), "requester should not be delayed in other namespaces")
+
+	clock.Step(time.Second * 4)
+	assert.Equal(t, time.Second*6, denials.Delay("default", "noisy"), "delay should be relative to the last denial")
+
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Second*20, denials.Delay("default", "noisy"), "delay should double with further denials")
+
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Second*30, denials.Delay("default", "noisy"), "delay should be capped by max delay")
+
+	clock.Step(time.Second * 30)
+	assert.Equal(t, time.Duration(0), denials.Delay("default", "noisy"), "delay should have passed")
+
+	denials.Reset()
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Duration(0), denials.Delay("default", "noisy"), "reset should forget denials")
+
+	clock.Step(denialExpiry + time.Second)
+	denials.Denied("default", "other")
+	denials.Denied("default", "noisy")
+	assert.Equal(t, time.Duration(0), denials.Delay("default", "noisy"), "expired denials should be forgotten")
+}
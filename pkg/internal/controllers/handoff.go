@@ -0,0 +1,346 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// handoffLeaseName is the name of the Lease, in the leader election
+	// namespace, which holds the requests handed off by the last leader. It is
+	// separate from the leader election Lease so that writing it never
+	// conflicts with the leader releasing its lease.
+	handoffLeaseName = "policy.cert-manager.io-handoff"
+
+	// handoffAnnotationKey is the annotation of the handoff Lease holding the
+	// JSON list of `<namespace>/<name>` keys of the handed off requests.
+	handoffAnnotationKey = "policy.cert-manager.io/handoff-requests"
+
+	// maxHandoffRequests is the maximum number of requests handed off, which
+	// keeps the annotation well within the size limit of object metadata.
+	maxHandoffRequests = 500
+
+	// handoffTimeout is the timeout of reading and writing the handoff Lease.
+	handoffTimeout = 10 * time.Second
+
+	// handoffInterval is how often the requests in flight are written to the
+	// handoff Lease while leading, if they have changed.
+	handoffInterval = 30 * time.Second
+)
+
+// HandoffOptions configures handing off the requests in flight when
+// approver-policy stops leading to the next leader, which reviews them before
+// any other request. This reduces the latency of decisions during rolling
+// upgrades.
+//
+// The requests in flight are written when approver-policy shuts down, and
+// periodically while it leads. If leadership is lost rather than released on
+// shutdown, the manager stops without waiting for the final write, so the
+// next leader is handed off the requests of the last periodic write.
+type HandoffOptions struct {
+	// Namespace is the namespace of the handoff Lease, which is the leader
+	// election namespace. Empty disables handoff.
+	Namespace string
+}
+
+// handoff tracks the requests in the queue of the certificaterequests
+// controller, or being reconciled, so that they can be handed off to the next
+// leader. Tracking is best effort, since handed off requests are only
+// prioritized; every request is reconciled by the next leader once its caches
+// sync.
+type handoff struct {
+	log       logr.Logger
+	reader    client.Reader
+	client    client.Client
+	namespace string
+
+	// interval is how often the requests in flight are written while
+	// leading.
+	interval time.Duration
+
+	// opened is true once the queue has been created, and the requests handed
+	// off by the last leader have been read from the Lease. Until then,
+	// writing the Lease would overwrite them.
+	opened atomic.Bool
+
+	lock       sync.Mutex
+	queued     map[reconcile.Request]struct{}
+	processing map[reconcile.Request]struct{}
+}
+
+// addHandoff registers the handoff of requests with the manager, returning
+// nil if handoff is disabled.
+func addHandoff(opts Options) (*handoff, error) {
+	if len(opts.Handoff.Namespace) == 0 {
+		return nil, nil
+	}
+
+	h := &handoff{
+		log:        opts.Log.WithName("handoff"),
+		reader:     opts.Manager.GetAPIReader(),
+		client:     opts.Manager.GetClient(),
+		namespace:  opts.Handoff.Namespace,
+		interval:   handoffInterval,
+		queued:     make(map[reconcile.Request]struct{}),
+		processing: make(map[reconcile.Request]struct{}),
+	}
+	if err := opts.Manager.Add(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Start writes the requests in flight to the handoff Lease every interval
+// while approver-policy leads, if they have changed, and once more when it
+// stops leading. It does not implement LeaderElectionRunnable, so is only
+// started on the leader.
+func (h *handoff) Start(ctx context.Context) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	var written []reconcile.Request
+	for {
+		select {
+		case <-ctx.Done():
+			if !h.opened.Load() {
+				return nil
+			}
+			requests := h.inFlight()
+			if err := h.writeWithTimeout(requests); err != nil {
+				h.log.Error(err, "failed to hand off in-flight requests to the next leader", "requests", len(requests))
+				return nil
+			}
+			if len(requests) > 0 {
+				h.log.Info("handed off in-flight requests to the next leader", "requests", len(requests))
+			}
+			return nil
+
+		case <-ticker.C:
+			if !h.opened.Load() {
+				continue
+			}
+			requests := h.inFlight()
+			if slices.Equal(requests, written) {
+				continue
+			}
+			if err := h.writeWithTimeout(requests); err != nil {
+				h.log.Error(err, "failed to write in-flight requests for the next leader", "requests", len(requests))
+				continue
+			}
+			written = requests
+		}
+	}
+}
+
+// writeWithTimeout writes the requests to the handoff Lease. The manager's
+// context is cancelled on shutdown, so the Lease is written with a context of
+// its own.
+func (h *handoff) writeWithTimeout(requests []reconcile.Request) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+	return h.write(ctx, requests)
+}
+
+// newQueue returns the queue of the certificaterequests controller, which is
+// created once approver-policy becomes leader. The requests handed off by the
+// last leader are added first, so that they are reconciled before the
+// requests added once the caches sync.
+func (h *handoff) newQueue(name string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	queue := &handoffQueue{
+		TypedRateLimitingInterface: workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter,
+			workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{Name: name}),
+		handoff: h,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+
+	defer h.opened.Store(true)
+	requests, err := h.read(ctx)
+	if err != nil {
+		h.log.Error(err, "failed to read requests handed off by the last leader")
+		return queue
+	}
+	if len(requests) > 0 {
+		h.log.Info("reviewing requests handed off by the last leader first", "requests", len(requests))
+	}
+	for _, req := range requests {
+		queue.Add(req)
+	}
+
+	return queue
+}
+
+// inFlight returns the requests which are queued or being reconciled, in
+// order, up to maxHandoffRequests.
+func (h *handoff) inFlight() []reconcile.Request {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	requests := make([]reconcile.Request, 0, len(h.queued)+len(h.processing))
+	for req := range h.queued {
+		requests = append(requests, req)
+	}
+	for req := range h.processing {
+		if _, ok := h.queued[req]; !ok {
+			requests = append(requests, req)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].String() < requests[j].String() })
+
+	return requests[:min(len(requests), maxHandoffRequests)]
+}
+
+// write sets the requests on the handoff Lease, creating it if needed. No
+// requests removes the annotation.
+func (h *handoff) write(ctx context.Context, requests []reconcile.Request) error {
+	var value *string
+	if len(requests) > 0 {
+		keys := make([]string, 0, len(requests))
+		for _, req := range requests {
+			keys = append(keys, req.String())
+		}
+		encoded, err := json.Marshal(keys)
+		if err != nil {
+			return fmt.Errorf("failed to encode handed off requests: %w", err)
+		}
+		value = ptr.To(string(encoded))
+	}
+
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"annotations": map[string]*string{handoffAnnotationKey: value}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode handoff Lease patch: %w", err)
+	}
+
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: h.namespace, Name: handoffLeaseName}}
+	err = h.client.Patch(ctx, lease, client.RawPatch(types.MergePatchType, patch))
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+
+	lease.Annotations = map[string]string{handoffAnnotationKey: *value}
+	return h.client.Create(ctx, lease)
+}
+
+// read returns the requests on the handoff Lease, removing them so that they
+// are not handed off again.
+func (h *handoff) read(ctx context.Context) ([]reconcile.Request, error) {
+	var lease coordinationv1.Lease
+	if err := h.reader.Get(ctx, client.ObjectKey{Namespace: h.namespace, Name: handoffLeaseName}, &lease); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get handoff Lease: %w", err)
+	}
+
+	value, ok := lease.Annotations[handoffAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal([]byte(value), &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode %q annotation of handoff Lease: %w", handoffAnnotationKey, err)
+	}
+
+	requests := make([]reconcile.Request, 0, len(keys))
+	for _, key := range keys {
+		namespace, name, ok := strings.Cut(key, "/")
+		if !ok {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+	}
+
+	if err := h.write(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to clear handoff Lease: %w", err)
+	}
+
+	return requests, nil
+}
+
+// handoffQueue is the queue of the certificaterequests controller, which
+// tracks the requests in flight for the handoff.
+type handoffQueue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+	handoff *handoff
+}
+
+func (q *handoffQueue) Add(req reconcile.Request) {
+	q.queue(req)
+	q.TypedRateLimitingInterface.Add(req)
+}
+
+// AddAfter does not track the request until it is reconciled, since requests
+// added after a delay, such as those of requesters in denial backoff, are
+// parked rather than in flight.
+func (q *handoffQueue) AddAfter(req reconcile.Request, duration time.Duration) {
+	q.TypedRateLimitingInterface.AddAfter(req, duration)
+}
+
+func (q *handoffQueue) AddRateLimited(req reconcile.Request) {
+	q.queue(req)
+	q.TypedRateLimitingInterface.AddRateLimited(req)
+}
+
+// Get moves the request from queued to processing.
+func (q *handoffQueue) Get() (reconcile.Request, bool) {
+	req, shutdown := q.TypedRateLimitingInterface.Get()
+	if !shutdown {
+		q.handoff.lock.Lock()
+		delete(q.handoff.queued, req)
+		q.handoff.processing[req] = struct{}{}
+		q.handoff.lock.Unlock()
+	}
+	return req, shutdown
+}
+
+// Done stops tracking the request, unless it has been queued again while
+// being reconciled.
+func (q *handoffQueue) Done(req reconcile.Request) {
+	q.handoff.lock.Lock()
+	delete(q.handoff.processing, req)
+	q.handoff.lock.Unlock()
+	q.TypedRateLimitingInterface.Done(req)
+}
+
+func (q *handoffQueue) queue(req reconcile.Request) {
+	q.handoff.lock.Lock()
+	defer q.handoff.lock.Unlock()
+	q.handoff.queued[req] = struct{}{}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_handoff(t *testing.T) {
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: name}}
+	}
+
+	fakeclient := fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).Build()
+	newHandoff := func() *handoff {
+		return &handoff{
+			log:        logr.Discard(),
+			reader:     fakeclient,
+			client:     fakeclient,
+			namespace:  "cert-manager",
+			interval:   time.Hour,
+			queued:     make(map[reconcile.Request]struct{}),
+			processing: make(map[reconcile.Request]struct{}),
+		}
+	}
+
+	// The first leader has no requests handed off.
+	leader := newHandoff()
+	queue := leader.newQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	assert.Equal(t, 0, queue.Len())
+
+	// Requests which are queued or being reconciled are in flight, and those
+	// which have been reconciled or are parked in backoff are not.
+	for _, name := range []string{"reconciled", "processing", "queued"} {
+		queue.Add(request(name))
+	}
+	queue.AddAfter(request("backoff"), time.Hour)
+	for _, name := range []string{"reconciled", "processing"} {
+		req, _ := queue.Get()
+		require.Equal(t, request(name), req)
+	}
+	queue.Done(request("reconciled"))
+	assert.Equal(t, []reconcile.Request{request("processing"), request("queued")}, leader.inFlight())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	require.NoError(t, leader.Start(ctx))
+	queue.ShutDown()
+
+	var lease coordinationv1.Lease
+	require.NoError(t, fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "cert-manager", Name: handoffLeaseName}, &lease))
+	assert.Equal(t, `["team-a/processing","team-a/queued"]`, lease.Annotations[handoffAnnotationKey])
+
+	// The next leader queues the handed off requests first, and clears them
+	// from the Lease.
+	next := newHandoff()
+	queue = next.newQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	queue.Add(request("new"))
+	for _, name := range []string{"processing", "queued", "new"} {
+		req, _ := queue.Get()
+		assert.Equal(t, request(name), req)
+	}
+	queue.ShutDown()
+
+	require.NoError(t, fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "cert-manager", Name: handoffLeaseName}, &lease))
+	assert.NotContains(t, lease.Annotations, handoffAnnotationKey)
+
+	// While leading, the requests in flight are written periodically, so they
+	// are handed off if leadership is lost without a final write.
+	periodic := newHandoff()
+	periodic.interval = 10 * time.Millisecond
+	queue = periodic.newQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	queue.Add(request("periodic"))
+
+	ctx, cancel = context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, periodic.Start(ctx))
+	}()
+	assert.Eventually(t, func() bool {
+		var lease coordinationv1.Lease
+		if err := fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "cert-manager", Name: handoffLeaseName}, &lease); err != nil {
+			return false
+		}
+		return lease.Annotations[handoffAnnotationKey] == `["team-a/periodic"]`
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	queue.ShutDown()
+
+	// A leader whose queue has not been created does not overwrite the
+	// requests handed off by the last leader.
+	unopened := newHandoff()
+	ctx, cancel = context.WithCancel(context.TODO())
+	cancel()
+	require.NoError(t, unopened.Start(ctx))
+	require.NoError(t, fakeclient.Get(context.TODO(), client.ObjectKey{Namespace: "cert-manager", Name: handoffLeaseName}, &lease))
+	assert.Equal(t, `["team-a/periodic"]`, lease.Annotations[handoffAnnotationKey])
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// InvalidRequestAction is the action taken on structurally invalid
+// CertificateRequests, such as those with an empty CSR, invalid PEM or a
+// mismatched key, which can never be signed.
+type InvalidRequestAction string
+
+const (
+	// InvalidRequestActionDeny denies structurally invalid requests.
+	InvalidRequestActionDeny InvalidRequestAction = "Deny"
+
+	// InvalidRequestActionIgnore leaves structurally invalid requests
+	// unreviewed, for another approver to decide.
+	InvalidRequestActionIgnore InvalidRequestAction = "Ignore"
+)
+
+// checkStructure checks whether the request is structurally invalid before it
+// is reviewed against policies. If so, it records the problem and returns the
+// status patch and verdict denying the request for the Deny action, or a nil
+// patch for the Ignore action. ok is true if the request is structurally valid
+// and should be reviewed. The request is checked once normalized with the
+// RequestDecoder of its issuer group, while the request itself is left as it
+// is, so that the original request is audited and recorded.
+func (c *certificaterequests) checkStructure(log logr.Logger, cr *cmapi.CertificateRequest) (*cmapi.CertificateRequestStatus, *verdict, bool) {
+	normalized := cr.DeepCopy()
+	err := c.decoders.Normalize(normalized)
+	if err == nil {
+		err = internalcsr.CheckStructure(normalized.Spec.Request)
+	}
+	var structuralErr *internalcsr.StructuralError
+	if !errors.As(err, &structuralErr) {
+		return nil, nil, true
+	}
+
+	message := fmt.Sprintf("Request is structurally invalid (%s): %s", structuralErr.Problem, structuralErr.Err)
+	c.recorder.Event(cr, corev1.EventTypeWarning, "InvalidRequest", message)
+
+	if c.invalidRequestAction == InvalidRequestActionIgnore {
+		log.V(2).Info("ignoring structurally invalid request", "problem", structuralErr.Problem, "error", structuralErr.Err)
+		metrics.InvalidRequests.WithLabelValues(string(structuralErr.Problem), metrics.InvalidRequestIgnored).Inc()
+		return nil, nil, false
+	}
+
+	log.V(2).Info("denying structurally invalid request", "problem", structuralErr.Problem, "error", structuralErr.Err)
+	metrics.InvalidRequests.WithLabelValues(string(structuralErr.Problem), metrics.InvalidRequestDenied).Inc()
+
+	crPatch := &cmapi.CertificateRequestStatus{}
+	setCertificateRequestStatusCondition(
+		c.clock,
+		cr.Status.Conditions,
+		&crPatch.Conditions,
+		cmapi.CertificateRequestConditionDenied,
+		cmmeta.ConditionTrue,
+		"policy.cert-manager.io",
+		message,
+	)
+	response := manager.ReviewResponse{Result: manager.ResultDenied, Message: message}
+	return crPatch, &verdict{
+		request:  cr,
+		response: response,
+		record:   c.decisionRecord(cr, response),
+		invalid:  true,
+	}, false
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+)
+
+// namedIssuers returns the issuers named by the selector of the policy which
+// can be verified to exist. Only cert-manager.io Issuers and ClusterIssuers
+// whose name is not a wildcard are returned. A selector which omits the kind
+// names both an Issuer and a ClusterIssuer, and either existing is enough.
+// Aliases are resolved with the given aliases, and unknown aliases are
+// ignored as they match no requests by design.
+func namedIssuers(policy *policyapi.CertificateRequestPolicy, aliases map[string]cmmeta.ObjectReference) []cmmeta.ObjectReference {
+	sel := policy.Spec.Selector.IssuerRef
+	if sel == nil {
+		return nil
+	}
+
+	var name, kind, group string
+	switch {
+	case sel.Alias != nil:
+		ref, ok := aliases[*sel.Alias]
+		if !ok {
+			return nil
+		}
+		name, kind, group = ref.Name, ref.Kind, ref.Group
+	case sel.Name != nil:
+		name = *sel.Name
+		if sel.Kind != nil {
+			kind = *sel.Kind
+		}
+		if sel.Group != nil {
+			group = *sel.Group
+		}
+	default:
+		return nil
+	}
+
+	if len(name) == 0 || strings.Contains(name, "*") || strings.Contains(kind, "*") {
+		return nil
+	}
+	if len(group) > 0 && group != "cert-manager.io" {
+		return nil
+	}
+
+	switch kind {
+	case "":
+		return []cmmeta.ObjectReference{{Name: name, Group: "cert-manager.io"}}
+	case cmapi.IssuerKind, cmapi.ClusterIssuerKind:
+		return []cmmeta.ObjectReference{{Name: name, Kind: kind, Group: "cert-manager.io"}}
+	default:
+		return nil
+	}
+}
+
+// issuerNameField is the field index of Issuers by name, so that Issuers of a
+// name can be found in any namespace without listing every Issuer.
+const issuerNameField = ".metadata.name"
+
+// issuerName is the IndexerFunc of issuerNameField.
+func issuerName(obj client.Object) []string {
+	return []string{obj.GetName()}
+}
+
+// missingIssuers returns the issuers named by the selector of the policy
+// which don't exist, formatted as `<kind>.<group>/<name>`, with a kind of `*`
+// if the selector omits it. Issuers are namespaced, so an Issuer exists if it
+// exists in any namespace.
+func (c *certificaterequestpolicies) missingIssuers(ctx context.Context, policy *policyapi.CertificateRequestPolicy) ([]string, error) {
+	var missing []string
+	for _, ref := range namedIssuers(policy, c.issuerAliases) {
+		found := false
+
+		if ref.Kind == "" || ref.Kind == cmapi.ClusterIssuerKind {
+			err := c.lister.Get(ctx, client.ObjectKey{Name: ref.Name}, new(cmapi.ClusterIssuer))
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get ClusterIssuer %q: %w", ref.Name, err)
+			}
+			found = err == nil
+		}
+
+		if !found && (ref.Kind == "" || ref.Kind == cmapi.IssuerKind) {
+			var issuers cmapi.IssuerList
+			if err := c.lister.List(ctx, &issuers, client.MatchingFields{issuerNameField: ref.Name}); err != nil {
+				return nil, fmt.Errorf("failed to list Issuers %q: %w", ref.Name, err)
+			}
+			found = len(issuers.Items) > 0
+		}
+
+		if !found {
+			if ref.Kind == "" {
+				ref.Kind = "*"
+			}
+			missing = append(missing, internalmanager.FormatIssuerRef(ref))
+		}
+	}
+	return missing, nil
+}
+
+// setIssuersFoundCondition sets the IssuersFound condition of the policy on
+// the status patch, raising a Warning event when a named issuer goes missing.
+func (c *certificaterequestpolicies) setIssuersFoundCondition(ctx context.Context, policy *policyapi.CertificateRequestPolicy, policyPatch *policyapi.CertificateRequestPolicyStatus) error {
+	missing, err := c.missingIssuers(ctx, policy)
+	if err != nil {
+		return err
+	}
+
+	condition := policyapi.CertificateRequestPolicyCondition{
+		Type:    policyapi.CertificateRequestPolicyConditionIssuersFound,
+		Status:  corev1.ConditionTrue,
+		Reason:  "IssuersFound",
+		Message: "All issuers named by the selector exist",
+	}
+	if len(missing) > 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "IssuerNotFound"
+		condition.Message = fmt.Sprintf("Issuers named by the selector do not exist: %s", strings.Join(missing, ", "))
+
+		// The event is only raised on transition, not on every resync of a
+		// policy whose issuers are still missing.
+		if !slices.ContainsFunc(policy.Status.Conditions, func(existing policyapi.CertificateRequestPolicyCondition) bool {
+			return existing.Type == condition.Type && existing.Status == condition.Status && existing.Message == condition.Message
+		}) {
+			c.recorder.Event(policy, corev1.EventTypeWarning, condition.Reason, condition.Message)
+		}
+	}
+
+	c.setCertificateRequestPolicyCondition(policy.Status.Conditions, &policyPatch.Conditions, policy.Generation, condition)
+	return nil
+}
+
+// enqueuePoliciesNamingIssuer returns the policies whose selector names the
+// issuer, so that their IssuersFound condition is updated when the issuer is
+// created or deleted.
+func (c *certificaterequestpolicies) enqueuePoliciesNamingIssuer(ctx context.Context, obj client.Object) []reconcile.Request {
+	kind := cmapi.IssuerKind
+	if _, ok := obj.(*cmapi.ClusterIssuer); ok {
+		kind = cmapi.ClusterIssuerKind
+	}
+
+	var policies policyapi.CertificateRequestPolicyList
+	if err := c.lister.List(ctx, &policies); err != nil {
+		c.log.Error(err, "failed to list CertificateRequestPolicies to enqueue after issuer change")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range policies.Items {
+		named := slices.ContainsFunc(namedIssuers(&policies.Items[i], c.issuerAliases), func(ref cmmeta.ObjectReference) bool {
+			return ref.Name == obj.GetName() && (ref.Kind == "" || ref.Kind == kind)
+		})
+		if named {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: policies.Items[i].Name}})
+		}
+	}
+	return requests
+}
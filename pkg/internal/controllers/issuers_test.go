@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_namedIssuers(t *testing.T) {
+	aliases := map[string]cmmeta.ObjectReference{
+		"internal-mtls": {Name: "vault-mtls", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		"external":      {Name: "acme", Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"},
+	}
+
+	tests := map[string]struct {
+		issuerRef *policyapi.CertificateRequestPolicySelectorIssuerRef
+		exp       []cmmeta.ObjectReference
+	}{
+		"nil selector names no issuer": {
+			issuerRef: nil,
+			exp:       nil,
+		},
+		"wildcard name names no issuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca-*")},
+			exp:       nil,
+		},
+		"wildcard kind names no issuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca"), Kind: ptr.To("*Issuer")},
+			exp:       nil,
+		},
+		"external issuer group names no issuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca"), Group: ptr.To("example.com")},
+			exp:       nil,
+		},
+		"name without kind names an Issuer or ClusterIssuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca")},
+			exp:       []cmmeta.ObjectReference{{Name: "ca", Group: "cert-manager.io"}},
+		},
+		"name with kind names that kind": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("ca"), Kind: ptr.To("Issuer"), Group: ptr.To("cert-manager.io")},
+			exp:       []cmmeta.ObjectReference{{Name: "ca", Kind: "Issuer", Group: "cert-manager.io"}},
+		},
+		"alias is resolved": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("internal-mtls")},
+			exp:       []cmmeta.ObjectReference{{Name: "vault-mtls", Kind: "ClusterIssuer", Group: "cert-manager.io"}},
+		},
+		"alias of an external issuer names no issuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("external")},
+			exp:       nil,
+		},
+		"unknown alias names no issuer": {
+			issuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("unknown")},
+			exp:       nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy := &policyapi.CertificateRequestPolicy{
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: test.issuerRef},
+				},
+			}
+			assert.Equal(t, test.exp, namedIssuers(policy, aliases))
+		})
+	}
+}
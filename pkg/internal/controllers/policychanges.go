@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"slices"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// reviewedPolicy holds the parts of a CertificateRequestPolicy which are used
+// to review requests. Changes to any other field, such as the resourceVersion,
+// managed fields, or condition timestamps and messages, do not change the
+// result of reviewing a request.
+type reviewedPolicy struct {
+	Spec           policyapi.CertificateRequestPolicySpec `json:"spec"`
+	Ready          bool                                   `json:"ready"`
+	UnreadyPlugins []string                               `json:"unreadyPlugins,omitempty"`
+}
+
+// policyReviewHash returns the semantic hash of the parts of the policy which
+// are used to review requests.
+func policyReviewHash(policy *policyapi.CertificateRequestPolicy) ([sha256.Size]byte, error) {
+	reviewed := reviewedPolicy{
+		Spec:           policy.Spec,
+		UnreadyPlugins: slices.Clone(policy.Status.UnreadyPlugins),
+	}
+	slices.Sort(reviewed.UnreadyPlugins)
+	for _, condition := range policy.Status.Conditions {
+		if condition.Type == policyapi.CertificateRequestPolicyConditionReady && condition.Status == corev1.ConditionTrue {
+			reviewed.Ready = true
+		}
+	}
+
+	b, err := json.Marshal(reviewed)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+// policyReviewChanged returns a predicate which filters out updates of
+// CertificateRequestPolicies that do not change how requests are reviewed.
+// Every status update of a policy would otherwise cause all pending requests
+// to be reviewed again. Skipped and passed updates are counted by the
+// PolicyUpdates metric.
+func policyReviewChanged(log logr.Logger) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPolicy, ok := e.ObjectOld.(*policyapi.CertificateRequestPolicy)
+			if !ok {
+				return true
+			}
+			newPolicy, ok := e.ObjectNew.(*policyapi.CertificateRequestPolicy)
+			if !ok {
+				return true
+			}
+
+			oldHash, err := policyReviewHash(oldPolicy)
+			if err != nil {
+				log.Error(err, "failed to hash CertificateRequestPolicy, treating update as a change", "name", oldPolicy.Name)
+				return true
+			}
+			newHash, err := policyReviewHash(newPolicy)
+			if err != nil {
+				log.Error(err, "failed to hash CertificateRequestPolicy, treating update as a change", "name", newPolicy.Name)
+				return true
+			}
+
+			if oldHash == newHash {
+				metrics.PolicyUpdates.WithLabelValues(metrics.PolicyUpdateSkipped).Inc()
+				return false
+			}
+			metrics.PolicyUpdates.WithLabelValues(metrics.PolicyUpdateChanged).Inc()
+			return true
+		},
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+func Test_policyReviewChanged(t *testing.T) {
+	basePolicy := func() *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-policy", ResourceVersion: "1"},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("example.com")}},
+			},
+			Status: policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady, Status: corev1.ConditionTrue, Message: "ready", ObservedGeneration: 1},
+				},
+				UnreadyPlugins: []string{"a", "b"},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		mutate    func(*policyapi.CertificateRequestPolicy)
+		expResult bool
+	}{
+		"no change should be skipped": {
+			mutate:    func(*policyapi.CertificateRequestPolicy) {},
+			expResult: false,
+		},
+		"metadata change should be skipped": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.ResourceVersion = "2"
+				p.Annotations = map[string]string{"foo": "bar"}
+			},
+			expResult: false,
+		},
+		"condition message and generation change should be skipped": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.Status.Conditions[0].Message = "still ready"
+				p.Status.Conditions[0].ObservedGeneration = 2
+				p.Status.Conditions[0].LastTransitionTime = ptr.To(metav1.Now())
+			},
+			expResult: false,
+		},
+		"reordered unready plugins should be skipped": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.Status.UnreadyPlugins = []string{"b", "a"}
+			},
+			expResult: false,
+		},
+		"spec change should be passed": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.Spec.Allowed.CommonName.Value = ptr.To("other.example.com")
+			},
+			expResult: true,
+		},
+		"ready condition change should be passed": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.Status.Conditions[0].Status = corev1.ConditionFalse
+			},
+			expResult: true,
+		},
+		"unready plugins change should be passed": {
+			mutate: func(p *policyapi.CertificateRequestPolicy) {
+				p.Status.UnreadyPlugins = []string{"a"}
+			},
+			expResult: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			metrics.PolicyUpdates.Reset()
+
+			oldPolicy := basePolicy()
+			newPolicy := basePolicy()
+			test.mutate(newPolicy)
+
+			result := policyReviewChanged(logr.Discard()).Update(event.UpdateEvent{ObjectOld: oldPolicy, ObjectNew: newPolicy})
+			assert.Equal(t, test.expResult, result)
+
+			expChanged, expSkipped := 0.0, 1.0
+			if test.expResult {
+				expChanged, expSkipped = 1, 0
+			}
+			assert.Equal(t, expChanged, testutil.ToFloat64(metrics.PolicyUpdates.WithLabelValues(metrics.PolicyUpdateChanged)))
+			assert.Equal(t, expSkipped, testutil.ToFloat64(metrics.PolicyUpdates.WithLabelValues(metrics.PolicyUpdateSkipped)))
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// CacheByObject returns the cache options of the objects watched by the
+// controllers, to be set on the manager. The rules and subjects of RBAC
+// objects are needed to tell whether a change is relevant, so full objects are
+// watched rather than their metadata. To keep the cost of caching every RBAC
+// object in the cluster down, they are transformed by rbacCacheTransform.
+func CacheByObject() map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&rbacv1.Role{}:               {Transform: rbacCacheTransform},
+		&rbacv1.ClusterRole{}:        {Transform: rbacCacheTransform},
+		&rbacv1.RoleBinding{}:        {Transform: rbacCacheTransform},
+		&rbacv1.ClusterRoleBinding{}: {Transform: rbacCacheTransform},
+	}
+}
+
+// rbacCacheTransform strips RBAC objects of what is not needed to tell
+// whether a change grants or revokes the use of CertificateRequestPolicies:
+// their annotations and managed fields, and the rules of roles which do not
+// grant use.
+func rbacCacheTransform(obj any) (any, error) {
+	var meta *metav1.ObjectMeta
+	switch obj := obj.(type) {
+	case *rbacv1.Role:
+		obj.Rules = useRules(obj.Rules)
+		meta = &obj.ObjectMeta
+	case *rbacv1.ClusterRole:
+		// The rules of aggregated ClusterRoles are aggregated by the API
+		// server, so the aggregation rule is not needed.
+		obj.Rules, obj.AggregationRule = useRules(obj.Rules), nil
+		meta = &obj.ObjectMeta
+	case *rbacv1.RoleBinding:
+		meta = &obj.ObjectMeta
+	case *rbacv1.ClusterRoleBinding:
+		meta = &obj.ObjectMeta
+	default:
+		return obj, nil
+	}
+
+	meta.Annotations, meta.ManagedFields = nil, nil
+	return obj, nil
+}
+
+// useRules returns the rules which grant the use of any
+// CertificateRequestPolicy.
+func useRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	var use []rbacv1.PolicyRule
+	for _, rule := range rules {
+		if bindings.RuleGrantsAnyUse(rule) {
+			use = append(use, rule)
+		}
+	}
+	return use
+}
+
+// rbacBindingChanged returns a predicate which filters out changes to Roles,
+// ClusterRoles, RoleBindings and ClusterRoleBindings that cannot change which
+// CertificateRequestPolicies are bound to requesters. Any other RBAC change in
+// the cluster would otherwise cause all pending requests to be reviewed again.
+// Roles referenced by bindings are read from the lister. Relevant and skipped
+// changes are counted by the RBACChanges metric.
+func rbacBindingChanged(ctx context.Context, log logr.Logger, lister client.Reader) predicate.Predicate {
+	observe := func(obj client.Object, relevant bool) bool {
+		result := metrics.RBACChangeSkipped
+		if relevant {
+			result = metrics.RBACChangeRelevant
+		}
+		metrics.RBACChanges.WithLabelValues(rbacKind(obj), result).Inc()
+		return relevant
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return observe(e.Object, rbacGrantsUse(ctx, log, lister, e.Object))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return observe(e.Object, rbacGrantsUse(ctx, log, lister, e.Object))
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !rbacBindingFieldsChanged(e.ObjectOld, e.ObjectNew) {
+				return observe(e.ObjectNew, false)
+			}
+			return observe(e.ObjectNew, rbacGrantsUse(ctx, log, lister, e.ObjectOld) || rbacGrantsUse(ctx, log, lister, e.ObjectNew))
+		},
+	}
+}
+
+// rbacBindingFieldsChanged returns true if the rules of a role, or the
+// subjects of a binding, differ between the objects. The role referenced by a
+// binding is immutable.
+func rbacBindingFieldsChanged(oldObj, newObj client.Object) bool {
+	switch oldObj := oldObj.(type) {
+	case *rbacv1.Role:
+		newObj, ok := newObj.(*rbacv1.Role)
+		return !ok || !apiequality.Semantic.DeepEqual(oldObj.Rules, newObj.Rules)
+	case *rbacv1.ClusterRole:
+		newObj, ok := newObj.(*rbacv1.ClusterRole)
+		return !ok || !apiequality.Semantic.DeepEqual(oldObj.Rules, newObj.Rules)
+	case *rbacv1.RoleBinding:
+		newObj, ok := newObj.(*rbacv1.RoleBinding)
+		return !ok || !apiequality.Semantic.DeepEqual(oldObj.Subjects, newObj.Subjects)
+	case *rbacv1.ClusterRoleBinding:
+		newObj, ok := newObj.(*rbacv1.ClusterRoleBinding)
+		return !ok || !apiequality.Semantic.DeepEqual(oldObj.Subjects, newObj.Subjects)
+	default:
+		return true
+	}
+}
+
+// rbacGrantsUse returns true if the role, or the role referenced by the
+// binding, grants the use of any CertificateRequestPolicy. If the referenced
+// role cannot be read, the binding is assumed to grant use so that no change
+// is missed.
+func rbacGrantsUse(ctx context.Context, log logr.Logger, lister client.Reader, obj client.Object) bool {
+	var (
+		namespace string
+		roleRef   rbacv1.RoleRef
+	)
+
+	switch obj := obj.(type) {
+	case *rbacv1.Role:
+		return rulesGrantUse(obj.Rules)
+	case *rbacv1.ClusterRole:
+		return rulesGrantUse(obj.Rules)
+	case *rbacv1.RoleBinding:
+		namespace, roleRef = obj.Namespace, obj.RoleRef
+	case *rbacv1.ClusterRoleBinding:
+		roleRef = obj.RoleRef
+	default:
+		return true
+	}
+
+	var role client.Object
+	switch roleRef.Kind {
+	case "Role":
+		role = &rbacv1.Role{}
+	case "ClusterRole":
+		role, namespace = &rbacv1.ClusterRole{}, ""
+	default:
+		return false
+	}
+
+	if err := lister.Get(ctx, client.ObjectKey{Namespace: namespace, Name: roleRef.Name}, role); err != nil {
+		// A binding to a role which does not exist grants nothing. The role
+		// being created is itself a relevant change.
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		log.Error(err, "failed to get role referenced by binding, treating change as relevant", "kind", roleRef.Kind, "namespace", namespace, "name", roleRef.Name)
+		return true
+	}
+
+	return rbacGrantsUse(ctx, log, lister, role)
+}
+
+func rulesGrantUse(rules []rbacv1.PolicyRule) bool {
+	return len(useRules(rules)) > 0
+}
+
+// rbacKind returns the kind label value of the RBAC object.
+func rbacKind(obj client.Object) string {
+	switch obj.(type) {
+	case *rbacv1.Role:
+		return "Role"
+	case *rbacv1.ClusterRole:
+		return "ClusterRole"
+	case *rbacv1.RoleBinding:
+		return "RoleBinding"
+	case *rbacv1.ClusterRoleBinding:
+		return "ClusterRoleBinding"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+func Test_rbacBindingChanged(t *testing.T) {
+	useRule := rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}}
+	otherRule := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}
+	subject := rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}
+
+	clusterRole := func(name string, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name}, Rules: rules}
+	}
+	roleBinding := func(roleKind, roleName string, subjects ...rbacv1.Subject) *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "binding"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: roleKind, Name: roleName},
+			Subjects:   subjects,
+		}
+	}
+
+	lister := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithObjects(
+			clusterRole("use-policies", useRule),
+			clusterRole("read-secrets", otherRule),
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "use-policies"}, Rules: []rbacv1.PolicyRule{useRule}},
+		).
+		Build()
+
+	tests := map[string]struct {
+		oldObj, newObj client.Object
+		expRelevant    bool
+	}{
+		"created role granting use should be relevant": {
+			newObj:      clusterRole("new", useRule),
+			expRelevant: true,
+		},
+		"created role not granting use should be skipped": {
+			newObj:      clusterRole("new", otherRule),
+			expRelevant: false,
+		},
+		"deleted role granting use should be relevant": {
+			oldObj:      clusterRole("old", useRule),
+			expRelevant: true,
+		},
+		"role which no longer grants use should be relevant": {
+			oldObj:      clusterRole("role", useRule),
+			newObj:      clusterRole("role", otherRule),
+			expRelevant: true,
+		},
+		"role updated without changing rules should be skipped": {
+			oldObj: clusterRole("role", useRule),
+			newObj: func() client.Object {
+				role := clusterRole("role", useRule)
+				role.Labels = map[string]string{"foo": "bar"}
+				return role
+			}(),
+			expRelevant: false,
+		},
+		"created binding to cluster role granting use should be relevant": {
+			newObj:      roleBinding("ClusterRole", "use-policies", subject),
+			expRelevant: true,
+		},
+		"created binding to role granting use should be relevant": {
+			newObj:      roleBinding("Role", "use-policies", subject),
+			expRelevant: true,
+		},
+		"created binding to role not granting use should be skipped": {
+			newObj:      roleBinding("ClusterRole", "read-secrets", subject),
+			expRelevant: false,
+		},
+		"created binding to role which does not exist should be skipped": {
+			newObj:      roleBinding("ClusterRole", "does-not-exist", subject),
+			expRelevant: false,
+		},
+		"binding with changed subjects should be relevant": {
+			oldObj:      roleBinding("ClusterRole", "use-policies", subject),
+			newObj:      roleBinding("ClusterRole", "use-policies", subject, rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "team-a"}),
+			expRelevant: true,
+		},
+		"binding with unchanged subjects should be skipped": {
+			oldObj:      roleBinding("ClusterRole", "use-policies", subject),
+			newObj:      roleBinding("ClusterRole", "use-policies", subject),
+			expRelevant: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			metrics.RBACChanges.Reset()
+
+			p := rbacBindingChanged(context.TODO(), logr.Discard(), lister)
+
+			var result bool
+			var obj client.Object
+			switch {
+			case test.oldObj == nil:
+				obj, result = test.newObj, p.Create(event.CreateEvent{Object: test.newObj})
+			case test.newObj == nil:
+				obj, result = test.oldObj, p.Delete(event.DeleteEvent{Object: test.oldObj})
+			default:
+				obj, result = test.newObj, p.Update(event.UpdateEvent{ObjectOld: test.oldObj, ObjectNew: test.newObj})
+			}
+			assert.Equal(t, test.expRelevant, result)
+
+			expRelevant, expSkipped := 0.0, 1.0
+			if test.expRelevant {
+				expRelevant, expSkipped = 1, 0
+			}
+			assert.Equal(t, expRelevant, testutil.ToFloat64(metrics.RBACChanges.WithLabelValues(rbacKind(obj), metrics.RBACChangeRelevant)))
+			assert.Equal(t, expSkipped, testutil.ToFloat64(metrics.RBACChanges.WithLabelValues(rbacKind(obj), metrics.RBACChangeSkipped)))
+		})
+	}
+}
+
+func Test_rbacCacheTransform(t *testing.T) {
+	useRule := rbacv1.PolicyRule{APIGroups: []string{"policy.cert-manager.io"}, Resources: []string{"certificaterequestpolicies"}, Verbs: []string{"use"}}
+	otherRule := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}
+	meta := func(namespace, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Namespace:     namespace,
+			Name:          name,
+			Labels:        map[string]string{"foo": "bar"},
+			Annotations:   map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		}
+	}
+	strippedMeta := func(namespace, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"foo": "bar"}}
+	}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "use-policies"}
+
+	tests := map[string]struct {
+		obj, expObj any
+	}{
+		"role should keep only rules granting use": {
+			obj:    &rbacv1.Role{ObjectMeta: meta("team-a", "role"), Rules: []rbacv1.PolicyRule{otherRule, useRule}},
+			expObj: &rbacv1.Role{ObjectMeta: strippedMeta("team-a", "role"), Rules: []rbacv1.PolicyRule{useRule}},
+		},
+		"cluster role should keep only rules granting use": {
+			obj: &rbacv1.ClusterRole{
+				ObjectMeta:      meta("", "role"),
+				Rules:           []rbacv1.PolicyRule{otherRule},
+				AggregationRule: &rbacv1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"foo": "bar"}}}},
+			},
+			expObj: &rbacv1.ClusterRole{ObjectMeta: strippedMeta("", "role")},
+		},
+		"role binding should keep its role and subjects": {
+			obj:    &rbacv1.RoleBinding{ObjectMeta: meta("team-a", "binding"), RoleRef: roleRef, Subjects: subjects},
+			expObj: &rbacv1.RoleBinding{ObjectMeta: strippedMeta("team-a", "binding"), RoleRef: roleRef, Subjects: subjects},
+		},
+		"cluster role binding should keep its role and subjects": {
+			obj:    &rbacv1.ClusterRoleBinding{ObjectMeta: meta("", "binding"), RoleRef: roleRef, Subjects: subjects},
+			expObj: &rbacv1.ClusterRoleBinding{ObjectMeta: strippedMeta("", "binding"), RoleRef: roleRef, Subjects: subjects},
+		},
+		"other objects should be unchanged": {
+			obj:    "tombstone",
+			expObj: "tombstone",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj, err := rbacCacheTransform(test.obj)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expObj, obj)
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/klog/v2/ktesting"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -206,6 +207,9 @@ func startControllers(registry *registry.Registry) (context.Context, func(), cor
 
 	mgr, err := ctrl.NewManager(env.Config, ctrl.Options{
 		Scheme: policyapi.GlobalScheme,
+		Cache: cache.Options{
+			ByObject: controllers.CacheByObject(),
+		},
 		Metrics: server.Options{
 			BindAddress: "0",
 		},
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package convert checks that live CertificateRequestPolicies survive
+// conversion to another API version without loss, so that lossy or failing
+// conversions can be found before an upgrade is attempted.
+package convert
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/diff"
+)
+
+// Converter converts CertificateRequestPolicies between v1alpha1 and another
+// API version.
+type Converter interface {
+	// Version is the API version that the Converter converts to and from.
+	Version() string
+
+	// ConvertTo converts the v1alpha1 policy to the Converter's version.
+	ConvertTo(*policyapi.CertificateRequestPolicy) (runtime.Object, error)
+
+	// ConvertFrom converts a policy of the Converter's version back to
+	// v1alpha1.
+	ConvertFrom(runtime.Object) (*policyapi.CertificateRequestPolicy, error)
+}
+
+// converters are the registered Converters, keyed by version. A Converter for
+// a new API version is registered here alongside its conversion functions.
+var converters = map[string]Converter{
+	policyapi.SchemeGroupVersion.Version: identity{},
+}
+
+// Versions returns the versions which have a registered Converter.
+func Versions() []string {
+	var versions []string
+	for version := range converters {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Lookup returns the registered Converter for the given version.
+func Lookup(version string) (Converter, error) {
+	converter, ok := converters[version]
+	if !ok {
+		return nil, fmt.Errorf("no conversion to version %q is registered, must be one of %q", version, Versions())
+	}
+	return converter, nil
+}
+
+// Result is the result of checking the conversion of a policy.
+type Result struct {
+	// Policy is the name of the policy.
+	Policy string `json:"policy"`
+
+	// Error is set if the policy failed to convert.
+	Error string `json:"error,omitempty"`
+
+	// LostFields are the spec fields whose value did not survive conversion
+	// to the target version and back.
+	LostFields []string `json:"lostFields,omitempty"`
+}
+
+// OK returns true if the policy converted without failing or losing fields.
+func (r Result) OK() bool {
+	return len(r.Error) == 0 && len(r.LostFields) == 0
+}
+
+// Check converts the live policy to the Converter's version and back,
+// reporting any failure or spec fields which were lost. The policy is given as
+// it is stored, so that fields unknown to this version of approver-policy are
+// also reported as lost.
+func Check(obj *unstructured.Unstructured, converter Converter) Result {
+	result := Result{Policy: obj.GetName()}
+
+	var policy policyapi.CertificateRequestPolicy
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &policy); err != nil {
+		result.Error = fmt.Sprintf("failed to decode policy: %s", err)
+		return result
+	}
+
+	converted, err := converter.ConvertTo(&policy)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to convert to %s: %s", converter.Version(), err)
+		return result
+	}
+
+	roundTripped, err := converter.ConvertFrom(converted)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to convert back from %s: %s", converter.Version(), err)
+		return result
+	}
+
+	stored, err := diff.Flatten(obj.Object["spec"])
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to flatten stored policy spec: %s", err)
+		return result
+	}
+	decoded, err := diff.Flatten(policy.Spec)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to flatten policy spec: %s", err)
+		return result
+	}
+	after, err := diff.Flatten(roundTripped.Spec)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to flatten converted policy spec: %s", err)
+		return result
+	}
+
+	lost := make(map[string]struct{})
+
+	// Fields of the stored policy which are unknown to this version are
+	// dropped when decoded. Values are not compared here, since decoding
+	// normalises some values, such as durations.
+	for path := range stored {
+		if _, ok := decoded[path]; !ok {
+			lost["spec"+path] = struct{}{}
+		}
+	}
+
+	for path, value := range decoded {
+		if after[path] != value {
+			lost["spec"+path] = struct{}{}
+		}
+	}
+
+	for path := range lost {
+		result.LostFields = append(result.LostFields, path)
+	}
+	sort.Strings(result.LostFields)
+
+	return result
+}
+
+// identity is the Converter for v1alpha1 itself. Checking it verifies that
+// the stored policies are fully understood by this version of
+// approver-policy.
+type identity struct{}
+
+func (identity) Version() string {
+	return policyapi.SchemeGroupVersion.Version
+}
+
+func (identity) ConvertTo(policy *policyapi.CertificateRequestPolicy) (runtime.Object, error) {
+	return policy.DeepCopy(), nil
+}
+
+func (identity) ConvertFrom(obj runtime.Object) (*policyapi.CertificateRequestPolicy, error) {
+	policy, ok := obj.(*policyapi.CertificateRequestPolicy)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	return policy, nil
+}
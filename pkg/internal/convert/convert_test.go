@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package convert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// fakeConverter is a Converter which applies a mutation to policies when
+// converting.
+type fakeConverter struct {
+	identity
+	mutate func(*policyapi.CertificateRequestPolicy) error
+}
+
+func (f fakeConverter) ConvertTo(policy *policyapi.CertificateRequestPolicy) (runtime.Object, error) {
+	policy = policy.DeepCopy()
+	return policy, f.mutate(policy)
+}
+
+func Test_Check(t *testing.T) {
+	storedPolicy := func(spec map[string]any) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "policy.cert-manager.io/v1alpha1",
+			"kind":       "CertificateRequestPolicy",
+			"metadata":   map[string]any{"name": "test-policy"},
+			"spec":       spec,
+		}}
+	}
+
+	spec := map[string]any{
+		"allowed": map[string]any{
+			"dnsNames": map[string]any{"values": []any{"*.example.com"}},
+		},
+		"constraints": map[string]any{"maxDuration": "1h"},
+		"selector":    map[string]any{"issuerRef": map[string]any{}},
+	}
+
+	tests := map[string]struct {
+		obj       *unstructured.Unstructured
+		converter Converter
+		expResult Result
+	}{
+		"a policy fully understood by v1alpha1 should convert without loss": {
+			obj:       storedPolicy(spec),
+			converter: identity{},
+			expResult: Result{Policy: "test-policy"},
+		},
+		"a policy with fields unknown to this version should report them as lost": {
+			obj: storedPolicy(map[string]any{
+				"selector":    map[string]any{"issuerRef": map[string]any{}},
+				"futureField": map[string]any{"enabled": true},
+			}),
+			converter: identity{},
+			expResult: Result{Policy: "test-policy", LostFields: []string{"spec.futureField.enabled"}},
+		},
+		"a conversion dropping a field should report it as lost": {
+			obj: storedPolicy(spec),
+			converter: fakeConverter{mutate: func(policy *policyapi.CertificateRequestPolicy) error {
+				policy.Spec.Constraints = nil
+				return nil
+			}},
+			expResult: Result{Policy: "test-policy", LostFields: []string{"spec.constraints.maxDuration"}},
+		},
+		"a failing conversion should report the error": {
+			obj: storedPolicy(spec),
+			converter: fakeConverter{mutate: func(*policyapi.CertificateRequestPolicy) error {
+				return errors.New("this is an error")
+			}},
+			expResult: Result{Policy: "test-policy", Error: "failed to convert to v1alpha1: this is an error"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := Check(test.obj, test.converter)
+			assert.Equal(t, test.expResult, result)
+			assert.Equal(t, len(test.expResult.Error) == 0 && len(test.expResult.LostFields) == 0, result.OK())
+		})
+	}
+}
+
+func Test_Lookup(t *testing.T) {
+	converter, err := Lookup("v1alpha1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1alpha1", converter.Version())
+
+	_, err = Lookup("v2")
+	assert.EqualError(t, err, `no conversion to version "v2" is registered, must be one of ["v1alpha1"]`)
+}
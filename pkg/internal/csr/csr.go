@@ -0,0 +1,315 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"crypto/x509"
+	encodingasn1 "encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// MaxRequestBytes is the maximum size in bytes of the PEM encoded request
+	// that will be parsed. Requests larger than this are rejected before any
+	// ASN.1 parsing takes place.
+	MaxRequestBytes = 128 * 1024
+
+	// MaxExtensions is the maximum number of X.509 extensions that may be
+	// present in a request.
+	MaxExtensions = 64
+
+	// MaxSubjectAlternativeNames is the maximum number of Subject Alternative
+	// Names (DNS, IP, URI and email combined) that may be present in a
+	// request.
+	MaxSubjectAlternativeNames = 512
+
+	// MaxSubjectRDNs is the maximum number of attributes that may be present
+	// in the Subject of a request.
+	MaxSubjectRDNs = 64
+)
+
+// LimitError is returned when a request exceeds one or more of the hard
+// limits enforced during parsing. LimitError is a policy violation rather
+// than an internal error, and consumers should deny the request with the
+// message instead of retrying.
+type LimitError struct {
+	// Errors is the list of limits that were exceeded.
+	Errors field.ErrorList
+}
+
+func (e *LimitError) Error() string {
+	return e.Errors.ToAggregate().Error()
+}
+
+// Problem is a structural problem of a request, which means that the request
+// can never be signed regardless of policy.
+type Problem string
+
+const (
+	// ProblemEmptyRequest is a request with no CSR.
+	ProblemEmptyRequest Problem = "EmptyRequest"
+
+	// ProblemInvalidPEM is a request whose CSR is not PEM encoded.
+	ProblemInvalidPEM Problem = "InvalidPEM"
+
+	// ProblemInvalidRequest is a request whose PEM block is not a valid X.509
+	// certificate request.
+	ProblemInvalidRequest Problem = "InvalidRequest"
+
+	// ProblemKeyMismatch is a request which is not signed by the private key
+	// of the public key it contains.
+	ProblemKeyMismatch Problem = "KeyMismatch"
+
+	// ProblemUnsupportedAlgorithm is a request whose signature algorithm is
+	// insecure or unsupported, so its signature cannot be verified.
+	ProblemUnsupportedAlgorithm Problem = "UnsupportedAlgorithm"
+
+	// ProblemInvalidEncoding is a request which the RequestDecoder of its
+	// issuer group failed to decode.
+	ProblemInvalidEncoding Problem = "InvalidEncoding"
+)
+
+// StructuralError is returned when a request is structurally invalid.
+// StructuralError is neither a policy violation nor an internal error, since
+// retrying will never succeed.
+type StructuralError struct {
+	// Problem is the structural problem of the request.
+	Problem Problem
+
+	// Err describes the problem.
+	Err error
+}
+
+func (e *StructuralError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StructuralError) Unwrap() error {
+	return e.Err
+}
+
+// CheckStructure returns a *StructuralError if the request is not a PEM
+// encoded X.509 certificate request signed with the private key of its public
+// key. Requests exceeding the limits of Decode are not structurally invalid.
+func CheckStructure(request []byte) error {
+	if len(request) == 0 {
+		return &StructuralError{Problem: ProblemEmptyRequest, Err: errors.New("request does not contain a certificate request")}
+	}
+	if len(request) > MaxRequestBytes {
+		return nil
+	}
+
+	csr, err := parse(request)
+	if err != nil {
+		return err
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		var insecureErr x509.InsecureAlgorithmError
+		if errors.As(err, &insecureErr) || errors.Is(err, x509.ErrUnsupportedAlgorithm) {
+			return &StructuralError{Problem: ProblemUnsupportedAlgorithm, Err: fmt.Errorf("certificate request signature cannot be verified: %w", err)}
+		}
+		return &StructuralError{Problem: ProblemKeyMismatch, Err: fmt.Errorf("certificate request signature does not match its public key: %w", err)}
+	}
+
+	return nil
+}
+
+// parse parses the PEM encoded X.509 certificate request, returning a
+// *StructuralError if it cannot be parsed.
+func parse(request []byte) (*x509.CertificateRequest, error) {
+	der, err := decodePEM(request)
+	if err != nil {
+		return nil, err
+	}
+	return parseDER(der)
+}
+
+// decodePEM returns the DER of the PEM encoded request, or a
+// *StructuralError if it is not PEM encoded.
+func decodePEM(request []byte) ([]byte, error) {
+	block, _ := pem.Decode(request)
+	if block == nil {
+		return nil, &StructuralError{Problem: ProblemInvalidPEM, Err: errors.New("error decoding certificate request PEM block")}
+	}
+	return block.Bytes, nil
+}
+
+// parseDER parses the DER encoded X.509 certificate request, returning a
+// *StructuralError if it cannot be parsed.
+func parseDER(der []byte) (*x509.CertificateRequest, error) {
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, &StructuralError{Problem: ProblemInvalidRequest, Err: fmt.Errorf("error parsing certificate request: %w", err)}
+	}
+	return csr, nil
+}
+
+// Decode decodes the PEM encoded X.509 certificate request, enforcing hard
+// limits on its size and contents. If the request exceeds any limit, a
+// *LimitError is returned which describes every limit that was exceeded.
+// The limits are enforced by counting the elements of the DER encoding before
+// the request is parsed, so the parser never allocates the contents of a
+// request which exceeds them.
+func Decode(request []byte) (*x509.CertificateRequest, error) {
+	fldPath := field.NewPath("spec", "request")
+
+	if len(request) > MaxRequestBytes {
+		return nil, &LimitError{Errors: field.ErrorList{
+			field.TooLong(fldPath, "", MaxRequestBytes),
+		}}
+	}
+
+	der, err := decodePEM(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// A request whose elements cannot be counted is malformed, which parsing
+	// reports as a structural problem.
+	if c, ok := countElements(der); ok {
+		var el field.ErrorList
+
+		if c.extensions > MaxExtensions {
+			el = append(el, field.TooMany(fldPath.Child("extensions"), c.extensions, MaxExtensions))
+		}
+
+		if c.subjectAltNames > MaxSubjectAlternativeNames {
+			el = append(el, field.TooMany(fldPath.Child("subjectAltNames"), c.subjectAltNames, MaxSubjectAlternativeNames))
+		}
+
+		if c.subjectAttributes > MaxSubjectRDNs {
+			el = append(el, field.TooMany(fldPath.Child("subject"), c.subjectAttributes, MaxSubjectRDNs))
+		}
+
+		if len(el) > 0 {
+			return nil, &LimitError{Errors: el}
+		}
+	}
+
+	return parseDER(der)
+}
+
+var (
+	oidExtensionRequest = encodingasn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
+	oidSubjectAltName   = encodingasn1.ObjectIdentifier{2, 5, 29, 17}
+)
+
+// elementCounts are the number of elements of a request which are limited by
+// Decode.
+type elementCounts struct {
+	extensions        int
+	subjectAltNames   int
+	subjectAttributes int
+}
+
+// countElements counts the extensions, Subject Alternative Names and subject
+// attributes of the DER encoded request, in the same way as the fields of
+// x509.CertificateRequest they are parsed into. Only DNS, IP, URI and email
+// names are counted, which are the names the parser keeps. ok is false if the
+// DER is not a well formed certificate request.
+func countElements(der []byte) (c elementCounts, ok bool) {
+	input := cryptobyte.String(der)
+
+	var request, info, subject cryptobyte.String
+	if !input.ReadASN1(&request, asn1.SEQUENCE) ||
+		!request.ReadASN1(&info, asn1.SEQUENCE) ||
+		!info.SkipASN1(asn1.INTEGER) ||
+		!info.ReadASN1(&subject, asn1.SEQUENCE) ||
+		!info.SkipASN1(asn1.SEQUENCE) {
+		return c, false
+	}
+
+	for !subject.Empty() {
+		var rdn cryptobyte.String
+		if !subject.ReadASN1(&rdn, asn1.SET) {
+			return c, false
+		}
+		for !rdn.Empty() {
+			if !rdn.SkipASN1(asn1.SEQUENCE) {
+				return c, false
+			}
+			c.subjectAttributes++
+		}
+	}
+
+	var attributes cryptobyte.String
+	if !info.ReadASN1(&attributes, asn1.Tag(0).Constructed().ContextSpecific()) {
+		return c, false
+	}
+
+	for !attributes.Empty() {
+		var attribute, values cryptobyte.String
+		var oid encodingasn1.ObjectIdentifier
+		if !attributes.ReadASN1(&attribute, asn1.SEQUENCE) ||
+			!attribute.ReadASN1ObjectIdentifier(&oid) ||
+			!attribute.ReadASN1(&values, asn1.SET) {
+			return c, false
+		}
+		if !oid.Equal(oidExtensionRequest) {
+			continue
+		}
+
+		for !values.Empty() {
+			var extensions cryptobyte.String
+			if !values.ReadASN1(&extensions, asn1.SEQUENCE) {
+				return c, false
+			}
+			for !extensions.Empty() {
+				var extension cryptobyte.String
+				var id encodingasn1.ObjectIdentifier
+				if !extensions.ReadASN1(&extension, asn1.SEQUENCE) ||
+					!extension.ReadASN1ObjectIdentifier(&id) {
+					return c, false
+				}
+				c.extensions++
+				if !id.Equal(oidSubjectAltName) {
+					continue
+				}
+
+				var value, names cryptobyte.String
+				if !extension.SkipOptionalASN1(asn1.BOOLEAN) ||
+					!extension.ReadASN1(&value, asn1.OCTET_STRING) ||
+					!value.ReadASN1(&names, asn1.SEQUENCE) {
+					return c, false
+				}
+				for !names.Empty() {
+					var name cryptobyte.String
+					var tag asn1.Tag
+					if !names.ReadAnyASN1(&name, &tag) {
+						return c, false
+					}
+					switch tag {
+					case asn1.Tag(1).ContextSpecific(), // rfc822Name
+						asn1.Tag(2).ContextSpecific(), // dNSName
+						asn1.Tag(6).ContextSpecific(), // uniformResourceIdentifier
+						asn1.Tag(7).ContextSpecific(): // iPAddress
+						c.subjectAltNames++
+					}
+				}
+			}
+		}
+	}
+
+	return c, true
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func Test_Decode(t *testing.T) {
+	fldPath := field.NewPath("spec", "request")
+
+	tests := map[string]struct {
+		request     []byte
+		expLimitErr field.ErrorList
+		expErr      bool
+	}{
+		"a request with no PEM block should error": {
+			request: []byte("not a certificate request"),
+			expErr:  true,
+		},
+		"a request with a PEM block containing garbage should error": {
+			request: []byte("-----BEGIN CERTIFICATE REQUEST-----\nZm9vCg==\n-----END CERTIFICATE REQUEST-----\n"),
+			expErr:  true,
+		},
+		"a request larger than the maximum size should return a limit error": {
+			request:     bytes.Repeat([]byte("a"), MaxRequestBytes+1),
+			expLimitErr: field.ErrorList{field.TooLong(fldPath, "", MaxRequestBytes)},
+		},
+		"a request within all limits should decode": {
+			request: csrFrom(t, gen.SetCSRDNSNames("example.com"), gen.SetCSRCommonName("example.com")),
+		},
+		"a request with too many SANs should return a limit error": {
+			request: csrFrom(t,
+				gen.SetCSRDNSNames(names("dns-%d.example.com", MaxSubjectAlternativeNames-1)...),
+				gen.SetCSRURIsFromStrings("spiffe://example.com/a", "spiffe://example.com/b"),
+			),
+			expLimitErr: field.ErrorList{field.TooMany(fldPath.Child("subjectAltNames"), MaxSubjectAlternativeNames+1, MaxSubjectAlternativeNames)},
+		},
+		"a request with too many extensions should return a limit error": {
+			request:     csrFrom(t, setExtraExtensions(MaxExtensions+1)),
+			expLimitErr: field.ErrorList{field.TooMany(fldPath.Child("extensions"), MaxExtensions+1, MaxExtensions)},
+		},
+		"a request with too many subject attributes should return a limit error": {
+			request:     csrFrom(t, setSubjectOrganizations(MaxSubjectRDNs+1)),
+			expLimitErr: field.ErrorList{field.TooMany(fldPath.Child("subject"), MaxSubjectRDNs+1, MaxSubjectRDNs)},
+		},
+		"a request exceeding multiple limits should return all limit errors": {
+			request: csrFrom(t, setExtraExtensions(MaxExtensions+1), setSubjectOrganizations(MaxSubjectRDNs+1)),
+			expLimitErr: field.ErrorList{
+				field.TooMany(fldPath.Child("extensions"), MaxExtensions+1, MaxExtensions),
+				field.TooMany(fldPath.Child("subject"), MaxSubjectRDNs+1, MaxSubjectRDNs),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			csr, err := Decode(test.request)
+
+			var limitErr *LimitError
+			if test.expLimitErr != nil {
+				if !errors.As(err, &limitErr) {
+					t.Fatalf("expected limit error, got: %v", err)
+				}
+				assert.Equal(t, test.expLimitErr, limitErr.Errors)
+				assert.Nil(t, csr)
+				return
+			}
+
+			assert.False(t, errors.As(err, &limitErr), "unexpected limit error: %v", err)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expErr, csr == nil)
+		})
+	}
+}
+
+func Test_CheckStructure(t *testing.T) {
+	valid := csrFrom(t, gen.SetCSRDNSNames("example.com"))
+
+	// Corrupt the last byte of the DER, which is part of the signature, so
+	// that the request is no longer signed by its own key.
+	block, _ := pem.Decode(valid)
+	der := bytes.Clone(block.Bytes)
+	der[len(der)-1] ^= 0xff
+	mismatched := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+
+	// MD5 signatures cannot be created, so the signature algorithm of a valid
+	// request is replaced instead.
+	md5WithRSA := withSignatureAlgorithm(t, valid, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 4})
+	unknownAlgorithm := withSignatureAlgorithm(t, valid, asn1.ObjectIdentifier{1, 2, 3, 4})
+
+	tests := map[string]struct {
+		request    []byte
+		expProblem Problem
+	}{
+		"a valid request should have no problem": {
+			request: valid,
+		},
+		"an empty request should be an empty request": {
+			request:    nil,
+			expProblem: ProblemEmptyRequest,
+		},
+		"a request with no PEM block should be invalid PEM": {
+			request:    []byte("not a certificate request"),
+			expProblem: ProblemInvalidPEM,
+		},
+		"a request with a PEM block containing garbage should be an invalid request": {
+			request:    []byte("-----BEGIN CERTIFICATE REQUEST-----\nZm9vCg==\n-----END CERTIFICATE REQUEST-----\n"),
+			expProblem: ProblemInvalidRequest,
+		},
+		"a request with a signature not matching its key should be a key mismatch": {
+			request:    mismatched,
+			expProblem: ProblemKeyMismatch,
+		},
+		"a request signed with an insecure algorithm should be an unsupported algorithm": {
+			request:    md5WithRSA,
+			expProblem: ProblemUnsupportedAlgorithm,
+		},
+		"a request signed with an unknown algorithm should be an unsupported algorithm": {
+			request:    unknownAlgorithm,
+			expProblem: ProblemUnsupportedAlgorithm,
+		},
+		"a request larger than the maximum size should not be a structural problem": {
+			request: bytes.Repeat([]byte("a"), MaxRequestBytes+1),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckStructure(test.request)
+			if len(test.expProblem) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var structuralErr *StructuralError
+			if !errors.As(err, &structuralErr) {
+				t.Fatalf("expected structural error, got: %v", err)
+			}
+			assert.Equal(t, test.expProblem, structuralErr.Problem)
+		})
+	}
+}
+
+// withSignatureAlgorithm returns the PEM encoded request with its signature
+// algorithm replaced by the given algorithm.
+func withSignatureAlgorithm(t *testing.T, request []byte, algorithm asn1.ObjectIdentifier) []byte {
+	block, _ := pem.Decode(request)
+	require.NotNil(t, block)
+
+	var csr struct {
+		Info      asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}
+	_, err := asn1.Unmarshal(block.Bytes, &csr)
+	require.NoError(t, err)
+
+	csr.Algorithm = pkix.AlgorithmIdentifier{Algorithm: algorithm, Parameters: asn1.NullRawValue}
+	der, err := asn1.Marshal(csr)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+// FuzzDecode ensures that Decode never panics on arbitrary input, and that
+// any request that is successfully decoded is within all limits.
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(""))
+	f.Add([]byte("-----BEGIN CERTIFICATE REQUEST-----\n-----END CERTIFICATE REQUEST-----\n"))
+	f.Add(fuzzCSRFrom(f))
+	f.Add(fuzzCSRFrom(f, gen.SetCSRDNSNames("example.com", "*.example.com"), gen.SetCSRIPAddressesFromStrings("10.0.0.1", "::1")))
+	f.Add(fuzzCSRFrom(f, gen.SetCSRURIsFromStrings("spiffe://example.com/ns/default/sa/default"), gen.SetCSREmails([]string{"foo@example.com"})))
+	f.Add(fuzzCSRFrom(f, setExtraExtensions(4), setSubjectOrganizations(4)))
+
+	f.Fuzz(func(t *testing.T, request []byte) {
+		csr, err := Decode(request)
+		if err != nil {
+			if csr != nil {
+				t.Fatalf("expected nil request on error: %v", err)
+			}
+			return
+		}
+
+		if n := len(csr.Extensions); n > MaxExtensions {
+			t.Fatalf("decoded request with %d extensions", n)
+		}
+		if n := len(csr.DNSNames) + len(csr.IPAddresses) + len(csr.URIs) + len(csr.EmailAddresses); n > MaxSubjectAlternativeNames {
+			t.Fatalf("decoded request with %d subject alternative names", n)
+		}
+		if n := len(csr.Subject.Names); n > MaxSubjectRDNs {
+			t.Fatalf("decoded request with %d subject attributes", n)
+		}
+	})
+}
+
+func csrFrom(t *testing.T, mods ...gen.CSRModifier) []byte {
+	csr, _, err := gen.CSR(x509.ECDSA, mods...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func fuzzCSRFrom(f *testing.F, mods ...gen.CSRModifier) []byte {
+	csr, _, err := gen.CSR(x509.ECDSA, mods...)
+	if err != nil {
+		f.Fatal(err)
+	}
+	return csr
+}
+
+func names(format string, n int) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		names = append(names, fmt.Sprintf(format, i))
+	}
+	return names
+}
+
+func setExtraExtensions(n int) gen.CSRModifier {
+	return func(cr *x509.CertificateRequest) error {
+		for i := 0; i < n; i++ {
+			cr.ExtraExtensions = append(cr.ExtraExtensions, pkix.Extension{
+				// Private enterprise arc used for testing only.
+				Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, i + 1},
+				Value: []byte{0x05, 0x00},
+			})
+		}
+		return nil
+	}
+}
+
+func setSubjectOrganizations(n int) gen.CSRModifier {
+	return func(cr *x509.CertificateRequest) error {
+		cr.Subject.Organization = names("org-%d", n)
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"encoding/pem"
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver"
+)
+
+// Decoders are the RequestDecoders of the issuer groups whose requests are
+// encoded differently from PEM.
+type Decoders []approver.RequestDecoder
+
+// NewDecoders returns the Decoders, returning an error if a RequestDecoder
+// names no issuer groups, or an issuer group is decoded by more than one.
+func NewDecoders(decoders []approver.RequestDecoder) (Decoders, error) {
+	groups := make(map[string]string)
+	for _, decoder := range decoders {
+		scope := decoder.Scope()
+		if len(scope.IssuerGroups) == 0 {
+			return nil, fmt.Errorf("request decoder %q must be scoped to at least one issuer group", decoder.Name())
+		}
+		for _, group := range scope.IssuerGroups {
+			if existing, ok := groups[group]; ok {
+				return nil, fmt.Errorf("issuer group %q is decoded by both request decoders %q and %q", group, existing, decoder.Name())
+			}
+			groups[group] = decoder.Name()
+		}
+	}
+	return decoders, nil
+}
+
+// Normalize replaces the `spec.request` of the request with the PEM encoded
+// X.509 certificate request decoded by the RequestDecoder of its issuer
+// group. Requests which are already PEM encoded, have no RequestDecoder, or
+// exceed MaxRequestBytes are left unchanged, to be checked as they are. An
+// empty `spec.request` is passed to the RequestDecoder, since issuers may
+// carry the request elsewhere. A *StructuralError is returned if the request
+// cannot be decoded.
+func (d Decoders) Normalize(cr *cmapi.CertificateRequest) error {
+	if len(cr.Spec.Request) > MaxRequestBytes {
+		return nil
+	}
+	if block, _ := pem.Decode(cr.Spec.Request); block != nil {
+		return nil
+	}
+
+	for _, decoder := range d {
+		if !decoder.Scope().IncludesIssuerGroup(cr.Spec.IssuerRef.Group) {
+			continue
+		}
+
+		request, err := decoder.Decode(cr)
+		if err != nil {
+			return &StructuralError{Problem: ProblemInvalidEncoding, Err: fmt.Errorf("request decoder %q failed to decode request: %w", decoder.Name(), err)}
+		}
+		cr.Spec.Request = request
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csr
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	"github.com/cert-manager/approver-policy/pkg/approver/fake"
+)
+
+func Test_NewDecoders(t *testing.T) {
+	decoder := func(name string, groups ...string) approver.RequestDecoder {
+		return fake.NewFakeRequestDecoder().WithName(name).WithScope(approver.Scope{IssuerGroups: groups})
+	}
+
+	tests := map[string]struct {
+		decoders []approver.RequestDecoder
+		expErr   bool
+	}{
+		"no decoders should not error": {},
+		"decoders of distinct issuer groups should not error": {
+			decoders: []approver.RequestDecoder{decoder("a", "acme.example.com"), decoder("b", "jose.example.com", "other.example.com")},
+		},
+		"a decoder scoped to no issuer groups should error": {
+			decoders: []approver.RequestDecoder{decoder("a")},
+			expErr:   true,
+		},
+		"decoders sharing an issuer group should error": {
+			decoders: []approver.RequestDecoder{decoder("a", "acme.example.com"), decoder("b", "jose.example.com", "acme.example.com")},
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewDecoders(test.decoders)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+		})
+	}
+}
+
+func Test_Decoders_Normalize(t *testing.T) {
+	csrPEM := csrFrom(t, gen.SetCSRDNSNames("example.com"))
+	block, _ := pem.Decode(csrPEM)
+	require.NotNil(t, block)
+	encoded := []byte(base64.RawURLEncoding.EncodeToString(block.Bytes))
+
+	decoders, err := NewDecoders([]approver.RequestDecoder{
+		fake.NewFakeRequestDecoder().WithName("jose").
+			WithScope(approver.Scope{IssuerGroups: []string{"jose.example.com"}}).
+			WithDecode(func(cr *cmapi.CertificateRequest) ([]byte, error) {
+				der, err := base64.RawURLEncoding.DecodeString(string(cr.Spec.Request))
+				if err != nil {
+					return nil, err
+				}
+				return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+			}),
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		group      string
+		request    []byte
+		expRequest []byte
+		expProblem Problem
+	}{
+		"a PEM encoded request should not be decoded": {
+			group:      "jose.example.com",
+			request:    csrPEM,
+			expRequest: csrPEM,
+		},
+		"a request of an issuer group with no decoder should not be decoded": {
+			group:      "cert-manager.io",
+			request:    encoded,
+			expRequest: encoded,
+		},
+		"a request of an issuer group with a decoder should be decoded": {
+			group:      "jose.example.com",
+			request:    encoded,
+			expRequest: csrPEM,
+		},
+		"an empty request should be passed to the decoder": {
+			group:      "jose.example.com",
+			request:    []byte{},
+			expRequest: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte{}}),
+		},
+		"a request the decoder fails to decode should return an invalid encoding error": {
+			group:      "jose.example.com",
+			request:    []byte("not base64url!"),
+			expRequest: []byte("not base64url!"),
+			expProblem: ProblemInvalidEncoding,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := &cmapi.CertificateRequest{Spec: cmapi.CertificateRequestSpec{
+				IssuerRef: cmmeta.ObjectReference{Group: test.group},
+				Request:   test.request,
+			}}
+
+			err := decoders.Normalize(cr)
+			assert.Equal(t, test.expRequest, cr.Spec.Request)
+			if len(test.expProblem) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var structuralErr *StructuralError
+			require.True(t, errors.As(err, &structuralErr), "%v", err)
+			assert.Equal(t, test.expProblem, structuralErr.Problem)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+)
+
+// DifferenceType is the type of semantic difference between two clusters.
+type DifferenceType string
+
+const (
+	// DifferencePolicy is a CertificateRequestPolicy that only exists in one
+	// cluster.
+	DifferencePolicy DifferenceType = "Policy"
+
+	// DifferenceSpec is a field of a CertificateRequestPolicy spec which has a
+	// different value in each cluster.
+	DifferenceSpec DifferenceType = "Spec"
+
+	// DifferenceReady is a CertificateRequestPolicy which is Ready in one
+	// cluster but not the other. Policies which are not Ready are not used for
+	// approval.
+	DifferenceReady DifferenceType = "Ready"
+
+	// DifferenceBinding is a subject which is bound to a
+	// CertificateRequestPolicy in only one cluster.
+	DifferenceBinding DifferenceType = "Binding"
+)
+
+// Cluster is the effective policy set of a cluster.
+type Cluster struct {
+	// Name is the name used to identify the cluster in differences, typically
+	// the kubeconfig context.
+	Name string
+
+	// Policies are the CertificateRequestPolicies in the cluster.
+	Policies []policyapi.CertificateRequestPolicy
+
+	// Bindings are the subjects bound to CertificateRequestPolicies in the
+	// cluster.
+	Bindings []bindings.Binding
+}
+
+// Difference is a semantic difference between the policy sets of two
+// clusters, affecting what each would approve.
+type Difference struct {
+	// Type is the type of difference.
+	Type DifferenceType `json:"type"`
+
+	// Policy is the name of the CertificateRequestPolicy that differs.
+	Policy string `json:"policy"`
+
+	// Field is the field path, or binding, that differs.
+	Field string `json:"field,omitempty"`
+
+	// A and B are the values in each cluster. Empty values mean the field or
+	// policy is not present in that cluster.
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// Load loads the effective policy set of a cluster using the given reader.
+func Load(ctx context.Context, name string, reader client.Reader) (*Cluster, error) {
+	var policyList policyapi.CertificateRequestPolicyList
+	if err := reader.List(ctx, &policyList); err != nil {
+		return nil, fmt.Errorf("failed to list CertificateRequestPolicies: %w", err)
+	}
+
+	rbac, err := bindings.List(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, policy := range policyList.Items {
+		names = append(names, policy.Name)
+	}
+
+	return &Cluster{
+		Name:     name,
+		Policies: policyList.Items,
+		Bindings: rbac.Resolve(names),
+	}, nil
+}
+
+// Compare returns the semantic differences between the policy sets of
+// cluster a and b, sorted by policy name.
+func Compare(a, b *Cluster) ([]Difference, error) {
+	policiesA, policiesB := policyMap(a), policyMap(b)
+	bindingsA, bindingsB := bindingMap(a), bindingMap(b)
+
+	var diffs []Difference
+	for _, name := range unionKeys(policiesA, policiesB) {
+		policyA, okA := policiesA[name]
+		policyB, okB := policiesB[name]
+		if !okA || !okB {
+			diffs = append(diffs, Difference{Type: DifferencePolicy, Policy: name, A: present(okA), B: present(okB)})
+			continue
+		}
+
+		fieldsA, err := Flatten(policyA.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten policy %q of cluster %q: %w", name, a.Name, err)
+		}
+		fieldsB, err := Flatten(policyB.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten policy %q of cluster %q: %w", name, b.Name, err)
+		}
+		for _, path := range unionKeys(fieldsA, fieldsB) {
+			if fieldsA[path] != fieldsB[path] {
+				diffs = append(diffs, Difference{Type: DifferenceSpec, Policy: name, Field: "spec" + path, A: fieldsA[path], B: fieldsB[path]})
+			}
+		}
+
+		if readyA, readyB := ready(policyA), ready(policyB); readyA != readyB {
+			diffs = append(diffs, Difference{Type: DifferenceReady, Policy: name, A: readyA, B: readyB})
+		}
+
+		for _, binding := range unionKeys(bindingsA[name], bindingsB[name]) {
+			_, okA := bindingsA[name][binding]
+			_, okB := bindingsB[name][binding]
+			if okA != okB {
+				diffs = append(diffs, Difference{Type: DifferenceBinding, Policy: name, Field: binding, A: present(okA), B: present(okB)})
+			}
+		}
+	}
+
+	return diffs, nil
+}
+
+func policyMap(c *Cluster) map[string]policyapi.CertificateRequestPolicy {
+	policies := make(map[string]policyapi.CertificateRequestPolicy)
+	for _, policy := range c.Policies {
+		policies[policy.Name] = policy
+	}
+	return policies
+}
+
+// bindingMap returns the set of bindings of each policy. Bindings are keyed
+// by subject and scope only, so that differently named RoleBindings or Roles
+// granting the same access are not reported as differences.
+func bindingMap(c *Cluster) map[string]map[string]struct{} {
+	bindingsByPolicy := make(map[string]map[string]struct{})
+	for _, binding := range c.Bindings {
+		if _, ok := bindingsByPolicy[binding.Policy]; !ok {
+			bindingsByPolicy[binding.Policy] = make(map[string]struct{})
+		}
+		bindingsByPolicy[binding.Policy][fmt.Sprintf("%s in %s", bindings.SubjectString(binding.Subject), binding.Scope())] = struct{}{}
+	}
+	return bindingsByPolicy
+}
+
+func ready(policy policyapi.CertificateRequestPolicy) string {
+	for _, condition := range policy.Status.Conditions {
+		if condition.Type == policyapi.CertificateRequestPolicyConditionReady {
+			return string(condition.Status)
+		}
+	}
+	return string(corev1.ConditionUnknown)
+}
+
+func present(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return ""
+}
+
+// Flatten returns the JSON encoded leaf values of the object, keyed by field
+// path beginning with ".". Lists of strings are sorted, since the order of
+// allowed values has no effect on what is approved.
+func Flatten(obj any) (map[string]string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	if err := flattenInto(fields, "", value); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func flattenInto(fields map[string]string, path string, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			fields[path] = "{}"
+		}
+		for key, child := range v {
+			if err := flattenInto(fields, path+"."+key, child); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				strs = nil
+				break
+			}
+			strs = append(strs, str)
+		}
+		if strs != nil {
+			sort.Strings(strs)
+			value = strs
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fields[path] = string(data)
+	return nil
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make(map[string]struct{})
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// String returns a human readable representation of the difference, naming
+// the clusters a and b.
+func (d Difference) String(a, b string) string {
+	switch d.Type {
+	case DifferencePolicy:
+		return fmt.Sprintf("policy %q only exists in cluster %q", d.Policy, onlyIn(d, a, b))
+	case DifferenceBinding:
+		return fmt.Sprintf("policy %q: %s is only bound in cluster %q", d.Policy, d.Field, onlyIn(d, a, b))
+	case DifferenceReady:
+		return fmt.Sprintf("policy %q: Ready is %s in cluster %q, %s in cluster %q", d.Policy, d.A, a, d.B, b)
+	default:
+		return fmt.Sprintf("policy %q: %s is %s in cluster %q, %s in cluster %q", d.Policy, d.Field, orUnset(d.A), a, orUnset(d.B), b)
+	}
+}
+
+func onlyIn(d Difference, a, b string) string {
+	if len(d.A) > 0 {
+		return a
+	}
+	return b
+}
+
+func orUnset(value string) string {
+	if len(value) == 0 {
+		return "unset"
+	}
+	return value
+}
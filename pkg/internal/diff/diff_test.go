@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+)
+
+func Test_Compare(t *testing.T) {
+	policy := func(name string, dnsNames []string, ready corev1.ConditionStatus) policyapi.CertificateRequestPolicy {
+		return policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{
+					DNSNames: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: ptr.To(dnsNames)},
+				},
+				Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+			},
+			Status: policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{{Type: policyapi.CertificateRequestPolicyConditionReady, Status: ready}},
+			},
+		}
+	}
+	binding := func(policy, user, bindingName string) bindings.Binding {
+		return bindings.Binding{Policy: policy, Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: user}, BindingKind: "ClusterRoleBinding", BindingName: bindingName}
+	}
+
+	tests := map[string]struct {
+		a, b     *Cluster
+		expDiffs []Difference
+	}{
+		"identical clusters should have no differences": {
+			a: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"a", "b"}, corev1.ConditionTrue)}, Bindings: []bindings.Binding{binding("p", "alice", "x")}},
+			b: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"a", "b"}, corev1.ConditionTrue)}, Bindings: []bindings.Binding{binding("p", "alice", "x")}},
+		},
+		"re-ordered values and differently named bindings should have no differences": {
+			a: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"a", "b"}, corev1.ConditionTrue)}, Bindings: []bindings.Binding{binding("p", "alice", "x")}},
+			b: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"b", "a"}, corev1.ConditionTrue)}, Bindings: []bindings.Binding{binding("p", "alice", "y")}},
+		},
+		"policies only in one cluster should be reported": {
+			a: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", nil, corev1.ConditionTrue)}},
+			b: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("q", nil, corev1.ConditionTrue)}},
+			expDiffs: []Difference{
+				{Type: DifferencePolicy, Policy: "p", A: "present"},
+				{Type: DifferencePolicy, Policy: "q", B: "present"},
+			},
+		},
+		"differing spec, ready and bindings should be reported": {
+			a: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"a"}, corev1.ConditionTrue)}, Bindings: []bindings.Binding{binding("p", "alice", "x")}},
+			b: &Cluster{Policies: []policyapi.CertificateRequestPolicy{policy("p", []string{"a", "c"}, corev1.ConditionFalse)}, Bindings: []bindings.Binding{binding("p", "bob", "x")}},
+			expDiffs: []Difference{
+				{Type: DifferenceSpec, Policy: "p", Field: "spec.allowed.dnsNames.values", A: `["a"]`, B: `["a","c"]`},
+				{Type: DifferenceReady, Policy: "p", A: "True", B: "False"},
+				{Type: DifferenceBinding, Policy: "p", Field: "User:alice in all namespaces", A: "present"},
+				{Type: DifferenceBinding, Policy: "p", Field: "User:bob in all namespaces", B: "present"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diffs, err := Compare(test.a, test.b)
+			require.NoError(t, err)
+			assert.Equal(t, test.expDiffs, diffs)
+		})
+	}
+}
+
+func Test_DifferenceString(t *testing.T) {
+	assert.Equal(t, `policy "p" only exists in cluster "b"`, Difference{Type: DifferencePolicy, Policy: "p", B: "present"}.String("a", "b"))
+	assert.Equal(t, `policy "p": spec.allowed.commonName.value is unset in cluster "a", "foo" in cluster "b"`,
+		Difference{Type: DifferenceSpec, Policy: "p", Field: "spec.allowed.commonName.value", B: `"foo"`}.String("a", "b"))
+}
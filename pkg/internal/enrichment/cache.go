@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enrichment
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// Type is a type of object served by the Cache, which has its own TTL.
+type Type string
+
+const (
+	// TypeCertificates is the Type of Certificates, read to evaluate the
+	// owner constraints of requests.
+	TypeCertificates Type = "certificates"
+)
+
+// TTLs are how long objects of each Type are served from the Cache after
+// they were read. Types without a positive TTL are not cached.
+type TTLs map[Type]time.Duration
+
+var _ client.Reader = &Cache{}
+
+// Cache is the enrichment cache shared by evaluators to read the objects
+// related to the requests they evaluate. Objects of the cached Types, and
+// whether they exist, are served for up to their TTL after they were read, so
+// that evaluating many requests from the same owner doesn't read the same
+// object from the API server for each. Namespaces are already watched by
+// approver-policy, so are read from the informer-backed lister. Other objects,
+// and lists, are read straight from the underlying reader.
+type Cache struct {
+	reader client.Reader
+	lister client.Reader
+	ttls   TTLs
+	clock  clock.PassiveClock
+
+	lock      sync.Mutex
+	entries   map[entryKey]entry
+	lastPrune time.Time
+}
+
+// entryKey identifies a cached object by its Go type and key.
+type entryKey struct {
+	goType reflect.Type
+	key    client.ObjectKey
+}
+
+// entry is a cached read of an object.
+type entry struct {
+	typ Type
+
+	// obj is the object read, or nil if it was not found.
+	obj client.Object
+
+	// err is the NotFound error returned if the object was not found.
+	err error
+
+	// readAt is the time the read started, from which the age of the entry
+	// is measured.
+	readAt time.Time
+}
+
+// New returns a Cache which reads objects using the reader, typically the
+// manager's API reader, caching them for their TTL. Watched objects are read
+// from the lister, typically the manager's cache.
+func New(reader, lister client.Reader, ttls TTLs) *Cache {
+	return &Cache{
+		reader:  reader,
+		lister:  lister,
+		ttls:    ttls,
+		clock:   clock.RealClock{},
+		entries: make(map[entryKey]entry),
+	}
+}
+
+// Get reads the object from the Cache if it was read within the TTL of its
+// Type, otherwise from the underlying reader. Objects which don't exist are
+// cached too, returning a NotFound error.
+func (c *Cache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*corev1.Namespace); ok {
+		return c.lister.Get(ctx, key, obj, opts...)
+	}
+
+	typ, ok := typeOf(obj)
+	ttl := c.ttls[typ]
+	if !ok || ttl <= 0 || len(opts) > 0 {
+		return c.reader.Get(ctx, key, obj, opts...)
+	}
+
+	ekey := entryKey{goType: reflect.TypeOf(obj), key: key}
+	now := c.clock.Now()
+
+	c.lock.Lock()
+	cached, found := c.entries[ekey]
+	c.lock.Unlock()
+
+	if age := now.Sub(cached.readAt); found && age < ttl {
+		metrics.EnrichmentCacheReads.WithLabelValues(string(typ), metrics.EnrichmentCacheHit).Inc()
+		metrics.EnrichmentCacheStaleness.WithLabelValues(string(typ)).Observe(age.Seconds())
+		if cached.obj == nil {
+			return cached.err
+		}
+		reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(cached.obj.DeepCopyObject()).Elem())
+		return nil
+	}
+
+	metrics.EnrichmentCacheReads.WithLabelValues(string(typ), metrics.EnrichmentCacheMiss).Inc()
+
+	err := c.reader.Get(ctx, key, obj)
+	switch {
+	case err == nil:
+		c.store(ekey, entry{typ: typ, obj: obj.DeepCopyObject().(client.Object), readAt: now})
+	case apierrors.IsNotFound(err):
+		c.store(ekey, entry{typ: typ, err: err, readAt: now})
+	}
+	return err
+}
+
+// List lists objects straight from the underlying reader. Lists are never
+// cached.
+func (c *Cache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.reader.List(ctx, list, opts...)
+}
+
+// store caches the entry, first removing every expired entry if none have
+// been removed for the longest TTL, so that objects which are not read again
+// are not kept indefinitely.
+func (c *Cache) store(key entryKey, e entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var maxTTL time.Duration
+	for _, ttl := range c.ttls {
+		maxTTL = max(maxTTL, ttl)
+	}
+	if e.readAt.Sub(c.lastPrune) >= maxTTL {
+		for k, cached := range c.entries {
+			if e.readAt.Sub(cached.readAt) >= c.ttls[cached.typ] {
+				delete(c.entries, k)
+			}
+		}
+		c.lastPrune = e.readAt
+	}
+
+	c.entries[key] = e
+}
+
+// typeOf returns the Type of the object, and false if objects of its kind are
+// not cached.
+func typeOf(obj client.Object) (Type, bool) {
+	switch obj.(type) {
+	case *cmapi.Certificate:
+		return TypeCertificates, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enrichment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// countingReader counts the objects read from the underlying reader.
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (c *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.gets++
+	return c.Reader.Get(ctx, key, obj, opts...)
+}
+
+func Test_Get(t *testing.T) {
+	crt := &cmapi.Certificate{ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-certificate"}}
+
+	tests := map[string]struct {
+		obj     client.Object
+		key     client.ObjectKey
+		ttl     time.Duration
+		elapsed time.Duration
+
+		expGets     int
+		expNotFound bool
+	}{
+		"second read within the TTL should be served from the cache": {
+			obj:     new(cmapi.Certificate),
+			key:     client.ObjectKeyFromObject(crt),
+			ttl:     30 * time.Second,
+			elapsed: 29 * time.Second,
+			expGets: 1,
+		},
+		"second read after the TTL should be read again": {
+			obj:     new(cmapi.Certificate),
+			key:     client.ObjectKeyFromObject(crt),
+			ttl:     30 * time.Second,
+			elapsed: 30 * time.Second,
+			expGets: 2,
+		},
+		"object which doesn't exist should be cached as not found": {
+			obj:         new(cmapi.Certificate),
+			key:         client.ObjectKey{Namespace: "test-namespace", Name: "missing"},
+			ttl:         30 * time.Second,
+			elapsed:     time.Second,
+			expGets:     1,
+			expNotFound: true,
+		},
+		"type without a TTL should always be read": {
+			obj:     new(cmapi.Certificate),
+			key:     client.ObjectKeyFromObject(crt),
+			expGets: 2,
+		},
+		"type which is not cached should always be read": {
+			obj:     new(policyapi.CertificateRequestPolicy),
+			key:     client.ObjectKey{Name: "missing"},
+			ttl:     30 * time.Second,
+			expGets: 2,
+
+			expNotFound: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reader := &countingReader{Reader: fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(crt).Build()}
+			clock := fakeclock.NewFakeClock(time.Now())
+			cache := New(reader, nil, TTLs{TypeCertificates: test.ttl})
+			cache.clock = clock
+
+			for range 2 {
+				obj := test.obj.DeepCopyObject().(client.Object)
+				err := cache.Get(context.TODO(), test.key, obj)
+				if test.expNotFound {
+					assert.True(t, apierrors.IsNotFound(err), "expected NotFound, got %v", err)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, test.key.Name, obj.GetName())
+				}
+				clock.Step(test.elapsed)
+			}
+
+			assert.Equal(t, test.expGets, reader.gets)
+		})
+	}
+}
+
+func Test_GetCopies(t *testing.T) {
+	crt := &cmapi.Certificate{ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-certificate", Labels: map[string]string{"foo": "bar"}}}
+	cache := New(fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(crt).Build(), nil,
+		TTLs{TypeCertificates: time.Minute})
+
+	var first cmapi.Certificate
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKeyFromObject(crt), &first))
+	first.Labels["foo"] = "modified"
+
+	before := testutil.ToFloat64(metrics.EnrichmentCacheReads.WithLabelValues(string(TypeCertificates), metrics.EnrichmentCacheHit))
+	var second cmapi.Certificate
+	require.NoError(t, cache.Get(context.TODO(), client.ObjectKeyFromObject(crt), &second))
+	assert.Equal(t, "bar", second.Labels["foo"], "cached object must not be modified by callers")
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.EnrichmentCacheReads.WithLabelValues(string(TypeCertificates), metrics.EnrichmentCacheHit)))
+}
+
+func Test_GetWatched(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-namespace"}}
+	reader := &countingReader{Reader: fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).Build()}
+	lister := &countingReader{Reader: fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).WithObjects(namespace).Build()}
+	cache := New(reader, lister, TTLs{TypeCertificates: time.Minute})
+
+	for range 2 {
+		require.NoError(t, cache.Get(context.TODO(), client.ObjectKey{Name: "test-namespace"}, new(corev1.Namespace)))
+	}
+	assert.Equal(t, 0, reader.gets, "watched objects should not be read from the API server")
+	assert.Equal(t, 2, lister.gets, "watched objects should always be read from the lister")
+}
+
+func Test_store(t *testing.T) {
+	clock := fakeclock.NewFakeClock(time.Now())
+	cache := New(fakeclient.NewClientBuilder().WithScheme(policyapi.GlobalScheme).Build(), nil, TTLs{TypeCertificates: time.Minute})
+	cache.clock = clock
+
+	for _, name := range []string{"a", "b"} {
+		_ = cache.Get(context.TODO(), client.ObjectKey{Namespace: "test-namespace", Name: name}, new(cmapi.Certificate))
+	}
+	require.Len(t, cache.entries, 2)
+
+	clock.Step(time.Minute)
+	_ = cache.Get(context.TODO(), client.ObjectKey{Namespace: "test-namespace", Name: "c"}, new(cmapi.Certificate))
+	assert.Len(t, cache.entries, 1, "expired entries should be pruned")
+}
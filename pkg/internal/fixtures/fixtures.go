@@ -0,0 +1,276 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fixtures evaluates CertificateRequests against
+// CertificateRequestPolicies and the objects their evaluation depends on,
+// loaded from YAML manifests rather than a cluster, using the same evaluation
+// engine as approver-policy. It is shared by the testharness and the replay
+// and verify-bundle commands.
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	approvermanager "github.com/cert-manager/approver-policy/pkg/approver/manager"
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/bindings"
+	"github.com/cert-manager/approver-policy/pkg/internal/controllers"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+	"github.com/cert-manager/approver-policy/pkg/registry"
+
+	_ "github.com/cert-manager/approver-policy/pkg/internal/approver/allowed"
+	_ "github.com/cert-manager/approver-policy/pkg/internal/approver/constraints"
+)
+
+// Options configures the evaluation engine, as the equivalent flags
+// configure approver-policy.
+type Options struct {
+	// DefaultSelectorMode is the selector mode of policies which do not set
+	// one, as configured by --default-selector-mode. Defaults to "All".
+	DefaultSelectorMode policyapi.SelectorMode
+
+	// IssuerAliases are the issuer aliases referenced by policy selectors,
+	// as configured by --issuer-alias.
+	IssuerAliases map[string]cmmeta.ObjectReference
+}
+
+// Verdict is the verdict of the review of a request.
+type Verdict string
+
+const (
+	// VerdictApproved is the verdict of requests which are approved.
+	VerdictApproved Verdict = "Approved"
+
+	// VerdictDenied is the verdict of requests which are denied.
+	VerdictDenied Verdict = "Denied"
+
+	// VerdictUnprocessed is the verdict of requests which no policy is bound
+	// or applicable to, which approver-policy neither approves nor denies.
+	VerdictUnprocessed Verdict = "Unprocessed"
+)
+
+// NewReviewer returns a manager which reviews requests against the
+// CertificateRequestPolicies in the objects, with the same evaluation engine
+// as approver-policy. The namespace selector of policies reads the Namespace
+// of requests, so the given namespaces which are not in the objects are
+// created unlabelled. The registered Approvers are prepared against the
+// objects, so reviewers must not be created concurrently.
+func NewReviewer(ctx context.Context, objects []client.Object, namespaces []string, opts Options) (approvermanager.Interface, error) {
+	if len(opts.DefaultSelectorMode) == 0 {
+		opts.DefaultSelectorMode = policyapi.SelectorModeAll
+	}
+
+	objects = append(slices.Clone(objects), missingNamespaces(objects, namespaces)...)
+	cl := newClient(objects)
+
+	if err := prepareApprovers(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	if err := setPolicyStatuses(ctx, cl); err != nil {
+		return nil, err
+	}
+
+	decoders, err := internalcsr.NewDecoders(registry.Shared.RequestDecoders())
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure request decoders: %w", err)
+	}
+
+	return internalmanager.NewDecoding(decoders,
+		internalmanager.NewExemptions(cl, registry.Shared.Evaluators(),
+			internalmanager.New(cl, cl, registry.Shared.Evaluators(), internalmanager.PredicateOptions{
+				DefaultSelectorMode: opts.DefaultSelectorMode,
+				IssuerAliases:       opts.IssuerAliases,
+			}, internalmanager.AdvisorySampling{}),
+		),
+	), nil
+}
+
+// VerdictOf returns the Verdict of the result of a review.
+func VerdictOf(result approvermanager.ReviewResult) Verdict {
+	switch result {
+	case approvermanager.ResultApproved:
+		return VerdictApproved
+	case approvermanager.ResultDenied:
+		return VerdictDenied
+	default:
+		return VerdictUnprocessed
+	}
+}
+
+// missingNamespaces returns the given Namespaces which are not in objects.
+func missingNamespaces(objects []client.Object, names []string) []client.Object {
+	exists := make(map[string]bool)
+	for _, obj := range objects {
+		if _, ok := obj.(*corev1.Namespace); ok {
+			exists[obj.GetName()] = true
+		}
+	}
+
+	var namespaces []client.Object
+	for _, ns := range names {
+		if !exists[ns] {
+			exists[ns] = true
+			namespaces = append(namespaces, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})
+		}
+	}
+	return namespaces
+}
+
+// newClient returns a fake client holding the objects, which answers
+// SubjectAccessReviews for the "use" verb on CertificateRequestPolicies
+// from the RBAC in the objects.
+func newClient(objects []client.Object) client.Client {
+	var (
+		rbac        bindings.RBAC
+		policyNames []string
+	)
+	for _, obj := range objects {
+		switch obj := obj.(type) {
+		case *policyapi.CertificateRequestPolicy:
+			policyNames = append(policyNames, obj.Name)
+		case *rbacv1.Role:
+			rbac.Roles = append(rbac.Roles, *obj)
+		case *rbacv1.ClusterRole:
+			rbac.ClusterRoles = append(rbac.ClusterRoles, *obj)
+		case *rbacv1.RoleBinding:
+			rbac.RoleBindings = append(rbac.RoleBindings, *obj)
+		case *rbacv1.ClusterRoleBinding:
+			rbac.ClusterRoleBindings = append(rbac.ClusterRoleBindings, *obj)
+		}
+	}
+	policyBindings := rbac.Resolve(policyNames)
+
+	return fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithObjects(objects...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				sar, ok := obj.(*authzv1.SubjectAccessReview)
+				if !ok {
+					return cl.Create(ctx, obj, opts...)
+				}
+				sar.Status.Allowed = subjectAccessAllowed(policyBindings, sar.Spec)
+				return nil
+			},
+		}).
+		Build()
+}
+
+// subjectAccessAllowed returns true if one of the bindings grants the user
+// of the review the "use" verb on the CertificateRequestPolicy.
+func subjectAccessAllowed(policyBindings []bindings.Binding, spec authzv1.SubjectAccessReviewSpec) bool {
+	attrs := spec.ResourceAttributes
+	if attrs == nil || attrs.Group != policyapi.SchemeGroupVersion.Group || attrs.Resource != "certificaterequestpolicies" {
+		return false
+	}
+
+	for _, binding := range policyBindings {
+		if binding.Policy != attrs.Name || (len(binding.Namespace) > 0 && binding.Namespace != attrs.Namespace) {
+			continue
+		}
+
+		switch subject := binding.Subject; subject.Kind {
+		case rbacv1.UserKind:
+			if subject.Name == spec.User {
+				return true
+			}
+		case rbacv1.GroupKind:
+			if slices.Contains(spec.Groups, subject.Name) {
+				return true
+			}
+		case rbacv1.ServiceAccountKind:
+			if fmt.Sprintf("system:serviceaccount:%s:%s", subject.Namespace, subject.Name) == spec.User {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// prepareApprovers prepares every registered Approver against the client,
+// with the defaults of their flags.
+func prepareApprovers(ctx context.Context, cl client.Client) error {
+	mgr := fixturesManager{client: cl}
+	for _, approver := range registry.Shared.Approvers() {
+		fs := pflag.NewFlagSet(approver.Name(), pflag.ContinueOnError)
+		approver.RegisterFlags(fs)
+		if err := fs.Parse(nil); err != nil {
+			return fmt.Errorf("failed to parse flags of approver %q: %w", approver.Name(), err)
+		}
+		if err := approver.Prepare(ctx, logr.Discard(), mgr); err != nil {
+			return fmt.Errorf("failed to prepare approver %q: %w", approver.Name(), err)
+		}
+	}
+	return nil
+}
+
+// setPolicyStatuses sets the status of every CertificateRequestPolicy to
+// the status built by the registered Reconcilers, so that policies which
+// approver-policy would not mark Ready are not evaluated.
+func setPolicyStatuses(ctx context.Context, cl client.Client) error {
+	var policies policyapi.CertificateRequestPolicyList
+	if err := cl.List(ctx, &policies); err != nil {
+		return fmt.Errorf("failed to list CertificateRequestPolicies: %w", err)
+	}
+
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		status, err := controllers.PolicyStatus(ctx, cl, registry.Shared.Reconcilers(), policy.Name)
+		if err != nil {
+			return err
+		}
+		if status == nil {
+			// Expired break glass policies are never evaluated.
+			continue
+		}
+		policy.Status = *status
+		if err := cl.Update(ctx, policy); err != nil {
+			return fmt.Errorf("failed to set status of CertificateRequestPolicy %q: %w", policy.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// fixturesManager is a controller-runtime Manager which serves the fixtures
+// to Approvers being prepared. Methods other than those returning clients
+// are not implemented.
+type fixturesManager struct {
+	manager.Manager
+	client client.Client
+}
+
+func (m fixturesManager) GetClient() client.Client    { return m.client }
+func (m fixturesManager) GetAPIReader() client.Reader { return m.client }
+func (m fixturesManager) GetScheme() *runtime.Scheme  { return policyapi.GlobalScheme }
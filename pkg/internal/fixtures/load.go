@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixtures
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// LoadObjects returns the objects of every YAML document in the directory.
+// Documents must be of a kind known to approver-policy, such as
+// CertificateRequestPolicies, RBAC and Namespaces.
+func LoadObjects(dir string) ([]client.Object, error) {
+	decoder := serializer.NewCodecFactory(policyapi.GlobalScheme).UniversalDeserializer()
+
+	var objects []client.Object
+	err := WalkDocuments(dir, func(path string, index int, doc []byte) error {
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return fmt.Errorf("%s: failed to decode document %d: %w", path, index, err)
+		}
+		cobj, ok := obj.(client.Object)
+		if !ok {
+			return fmt.Errorf("%s: document %d is not an object: %T", path, index, obj)
+		}
+		objects = append(objects, cobj)
+		return nil
+	})
+	return objects, err
+}
+
+// WalkDocuments calls fn with every non-empty YAML document of the ".yaml"
+// and ".yml" files in the directory, in lexical order of the file paths.
+func WalkDocuments(dir string, fn func(path string, index int, doc []byte) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+		for index := 0; ; index++ {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s: failed to read document %d: %w", path, index, err)
+			}
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			if err := fn(path, index, doc); err != nil {
+				return err
+			}
+		}
+	})
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httpserver holds shared functionality for the HTTP endpoints served
+// by approver-policy, such as policy reviews and debugging endpoints.
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrUnauthenticated is returned by an Authorizer when the identity of the
+	// client could not be established.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden is returned by an Authorizer when the client is not
+	// permitted to make the request.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Authorizer decides whether an HTTP request to an approver-policy endpoint is
+// permitted.
+type Authorizer interface {
+	// Authorize returns nil if the request is permitted. ErrUnauthenticated or
+	// ErrForbidden are returned if the request is not permitted. Any other
+	// error signals that the decision could not be made.
+	Authorize(ctx context.Context, req *http.Request) error
+}
+
+// AuthorizationMode is the mode used to authorize requests to endpoints.
+type AuthorizationMode string
+
+const (
+	// AuthorizationModeDelegated authenticates the bearer token of requests
+	// with a TokenReview, and authorizes the user with a SubjectAccessReview
+	// for the request path and verb.
+	AuthorizationModeDelegated AuthorizationMode = "Delegated"
+
+	// AuthorizationModeStaticToken authorizes requests whose bearer token
+	// matches a static token.
+	AuthorizationModeStaticToken AuthorizationMode = "StaticToken"
+
+	// AuthorizationModeNone authorizes all requests.
+	AuthorizationModeNone AuthorizationMode = "None"
+)
+
+// NewAuthorizer returns an Authorizer for the given mode. The token file is
+// only used, and required, by the StaticToken mode.
+func NewAuthorizer(mode AuthorizationMode, client client.Client, tokenFile string) (Authorizer, error) {
+	switch mode {
+	case AuthorizationModeDelegated:
+		return NewDelegatedAuthorizer(client), nil
+
+	case AuthorizationModeStaticToken:
+		if len(tokenFile) == 0 {
+			return nil, errors.New("a token file must be given for the StaticToken authorization mode")
+		}
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		return NewStaticTokenAuthorizer(strings.TrimSpace(string(token)))
+
+	case AuthorizationModeNone:
+		return allowAll{}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown authorization mode %q, must be one of %q, %q or %q",
+			mode, AuthorizationModeDelegated, AuthorizationModeStaticToken, AuthorizationModeNone)
+	}
+}
+
+// WithAuthorization wraps the given handler, only serving requests which are
+// permitted by the Authorizer.
+func WithAuthorization(log logr.Logger, authorizer Authorizer, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		err := authorizer.Authorize(req.Context(), req)
+		switch {
+		case err == nil:
+			handler.ServeHTTP(w, req)
+
+		case errors.Is(err, ErrUnauthenticated):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="approver-policy"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+		case errors.Is(err, ErrForbidden):
+			http.Error(w, "Forbidden", http.StatusForbidden)
+
+		default:
+			log.Error(err, "failed to authorize request", "path", req.URL.Path)
+			http.Error(w, "failed to authorize request", http.StatusInternalServerError)
+		}
+	})
+}
+
+// delegatedAuthorizer authenticates and authorizes requests against the
+// Kubernetes API server.
+type delegatedAuthorizer struct {
+	client client.Client
+}
+
+// NewDelegatedAuthorizer returns an Authorizer which authenticates the bearer
+// token of requests with a TokenReview, then authorizes the user with a
+// SubjectAccessReview for the non-resource URL of the request path, and the
+// lower-cased HTTP method as the verb.
+func NewDelegatedAuthorizer(client client.Client) Authorizer {
+	return &delegatedAuthorizer{client: client}
+}
+
+func (d *delegatedAuthorizer) Authorize(ctx context.Context, req *http.Request) error {
+	token, ok := bearerToken(req)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	tokenReview := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}
+	if err := d.client.Create(ctx, tokenReview); err != nil {
+		return fmt.Errorf("failed to create tokenreview: %w", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return ErrUnauthenticated
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authzv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+
+	sar := &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			NonResourceAttributes: &authzv1.NonResourceAttributes{
+				Path: req.URL.Path,
+				Verb: strings.ToLower(req.Method),
+			},
+		},
+	}
+	if err := d.client.Create(ctx, sar); err != nil {
+		return fmt.Errorf("failed to create subjectaccessreview: %w", err)
+	}
+	if !sar.Status.Allowed {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// staticTokenAuthorizer authorizes requests bearing a static token.
+type staticTokenAuthorizer struct {
+	token []byte
+}
+
+// NewStaticTokenAuthorizer returns an Authorizer which permits requests whose
+// bearer token matches the given token.
+func NewStaticTokenAuthorizer(token string) (Authorizer, error) {
+	if len(token) == 0 {
+		return nil, errors.New("static token must not be empty")
+	}
+	return &staticTokenAuthorizer{token: []byte(token)}, nil
+}
+
+func (s *staticTokenAuthorizer) Authorize(_ context.Context, req *http.Request) error {
+	token, ok := bearerToken(req)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if subtle.ConstantTimeCompare([]byte(token), s.token) != 1 {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// allowAll is an Authorizer which permits all requests.
+type allowAll struct{}
+
+func (allowAll) Authorize(context.Context, *http.Request) error {
+	return nil
+}
+
+// bearerToken returns the bearer token of the request, if present.
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, len(token) > 0
+}
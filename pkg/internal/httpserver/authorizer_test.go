@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_WithAuthorization(t *testing.T) {
+	// The API server knows the token "alice-token" as alice, who may only
+	// POST to /allowed.
+	delegatedClient := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+				switch review := obj.(type) {
+				case *authnv1.TokenReview:
+					if review.Spec.Token == "alice-token" {
+						review.Status.Authenticated = true
+						review.Status.User = authnv1.UserInfo{Username: "alice"}
+					}
+				case *authzv1.SubjectAccessReview:
+					attrs := review.Spec.NonResourceAttributes
+					review.Status.Allowed = review.Spec.User == "alice" && attrs.Path == "/allowed" && attrs.Verb == "post"
+				}
+				return nil
+			},
+		}).
+		Build()
+
+	staticToken, err := NewStaticTokenAuthorizer("static-token")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		authorizer Authorizer
+		path       string
+		token      string
+		expCode    int
+	}{
+		"delegated: a request without a token should be unauthorized": {
+			authorizer: NewDelegatedAuthorizer(delegatedClient),
+			path:       "/allowed",
+			expCode:    http.StatusUnauthorized,
+		},
+		"delegated: a request with an unknown token should be unauthorized": {
+			authorizer: NewDelegatedAuthorizer(delegatedClient),
+			path:       "/allowed",
+			token:      "unknown-token",
+			expCode:    http.StatusUnauthorized,
+		},
+		"delegated: a request to a path the user may not access should be forbidden": {
+			authorizer: NewDelegatedAuthorizer(delegatedClient),
+			path:       "/not-allowed",
+			token:      "alice-token",
+			expCode:    http.StatusForbidden,
+		},
+		"delegated: a request to a path the user may access should be served": {
+			authorizer: NewDelegatedAuthorizer(delegatedClient),
+			path:       "/allowed",
+			token:      "alice-token",
+			expCode:    http.StatusOK,
+		},
+		"static token: a request without a token should be unauthorized": {
+			authorizer: staticToken,
+			path:       "/allowed",
+			expCode:    http.StatusUnauthorized,
+		},
+		"static token: a request with the wrong token should be forbidden": {
+			authorizer: staticToken,
+			path:       "/allowed",
+			token:      "wrong-token",
+			expCode:    http.StatusForbidden,
+		},
+		"static token: a request with the token should be served": {
+			authorizer: staticToken,
+			path:       "/allowed",
+			token:      "static-token",
+			expCode:    http.StatusOK,
+		},
+		"none: a request without a token should be served": {
+			authorizer: allowAll{},
+			path:       "/allowed",
+			expCode:    http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := WithAuthorization(logr.Discard(), test.authorizer, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, test.path, nil)
+			if len(test.token) > 0 {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expCode, rec.Code)
+		})
+	}
+}
+
+func Test_NewAuthorizer(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("static-token\n"), 0600))
+
+	authorizer, err := NewAuthorizer(AuthorizationModeStaticToken, nil, tokenFile)
+	require.NoError(t, err)
+	assert.Equal(t, &staticTokenAuthorizer{token: []byte("static-token")}, authorizer, "token should be read from file and trimmed")
+
+	_, err = NewAuthorizer(AuthorizationModeStaticToken, nil, "")
+	assert.Error(t, err, "StaticToken mode should require a token file")
+
+	_, err = NewAuthorizer("Unknown", nil, "")
+	assert.Error(t, err, "unknown modes should error")
+}
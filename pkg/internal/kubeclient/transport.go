@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubeclient instruments the requests approver-policy makes to the
+// Kubernetes API server, and bounds how long each of them may take.
+package kubeclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/transport"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+var requestInfoFactory = &request.RequestInfoFactory{
+	APIPrefixes:          sets.NewString("api", "apis"),
+	GrouplessAPIPrefixes: sets.NewString("api"),
+}
+
+// WrapTransport returns a transport wrapper for a rest config which records
+// the KubeClientRequests and KubeClientRequestDuration metrics of every
+// request, and applies the given timeout to each request.
+//
+// The timeout is applied on top of the context of the request, so a request
+// made while reviewing a CertificateRequest is cancelled by whichever of the
+// review and the timeout ends first. Without it a request to an unresponsive
+// API server hangs until the review is cancelled, occupying a worker without
+// any indication of why. Watch and list requests are not given a timeout, as
+// these are made by informers which may legitimately take longer to list
+// large clusters, and which manage their own timeouts. A timeout of 0
+// disables timeouts.
+func WrapTransport(timeout time.Duration) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{rt: rt, timeout: timeout}
+	}
+}
+
+type roundTripper struct {
+	rt      http.RoundTripper
+	timeout time.Duration
+}
+
+func (r *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, resource := "unknown", "unknown"
+	if info, err := requestInfoFactory.NewRequestInfo(req); err == nil {
+		verb = info.Verb
+		if info.IsResourceRequest {
+			resource = info.Resource
+			if len(info.APIGroup) > 0 {
+				resource += "." + info.APIGroup
+			}
+		} else {
+			resource = "nonresource"
+		}
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if r.timeout > 0 && verb != "watch" && verb != "list" {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), r.timeout)
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := r.rt.RoundTrip(req)
+	metrics.KubeClientRequestDuration.WithLabelValues(verb, resource).Add(time.Since(start).Seconds())
+
+	if err != nil {
+		result := metrics.KubeClientRequestError
+		if errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+			result = metrics.KubeClientRequestTimeout
+		}
+		metrics.KubeClientRequests.WithLabelValues(verb, resource, result).Inc()
+		cancel()
+		return nil, err
+	}
+
+	metrics.KubeClientRequests.WithLabelValues(verb, resource, metrics.KubeClientRequestSuccess).Inc()
+
+	// The body is read after RoundTrip returns, so the timeout must only be
+	// cancelled once the caller is done with it.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// WrappedRoundTripper implements net.RoundTripperWrapper.
+func (r *roundTripper) WrappedRoundTripper() http.RoundTripper {
+	return r.rt
+}
+
+// cancelOnClose cancels the context of a request once its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
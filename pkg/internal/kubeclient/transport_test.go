@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+func Test_WrapTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") == "true" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+
+	rt := WrapTransport(100 * time.Millisecond)(http.DefaultTransport)
+
+	tests := map[string]struct {
+		method string
+		path   string
+
+		expVerb     string
+		expResource string
+		expResult   string
+	}{
+		"a request which responds in time should succeed": {
+			method:      http.MethodPost,
+			path:        "/apis/authorization.k8s.io/v1/subjectaccessreviews",
+			expVerb:     "create",
+			expResource: "subjectaccessreviews.authorization.k8s.io",
+			expResult:   metrics.KubeClientRequestSuccess,
+		},
+		"a request which doesn't respond in time should time out": {
+			method:      http.MethodGet,
+			path:        "/apis/cert-manager.io/v1/namespaces/team-a/certificates/my-cert?slow=true",
+			expVerb:     "get",
+			expResource: "certificates.cert-manager.io",
+			expResult:   metrics.KubeClientRequestTimeout,
+		},
+		"a list which doesn't respond in time should not time out": {
+			method:      http.MethodGet,
+			path:        "/api/v1/namespaces?slow=true",
+			expVerb:     "list",
+			expResource: "namespaces",
+			expResult:   metrics.KubeClientRequestSuccess,
+		},
+		"a watch which doesn't respond in time should not time out": {
+			method:      http.MethodGet,
+			path:        "/api/v1/namespaces/team-a/secrets?watch=true&slow=true",
+			expVerb:     "watch",
+			expResource: "secrets",
+			expResult:   metrics.KubeClientRequestSuccess,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			counter := metrics.KubeClientRequests.WithLabelValues(test.expVerb, test.expResource, test.expResult)
+			before := testutil.ToFloat64(counter)
+
+			req, err := http.NewRequestWithContext(context.TODO(), test.method, server.URL+test.path, nil)
+			require.NoError(t, err)
+
+			resp, err := rt.RoundTrip(req)
+			if test.expResult == metrics.KubeClientRequestTimeout {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			} else {
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "{}", string(body))
+				require.NoError(t, resp.Body.Close())
+			}
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
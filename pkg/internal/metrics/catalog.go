@@ -0,0 +1,355 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Type is the Prometheus type of a metric.
+type Type string
+
+const (
+	// TypeGauge is a metric whose value can go up and down.
+	TypeGauge Type = "gauge"
+
+	// TypeCounter is a metric whose value only increases.
+	TypeCounter Type = "counter"
+
+	// TypeHistogram is a metric which counts observed values in buckets.
+	TypeHistogram Type = "histogram"
+)
+
+// Definition describes a metric exported by approver-policy. Metrics are
+// constructed from their Definition, so that observability tooling generated
+// from the Catalog always matches the metrics of this version.
+type Definition struct {
+	// Name is the fully qualified name of the metric.
+	Name string
+
+	// Help is the description of the metric.
+	Help string
+
+	// Type is the Prometheus type of the metric.
+	Type Type
+
+	// Labels are the variable label names of the metric.
+	Labels []string
+
+	// Buckets are the upper bounds of the buckets of a histogram.
+	Buckets []float64
+
+	// Alert is an optional alerting rule on the metric.
+	Alert *Alert
+}
+
+// Alert describes a Prometheus alerting rule on a metric.
+type Alert struct {
+	// Name is the name of the alert.
+	Name string
+
+	// Expr is the PromQL expression of the alert.
+	Expr string
+
+	// For is how long the expression must be true before the alert fires.
+	For time.Duration
+
+	// Severity is the value of the severity label of the alert.
+	Severity string
+
+	// Summary is a short description of the alert.
+	Summary string
+
+	// Description is a longer description of the alert, which may use
+	// Prometheus alert templating.
+	Description string
+}
+
+var (
+	approvedCountDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_approved_count",
+		Help:   "Number of CertificateRequests that have been approved (Approved=True).",
+		Type:   TypeGauge,
+		Labels: []string{"namespace"},
+	}
+
+	deniedCountDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_denied_count",
+		Help:   "Number of CertificateRequests that have been denied (Denied=True).",
+		Type:   TypeGauge,
+		Labels: []string{"namespace"},
+	}
+
+	unmatchedCountDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_unmatched_count",
+		Help:   "Number of CertificateRequests not matched to any policy, i.e., that don't have an Approved or Denied condition set yet.",
+		Type:   TypeGauge,
+		Labels: []string{"namespace"},
+		Alert: &Alert{
+			Name:        "ApproverPolicyCertificateRequestsUnmatched",
+			Expr:        "sum by (namespace) (approverpolicy_certificaterequest_unmatched_count) > 0",
+			For:         15 * time.Minute,
+			Severity:    "warning",
+			Summary:     "CertificateRequests are not being approved or denied",
+			Description: "{{ $value }} CertificateRequests in namespace {{ $labels.namespace }} have not been approved or denied for 15 minutes.",
+		},
+	}
+
+	defaultApproverApprovedCountDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_default_approver_approved_count",
+		Help:   "Number of CertificateRequests that have been approved by cert-manager's built-in approver, which approves every request without evaluating CertificateRequestPolicies.",
+		Type:   TypeGauge,
+		Labels: []string{"namespace"},
+		Alert: &Alert{
+			Name:        "ApproverPolicyDefaultApproverEnabled",
+			Expr:        "sum by (namespace) (approverpolicy_certificaterequest_default_approver_approved_count) > 0",
+			For:         5 * time.Minute,
+			Severity:    "critical",
+			Summary:     "cert-manager's built-in approver is bypassing CertificateRequestPolicies",
+			Description: "{{ $value }} CertificateRequests in namespace {{ $labels.namespace }} were approved by cert-manager's built-in approver. Disable it with the cert-manager controller flag --controllers=*,-certificaterequests-approver.",
+		},
+	}
+
+	denialBackoffTotalDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_denial_backoff_total",
+		Help:   "Number of times the review of a CertificateRequest was delayed because its requester has repeatedly had requests denied.",
+		Type:   TypeCounter,
+		Labels: []string{"namespace"},
+		Alert: &Alert{
+			Name:        "ApproverPolicyDenialBackoff",
+			Expr:        "sum by (namespace) (rate(approverpolicy_certificaterequest_denial_backoff_total[5m])) > 0",
+			For:         30 * time.Minute,
+			Severity:    "info",
+			Summary:     "Requesters are repeatedly having CertificateRequests denied",
+			Description: "Reviews of CertificateRequests in namespace {{ $labels.namespace }} have been delayed by denial backoff for 30 minutes.",
+		},
+	}
+
+	// policyDecisionsTotalDefinition has the labels common to all
+	// installations. Custom policy labels which have been configured are
+	// appended to them.
+	policyDecisionsTotalDefinition = Definition{
+		Name:   "approverpolicy_certificaterequest_policy_decisions_total",
+		Help:   "Number of CertificateRequests approved or denied by each CertificateRequestPolicy, with custom labels set by policy annotations.",
+		Type:   TypeCounter,
+		Labels: []string{"namespace", "policy", "decision"},
+	}
+
+	policyFieldExercisedTotalDefinition = Definition{
+		Name:   "approverpolicy_policy_field_exercised_total",
+		Help:   "Number of requests reviewed against each CertificateRequestPolicy which exercised an allowed or constraints field of its spec. Allowed fields are exercised by requests for the attributes they allow, and constraints by requests which reach or exceed them.",
+		Type:   TypeCounter,
+		Labels: []string{"policy", "field"},
+	}
+
+	policyFieldUnexercisedDefinition = Definition{
+		Name:   "approverpolicy_policy_field_unexercised",
+		Help:   "Set to 1 for each allowed or constraints field of a CertificateRequestPolicy which no request reviewed against the current generation of the policy has exercised, once the configured minimum number of requests have been reviewed.",
+		Type:   TypeGauge,
+		Labels: []string{"policy", "field"},
+	}
+
+	policiesIgnoredCountDefinition = Definition{
+		Name: "approverpolicy_policies_ignored_count",
+		Help: "Number of CertificateRequestPolicies ignored when reviewing CertificateRequests because the cluster has more policies than the configured maximum.",
+		Type: TypeGauge,
+		Alert: &Alert{
+			Name:        "ApproverPolicyMaxPoliciesExceeded",
+			Expr:        "approverpolicy_policies_ignored_count > 0",
+			For:         5 * time.Minute,
+			Severity:    "critical",
+			Summary:     "CertificateRequestPolicies are being ignored",
+			Description: "{{ $value }} of the newest CertificateRequestPolicies are ignored because the cluster has more policies than --max-policies.",
+		},
+	}
+
+	policyLimitRejectionsTotalDefinition = Definition{
+		Name: "approverpolicy_policy_limit_rejections_total",
+		Help: "Number of CertificateRequestPolicies rejected on creation because the cluster already has the configured maximum number of policies.",
+		Type: TypeCounter,
+	}
+
+	policyUpdatesTotalDefinition = Definition{
+		Name:   "approverpolicy_policy_updates_total",
+		Help:   "Number of CertificateRequestPolicy updates, by whether they changed how requests are reviewed or were skipped because only metadata or status changed.",
+		Type:   TypeCounter,
+		Labels: []string{"result"},
+	}
+
+	rbacChangesTotalDefinition = Definition{
+		Name:   "approverpolicy_rbac_changes_total",
+		Help:   "Number of changes to Roles, ClusterRoles, RoleBindings and ClusterRoleBindings, by kind and whether they were relevant to the binding of CertificateRequestPolicies and triggered the review of pending CertificateRequests.",
+		Type:   TypeCounter,
+		Labels: []string{"kind", "result"},
+	}
+
+	policiesFailingValidationCountDefinition = Definition{
+		Name: "approverpolicy_policies_failing_validation_count",
+		Help: "Number of existing CertificateRequestPolicies which would no longer pass admission, as validated when approver-policy became leader.",
+		Type: TypeGauge,
+		Alert: &Alert{
+			Name:        "ApproverPolicyPoliciesFailingValidation",
+			Expr:        "approverpolicy_policies_failing_validation_count > 0",
+			For:         5 * time.Minute,
+			Severity:    "warning",
+			Summary:     "CertificateRequestPolicies would no longer pass admission",
+			Description: "{{ $value }} existing CertificateRequestPolicies fail the current webhook validation. See the FailedValidation events on the policies.",
+		},
+	}
+
+	advisoryEvaluationsTotalDefinition = Definition{
+		Name:   "approverpolicy_advisory_evaluations_total",
+		Help:   "Number of evaluations by advisory evaluators, which never affect whether a request is approved, by evaluator and result. Each evaluator evaluates a request once, in the background. Requests which were not sampled, or which were sampled while the maximum number of evaluations were in progress, have the result skipped.",
+		Type:   TypeCounter,
+		Labels: []string{"evaluator", "result"},
+	}
+
+	pluginFailuresTotalDefinition = Definition{
+		Name:   "approverpolicy_plugin_failures_total",
+		Help:   "Number of plugin evaluations which failed and had the failure policy of the plugin applied, by plugin and failure policy. Failures of plugins with the Deny failure policy deny requests, while those with the Skip failure policy are ignored. Failures of plugins with the Block failure policy are returned as reconcile errors instead.",
+		Type:   TypeCounter,
+		Labels: []string{"plugin", "failure_policy"},
+	}
+
+	invalidRequestsTotalDefinition = Definition{
+		Name:   "approverpolicy_invalid_requests_total",
+		Help:   "Number of structurally invalid CertificateRequests, such as those with an empty CSR, invalid PEM or a mismatched key, by problem and whether they were denied or ignored.",
+		Type:   TypeCounter,
+		Labels: []string{"problem", "action"},
+	}
+
+	postProcessesTotalDefinition = Definition{
+		Name:   "approverpolicy_post_processes_total",
+		Help:   "Number of verdicts passed to each registered post-processor after being written to the CertificateRequest, by post-processor and whether processing succeeded.",
+		Type:   TypeCounter,
+		Labels: []string{"post_processor", "result"},
+	}
+
+	compiledMatchersMemoryBytesDefinition = Definition{
+		Name: "approverpolicy_compiled_matchers_memory_bytes",
+		Help: "Estimated memory used by compiled CEL validation expressions.",
+		Type: TypeGauge,
+	}
+
+	compiledMatchersEvictionsTotalDefinition = Definition{
+		Name: "approverpolicy_compiled_matchers_evictions_total",
+		Help: "Number of compiled CEL validation expressions evicted because the compiled expressions exceeded their memory limit.",
+		Type: TypeCounter,
+		Alert: &Alert{
+			Name:        "ApproverPolicyCompiledMatchersEvicted",
+			Expr:        "rate(approverpolicy_compiled_matchers_evictions_total[5m]) > 0",
+			For:         15 * time.Minute,
+			Severity:    "info",
+			Summary:     "Compiled CEL expressions are being evicted",
+			Description: "CEL validation expressions have been evicted and recompiled for 15 minutes, consider raising --max-compiled-matchers-memory.",
+		},
+	}
+
+	kubeClientRequestsTotalDefinition = Definition{
+		Name:   "approverpolicy_kube_client_requests_total",
+		Help:   "Number of requests made to the Kubernetes API server, by verb, resource and whether they succeeded, failed or timed out after --kube-client-timeout.",
+		Type:   TypeCounter,
+		Labels: []string{"verb", "resource", "result"},
+		Alert: &Alert{
+			Name:        "ApproverPolicyKubeClientTimeouts",
+			Expr:        `sum by (verb, resource) (rate(approverpolicy_kube_client_requests_total{result="timeout"}[5m])) > 0`,
+			For:         10 * time.Minute,
+			Severity:    "warning",
+			Summary:     "Requests to the Kubernetes API server are timing out",
+			Description: "{{ $labels.verb }} requests for {{ $labels.resource }} have been timing out for 10 minutes, delaying the review of CertificateRequests.",
+		},
+	}
+
+	kubeClientRequestDurationSecondsTotalDefinition = Definition{
+		Name:   "approverpolicy_kube_client_request_duration_seconds_total",
+		Help:   "Total time spent waiting for responses from the Kubernetes API server, by verb and resource.",
+		Type:   TypeCounter,
+		Labels: []string{"verb", "resource"},
+	}
+
+	enrichmentCacheReadsTotalDefinition = Definition{
+		Name:   "approverpolicy_enrichment_cache_reads_total",
+		Help:   "Number of reads of objects related to CertificateRequests made by evaluators through the enrichment cache, by type and whether they were served from the cache.",
+		Type:   TypeCounter,
+		Labels: []string{"type", "result"},
+	}
+
+	enrichmentCacheStalenessSecondsDefinition = Definition{
+		Name:    "approverpolicy_enrichment_cache_staleness_seconds",
+		Help:    "Age of the objects served from the enrichment cache when they were read by evaluators, by type.",
+		Type:    TypeHistogram,
+		Labels:  []string{"type"},
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+	}
+)
+
+// Catalog returns the Definitions of all metrics exported by approver-policy.
+func Catalog() []Definition {
+	return []Definition{
+		approvedCountDefinition,
+		deniedCountDefinition,
+		unmatchedCountDefinition,
+		defaultApproverApprovedCountDefinition,
+		denialBackoffTotalDefinition,
+		policyDecisionsTotalDefinition,
+		policyFieldExercisedTotalDefinition,
+		policyFieldUnexercisedDefinition,
+		policiesIgnoredCountDefinition,
+		policyLimitRejectionsTotalDefinition,
+		policyUpdatesTotalDefinition,
+		rbacChangesTotalDefinition,
+		policiesFailingValidationCountDefinition,
+		advisoryEvaluationsTotalDefinition,
+		pluginFailuresTotalDefinition,
+		invalidRequestsTotalDefinition,
+		postProcessesTotalDefinition,
+		compiledMatchersMemoryBytesDefinition,
+		compiledMatchersEvictionsTotalDefinition,
+		kubeClientRequestsTotalDefinition,
+		kubeClientRequestDurationSecondsTotalDefinition,
+		enrichmentCacheReadsTotalDefinition,
+		enrichmentCacheStalenessSecondsDefinition,
+	}
+}
+
+// desc returns the Prometheus description of the metric, for use by custom
+// collectors.
+func (d Definition) desc() *prometheus.Desc {
+	return prometheus.NewDesc(d.Name, d.Help, d.Labels, nil)
+}
+
+// counterVec returns a new CounterVec for the metric.
+func (d Definition) counterVec() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: d.Name, Help: d.Help}, d.Labels)
+}
+
+// histogramVec returns a new HistogramVec for the metric.
+func (d Definition) histogramVec() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: d.Name, Help: d.Help, Buckets: d.Buckets}, d.Labels)
+}
+
+// gauge returns a new Gauge for a metric without labels.
+func (d Definition) gauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{Name: d.Name, Help: d.Help})
+}
+
+// counter returns a new Counter for a metric without labels.
+func (d Definition) counter() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{Name: d.Name, Help: d.Help})
+}
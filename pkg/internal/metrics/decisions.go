@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
+)
+
+const (
+	// MaxPolicyLabels is the maximum number of custom labels which may be
+	// attached to policy decision metrics.
+	MaxPolicyLabels = 5
+
+	// MaxPolicyLabelValueLength is the maximum length of the value of a custom
+	// policy label.
+	MaxPolicyLabelValueLength = 63
+
+	// OverflowLabelValue is the value reported for a custom policy label once
+	// the label has reached its limit of distinct values, or if the value is
+	// too long.
+	OverflowLabelValue = "overflow"
+
+	// DecisionApproved is the decision label value of approved requests.
+	DecisionApproved = "approved"
+
+	// DecisionDenied is the decision label value of denied requests.
+	DecisionDenied = "denied"
+)
+
+// PolicyDecisions counts the decisions made by each CertificateRequestPolicy.
+// Policies may attach custom labels to their decision metrics with annotations
+// using the policy.MetricLabelAnnotationPrefix, for example
+// `metrics.policy.cert-manager.io/team: payments`. Only label names which
+// have been configured are used, and the number of distinct values of each
+// label is limited to bound the cardinality of the metric.
+type PolicyDecisions struct {
+	counter *prometheus.CounterVec
+
+	// labels are the names of the custom labels.
+	labels []string
+
+	// maxValues is the maximum number of distinct values of each custom
+	// label. Further values are reported as OverflowLabelValue.
+	maxValues int
+
+	lock sync.Mutex
+
+	// values are the distinct values seen for each custom label.
+	values map[string]map[string]struct{}
+}
+
+// NewPolicyDecisions returns a PolicyDecisions with the given custom label
+// names, each of which may have up to maxValues distinct values. At most
+// MaxPolicyLabels label names may be given, and they must not clash with the
+// labels of the metric itself.
+func NewPolicyDecisions(labels []string, maxValues int) (*PolicyDecisions, error) {
+	if len(labels) > MaxPolicyLabels {
+		return nil, fmt.Errorf("at most %d policy metric labels may be configured, got %d", MaxPolicyLabels, len(labels))
+	}
+	if len(labels) > 0 && maxValues < 1 {
+		return nil, fmt.Errorf("the maximum number of values of policy metric labels must be at least 1, got %d", maxValues)
+	}
+
+	values := make(map[string]map[string]struct{}, len(labels))
+	for _, label := range labels {
+		if err := ValidatePolicyLabelName(label); err != nil {
+			return nil, err
+		}
+		if _, ok := values[label]; ok {
+			return nil, fmt.Errorf("policy metric label %q is configured more than once", label)
+		}
+		values[label] = make(map[string]struct{})
+	}
+
+	def := policyDecisionsTotalDefinition
+	def.Labels = append(slices.Clone(def.Labels), labels...)
+
+	return &PolicyDecisions{
+		counter:   def.counterVec(),
+		labels:    labels,
+		maxValues: maxValues,
+		values:    values,
+	}, nil
+}
+
+// ValidatePolicyLabelName returns an error if the name cannot be used as a
+// custom policy label.
+func ValidatePolicyLabelName(name string) error {
+	if !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("%q is not a valid Prometheus label name", name)
+	}
+	if slices.Contains(policyDecisionsTotalDefinition.Labels, name) {
+		return fmt.Errorf("%q is reserved and cannot be used as a policy metric label", name)
+	}
+	return nil
+}
+
+// Collector returns the Prometheus collector of the decision metrics.
+func (p *PolicyDecisions) Collector() prometheus.Collector {
+	return p.counter
+}
+
+// Observe counts a decision made by the named policy for a request in the
+// given namespace. annotations are the annotations of the policy, from which
+// the values of the custom labels are read.
+func (p *PolicyDecisions) Observe(namespace, policyName, decision string, annotations map[string]string) {
+	if p == nil {
+		return
+	}
+
+	labelValues := []string{namespace, policyName, decision}
+	for _, label := range p.labels {
+		labelValues = append(labelValues, p.labelValue(label, annotations[policy.MetricLabelAnnotationPrefix+label]))
+	}
+
+	p.counter.WithLabelValues(labelValues...).Inc()
+}
+
+// labelValue returns the value to report for the custom label, limiting the
+// number of distinct values of the label.
+func (p *PolicyDecisions) labelValue(label, value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+	if len(value) > MaxPolicyLabelValueLength {
+		return OverflowLabelValue
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	values := p.values[label]
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= p.maxValues {
+		return OverflowLabelValue
+	}
+	values[value] = struct{}{}
+	return value
+}
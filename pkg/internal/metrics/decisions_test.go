@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewPolicyDecisions(t *testing.T) {
+	tests := map[string]struct {
+		labels    []string
+		maxValues int
+		expErr    string
+	}{
+		"no labels should be accepted": {
+			labels:    nil,
+			maxValues: 0,
+		},
+		"valid labels should be accepted": {
+			labels:    []string{"team", "tier"},
+			maxValues: 10,
+		},
+		"too many labels should error": {
+			labels:    []string{"a", "b", "c", "d", "e", "f"},
+			maxValues: 10,
+			expErr:    "at most 5 policy metric labels may be configured, got 6",
+		},
+		"no values should error": {
+			labels:    []string{"team"},
+			maxValues: 0,
+			expErr:    "the maximum number of values of policy metric labels must be at least 1, got 0",
+		},
+		"invalid label names should error": {
+			labels:    []string{"team-name"},
+			maxValues: 10,
+			expErr:    `"team-name" is not a valid Prometheus label name`,
+		},
+		"reserved label names should error": {
+			labels:    []string{"policy"},
+			maxValues: 10,
+			expErr:    `"policy" is reserved and cannot be used as a policy metric label`,
+		},
+		"duplicate label names should error": {
+			labels:    []string{"team", "team"},
+			maxValues: 10,
+			expErr:    `policy metric label "team" is configured more than once`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPolicyDecisions(test.labels, test.maxValues)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_PolicyDecisions(t *testing.T) {
+	decisions, err := NewPolicyDecisions([]string{"team"}, 2)
+	require.NoError(t, err)
+
+	decisions.Observe("ns-1", "policy-a", DecisionApproved, map[string]string{"metrics.policy.cert-manager.io/team": "payments"})
+	decisions.Observe("ns-1", "policy-a", DecisionApproved, map[string]string{"metrics.policy.cert-manager.io/team": "payments"})
+	decisions.Observe("ns-1", "policy-b", DecisionDenied, map[string]string{"metrics.policy.cert-manager.io/team": "identity"})
+	// The limit of 2 distinct values has been reached.
+	decisions.Observe("ns-2", "policy-c", DecisionApproved, map[string]string{"metrics.policy.cert-manager.io/team": "storage"})
+	decisions.Observe("ns-2", "policy-d", DecisionApproved, map[string]string{"metrics.policy.cert-manager.io/team": strings.Repeat("a", 64)})
+	// Policies without the annotation, and annotations for labels which are
+	// not configured, are counted without the label.
+	decisions.Observe("ns-2", "policy-e", DecisionDenied, map[string]string{"metrics.policy.cert-manager.io/tier": "1"})
+
+	expected := `
+		# HELP approverpolicy_certificaterequest_policy_decisions_total Number of CertificateRequests approved or denied by each CertificateRequestPolicy, with custom labels set by policy annotations.
+		# TYPE approverpolicy_certificaterequest_policy_decisions_total counter
+		approverpolicy_certificaterequest_policy_decisions_total{decision="approved",namespace="ns-1",policy="policy-a",team="payments"} 2
+		approverpolicy_certificaterequest_policy_decisions_total{decision="denied",namespace="ns-1",policy="policy-b",team="identity"} 1
+		approverpolicy_certificaterequest_policy_decisions_total{decision="approved",namespace="ns-2",policy="policy-c",team="overflow"} 1
+		approverpolicy_certificaterequest_policy_decisions_total{decision="approved",namespace="ns-2",policy="policy-d",team="overflow"} 1
+		approverpolicy_certificaterequest_policy_decisions_total{decision="denied",namespace="ns-2",policy="policy-e",team=""} 1
+	`
+	assert.NoError(t, testutil.CollectAndCompare(decisions.Collector(), strings.NewReader(expected)))
+
+	// A nil PolicyDecisions should not panic.
+	var nilDecisions *PolicyDecisions
+	nilDecisions.Observe("ns-1", "policy-a", DecisionApproved, nil)
+}
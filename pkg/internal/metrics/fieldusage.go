@@ -0,0 +1,407 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"sync"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/prometheus/client_golang/prometheus"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	internalcsr "github.com/cert-manager/approver-policy/pkg/internal/csr"
+)
+
+// fieldUsageEnqueueBuffer is the number of policies whose unexercised fields
+// changed which may be queued for a status update.
+const fieldUsageEnqueueBuffer = 1024
+
+// FieldUsage tracks which allowed and constraints fields of each
+// CertificateRequestPolicy are exercised by the requests reviewed against
+// it. An allowed field is exercised by a request for the attribute it allows,
+// and a constraint by a request which reaches or exceeds it. Fields which are
+// never exercised are candidates for removal, simplifying the policy.
+// Usage is tracked per generation of each policy, since approver-policy
+// started.
+type FieldUsage struct {
+	exercised *prometheus.CounterVec
+
+	// minReviews is the number of requests which must have been reviewed
+	// against a generation of a policy before its unexercised fields are
+	// reported.
+	minReviews int
+
+	// enqueue receives the names of policies whose unexercised fields have
+	// changed.
+	enqueue chan string
+
+	lock     sync.Mutex
+	policies map[string]*policyFieldUsage
+}
+
+// policyFieldUsage is the usage of the fields of a generation of a policy.
+type policyFieldUsage struct {
+	generation int64
+
+	// fields are the analysed fields which are set by the policy.
+	fields []string
+
+	reviews   int
+	exercised map[string]struct{}
+}
+
+// NewFieldUsage returns a FieldUsage which reports the unexercised fields of
+// policies once minReviews requests have been reviewed against them.
+func NewFieldUsage(minReviews int) (*FieldUsage, error) {
+	if minReviews < 1 {
+		return nil, fmt.Errorf("the minimum number of reviews before reporting unexercised fields must be at least 1, got %d", minReviews)
+	}
+
+	return &FieldUsage{
+		exercised:  policyFieldExercisedTotalDefinition.counterVec(),
+		minReviews: minReviews,
+		enqueue:    make(chan string, fieldUsageEnqueueBuffer),
+		policies:   make(map[string]*policyFieldUsage),
+	}, nil
+}
+
+// Observe records the fields of each of the policies exercised by a request
+// which was reviewed against them. The request is decoded once for all of the
+// policies, so must be the request as it was reviewed, with the `spec.request`
+// normalized by the RequestDecoder of its issuer group.
+func (f *FieldUsage) Observe(cr *cmapi.CertificateRequest, policies ...*policyapi.CertificateRequestPolicy) {
+	if f == nil || len(policies) == 0 {
+		return
+	}
+
+	r := newFieldRequest(cr)
+	for _, policy := range policies {
+		f.observe(policy, exercisedFields(&policy.Spec, r))
+	}
+}
+
+// observe records the exercised fields of the policy.
+func (f *FieldUsage) observe(policy *policyapi.CertificateRequestPolicy, exercised []string) {
+	f.lock.Lock()
+	usage, ok := f.policies[policy.Name]
+	if !ok || usage.generation != policy.Generation {
+		usage = &policyFieldUsage{
+			generation: policy.Generation,
+			fields:     setFields(&policy.Spec),
+			exercised:  make(map[string]struct{}),
+		}
+		f.policies[policy.Name] = usage
+	}
+
+	before := usage.unexercised(f.minReviews)
+	usage.reviews++
+	for _, field := range exercised {
+		usage.exercised[field] = struct{}{}
+		f.exercised.WithLabelValues(policy.Name, field).Inc()
+	}
+	changed := !slices.Equal(before, usage.unexercised(f.minReviews))
+	f.lock.Unlock()
+
+	if changed {
+		// Never block reviews on status updates. A dropped update is made the
+		// next time the policy is synced.
+		select {
+		case f.enqueue <- policy.Name:
+		default:
+		}
+	}
+}
+
+// Unexercised returns the fields of the policy which no request reviewed
+// against its current generation has exercised. Returns nil until enough
+// requests have been reviewed.
+func (f *FieldUsage) Unexercised(policy *policyapi.CertificateRequestPolicy) []string {
+	if f == nil {
+		return nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	usage, ok := f.policies[policy.Name]
+	if !ok || usage.generation != policy.Generation {
+		return nil
+	}
+	return usage.unexercised(f.minReviews)
+}
+
+// Forget stops tracking the usage of the named policy, which has been
+// deleted, and removes its metrics.
+func (f *FieldUsage) Forget(name string) {
+	if f == nil {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.policies, name)
+	f.exercised.DeletePartialMatch(prometheus.Labels{"policy": name})
+}
+
+// EnqueueChan returns a channel of the names of policies whose unexercised
+// fields have changed.
+func (f *FieldUsage) EnqueueChan() <-chan string {
+	return f.enqueue
+}
+
+// Collector returns the Prometheus collector of the field usage metrics.
+func (f *FieldUsage) Collector() prometheus.Collector {
+	return fieldUsageCollector{f}
+}
+
+func (u *policyFieldUsage) unexercised(minReviews int) []string {
+	if u.reviews < minReviews {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range u.fields {
+		if _, ok := u.exercised[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+type fieldUsageCollector struct {
+	f *FieldUsage
+}
+
+func (c fieldUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c fieldUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	c.f.exercised.Collect(ch)
+
+	c.f.lock.Lock()
+	defer c.f.lock.Unlock()
+
+	for name, usage := range c.f.policies {
+		for _, field := range usage.unexercised(c.f.minReviews) {
+			ch <- prometheus.MustNewConstMetric(policyFieldUnexercised, prometheus.GaugeValue, 1, name, field)
+		}
+	}
+}
+
+// policyFieldUnexercised is reported for each unexercised field of each
+// policy.
+var policyFieldUnexercised = policyFieldUnexercisedDefinition.desc()
+
+// fieldRequest is a request whose exercise of policy fields is analysed.
+type fieldRequest struct {
+	cr *cmapi.CertificateRequest
+
+	// csr is the decoded request, or nil if it could not be decoded. Fields
+	// which depend on the request are then not exercised.
+	csr *x509.CertificateRequest
+}
+
+// analysedField is a policy field whose usage is analysed.
+type analysedField struct {
+	path      string
+	set       func(*policyapi.CertificateRequestPolicySpec) bool
+	exercised func(*policyapi.CertificateRequestPolicySpec, fieldRequest) bool
+}
+
+// analysedFields are the policy fields whose usage is analysed.
+var analysedFields = []analysedField{
+	allowedField("spec.allowed.commonName",
+		func(a *policyapi.CertificateRequestPolicyAllowed) bool { return a.CommonName != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.CommonName) > 0 }),
+	allowedField("spec.allowed.dnsNames",
+		func(a *policyapi.CertificateRequestPolicyAllowed) bool { return a.DNSNames != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.DNSNames) > 0 }),
+	allowedField("spec.allowed.ipAddresses",
+		func(a *policyapi.CertificateRequestPolicyAllowed) bool { return a.IPAddresses != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.IPAddresses) > 0 }),
+	allowedField("spec.allowed.uris",
+		func(a *policyapi.CertificateRequestPolicyAllowed) bool { return a.URIs != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.URIs) > 0 }),
+	allowedField("spec.allowed.emailAddresses",
+		func(a *policyapi.CertificateRequestPolicyAllowed) bool { return a.EmailAddresses != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.EmailAddresses) > 0 }),
+	{
+		path: "spec.allowed.isCA",
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Allowed != nil && spec.Allowed.IsCA != nil
+		},
+		exercised: func(_ *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool { return r.cr.Spec.IsCA },
+	},
+	{
+		path: "spec.allowed.usages",
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Allowed != nil && spec.Allowed.Usages != nil
+		},
+		exercised: func(_ *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool { return len(r.cr.Spec.Usages) > 0 },
+	},
+	subjectField("spec.allowed.subject.organizations",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.Organizations != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.Organization) > 0 }),
+	subjectField("spec.allowed.subject.countries",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.Countries != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.Country) > 0 }),
+	subjectField("spec.allowed.subject.organizationalUnits",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool {
+			return s.OrganizationalUnits != nil
+		},
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.OrganizationalUnit) > 0 }),
+	subjectField("spec.allowed.subject.localities",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.Localities != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.Locality) > 0 }),
+	subjectField("spec.allowed.subject.provinces",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.Provinces != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.Province) > 0 }),
+	subjectField("spec.allowed.subject.streetAddresses",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.StreetAddresses != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.StreetAddress) > 0 }),
+	subjectField("spec.allowed.subject.postalCodes",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.PostalCodes != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.PostalCode) > 0 }),
+	subjectField("spec.allowed.subject.serialNumber",
+		func(s *policyapi.CertificateRequestPolicyAllowedX509Subject) bool { return s.SerialNumber != nil },
+		func(csr *x509.CertificateRequest) bool { return len(csr.Subject.SerialNumber) > 0 }),
+	{
+		path: "spec.constraints.minDuration",
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Constraints != nil && spec.Constraints.MinDuration != nil
+		},
+		exercised: func(spec *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool {
+			return r.cr.Spec.Duration == nil || r.cr.Spec.Duration.Duration <= spec.Constraints.MinDuration.Duration
+		},
+	},
+	{
+		path: "spec.constraints.maxDuration",
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Constraints != nil && spec.Constraints.MaxDuration != nil
+		},
+		exercised: func(spec *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool {
+			return r.cr.Spec.Duration == nil || r.cr.Spec.Duration.Duration >= spec.Constraints.MaxDuration.Duration
+		},
+	},
+	privateKeyField("spec.constraints.privateKey.algorithm",
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey) bool { return k.Algorithm != nil },
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey, alg cmapi.PrivateKeyAlgorithm, _ int) bool {
+			return alg != *k.Algorithm
+		}),
+	privateKeyField("spec.constraints.privateKey.minSize",
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey) bool { return k.MinSize != nil },
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey, _ cmapi.PrivateKeyAlgorithm, size int) bool {
+			return size >= 0 && size <= *k.MinSize
+		}),
+	privateKeyField("spec.constraints.privateKey.maxSize",
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey) bool { return k.MaxSize != nil },
+		func(k *policyapi.CertificateRequestPolicyConstraintsPrivateKey, _ cmapi.PrivateKeyAlgorithm, size int) bool {
+			return size >= *k.MaxSize
+		}),
+}
+
+// allowedField returns an allowed field which is exercised by requests whose
+// CSR has the attribute.
+func allowedField(path string, set func(*policyapi.CertificateRequestPolicyAllowed) bool, requested func(*x509.CertificateRequest) bool) analysedField {
+	return analysedField{
+		path: path,
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Allowed != nil && set(spec.Allowed)
+		},
+		exercised: func(_ *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool {
+			return r.csr != nil && requested(r.csr)
+		},
+	}
+}
+
+// subjectField returns an allowed subject field which is exercised by
+// requests whose CSR subject has the attribute.
+func subjectField(path string, set func(*policyapi.CertificateRequestPolicyAllowedX509Subject) bool, requested func(*x509.CertificateRequest) bool) analysedField {
+	return allowedField(path, func(a *policyapi.CertificateRequestPolicyAllowed) bool {
+		return a.Subject != nil && set(a.Subject)
+	}, requested)
+}
+
+// privateKeyField returns a private key constraint which is exercised by
+// requests whose public key reaches it.
+func privateKeyField(path string, set func(*policyapi.CertificateRequestPolicyConstraintsPrivateKey) bool,
+	reached func(*policyapi.CertificateRequestPolicyConstraintsPrivateKey, cmapi.PrivateKeyAlgorithm, int) bool) analysedField {
+	return analysedField{
+		path: path,
+		set: func(spec *policyapi.CertificateRequestPolicySpec) bool {
+			return spec.Constraints != nil && spec.Constraints.PrivateKey != nil && set(spec.Constraints.PrivateKey)
+		},
+		exercised: func(spec *policyapi.CertificateRequestPolicySpec, r fieldRequest) bool {
+			if r.csr == nil {
+				return false
+			}
+			alg, size := publicKeyAlgorithm(r.csr.PublicKey)
+			return reached(spec.Constraints.PrivateKey, alg, size)
+		},
+	}
+}
+
+// publicKeyAlgorithm returns the algorithm and size of the public key. The
+// size of keys without a configurable size is -1.
+func publicKeyAlgorithm(pub any) (cmapi.PrivateKeyAlgorithm, int) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return cmapi.RSAKeyAlgorithm, pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		return cmapi.ECDSAKeyAlgorithm, pub.Curve.Params().BitSize
+	default:
+		return cmapi.Ed25519KeyAlgorithm, -1
+	}
+}
+
+// setFields returns the analysed fields which are set by the spec.
+func setFields(spec *policyapi.CertificateRequestPolicySpec) []string {
+	var fields []string
+	for _, field := range analysedFields {
+		if field.set(spec) {
+			fields = append(fields, field.path)
+		}
+	}
+	return fields
+}
+
+// newFieldRequest decodes the request for analysis.
+func newFieldRequest(cr *cmapi.CertificateRequest) fieldRequest {
+	r := fieldRequest{cr: cr}
+	if csr, err := internalcsr.Decode(cr.Spec.Request); err == nil {
+		r.csr = csr
+	}
+	return r
+}
+
+// exercisedFields returns the analysed fields set by the spec which are
+// exercised by the request.
+func exercisedFields(spec *policyapi.CertificateRequestPolicySpec, r fieldRequest) []string {
+	var fields []string
+	for _, field := range analysedFields {
+		if field.set(spec) && field.exercised(spec, r) {
+			fields = append(fields, field.path)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/cert-manager/cert-manager/test/unit/gen"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_exercisedFields(t *testing.T) {
+	spec := &policyapi.CertificateRequestPolicySpec{
+		Allowed: &policyapi.CertificateRequestPolicyAllowed{
+			CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("*")},
+			DNSNames:   &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+			URIs:       &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+			Subject: &policyapi.CertificateRequestPolicyAllowedX509Subject{
+				Organizations: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+			},
+		},
+		Constraints: &policyapi.CertificateRequestPolicyConstraints{
+			MaxDuration: &policyapi.Duration{Duration: 24 * time.Hour},
+			PrivateKey: &policyapi.CertificateRequestPolicyConstraintsPrivateKey{
+				Algorithm: ptr.To(cmapi.ECDSAKeyAlgorithm),
+				MinSize:   ptr.To(256),
+			},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"spec.allowed.commonName",
+		"spec.allowed.dnsNames",
+		"spec.allowed.uris",
+		"spec.allowed.subject.organizations",
+		"spec.constraints.maxDuration",
+		"spec.constraints.privateKey.algorithm",
+		"spec.constraints.privateKey.minSize",
+	}, setFields(spec))
+
+	tests := map[string]struct {
+		request      *cmapi.CertificateRequest
+		expExercised []string
+	}{
+		"request for attributes within the constraints should exercise the allowed fields": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA, gen.SetCSRCommonName("foo"), gen.SetCSRDNSNames("foo.example.com"))),
+				gen.SetCertificateRequestDuration(&metav1.Duration{Duration: time.Hour}),
+			),
+			expExercised: []string{"spec.allowed.commonName", "spec.allowed.dnsNames", "spec.constraints.privateKey.minSize"},
+		},
+		"request reaching the constraints should exercise them": {
+			request: gen.CertificateRequest("",
+				gen.SetCertificateRequestCSR(csrFrom(t, x509.RSA, func(csr *x509.CertificateRequest) error {
+					csr.Subject.Organization = []string{"foo"}
+					return nil
+				})),
+				gen.SetCertificateRequestDuration(&metav1.Duration{Duration: 24 * time.Hour}),
+			),
+			expExercised: []string{"spec.allowed.subject.organizations", "spec.constraints.maxDuration", "spec.constraints.privateKey.algorithm"},
+		},
+		"request without a CSR should only exercise fields of the request spec": {
+			request:      gen.CertificateRequest(""),
+			expExercised: []string{"spec.constraints.maxDuration"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expExercised, exercisedFields(spec, newFieldRequest(test.request)))
+		})
+	}
+}
+
+func Test_FieldUsage(t *testing.T) {
+	_, err := NewFieldUsage(0)
+	assert.Error(t, err)
+
+	usage, err := NewFieldUsage(2)
+	require.NoError(t, err)
+
+	policy := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy-a", Generation: 1},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Allowed: &policyapi.CertificateRequestPolicyAllowed{
+				DNSNames: &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+				URIs:     &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*"}},
+			},
+		},
+	}
+	request := gen.CertificateRequest("", gen.SetCertificateRequestCSR(csrFrom(t, x509.ECDSA, gen.SetCSRDNSNames("foo.example.com"))))
+
+	// Unexercised fields are not reported until enough requests have been
+	// reviewed.
+	usage.Observe(request, policy)
+	assert.Nil(t, usage.Unexercised(policy))
+	assert.Empty(t, usage.EnqueueChan())
+
+	usage.Observe(request, policy)
+	assert.Equal(t, []string{"spec.allowed.uris"}, usage.Unexercised(policy))
+	assert.Equal(t, "policy-a", <-usage.EnqueueChan())
+
+	expected := `
+		# HELP approverpolicy_policy_field_exercised_total Number of requests reviewed against each CertificateRequestPolicy which exercised an allowed or constraints field of its spec. Allowed fields are exercised by requests for the attributes they allow, and constraints by requests which reach or exceed them.
+		# TYPE approverpolicy_policy_field_exercised_total counter
+		approverpolicy_policy_field_exercised_total{field="spec.allowed.dnsNames",policy="policy-a"} 2
+		# HELP approverpolicy_policy_field_unexercised Set to 1 for each allowed or constraints field of a CertificateRequestPolicy which no request reviewed against the current generation of the policy has exercised, once the configured minimum number of requests have been reviewed.
+		# TYPE approverpolicy_policy_field_unexercised gauge
+		approverpolicy_policy_field_unexercised{field="spec.allowed.uris",policy="policy-a"} 1
+	`
+	assert.NoError(t, testutil.CollectAndCompare(usage.Collector(), strings.NewReader(expected)))
+
+	// A new generation of the policy is tracked from scratch.
+	policy.Generation = 2
+	assert.Nil(t, usage.Unexercised(policy))
+	usage.Observe(request, policy)
+	assert.Nil(t, usage.Unexercised(policy))
+
+	// Forgetting a deleted policy removes its metrics.
+	usage.Forget("policy-a")
+	assert.Equal(t, 0, testutil.CollectAndCount(usage.Collector()))
+	usage.Observe(request, policy)
+	assert.Nil(t, usage.Unexercised(policy))
+
+	// A nil FieldUsage should not panic.
+	var nilUsage *FieldUsage
+	nilUsage.Observe(request, policy)
+	nilUsage.Forget("policy-a")
+	assert.Nil(t, nilUsage.Unexercised(policy))
+}
+
+func csrFrom(t *testing.T, keyAlgorithm x509.PublicKeyAlgorithm, mods ...gen.CSRModifier) []byte {
+	csr, _, err := gen.CSR(keyAlgorithm, mods...)
+	require.NoError(t, err)
+	return csr
+}
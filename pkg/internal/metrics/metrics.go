@@ -41,14 +41,7 @@ var (
 	//
 	// This is a gauge rather than a counter because certificate requests may
 	// get removed over time e.g. with revisionHistoryLimit.
-	approvedCount = prometheus.NewDesc(
-		"approverpolicy_certificaterequest_approved_count",
-		"Number of CertificateRequests that have been approved (Approved=True).",
-		[]string{
-			"namespace",
-		},
-		nil,
-	)
+	approvedCount = approvedCountDefinition.desc()
 
 	// deniedCount counts the number of CertificateRequest currently denied by
 	// looking at the Denied condition.
@@ -58,33 +51,187 @@ var (
 	//   reason: policy.cert-manager.io
 	//   message: 'No policy approved this request: [issuer-2: spec.allowed.dnsNames.values:
 	//     Invalid value: []string{"forbidden-domain-41.com"}: *.example.com, *.ca-wont-accept.org]'
-	deniedCount = prometheus.NewDesc(
-		"approverpolicy_certificaterequest_denied_count",
-		"Number of CertificateRequests that have been denied (Denied=True).",
-		[]string{
-			"namespace",
-		},
-		nil,
-	)
+	deniedCount = deniedCountDefinition.desc()
 
 	// unmatchedCount counts the current number of certificate requests that
 	// have not been matched by any approvers. An unmatched certificate request
 	// is defined as a certificate requests that doesn't have the Approved
 	// condition.
-	unmatchedCount = prometheus.NewDesc(
-		"approverpolicy_certificaterequest_unmatched_count",
-		"Number of CertificateRequests not matched to any policy, i.e., that don't have an Approved or Denied condition set yet.",
-		[]string{
-			"namespace",
-		},
-		nil,
-	)
+	unmatchedCount = unmatchedCountDefinition.desc()
+
+	// defaultApproverApprovedCount counts the number of CertificateRequests
+	// currently approved by cert-manager's built-in approver, rather than by
+	// approver-policy.
+	defaultApproverApprovedCount = defaultApproverApprovedCountDefinition.desc()
+)
+
+// DenialBackoffCount counts the number of times the review of a
+// CertificateRequest has been delayed because its requester has repeatedly had
+// requests denied.
+var DenialBackoffCount = denialBackoffTotalDefinition.counterVec()
+
+const (
+	// PolicyUpdateChanged is the result label value of policy updates which
+	// changed how requests are reviewed.
+	PolicyUpdateChanged = "changed"
+
+	// PolicyUpdateSkipped is the result label value of policy updates which
+	// only changed metadata or status.
+	PolicyUpdateSkipped = "skipped"
+)
+
+const (
+	// RBACChangeRelevant is the result label value of RBAC changes which may
+	// change the CertificateRequestPolicies bound to requesters.
+	RBACChangeRelevant = "relevant"
+
+	// RBACChangeSkipped is the result label value of RBAC changes which do
+	// not grant or revoke the use of CertificateRequestPolicies.
+	RBACChangeSkipped = "skipped"
+)
+
+const (
+	// AdvisoryDenied is the result label value of advisory evaluations which
+	// would have denied the request.
+	AdvisoryDenied = "denied"
+
+	// AdvisoryNotDenied is the result label value of advisory evaluations
+	// which would not have denied the request.
+	AdvisoryNotDenied = "not_denied"
+
+	// AdvisoryError is the result label value of advisory evaluations which
+	// failed.
+	AdvisoryError = "error"
+
+	// AdvisorySkipped is the result label value of requests which were not
+	// sampled for advisory evaluation.
+	AdvisorySkipped = "skipped"
+)
+
+const (
+	// InvalidRequestDenied is the action label value of structurally invalid
+	// requests which were denied.
+	InvalidRequestDenied = "denied"
+
+	// InvalidRequestIgnored is the action label value of structurally invalid
+	// requests which were left unreviewed.
+	InvalidRequestIgnored = "ignored"
+)
+
+const (
+	// PostProcessSuccess is the result label value of verdicts which were
+	// processed by a post-processor.
+	PostProcessSuccess = "success"
+
+	// PostProcessError is the result label value of verdicts which a
+	// post-processor failed to process.
+	PostProcessError = "error"
+)
+
+const (
+	// KubeClientRequestSuccess is the result label value of requests to the
+	// Kubernetes API server which received a response.
+	KubeClientRequestSuccess = "success"
+
+	// KubeClientRequestError is the result label value of requests to the
+	// Kubernetes API server which failed without a response.
+	KubeClientRequestError = "error"
+
+	// KubeClientRequestTimeout is the result label value of requests to the
+	// Kubernetes API server which exceeded their deadline.
+	KubeClientRequestTimeout = "timeout"
+)
+
+const (
+	// EnrichmentCacheHit is the result label value of enrichment cache reads
+	// served from the cache.
+	EnrichmentCacheHit = "hit"
+
+	// EnrichmentCacheMiss is the result label value of enrichment cache reads
+	// served by the API server.
+	EnrichmentCacheMiss = "miss"
+)
+
+var (
+	// PoliciesIgnoredCount is the number of CertificateRequestPolicies ignored
+	// by reviews because the cluster has more policies than the configured
+	// maximum.
+	PoliciesIgnoredCount = policiesIgnoredCountDefinition.gauge()
+
+	// PolicyLimitRejections counts the CertificateRequestPolicies rejected on
+	// creation because the cluster has the configured maximum of policies.
+	PolicyLimitRejections = policyLimitRejectionsTotalDefinition.counter()
+
+	// PolicyUpdates counts the updates of CertificateRequestPolicies, by
+	// whether they changed how requests are reviewed. The result label is
+	// PolicyUpdateChanged or PolicyUpdateSkipped.
+	PolicyUpdates = policyUpdatesTotalDefinition.counterVec()
+
+	// RBACChanges counts the changes of RBAC resources, by kind and whether
+	// they were relevant to the binding of CertificateRequestPolicies. The
+	// result label is RBACChangeRelevant or RBACChangeSkipped.
+	RBACChanges = rbacChangesTotalDefinition.counterVec()
+
+	// CompiledMatchersMemory is the estimated memory used by compiled CEL
+	// validation expressions.
+	CompiledMatchersMemory = compiledMatchersMemoryBytesDefinition.gauge()
+
+	// PoliciesFailingValidation is the number of existing
+	// CertificateRequestPolicies which failed the webhook validation when
+	// approver-policy last became leader.
+	PoliciesFailingValidation = policiesFailingValidationCountDefinition.gauge()
+
+	// AdvisoryEvaluations counts the evaluations of advisory evaluators, by
+	// evaluator and result. The result label is one of the Advisory* values.
+	AdvisoryEvaluations = advisoryEvaluationsTotalDefinition.counterVec()
+
+	// PluginFailures counts the plugin evaluations which failed and had their
+	// failure policy of Deny or Skip applied, by plugin and failure policy.
+	PluginFailures = pluginFailuresTotalDefinition.counterVec()
+
+	// InvalidRequests counts the structurally invalid CertificateRequests, by
+	// problem and action. The action label is InvalidRequestDenied or
+	// InvalidRequestIgnored.
+	InvalidRequests = invalidRequestsTotalDefinition.counterVec()
+
+	// PostProcesses counts the verdicts passed to post-processors, by
+	// post-processor and result. The result label is PostProcessSuccess or
+	// PostProcessError.
+	PostProcesses = postProcessesTotalDefinition.counterVec()
+
+	// CompiledMatchersEvictions counts the compiled CEL validation expressions
+	// evicted to stay within their memory limit.
+	CompiledMatchersEvictions = compiledMatchersEvictionsTotalDefinition.counter()
+
+	// KubeClientRequests counts the requests made to the Kubernetes API
+	// server, by verb, resource and result. The result label is one of the
+	// KubeClientRequest* values.
+	KubeClientRequests = kubeClientRequestsTotalDefinition.counterVec()
+
+	// KubeClientRequestDuration sums the time spent waiting for responses from
+	// the Kubernetes API server, by verb and resource.
+	KubeClientRequestDuration = kubeClientRequestDurationSecondsTotalDefinition.counterVec()
+
+	// EnrichmentCacheReads counts the reads made through the enrichment
+	// cache, by type and result. The result label is EnrichmentCacheHit or
+	// EnrichmentCacheMiss.
+	EnrichmentCacheReads = enrichmentCacheReadsTotalDefinition.counterVec()
+
+	// EnrichmentCacheStaleness observes the age of the objects served from
+	// the enrichment cache, by type.
+	EnrichmentCacheStaleness = enrichmentCacheStalenessSecondsDefinition.histogramVec()
 )
 
 // You don't need to wait for the cache to be synced before calling this. This
-// function is non-blocking.
-func RegisterMetrics(ctx context.Context, log logr.Logger, c cache.Cache) {
-	metrics.Registry.MustRegister(collector{ctx, log, c})
+// function is non-blocking. fieldUsage may be nil if field usage analysis is
+// disabled.
+func RegisterMetrics(ctx context.Context, log logr.Logger, c cache.Cache, decisions *PolicyDecisions, fieldUsage *FieldUsage) {
+	metrics.Registry.MustRegister(collector{ctx, log, c}, DenialBackoffCount, decisions.Collector(),
+		PoliciesIgnoredCount, PolicyLimitRejections, PolicyUpdates, RBACChanges, PoliciesFailingValidation, AdvisoryEvaluations, PluginFailures, InvalidRequests, PostProcesses, CompiledMatchersMemory, CompiledMatchersEvictions,
+		KubeClientRequests, KubeClientRequestDuration, EnrichmentCacheReads, EnrichmentCacheStaleness)
+	if fieldUsage != nil {
+		metrics.Registry.MustRegister(fieldUsage.Collector())
+	}
 }
 
 // We use a custom collector instead of prometheus.NewGaugeVec because it is
@@ -112,6 +259,7 @@ func (cc collector) Collect(ch chan<- prometheus.Metric) {
 	collectCRsApproved(cc.ctx, cc.log, cc.cache, ch)
 	collectCRsDenied(cc.ctx, cc.log, cc.cache, ch)
 	collectCRsUnmatched(cc.log, cc.cache, ch)
+	collectCRsApprovedByDefaultApprover(cc.ctx, cc.log, cc.cache, ch)
 }
 
 // hasSynced returns true if the cache has synced. Otherwise, it returns false.
@@ -249,6 +397,58 @@ func collectCRsUnmatched(logger logr.Logger, c cache.Cache, ch chan<- prometheus
 	}
 }
 
+// DefaultApproverReason is the reason of the Approved condition set by
+// cert-manager's built-in approver.
+const DefaultApproverReason = "cert-manager.io"
+
+// ApprovedByDefaultApprover returns true if the CertificateRequest was approved
+// by cert-manager's built-in approver, which approves every request and so
+// bypasses all CertificateRequestPolicies.
+func ApprovedByDefaultApprover(cr *cmapi.CertificateRequest) bool {
+	for _, cond := range cr.Status.Conditions {
+		if cond.Type == cmapi.CertificateRequestConditionApproved {
+			return cond.Status == cmmeta.ConditionTrue && cond.Reason == DefaultApproverReason
+		}
+	}
+	return false
+}
+
+func collectCRsApprovedByDefaultApprover(ctx context.Context, log logr.Logger, c cache.Cache, ch chan<- prometheus.Metric) {
+	list := &cmapi.CertificateRequestList{}
+	err := c.List(ctx, list)
+	if err != nil {
+		log.Error(err, "unable to list CertificateRequests")
+		return
+	}
+
+	type label struct{ namespace string }
+
+	var labels []label
+	count := make(map[label]int)
+
+	for i := range list.Items {
+		if !ApprovedByDefaultApprover(&list.Items[i]) {
+			continue
+		}
+
+		k := label{namespace: list.Items[i].Namespace}
+		_, exists := count[k]
+		if !exists {
+			labels = append(labels, k)
+		}
+		count[k] += 1
+	}
+
+	for _, key := range labels {
+		ch <- prometheus.MustNewConstMetric(
+			defaultApproverApprovedCount,
+			prometheus.GaugeValue,
+			float64(count[key]),
+			key.namespace,
+		)
+	}
+}
+
 // Returns "True" or "False", or "Unknown" if the condition with the given type
 // (e.g., "Approved" or "Denied") exists, or "" if the condition is not found.
 func getStatus(condTyp cmapi.CertificateRequestConditionType, conditions []cmapi.CertificateRequestCondition) cmmeta.ConditionStatus {
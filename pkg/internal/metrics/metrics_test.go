@@ -113,6 +113,36 @@ func Test_Metrics(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("default_approver_approved_count counts the CRs approved by cert-manager's built-in approver", func(t *testing.T) {
+		mock := mockCollector(t, []cmapi.CertificateRequest{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo1", Namespace: "bar"},
+				Status: cmapi.CertificateRequestStatus{Conditions: []cmapi.CertificateRequestCondition{
+					{Type: "Approved", Status: "True", Reason: "policy.cert-manager.io"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo2", Namespace: "bar"},
+				Status: cmapi.CertificateRequestStatus{Conditions: []cmapi.CertificateRequestCondition{
+					{Type: "Approved", Status: "True", Reason: "cert-manager.io"},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo3", Namespace: "bar"},
+				Status: cmapi.CertificateRequestStatus{Conditions: []cmapi.CertificateRequestCondition{
+					{Type: "Denied", Status: "True", Reason: "cert-manager.io"},
+				}},
+			},
+		})
+		const expected = `
+		# HELP approverpolicy_certificaterequest_default_approver_approved_count Number of CertificateRequests that have been approved by cert-manager's built-in approver, which approves every request without evaluating CertificateRequestPolicies.
+		# TYPE approverpolicy_certificaterequest_default_approver_approved_count gauge
+		approverpolicy_certificaterequest_default_approver_approved_count{namespace="bar"} 1
+		`
+		err := testutil.CollectAndCompare(mock, strings.NewReader(expected), "approverpolicy_certificaterequest_default_approver_approved_count")
+		require.NoError(t, err)
+	})
+
 	t.Run("unmatched_count is only about CRs with no Approved and Denied condition", func(t *testing.T) {
 		mock := mockCollector(t, []cmapi.CertificateRequest{
 			// Three unmatched CRs.
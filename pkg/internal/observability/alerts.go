@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package observability
+
+import (
+	"github.com/prometheus/common/model"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+type ruleFile struct {
+	Groups []ruleGroup `json:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AlertRules returns a Prometheus alerting rules file, encoded as YAML, with a
+// rule for each of the given metric definitions which defines an alert.
+func AlertRules(definitions []metrics.Definition) ([]byte, error) {
+	group := ruleGroup{Name: "approver-policy", Rules: []rule{}}
+
+	for _, def := range definitions {
+		alert := def.Alert
+		if alert == nil {
+			continue
+		}
+
+		r := rule{
+			Alert: alert.Name,
+			Expr:  alert.Expr,
+			Annotations: map[string]string{
+				"summary":     alert.Summary,
+				"description": alert.Description,
+			},
+		}
+		if alert.For > 0 {
+			r.For = model.Duration(alert.For).String()
+		}
+		if len(alert.Severity) > 0 {
+			r.Labels = map[string]string{"severity": alert.Severity}
+		}
+
+		group.Rules = append(group.Rules, r)
+	}
+
+	return yaml.Marshal(ruleFile{Groups: []ruleGroup{group}})
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package observability generates Grafana dashboards and Prometheus alerting
+// rules from the metric catalog of approver-policy.
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+const (
+	// dashboardUID is the stable UID of the generated Grafana dashboard, so
+	// that re-importing a newer export replaces the existing dashboard.
+	dashboardUID = "approver-policy"
+
+	// panelWidth and panelHeight are the size of each panel in Grafana grid
+	// units. The grid is 24 units wide.
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+type dashboard struct {
+	UID           string         `json:"uid"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Tags          []string       `json:"tags"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Editable      bool           `json:"editable"`
+	Refresh       string         `json:"refresh"`
+	Time          timeRange      `json:"time"`
+	Templating    templating     `json:"templating"`
+	Panels        []panel        `json:"panels"`
+	Annotations   annotationList `json:"annotations"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []templateVariable `json:"list"`
+}
+
+type templateVariable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type annotationList struct {
+	List []any `json:"list"`
+}
+
+type panel struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Datasource  datasource `json:"datasource"`
+	GridPos     gridPos    `json:"gridPos"`
+	Targets     []target   `json:"targets"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type target struct {
+	RefID        string     `json:"refId"`
+	Expr         string     `json:"expr"`
+	LegendFormat string     `json:"legendFormat"`
+	Datasource   datasource `json:"datasource"`
+}
+
+// Dashboard returns a Grafana dashboard, encoded as JSON, with a time series
+// panel for each of the given metric definitions. Gauges are plotted as their
+// value, and counters as their per-second rate, summed by the metric labels.
+// Histograms are plotted as their 90th percentile by the metric labels.
+func Dashboard(definitions []metrics.Definition) ([]byte, error) {
+	ds := datasource{Type: "prometheus", UID: "${datasource}"}
+
+	d := dashboard{
+		UID:           dashboardUID,
+		Title:         "approver-policy",
+		Description:   "CertificateRequest approval by approver-policy. Generated by `approver-policy observability export`.",
+		Tags:          []string{"cert-manager", "approver-policy"},
+		SchemaVersion: 39,
+		Editable:      true,
+		Refresh:       "1m",
+		Time:          timeRange{From: "now-6h", To: "now"},
+		Templating: templating{List: []templateVariable{{
+			Name:  "datasource",
+			Label: "Data source",
+			Type:  "datasource",
+			Query: "prometheus",
+		}}},
+		Panels:      []panel{},
+		Annotations: annotationList{List: []any{}},
+	}
+
+	for i, def := range definitions {
+		expr, err := panelExpr(def)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Panels = append(d.Panels, panel{
+			ID:          i + 1,
+			Type:        "timeseries",
+			Title:       def.Name,
+			Description: def.Help,
+			Datasource:  ds,
+			GridPos: gridPos{
+				H: panelHeight,
+				W: panelWidth,
+				X: (i % 2) * panelWidth,
+				Y: (i / 2) * panelHeight,
+			},
+			Targets: []target{{
+				RefID:        "A",
+				Expr:         expr,
+				LegendFormat: legendFormat(def.Labels),
+				Datasource:   ds,
+			}},
+		})
+	}
+
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// panelExpr returns the PromQL expression plotted for the metric.
+func panelExpr(def metrics.Definition) (string, error) {
+	series := def.Name
+	switch def.Type {
+	case metrics.TypeGauge:
+	case metrics.TypeCounter:
+		series = fmt.Sprintf("rate(%s[$__rate_interval])", def.Name)
+	case metrics.TypeHistogram:
+		labels := append([]string{"le"}, def.Labels...)
+		return fmt.Sprintf("histogram_quantile(0.9, sum by (%s) (rate(%s_bucket[$__rate_interval])))",
+			strings.Join(labels, ", "), def.Name), nil
+	default:
+		return "", fmt.Errorf("metric %q has unsupported type %q", def.Name, def.Type)
+	}
+
+	if len(def.Labels) == 0 {
+		return fmt.Sprintf("sum(%s)", series), nil
+	}
+	return fmt.Sprintf("sum by (%s) (%s)", strings.Join(def.Labels, ", "), series), nil
+}
+
+// legendFormat returns the Grafana legend template which names a series by
+// its labels.
+func legendFormat(labels []string) string {
+	var parts []string
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("{{%s}}", label))
+	}
+	return strings.Join(parts, " ")
+}
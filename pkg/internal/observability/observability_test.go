@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package observability
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+var testDefinitions = []metrics.Definition{
+	{
+		Name:   "test_gauge",
+		Help:   "A test gauge.",
+		Type:   metrics.TypeGauge,
+		Labels: []string{"namespace"},
+	},
+	{
+		Name: "test_total",
+		Help: "A test counter.",
+		Type: metrics.TypeCounter,
+		Alert: &metrics.Alert{
+			Name:        "TestAlert",
+			Expr:        "rate(test_total[5m]) > 0",
+			For:         90 * time.Minute,
+			Severity:    "warning",
+			Summary:     "Test summary",
+			Description: "Test description",
+		},
+	},
+}
+
+func Test_Dashboard(t *testing.T) {
+	b, err := Dashboard(testDefinitions)
+	require.NoError(t, err)
+
+	var d dashboard
+	require.NoError(t, json.Unmarshal(b, &d))
+
+	require.Len(t, d.Panels, 2)
+	assert.Equal(t, "sum by (namespace) (test_gauge)", d.Panels[0].Targets[0].Expr)
+	assert.Equal(t, "{{namespace}}", d.Panels[0].Targets[0].LegendFormat)
+	assert.Equal(t, gridPos{H: 8, W: 12, X: 0, Y: 0}, d.Panels[0].GridPos)
+	assert.Equal(t, "sum(rate(test_total[$__rate_interval]))", d.Panels[1].Targets[0].Expr)
+	assert.Equal(t, gridPos{H: 8, W: 12, X: 12, Y: 0}, d.Panels[1].GridPos)
+
+	b, err = Dashboard([]metrics.Definition{{Name: "test_seconds", Type: metrics.TypeHistogram, Labels: []string{"type"}}})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &d))
+	assert.Equal(t, "histogram_quantile(0.9, sum by (le, type) (rate(test_seconds_bucket[$__rate_interval])))", d.Panels[0].Targets[0].Expr)
+
+	_, err = Dashboard([]metrics.Definition{{Name: "test_summary", Type: "summary"}})
+	assert.EqualError(t, err, `metric "test_summary" has unsupported type "summary"`)
+}
+
+func Test_AlertRules(t *testing.T) {
+	b, err := AlertRules(testDefinitions)
+	require.NoError(t, err)
+
+	assert.Equal(t, `groups:
+- name: approver-policy
+  rules:
+  - alert: TestAlert
+    annotations:
+      description: Test description
+      summary: Test summary
+    expr: rate(test_total[5m]) > 0
+    for: 1h30m
+    labels:
+      severity: warning
+`, string(b))
+}
+
+func Test_Catalog(t *testing.T) {
+	// The catalog of this version must always produce valid output.
+	_, err := Dashboard(metrics.Catalog())
+	assert.NoError(t, err)
+	_, err = AlertRules(metrics.Catalog())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policyreview serves CertificateRequestPolicyReviews, which report
+// the CertificateRequestPolicies that would be used to evaluate requests from a
+// given subject. This allows users to discover which certificates they are
+// allowed to request, without creating a CertificateRequest.
+package policyreview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+)
+
+const (
+	// Path is the HTTP path that CertificateRequestPolicyReviews are served
+	// on.
+	Path = "/apis/policy.cert-manager.io/v1alpha1/certificaterequestpolicyreviews"
+
+	// Kind is the kind of a CertificateRequestPolicyReview.
+	Kind = "CertificateRequestPolicyReview"
+
+	// maxBodyBytes is the maximum size of a review request body.
+	maxBodyBytes = 1 << 20
+)
+
+// CertificateRequestPolicyReview is submitted by a client to discover which
+// CertificateRequestPolicies would be used to evaluate a request from the
+// given subject, for the given issuer and namespace.
+type CertificateRequestPolicyReview struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Spec holds the hypothetical request being reviewed.
+	Spec CertificateRequestPolicyReviewSpec `json:"spec"`
+
+	// Status is populated by the server with the result of the review.
+	Status CertificateRequestPolicyReviewStatus `json:"status,omitempty"`
+}
+
+// CertificateRequestPolicyReviewSpec describes the requester, issuer and
+// namespace of a hypothetical CertificateRequest.
+type CertificateRequestPolicyReviewSpec struct {
+	// User is the username of the requester.
+	User string `json:"user,omitempty"`
+
+	// Groups are the groups of the requester.
+	Groups []string `json:"groups,omitempty"`
+
+	// UID is the UID of the requester.
+	UID string `json:"uid,omitempty"`
+
+	// Extra is the extra information of the requester.
+	Extra map[string][]string `json:"extra,omitempty"`
+
+	// Namespace is the namespace the request would be created in.
+	Namespace string `json:"namespace"`
+
+	// IssuerRef is the issuer the request would reference.
+	IssuerRef cmmeta.ObjectReference `json:"issuerRef"`
+}
+
+// CertificateRequestPolicyReviewStatus holds the result of a review.
+type CertificateRequestPolicyReviewStatus struct {
+	// Policies are the names of the Ready CertificateRequestPolicies which
+	// select the issuer and namespace, and are bound to the requester. A
+	// request would be evaluated against these policies.
+	Policies []string `json:"policies"`
+}
+
+// Reviewer resolves the CertificateRequestPolicies that would be used to
+// evaluate a request, using the same predicates as the approver Manager.
+type Reviewer struct {
+	log        logr.Logger
+	lister     client.Reader
+	predicates []predicate.Predicate
+}
+
+// NewReviewer constructs a new Reviewer which filters the listed policies with
+// the given predicates.
+func NewReviewer(log logr.Logger, lister client.Reader, predicates []predicate.Predicate) *Reviewer {
+	return &Reviewer{
+		log:        log.WithName("policyreview"),
+		lister:     lister,
+		predicates: predicates,
+	}
+}
+
+// Review returns the names of the CertificateRequestPolicies that would be used
+// to evaluate a request matching the given spec.
+func (r *Reviewer) Review(ctx context.Context, spec CertificateRequestPolicyReviewSpec) ([]string, error) {
+	var policyList policyapi.CertificateRequestPolicyList
+	if err := r.lister.List(ctx, &policyList); err != nil {
+		return nil, fmt.Errorf("failed to list CertificateRequestPolicies: %w", err)
+	}
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: spec.Namespace},
+		Spec: cmapi.CertificateRequestSpec{
+			Username:  spec.User,
+			Groups:    spec.Groups,
+			UID:       spec.UID,
+			Extra:     spec.Extra,
+			IssuerRef: spec.IssuerRef,
+		},
+	}
+
+	policies := policyList.Items
+	for _, p := range r.predicates {
+		var err error
+		policies, err = p(ctx, cr, policies)
+		if err != nil {
+			return nil, fmt.Errorf("failed to perform predicate on policies: %w", err)
+		}
+	}
+
+	names := []string{}
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+
+	return names, nil
+}
+
+// ServeHTTP serves a CertificateRequestPolicyReview POSTed as JSON, responding
+// with the review and its status populated.
+func (r *Reviewer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var review CertificateRequestPolicyReview
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxBodyBytes)).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode %s: %s", Kind, err), http.StatusBadRequest)
+		return
+	}
+
+	if errs := validateSpec(review.Spec); len(errs) > 0 {
+		http.Error(w, errs.ToAggregate().Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	policies, err := r.Review(req.Context(), review.Spec)
+	if err != nil {
+		r.log.Error(err, "failed to review policies")
+		http.Error(w, "failed to review policies", http.StatusInternalServerError)
+		return
+	}
+
+	review.TypeMeta = metav1.TypeMeta{APIVersion: policyapi.SchemeGroupVersion.String(), Kind: Kind}
+	review.Status.Policies = policies
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		r.log.Error(err, "failed to write response")
+	}
+}
+
+// validateSpec validates that a review spec identifies a requester, namespace
+// and issuer.
+func validateSpec(spec CertificateRequestPolicyReviewSpec) field.ErrorList {
+	var (
+		el      field.ErrorList
+		fldPath = field.NewPath("spec")
+	)
+
+	if len(spec.User) == 0 && len(spec.Groups) == 0 {
+		el = append(el, field.Required(fldPath.Child("user"), "one of user or groups must be defined"))
+	}
+	if len(spec.Namespace) == 0 {
+		el = append(el, field.Required(fldPath.Child("namespace"), ""))
+	}
+	if len(spec.IssuerRef.Name) == 0 {
+		el = append(el, field.Required(fldPath.Child("issuerRef", "name"), ""))
+	}
+
+	return el
+}
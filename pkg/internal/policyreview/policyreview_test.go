@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policyreview
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	internalmanager "github.com/cert-manager/approver-policy/pkg/internal/approver/manager"
+)
+
+func Test_ServeHTTP(t *testing.T) {
+	policy := func(name, issuerName string) *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: &issuerName},
+				},
+			},
+			Status: policyapi.CertificateRequestPolicyStatus{
+				Conditions: []policyapi.CertificateRequestPolicyCondition{
+					{Type: policyapi.CertificateRequestPolicyConditionReady, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+	}
+
+	// alice is bound to the "bound" and "other-issuer" policies.
+	fakeclient := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithObjects(policy("bound", "my-issuer"), policy("not-bound", "my-issuer"), policy("other-issuer", "other")).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+				sar := obj.(*authzv1.SubjectAccessReview)
+				sar.Status.Allowed = sar.Spec.User == "alice" && sar.Spec.ResourceAttributes.Name != "not-bound"
+				return nil
+			},
+		}).
+		Build()
+
+	reviewer := NewReviewer(logr.Discard(), fakeclient, internalmanager.Predicates(fakeclient, fakeclient, internalmanager.PredicateOptions{DefaultSelectorMode: policyapi.SelectorModeAll}))
+
+	tests := map[string]struct {
+		method      string
+		body        string
+		expCode     int
+		expPolicies []string
+	}{
+		"a non-POST request should be rejected": {
+			method:  http.MethodGet,
+			expCode: http.StatusMethodNotAllowed,
+		},
+		"a malformed body should be rejected": {
+			method:  http.MethodPost,
+			body:    "{",
+			expCode: http.StatusBadRequest,
+		},
+		"a review without a subject, namespace or issuer should be rejected": {
+			method:  http.MethodPost,
+			body:    `{"spec":{}}`,
+			expCode: http.StatusUnprocessableEntity,
+		},
+		"a bound subject should have the bound policies selecting the issuer returned": {
+			method:      http.MethodPost,
+			body:        `{"spec":{"user":"alice","namespace":"default","issuerRef":{"name":"my-issuer"}}}`,
+			expCode:     http.StatusOK,
+			expPolicies: []string{"bound"},
+		},
+		"a subject bound to no policies should have an empty list returned": {
+			method:      http.MethodPost,
+			body:        `{"spec":{"user":"bob","namespace":"default","issuerRef":{"name":"my-issuer"}}}`,
+			expCode:     http.StatusOK,
+			expPolicies: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			reviewer.ServeHTTP(rec, httptest.NewRequest(test.method, Path, strings.NewReader(test.body)))
+			require.Equal(t, test.expCode, rec.Code, rec.Body.String())
+
+			if test.expCode != http.StatusOK {
+				return
+			}
+
+			var review CertificateRequestPolicyReview
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &review))
+			assert.Equal(t, Kind, review.Kind)
+			assert.Equal(t, test.expPolicies, review.Status.Policies)
+		})
+	}
+}
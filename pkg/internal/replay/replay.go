@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"k8s.io/apimachinery/pkg/types"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+)
+
+// maxLineSize is the maximum size of a single audit event line. Events
+// embed the full CertificateRequest, including its CSR.
+const maxLineSize = 4 << 20
+
+// Decision is a historical decision read from an audit log.
+type Decision struct {
+	// AuditID is the ID of the audit event recording the decision.
+	AuditID types.UID
+
+	// Request is the CertificateRequest as it was recorded. Values of the
+	// requester which were redacted are recorded as redacted.
+	Request *cmapi.CertificateRequest
+
+	// Verdict is the decision that was made, Approved or Denied.
+	Verdict fixtures.Verdict
+
+	// Message is the message of the decision.
+	Message string
+}
+
+// Change is a decision whose verdict changes when it is replayed.
+type Change struct {
+	AuditID   types.UID        `json:"auditID"`
+	Namespace string           `json:"namespace"`
+	Name      string           `json:"name"`
+	Old       fixtures.Verdict `json:"old"`
+	New       fixtures.Verdict `json:"new"`
+	Message   string           `json:"message"`
+}
+
+// String returns a human readable description of the change.
+func (c Change) String() string {
+	return fmt.Sprintf("%s/%s (%s): %s -> %s: %s", c.Namespace, c.Name, c.AuditID, c.Old, c.New, c.Message)
+}
+
+// Report is the result of replaying decisions.
+type Report struct {
+	// Replayed is the number of decisions which were replayed.
+	Replayed int `json:"replayed"`
+
+	// Changes are the decisions whose verdict changed, in the order of the
+	// audit log.
+	Changes []Change `json:"changes"`
+}
+
+// ReadDecisions reads the decisions recorded as JSON lines of audit events by
+// approver-policy. Empty lines, and events which do not record a decision on
+// a CertificateRequest, are skipped.
+func ReadDecisions(r io.Reader) ([]Decision, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	var decisions []Decision
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event auditv1.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: failed to decode audit event: %w", line, err)
+		}
+
+		verdict := fixtures.Verdict(event.Annotations[audit.AnnotationDecision])
+		if verdict != fixtures.VerdictApproved && verdict != fixtures.VerdictDenied {
+			continue
+		}
+		if event.RequestObject == nil || len(event.RequestObject.Raw) == 0 {
+			return nil, fmt.Errorf("line %d: audit event %s records a decision without a request object", line, event.AuditID)
+		}
+
+		var cr cmapi.CertificateRequest
+		if err := json.Unmarshal(event.RequestObject.Raw, &cr); err != nil {
+			return nil, fmt.Errorf("line %d: failed to decode CertificateRequest of audit event %s: %w", line, event.AuditID, err)
+		}
+		if cr.Kind != cmapi.CertificateRequestKind {
+			continue
+		}
+
+		decisions = append(decisions, Decision{
+			AuditID: event.AuditID,
+			Request: &cr,
+			Verdict: verdict,
+			Message: event.Annotations[audit.AnnotationMessage],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit events: %w", err)
+	}
+
+	return decisions, nil
+}
+
+// Replay reviews each decision's request again with the reviewer, and
+// reports those whose verdict changes.
+func Replay(ctx context.Context, reviewer manager.Interface, decisions []Decision) (*Report, error) {
+	report := &Report{Changes: []Change{}}
+	for _, decision := range decisions {
+		response, err := reviewer.Review(ctx, decision.Request.DeepCopy())
+		if err != nil {
+			return nil, fmt.Errorf("failed to review %s/%s of audit event %s: %w",
+				decision.Request.Namespace, decision.Request.Name, decision.AuditID, err)
+		}
+		report.Replayed++
+
+		if verdict := fixtures.VerdictOf(response.Result); verdict != decision.Verdict {
+			report.Changes = append(report.Changes, Change{
+				AuditID:   decision.AuditID,
+				Namespace: decision.Request.Namespace,
+				Name:      decision.Request.Name,
+				Old:       decision.Verdict,
+				New:       verdict,
+				Message:   response.Message,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// Namespaces returns the namespaces of the decisions' requests.
+func Namespaces(decisions []Decision) []string {
+	namespaces := make([]string, 0, len(decisions))
+	for _, decision := range decisions {
+		namespaces = append(namespaces, decision.Request.Namespace)
+	}
+	return namespaces
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/cert-manager/approver-policy/pkg/approver/manager"
+	fakemanager "github.com/cert-manager/approver-policy/pkg/approver/manager/fake"
+	"github.com/cert-manager/approver-policy/pkg/internal/audit"
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+)
+
+func request(name string) *cmapi.CertificateRequest {
+	return &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name},
+		Spec:       cmapi.CertificateRequestSpec{Username: "alice"},
+	}
+}
+
+func eventLine(t *testing.T, cr *cmapi.CertificateRequest, result manager.ReviewResult, message string) string {
+	event, err := audit.NewEvent(cr, manager.ReviewResponse{Result: result, Message: message}, time.Now())
+	require.NoError(t, err)
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func Test_ReadDecisions(t *testing.T) {
+	tests := map[string]struct {
+		lines        []string
+		expDecisions []Decision
+		expErr       bool
+	}{
+		"approved and denied decisions should be read in order": {
+			lines: []string{
+				eventLine(t, request("a"), manager.ResultApproved, "approved by foo"),
+				"",
+				eventLine(t, request("b"), manager.ResultDenied, "no policy"),
+			},
+			expDecisions: []Decision{
+				{Request: request("a"), Verdict: fixtures.VerdictApproved, Message: "approved by foo"},
+				{Request: request("b"), Verdict: fixtures.VerdictDenied, Message: "no policy"},
+			},
+		},
+		"events without a decision should be skipped": {
+			lines: []string{`{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"x"}`},
+		},
+		"malformed lines should error": {
+			lines:  []string{"{"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			decisions, err := ReadDecisions(strings.NewReader(strings.Join(test.lines, "\n")))
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+
+			for i := range decisions {
+				assert.NotEmpty(t, decisions[i].AuditID)
+				decisions[i].AuditID = ""
+				decisions[i].Request.TypeMeta = metav1.TypeMeta{}
+			}
+			assert.Equal(t, test.expDecisions, decisions)
+		})
+	}
+}
+
+func Test_Replay(t *testing.T) {
+	reviewer := fakemanager.NewFakeManager().WithReview(func(_ context.Context, cr *cmapi.CertificateRequest) (manager.ReviewResponse, error) {
+		switch cr.Name {
+		case "approved":
+			return manager.ReviewResponse{Result: manager.ResultApproved, Message: "approved by team-a"}, nil
+		case "denied":
+			return manager.ReviewResponse{Result: manager.ResultDenied, Message: "denied by team-a"}, nil
+		default:
+			return manager.ReviewResponse{Result: manager.ResultUnprocessed, Message: "no policy bound"}, nil
+		}
+	})
+
+	var log bytes.Buffer
+	for _, line := range []string{
+		eventLine(t, request("approved"), manager.ResultApproved, ""),
+		eventLine(t, request("denied"), manager.ResultApproved, ""),
+		eventLine(t, request("unbound"), manager.ResultDenied, ""),
+	} {
+		log.WriteString(line + "\n")
+	}
+
+	decisions, err := ReadDecisions(&log)
+	require.NoError(t, err)
+
+	report, err := Replay(context.TODO(), reviewer, decisions)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Replayed)
+	assert.Equal(t, []Change{
+		{AuditID: decisions[1].AuditID, Namespace: "team-a", Name: "denied", Old: fixtures.VerdictApproved, New: fixtures.VerdictDenied, Message: "denied by team-a"},
+		{AuditID: decisions[2].AuditID, Namespace: "team-a", Name: "unbound", Old: fixtures.VerdictDenied, New: fixtures.VerdictUnprocessed, Message: "no policy bound"},
+	}, report.Changes)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// bufferPool holds the buffers that policies are encoded into to compute their
+// cache key, so that large syncs of policies do not allocate a buffer per
+// admission request. The buffers are only used for hashing; admission
+// requests are decoded by controller-runtime.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// cacheKey is the hash of the parts of a policy which are validated.
+type cacheKey [sha256.Size]byte
+
+// cacheKeyObject holds the parts of a policy which are validated. Fields which
+// change on every write, such as the resourceVersion, are excluded so that
+// identical policies applied repeatedly share a key.
+type cacheKeyObject struct {
+	Name        string                                 `json:"name"`
+	Labels      map[string]string                      `json:"labels,omitempty"`
+	Annotations map[string]string                      `json:"annotations,omitempty"`
+	Spec        policyapi.CertificateRequestPolicySpec `json:"spec"`
+}
+
+// cacheEntry is the cached result of validating a policy.
+type cacheEntry struct {
+	warnings admission.Warnings
+	err      error
+	expires  time.Time
+}
+
+// responseCache caches the result of validating policies, keyed by their
+// content. GitOps reconcilers commonly re-apply unchanged policies during a
+// sync, which would otherwise run every validation, including registered
+// plugin webhooks, again.
+//
+// Caching only saves the cost of validation. Requests are still decoded one at
+// a time by controller-runtime's admission decoder; decoding batches of them
+// concurrently into pooled buffers would need a custom admission handler in
+// place of the CustomValidator, and is deferred until decoding shows up as a
+// cost during large syncs.
+type responseCache struct {
+	// clock returns time which can be overwritten for testing.
+	clock clock.PassiveClock
+
+	// ttl is how long validation results are cached for.
+	ttl time.Duration
+
+	// size is the maximum number of cached results.
+	size int
+
+	lock    sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+// newResponseCache returns a responseCache, or nil if the ttl or size is not
+// positive which disables caching.
+func newResponseCache(clock clock.PassiveClock, ttl time.Duration, size int) *responseCache {
+	if ttl <= 0 || size <= 0 {
+		return nil
+	}
+	return &responseCache{
+		clock:   clock,
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[cacheKey]cacheEntry),
+	}
+}
+
+// key returns the cache key of the policy.
+func (c *responseCache) key(policy *policyapi.CertificateRequestPolicy) (cacheKey, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	if err := json.NewEncoder(buf).Encode(cacheKeyObject{
+		Name:        policy.Name,
+		Labels:      policy.Labels,
+		Annotations: policy.Annotations,
+		Spec:        policy.Spec,
+	}); err != nil {
+		return cacheKey{}, err
+	}
+
+	return sha256.Sum256(buf.Bytes()), nil
+}
+
+// get returns the cached result for the key, if present and not expired.
+func (c *responseCache) get(key cacheKey) (cacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if !c.clock.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// set caches the result for the key. If the cache is full, expired entries are
+// removed, and if it is still full an arbitrary entry is evicted.
+func (c *responseCache) set(key cacheKey, warnings admission.Warnings, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.clock.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = cacheEntry{warnings: warnings, err: err, expires: now.Add(c.ttl)}
+}
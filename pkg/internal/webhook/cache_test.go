@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/ktesting"
+	fakeclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/approver"
+	fakeapprover "github.com/cert-manager/approver-policy/pkg/approver/fake"
+)
+
+func Test_responseCache(t *testing.T) {
+	fixedTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	policy := func(resourceVersion, issuerName string) *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-policy", ResourceVersion: resourceVersion},
+			Spec: policyapi.CertificateRequestPolicySpec{
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To(issuerName)},
+				},
+			},
+		}
+	}
+
+	assert.Nil(t, newResponseCache(fakeclock.NewFakePassiveClock(fixedTime), 0, 10), "zero ttl should disable caching")
+	assert.Nil(t, newResponseCache(fakeclock.NewFakePassiveClock(fixedTime), time.Minute, 0), "zero size should disable caching")
+
+	clock := fakeclock.NewFakePassiveClock(fixedTime)
+	cache := newResponseCache(clock, time.Minute, 2)
+
+	keyA1, err := cache.key(policy("1", "issuer-a"))
+	require.NoError(t, err)
+	keyA2, err := cache.key(policy("2", "issuer-a"))
+	require.NoError(t, err)
+	keyB, err := cache.key(policy("1", "issuer-b"))
+	require.NoError(t, err)
+	keyC, err := cache.key(policy("1", "issuer-c"))
+	require.NoError(t, err)
+
+	assert.Equal(t, keyA1, keyA2, "policies differing only by resourceVersion should share a key")
+	assert.NotEqual(t, keyA1, keyB, "policies with different specs should have different keys")
+
+	_, ok := cache.get(keyA1)
+	assert.False(t, ok, "empty cache should miss")
+
+	validationErr := errors.New("this is a validation error")
+	cache.set(keyA1, admission.Warnings{"some warning"}, nil)
+	cache.set(keyB, nil, validationErr)
+
+	entry, ok := cache.get(keyA2)
+	assert.True(t, ok)
+	assert.Equal(t, admission.Warnings{"some warning"}, entry.warnings)
+	assert.NoError(t, entry.err)
+
+	entry, ok = cache.get(keyB)
+	assert.True(t, ok)
+	assert.Equal(t, validationErr, entry.err)
+
+	cache.set(keyC, nil, nil)
+	assert.Len(t, cache.entries, 2, "cache should not grow beyond its size")
+
+	clock.SetTime(fixedTime.Add(time.Minute))
+	_, ok = cache.get(keyC)
+	assert.False(t, ok, "expired entries should miss")
+}
+
+func Test_validateCached(t *testing.T) {
+	var calls int
+	webhook := fakeapprover.NewFakeWebhook().WithValidate(func(context.Context, *policyapi.CertificateRequestPolicy) (approver.WebhookValidationResponse, error) {
+		calls++
+		if calls == 1 {
+			return approver.WebhookValidationResponse{}, errors.New("this is a transient error")
+		}
+		return approver.WebhookValidationResponse{Allowed: true, Warnings: admission.Warnings{"some warning"}}, nil
+	})
+
+	fixedTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	v := &validator{
+		log:           ktesting.NewLogger(t, ktesting.DefaultConfig),
+		webhooks:      []approver.Webhook{webhook},
+		maxBreakGlass: 72 * time.Hour,
+		cache:         newResponseCache(fakeclock.NewFakePassiveClock(fixedTime), time.Minute, 10),
+		clock:         fakeclock.NewFakePassiveClock(fixedTime),
+	}
+
+	policy := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-policy"},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+		},
+	}
+
+	_, err := v.validate(context.TODO(), nil, policy)
+	assert.EqualError(t, err, "this is a transient error")
+
+	for range 3 {
+		warnings, err := v.validate(context.TODO(), nil, policy)
+		assert.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"some warning"}, warnings)
+	}
+
+	assert.Equal(t, 2, calls, "errors should not be cached, and successful validations should be cached")
+
+	breakGlass := policy.DeepCopy()
+	breakGlass.Spec.BreakGlass = &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(fixedTime.Add(time.Hour)), IncidentRef: "INC-123"}
+	for range 2 {
+		_, err := v.validate(context.TODO(), nil, breakGlass)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, calls, "break glass policies should not be cached")
+}
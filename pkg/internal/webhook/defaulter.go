@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+// LoadPolicyDefaults reads the CertificateRequestPolicy spec defaults from
+// the YAML file at the given path. Unknown fields are rejected, so that a
+// misspelt default is not silently ignored.
+func LoadPolicyDefaults(path string) (*policyapi.CertificateRequestPolicySpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy defaults: %w", err)
+	}
+
+	var defaults policyapi.CertificateRequestPolicySpec
+	if err := yaml.UnmarshalStrict(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to decode policy defaults %q: %w", path, err)
+	}
+
+	return &defaults, nil
+}
+
+// defaulter sets the cluster's defaults on the spec of
+// CertificateRequestPolicies as they are created. Fields which are set on a
+// policy are never overridden, so the defaults only fill the fields which
+// authors have not set.
+//
+// The patch of the mutating webhook is computed from the policy as it is
+// encoded by approver-policy, so durations given in extended notation such as
+// "90d" are also stored as Go duration strings such as "2160h0m0s", whether
+// the policy is created or updated.
+type defaulter struct {
+	log logr.Logger
+
+	// defaults is the JSON object of the spec defaults. Nil if there are
+	// none.
+	defaults map[string]any
+}
+
+var _ admission.CustomDefaulter = &defaulter{}
+
+// newDefaulter returns a defaulter of the given spec defaults, which may be
+// nil.
+func newDefaulter(log logr.Logger, defaults *policyapi.CertificateRequestPolicySpec) (*defaulter, error) {
+	if defaults == nil {
+		return &defaulter{log: log}, nil
+	}
+
+	obj, err := toJSONObject(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy defaults: %w", err)
+	}
+	return &defaulter{log: log, defaults: obj}, nil
+}
+
+// Default sets the defaults on CertificateRequestPolicies being created.
+// Policies being updated are left as they are, so that changing the defaults
+// does not change existing policies the next time they are applied.
+func (d *defaulter) Default(ctx context.Context, obj runtime.Object) error {
+	policy, ok := obj.(*policyapi.CertificateRequestPolicy)
+	if !ok {
+		return fmt.Errorf("expected a CertificateRequestPolicy, but got a %T", obj)
+	}
+
+	if len(d.defaults) == 0 {
+		return nil
+	}
+
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Operation != admissionv1.Create {
+		return nil
+	}
+
+	spec, err := toJSONObject(&policy.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode CertificateRequestPolicy spec: %w", err)
+	}
+
+	defaulted := mergeDefaults(spec, d.defaults, "spec")
+	if len(defaulted) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode defaulted CertificateRequestPolicy spec: %w", err)
+	}
+	var newSpec policyapi.CertificateRequestPolicySpec
+	if err := json.Unmarshal(data, &newSpec); err != nil {
+		return fmt.Errorf("failed to decode defaulted CertificateRequestPolicy spec: %w", err)
+	}
+	policy.Spec = newSpec
+
+	d.log.V(2).Info("set defaults on CertificateRequestPolicy", "name", policy.Name, "fields", defaulted)
+
+	return nil
+}
+
+// mergeDefaults sets the fields of defaults on obj which obj does not set,
+// recursing into objects set on both. Lists are not merged, so a list set on
+// obj is kept as it is. Returns the sorted paths of the fields which were set.
+func mergeDefaults(obj, defaults map[string]any, path string) []string {
+	var defaulted []string
+	for key, value := range defaults {
+		fieldPath := path + "." + key
+
+		existing, ok := obj[key]
+		if !ok || existing == nil {
+			obj[key] = runtime.DeepCopyJSONValue(value)
+			defaulted = append(defaulted, fieldPath)
+			continue
+		}
+
+		existingObj, existingIsObj := existing.(map[string]any)
+		valueObj, valueIsObj := value.(map[string]any)
+		if existingIsObj && valueIsObj {
+			defaulted = append(defaulted, mergeDefaults(existingObj, valueObj, fieldPath)...)
+		}
+	}
+
+	sort.Strings(defaulted)
+	return defaulted
+}
+
+// toJSONObject returns the JSON object of the given value.
+func toJSONObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
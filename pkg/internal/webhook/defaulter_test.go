@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+)
+
+func Test_Default(t *testing.T) {
+	defaults := &policyapi.CertificateRequestPolicySpec{
+		Allowed: &policyapi.CertificateRequestPolicyAllowed{
+			Usages: &[]cmapi.KeyUsage{cmapi.UsageDigitalSignature},
+		},
+		Constraints: &policyapi.CertificateRequestPolicyConstraints{
+			MaxDuration: &policyapi.Duration{Duration: 90 * 24 * time.Hour},
+			PrivateKey:  &policyapi.CertificateRequestPolicyConstraintsPrivateKey{MinSize: ptr.To(2048)},
+		},
+	}
+
+	tests := map[string]struct {
+		operation admissionv1.Operation
+		spec      policyapi.CertificateRequestPolicySpec
+		expSpec   policyapi.CertificateRequestPolicySpec
+	}{
+		"a created policy without the defaults should have them set": {
+			operation: admissionv1.Create,
+			spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("*")}},
+			},
+			expSpec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{
+					CommonName: &policyapi.CertificateRequestPolicyAllowedString{Value: ptr.To("*")},
+					Usages:     &[]cmapi.KeyUsage{cmapi.UsageDigitalSignature},
+				},
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					MaxDuration: &policyapi.Duration{Duration: 90 * 24 * time.Hour},
+					PrivateKey:  &policyapi.CertificateRequestPolicyConstraintsPrivateKey{MinSize: ptr.To(2048)},
+				},
+			},
+		},
+		"fields set by a created policy should not be overridden": {
+			operation: admissionv1.Create,
+			spec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{Usages: &[]cmapi.KeyUsage{}},
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					MaxDuration: &policyapi.Duration{Duration: time.Hour},
+					PrivateKey:  &policyapi.CertificateRequestPolicyConstraintsPrivateKey{MaxSize: ptr.To(4096)},
+				},
+			},
+			expSpec: policyapi.CertificateRequestPolicySpec{
+				Allowed: &policyapi.CertificateRequestPolicyAllowed{Usages: &[]cmapi.KeyUsage{}},
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{
+					MaxDuration: &policyapi.Duration{Duration: time.Hour},
+					PrivateKey:  &policyapi.CertificateRequestPolicyConstraintsPrivateKey{MinSize: ptr.To(2048), MaxSize: ptr.To(4096)},
+				},
+			},
+		},
+		"an updated policy should not be defaulted": {
+			operation: admissionv1.Update,
+			spec:      policyapi.CertificateRequestPolicySpec{},
+			expSpec:   policyapi.CertificateRequestPolicySpec{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d, err := newDefaulter(ktesting.NewLogger(t, ktesting.DefaultConfig), defaults)
+			require.NoError(t, err)
+
+			ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: test.operation},
+			})
+			policy := &policyapi.CertificateRequestPolicy{Spec: test.spec}
+			require.NoError(t, d.Default(ctx, policy))
+			assert.Equal(t, test.expSpec, policy.Spec)
+		})
+	}
+}
+
+func Test_Default_canonicalizesDurations(t *testing.T) {
+	for _, operation := range []admissionv1.Operation{admissionv1.Create, admissionv1.Update} {
+		t.Run(string(operation), func(t *testing.T) {
+			d, err := newDefaulter(ktesting.NewLogger(t, ktesting.DefaultConfig), nil)
+			require.NoError(t, err)
+
+			handler := admission.WithCustomDefaulter(policyapi.GlobalScheme, &policyapi.CertificateRequestPolicy{}, d)
+			response := handler.Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: operation,
+					Object: runtime.RawExtension{Raw: []byte(`{"apiVersion":"policy.cert-manager.io/v1alpha1","kind":"CertificateRequestPolicy",` +
+						`"metadata":{"name":"test-policy"},"spec":{"constraints":{"minDuration":"1h","maxDuration":"90d"},"selector":{"issuerRef":{}}}}`)},
+				},
+			})
+			require.True(t, response.Allowed, response.Result)
+			patches := make(map[string]any)
+			for _, patch := range response.Patches {
+				patches[patch.Operation+" "+patch.Path] = patch.Value
+			}
+			assert.Equal(t, "2160h0m0s", patches["replace /spec/constraints/maxDuration"])
+			assert.Equal(t, "1h0m0s", patches["replace /spec/constraints/minDuration"])
+		})
+	}
+}
+
+func Test_LoadPolicyDefaults(t *testing.T) {
+	tests := map[string]struct {
+		data        string
+		expDefaults *policyapi.CertificateRequestPolicySpec
+		expErr      bool
+	}{
+		"valid defaults should be loaded": {
+			data: "constraints:\n  maxDuration: 2160h\n",
+			expDefaults: &policyapi.CertificateRequestPolicySpec{
+				Constraints: &policyapi.CertificateRequestPolicyConstraints{MaxDuration: &policyapi.Duration{Duration: 2160 * time.Hour}},
+			},
+		},
+		"unknown fields should be rejected": {
+			data:   "constraints:\n  maxDurration: 2160h\n",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "defaults.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.data), 0o600))
+
+			defaults, err := LoadPolicyDefaults(path)
+			assert.Equal(t, test.expErr, err != nil, "%v", err)
+			assert.Equal(t, test.expDefaults, defaults)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+// startupValidation validates every existing CertificateRequestPolicy with the
+// current webhook validation once approver-policy becomes leader. Policies
+// admitted by older, laxer versions of the webhook are reported with a Warning
+// event, a log line and the PoliciesFailingValidation metric, rather than
+// surfacing as confusing review results.
+type startupValidation struct {
+	log       logr.Logger
+	validator *validator
+	recorder  record.EventRecorder
+
+	// backoff is the backoff between failed attempts to validate the
+	// policies. Defaults to defaultStartupValidationBackoff.
+	backoff *wait.Backoff
+}
+
+// defaultStartupValidationBackoff retries startup validation from every
+// second, up to every minute, until it succeeds.
+var defaultStartupValidationBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      time.Minute,
+}
+
+// Start validates all existing policies. It does not implement
+// LeaderElectionRunnable, so is only started on the leader, once the caches
+// have synced.
+// Startup validation is informational, so failing to list the policies never
+// stops the manager. It is retried with backoff until it succeeds or the
+// context is cancelled.
+func (s *startupValidation) Start(ctx context.Context) error {
+	backoff := defaultStartupValidationBackoff
+	if s.backoff != nil {
+		backoff = *s.backoff
+	}
+
+	_ = wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		if err := s.validate(ctx); err != nil {
+			s.log.Error(err, "failed to validate existing CertificateRequestPolicies, retrying")
+			return false, nil
+		}
+		return true, nil
+	})
+
+	return nil
+}
+
+// validate validates all existing policies, reporting those which fail.
+func (s *startupValidation) validate(ctx context.Context) error {
+	var policyList policyapi.CertificateRequestPolicyList
+	if err := s.validator.lister.List(ctx, &policyList); err != nil {
+		return fmt.Errorf("failed to list CertificateRequestPolicies for startup validation: %w", err)
+	}
+
+	var failing []string
+	for i := range policyList.Items {
+		policy := &policyList.Items[i]
+
+		// The policy is validated as an update to itself, so that fields
+		// which may only be left unchanged, such as an expired break glass
+		// policy, are not reported.
+		_, errs, err := s.validator.validatePolicy(ctx, policy, policy)
+		if err != nil {
+			s.log.Error(err, "failed to validate existing CertificateRequestPolicy", "policy", policy.Name)
+			continue
+		}
+		if len(errs) == 0 {
+			continue
+		}
+
+		message := utilerrors.NewAggregate(errs).Error()
+		failing = append(failing, policy.Name)
+		s.log.Info("existing CertificateRequestPolicy would no longer pass admission", "policy", policy.Name, "errors", message)
+		s.recorder.Eventf(policy, corev1.EventTypeWarning, "FailedValidation", "Policy would no longer pass admission: %s", message)
+	}
+
+	metrics.PoliciesFailingValidation.Set(float64(len(failing)))
+	s.log.Info("validated existing CertificateRequestPolicies", "total", len(policyList.Items), "failing", len(failing), "failing-policies", failing)
+
+	return nil
+}
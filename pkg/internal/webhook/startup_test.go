@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/ktesting"
+	fakeclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
+)
+
+func Test_startupValidation(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	valid := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "valid"},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+		},
+	}
+	expiredBreakGlass := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "expired-break-glass"},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{ExpiresAt: metav1.NewTime(now.Add(-time.Hour)), IncidentRef: "INC-1"},
+			Selector:   policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+		},
+	}
+	noSelector := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-selector"},
+	}
+	emptyAlias := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "empty-alias"},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("")}},
+		},
+	}
+
+	fakeclient := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithObjects(valid, expiredBreakGlass, noSelector, emptyAlias).
+		Build()
+
+	recorder := record.NewFakeRecorder(10)
+	s := &startupValidation{
+		log: ktesting.NewLogger(t, ktesting.DefaultConfig),
+		validator: &validator{lister: fakeclient, log: ktesting.NewLogger(t, ktesting.DefaultConfig),
+			clock: fakeclock.NewFakePassiveClock(now)},
+		recorder: recorder,
+	}
+
+	require.NoError(t, s.Start(context.TODO()))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.PoliciesFailingValidation))
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	assert.Equal(t, []string{
+		"Warning FailedValidation Policy would no longer pass admission: spec.selector.issuerRef.alias: Invalid value: \"\": alias must not be empty",
+		"Warning FailedValidation Policy would no longer pass admission: spec.selector: Required value: one of issuerRef or namespace must be defined, hint: `{}` on either matches everything",
+	}, events)
+}
+
+func Test_startupValidation_retry(t *testing.T) {
+	var lists int
+	fakeclient := fakeclient.NewClientBuilder().
+		WithScheme(policyapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, client client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				lists++
+				if lists == 1 {
+					return errors.New("cache not ready")
+				}
+				return client.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	s := &startupValidation{
+		log:       ktesting.NewLogger(t, ktesting.DefaultConfig),
+		validator: &validator{lister: fakeclient, log: ktesting.NewLogger(t, ktesting.DefaultConfig), clock: fakeclock.NewFakePassiveClock(time.Now())},
+		recorder:  record.NewFakeRecorder(10),
+		backoff:   &wait.Backoff{Duration: time.Millisecond, Steps: math.MaxInt32},
+	}
+
+	// A failed list is retried, and never returned as an error which would
+	// stop the manager.
+	require.NoError(t, s.Start(context.TODO()))
+	assert.Equal(t, 2, lists)
+
+	// Once the context is cancelled, validation stops without an error.
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	require.NoError(t, s.Start(ctx))
+}
@@ -21,17 +21,24 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/cert-manager/approver-policy/pkg/apis/policy"
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
+	"github.com/cert-manager/approver-policy/pkg/internal/approver/manager/predicate"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 // validator validates against policy.cert-manager.io resources.
@@ -42,16 +49,46 @@ type validator struct {
 	webhooks          []approver.Webhook
 
 	lister client.Reader
+
+	// requireSelectorMode rejects policies with an empty selector which do not
+	// set `spec.selector.mode`.
+	requireSelectorMode bool
+
+	// strictPolicyMode rejects policies which do not explicitly allow or deny
+	// every category of request attribute.
+	strictPolicyMode bool
+
+	// issuerAliases are the issuer aliases configured for the cluster, which
+	// policy selectors may reference.
+	issuerAliases map[string]cmmeta.ObjectReference
+
+	// maxPolicies is the maximum number of policies in the cluster, beyond
+	// which new policies are rejected. 0 is unlimited.
+	maxPolicies int
+
+	// maxBreakGlass is the furthest in the future that the expiry of a break
+	// glass policy may be set.
+	maxBreakGlass time.Duration
+
+	// cache caches validation results of identical policies. May be nil if
+	// caching is disabled.
+	cache *responseCache
+
+	// clock returns time which can be overwritten for testing.
+	clock clock.PassiveClock
 }
 
 var _ admission.CustomValidator = &validator{}
 
 func (v *validator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
-	return v.validate(ctx, obj)
+	if err := v.validatePolicyCount(ctx); err != nil {
+		return nil, err
+	}
+	return v.validate(ctx, nil, obj)
 }
 
 func (v *validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
-	return v.validate(ctx, newObj)
+	return v.validate(ctx, oldObj, newObj)
 }
 
 func (v *validator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
@@ -59,19 +96,90 @@ func (v *validator) ValidateDelete(ctx context.Context, obj runtime.Object) (adm
 	return nil, nil
 }
 
-// certificateRequestPolicy validates the given CertificateRequestPolicy with
-// the base validations, along with all webhook validations registered.
-func (v *validator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+// validatePolicyCount returns an error if the cluster already has the maximum
+// number of CertificateRequestPolicies, so that a runaway generator of
+// policies cannot exhaust the memory of the approver.
+func (v *validator) validatePolicyCount(ctx context.Context) error {
+	if v.maxPolicies <= 0 {
+		return nil
+	}
+
+	var policyList policyapi.CertificateRequestPolicyList
+	if err := v.lister.List(ctx, &policyList, client.UnsafeDisableDeepCopy); err != nil {
+		return fmt.Errorf("failed to list CertificateRequestPolicies: %w", err)
+	}
+
+	if len(policyList.Items) >= v.maxPolicies {
+		metrics.PolicyLimitRejections.Inc()
+		return fmt.Errorf("the cluster already has %d CertificateRequestPolicies, the maximum allowed is %d", len(policyList.Items), v.maxPolicies)
+	}
+
+	return nil
+}
+
+// validate validates the given CertificateRequestPolicy, returning the cached
+// result of validating an identical policy if caching is enabled. oldObj is the
+// existing CertificateRequestPolicy on update, and nil on create.
+func (v *validator) validate(ctx context.Context, oldObj, obj runtime.Object) (admission.Warnings, error) {
 	policy, ok := obj.(*policyapi.CertificateRequestPolicy)
 	if !ok {
 		return nil, fmt.Errorf("expected a CertificateRequestPolicy, but got a %T", obj)
 	}
+
+	// Break glass policies are validated against the current time and the
+	// existing policy, so their results are never cached.
+	if v.cache == nil || policy.Spec.BreakGlass != nil {
+		warnings, errs, err := v.validatePolicy(ctx, oldObj, policy)
+		if err != nil {
+			return nil, err
+		}
+		return warnings, utilerrors.NewAggregate(errs)
+	}
+
+	key, err := v.cache.key(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute validation cache key: %w", err)
+	}
+	if entry, ok := v.cache.get(key); ok {
+		return entry.warnings, entry.err
+	}
+
+	warnings, errs, err := v.validatePolicy(ctx, oldObj, policy)
+	if err != nil {
+		// Errors which prevented validation may be transient, so are not
+		// cached.
+		return nil, err
+	}
+
+	aggregate := utilerrors.NewAggregate(errs)
+	v.cache.set(key, warnings, aggregate)
+	return warnings, aggregate
+}
+
+// validatePolicy validates the policy with the base validations, along with
+// all webhook validations registered, returning the validation errors of the
+// policy. An error is returned if the policy could not be validated.
+func (v *validator) validatePolicy(ctx context.Context, oldObj runtime.Object, policy *policyapi.CertificateRequestPolicy) (admission.Warnings, []error, error) {
 	var (
 		fieldErrs field.ErrorList
 		warnings  admission.Warnings
 		fldPath   = field.NewPath("spec")
 	)
 
+	if breakGlass := policy.Spec.BreakGlass; breakGlass != nil {
+		var oldBreakGlass *policyapi.CertificateRequestPolicyBreakGlass
+		if oldPolicy, ok := oldObj.(*policyapi.CertificateRequestPolicy); ok {
+			oldBreakGlass = oldPolicy.Spec.BreakGlass
+		}
+		fieldErrs = append(fieldErrs, v.validateBreakGlass(fldPath, policy, oldBreakGlass)...)
+		warnings = append(warnings, fmt.Sprintf("break glass policy approves all selected requests from bound users until %s",
+			breakGlass.ExpiresAt.UTC().Format(time.RFC3339)))
+	} else if v.strictPolicyMode {
+		// Break glass policies approve requests without evaluating them, so
+		// have no attributes to configure.
+		fieldErrs = append(fieldErrs, validateStrictPolicy(fldPath, policy.Spec)...)
+	}
+
 	// Ensure no plugin has been defined which is not registered.
 	var unrecognisedNames []string
 	for name := range policy.Spec.Plugins {
@@ -98,8 +206,25 @@ func (v *validator) validate(ctx context.Context, obj runtime.Object) (admission
 
 	if policy.Spec.Selector.IssuerRef == nil && policy.Spec.Selector.Namespace == nil {
 		fieldErrs = append(fieldErrs, field.Required(fldPath.Child("selector"), "one of issuerRef or namespace must be defined, hint: `{}` on either matches everything"))
+	} else if v.requireSelectorMode && policy.Spec.Selector.Mode == nil && predicate.SelectorIsEmpty(policy.Spec.Selector) {
+		fieldErrs = append(fieldErrs, field.Required(fldPath.Child("selector", "mode"), fmt.Sprintf("this cluster requires empty selectors to set a mode of %q or %q",
+			policyapi.SelectorModeAll, policyapi.SelectorModeNone)))
 	}
 
+	if issRefSel := policy.Spec.Selector.IssuerRef; issRefSel != nil && issRefSel.Alias != nil {
+		fldPath := fldPath.Child("selector", "issuerRef")
+		if len(*issRefSel.Alias) == 0 {
+			fieldErrs = append(fieldErrs, field.Invalid(fldPath.Child("alias"), *issRefSel.Alias, "alias must not be empty"))
+		} else if _, ok := v.issuerAliases[*issRefSel.Alias]; !ok {
+			warnings = append(warnings, fmt.Sprintf("issuer alias %q is not configured in this cluster, so the policy will match no requests", *issRefSel.Alias))
+		}
+		if issRefSel.Name != nil || issRefSel.Kind != nil || issRefSel.Group != nil {
+			fieldErrs = append(fieldErrs, field.Invalid(fldPath.Child("alias"), *issRefSel.Alias, "alias cannot be combined with name, kind or group"))
+		}
+	}
+
+	fieldErrs = append(fieldErrs, validateMetricLabels(field.NewPath("metadata", "annotations"), policy.Annotations)...)
+
 	if nsSel := policy.Spec.Selector.Namespace; nsSel != nil && len(nsSel.MatchLabels) > 0 {
 		if _, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: nsSel.MatchLabels}); err != nil {
 			fieldErrs = append(fieldErrs, field.Invalid(fldPath.Child("selector", "namespace", "matchLabels"), nsSel.MatchLabels, err.Error()))
@@ -110,7 +235,7 @@ func (v *validator) validate(ctx context.Context, obj runtime.Object) (admission
 	for _, webhook := range v.webhooks {
 		response, err := webhook.Validate(ctx, policy)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if !response.Allowed {
 			fieldErrs = append(fieldErrs, response.Errors...)
@@ -133,5 +258,115 @@ func (v *validator) validate(ctx context.Context, obj runtime.Object) (admission
 		errs = append(errs, errors.New("a plugin did not allow the CertificateRequest for unknown reasons"))
 	}
 
-	return warnings, utilerrors.NewAggregate(errs)
+	return warnings, errs, nil
+}
+
+// validateBreakGlass validates the break glass fields of the policy. The
+// expiry must be in the future and within the maximum break glass duration,
+// unless it is unchanged from the existing policy so that an expired policy
+// can still be updated, for example to remove finalizers.
+func (v *validator) validateBreakGlass(fldPath *field.Path, policy *policyapi.CertificateRequestPolicy, oldBreakGlass *policyapi.CertificateRequestPolicyBreakGlass) field.ErrorList {
+	var (
+		el         field.ErrorList
+		breakGlass = policy.Spec.BreakGlass
+		bgPath     = fldPath.Child("breakGlass")
+	)
+
+	switch {
+	case breakGlass.ExpiresAt.IsZero():
+		el = append(el, field.Required(bgPath.Child("expiresAt"), "break glass policies must expire"))
+	case oldBreakGlass != nil && oldBreakGlass.ExpiresAt.Equal(&breakGlass.ExpiresAt):
+	case !breakGlass.ExpiresAt.After(v.clock.Now()):
+		el = append(el, field.Invalid(bgPath.Child("expiresAt"), breakGlass.ExpiresAt.UTC().Format(time.RFC3339), "must be in the future"))
+	case breakGlass.ExpiresAt.After(v.clock.Now().Add(v.maxBreakGlass)):
+		el = append(el, field.Invalid(bgPath.Child("expiresAt"), breakGlass.ExpiresAt.UTC().Format(time.RFC3339),
+			fmt.Sprintf("must be no more than %s in the future", v.maxBreakGlass)))
+	}
+
+	if len(strings.TrimSpace(breakGlass.IncidentRef)) == 0 {
+		el = append(el, field.Required(bgPath.Child("incidentRef"), "break glass policies must reference an incident"))
+	}
+
+	if policy.Spec.Allowed != nil {
+		el = append(el, field.Forbidden(fldPath.Child("allowed"), "must not be set on a break glass policy"))
+	}
+	if policy.Spec.Constraints != nil {
+		el = append(el, field.Forbidden(fldPath.Child("constraints"), "must not be set on a break glass policy"))
+	}
+	if len(policy.Spec.Plugins) > 0 {
+		el = append(el, field.Forbidden(fldPath.Child("plugins"), "must not be set on a break glass policy"))
+	}
+
+	return el
+}
+
+// validateStrictPolicy returns an error for each category of request
+// attribute which the policy neither explicitly allows nor denies. Unset
+// allowed fields deny the attribute implicitly, which strict policy mode
+// doesn't trust to be intended, so the hint gives the value which denies the
+// attribute explicitly.
+func validateStrictPolicy(fldPath *field.Path, spec policyapi.CertificateRequestPolicySpec) field.ErrorList {
+	var allowed policyapi.CertificateRequestPolicyAllowed
+	if spec.Allowed != nil {
+		allowed = *spec.Allowed
+	}
+
+	var el field.ErrorList
+	for _, category := range []struct {
+		name string
+		set  bool
+		deny string
+	}{
+		{"dnsNames", allowed.DNSNames != nil, "{}"},
+		{"ipAddresses", allowed.IPAddresses != nil, "{}"},
+		{"uris", allowed.URIs != nil, "{}"},
+		{"emailAddresses", allowed.EmailAddresses != nil, "{}"},
+		{"subject", allowed.Subject != nil, "{}"},
+		{"usages", allowed.Usages != nil, "[]"},
+		{"isCA", allowed.IsCA != nil, "false"},
+	} {
+		if !category.set {
+			el = append(el, field.Required(fldPath.Child("allowed", category.name),
+				fmt.Sprintf("this cluster requires policies to explicitly allow or deny every request attribute, hint: `%s` denies all", category.deny)))
+		}
+	}
+
+	if spec.Constraints == nil || (spec.Constraints.MinDuration == nil && spec.Constraints.MaxDuration == nil) {
+		el = append(el, field.Required(fldPath.Child("constraints", "maxDuration"),
+			"this cluster requires policies to explicitly constrain the duration of requests with minDuration or maxDuration"))
+	}
+
+	return el
+}
+
+// validateMetricLabels validates the annotations of the policy which add
+// custom labels to its decision metrics. The number of labels and the length
+// of their values are limited to bound the cardinality of the metrics.
+func validateMetricLabels(fldPath *field.Path, annotations map[string]string) field.ErrorList {
+	var (
+		el   field.ErrorList
+		keys []string
+	)
+
+	for key := range annotations {
+		if strings.HasPrefix(key, policy.MetricLabelAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := metrics.ValidatePolicyLabelName(strings.TrimPrefix(key, policy.MetricLabelAnnotationPrefix)); err != nil {
+			el = append(el, field.Invalid(fldPath.Key(key), key, err.Error()))
+		}
+		if value := annotations[key]; len(value) > metrics.MaxPolicyLabelValueLength {
+			el = append(el, field.TooLong(fldPath.Key(key), value, metrics.MaxPolicyLabelValueLength))
+		}
+	}
+
+	if len(keys) > metrics.MaxPolicyLabels {
+		el = append(el, field.TooMany(fldPath, len(keys), metrics.MaxPolicyLabels))
+	}
+
+	return el
 }
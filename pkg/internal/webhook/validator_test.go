@@ -20,13 +20,18 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2/ktesting"
+	fakeclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -34,6 +39,7 @@ import (
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
 	fakeapprover "github.com/cert-manager/approver-policy/pkg/approver/fake"
+	"github.com/cert-manager/approver-policy/pkg/internal/metrics"
 )
 
 func Test_validate(t *testing.T) {
@@ -55,10 +61,29 @@ func Test_validate(t *testing.T) {
 	failingWebhook := fakeapprover.NewFakeWebhook().WithValidate(func(context.Context, *policyapi.CertificateRequestPolicy) (approver.WebhookValidationResponse, error) {
 		return approver.WebhookValidationResponse{}, errors.New("some error")
 	})
+	fixedTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	breakGlassPolicy := func(expiresAt time.Time, incidentRef string) *policyapi.CertificateRequestPolicy {
+		return &policyapi.CertificateRequestPolicy{
+			TypeMeta:   testTypeMeta,
+			ObjectMeta: testObjectMeta,
+			Spec: policyapi.CertificateRequestPolicySpec{
+				BreakGlass: &policyapi.CertificateRequestPolicyBreakGlass{
+					ExpiresAt:   metav1.NewTime(expiresAt),
+					IncidentRef: incidentRef,
+				},
+				Selector: policyapi.CertificateRequestPolicySelector{
+					IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+				},
+			},
+		}
+	}
 	tests := map[string]struct {
-		crp               runtime.Object
-		webhooks          []approver.Webhook
-		registeredPlugins []string
+		oldCRP              runtime.Object
+		crp                 runtime.Object
+		webhooks            []approver.Webhook
+		registeredPlugins   []string
+		requireSelectorMode bool
+		strictPolicyMode    bool
 
 		expectedWarnings admission.Warnings
 		expectedError    *string
@@ -206,6 +231,172 @@ func Test_validate(t *testing.T) {
 			registeredPlugins: []string{"foo", "bar"},
 			webhooks:          []approver.Webhook{passingWebhook},
 		},
+		"if selector modes are required and an empty selector does not set a mode, return an error": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+						Namespace: &policyapi.CertificateRequestPolicySelectorNamespace{},
+					},
+				},
+			},
+			requireSelectorMode: true,
+
+			expectedError: ptr.To(`spec.selector.mode: Required value: this cluster requires empty selectors to set a mode of "All" or "None"`),
+		},
+		"if selector modes are required and an empty selector sets a mode, allow it": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+						Mode:      ptr.To(policyapi.SelectorModeAll),
+					},
+				},
+			},
+			requireSelectorMode: true,
+		},
+		"if selector modes are required and the selector is not empty, allow it without a mode": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("my-issuer")},
+					},
+				},
+			},
+			requireSelectorMode: true,
+		},
+		"if strict policy mode is enabled and the policy configures every request attribute, allow it": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Allowed: &policyapi.CertificateRequestPolicyAllowed{
+						DNSNames:       &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*.example.com"}},
+						IPAddresses:    &policyapi.CertificateRequestPolicyAllowedStringSlice{},
+						URIs:           &policyapi.CertificateRequestPolicyAllowedStringSlice{},
+						EmailAddresses: &policyapi.CertificateRequestPolicyAllowedStringSlice{},
+						Subject:        &policyapi.CertificateRequestPolicyAllowedX509Subject{},
+						Usages:         &[]cmapi.KeyUsage{},
+						IsCA:           ptr.To(false),
+					},
+					Constraints: &policyapi.CertificateRequestPolicyConstraints{MaxDuration: &policyapi.Duration{Duration: time.Hour}},
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("my-issuer")},
+					},
+				},
+			},
+			strictPolicyMode: true,
+		},
+		"if strict policy mode is enabled and the policy leaves request attributes unset, return an error": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Allowed: &policyapi.CertificateRequestPolicyAllowed{
+						DNSNames:       &policyapi.CertificateRequestPolicyAllowedStringSlice{Values: &[]string{"*.example.com"}},
+						IPAddresses:    &policyapi.CertificateRequestPolicyAllowedStringSlice{},
+						EmailAddresses: &policyapi.CertificateRequestPolicyAllowedStringSlice{},
+						Subject:        &policyapi.CertificateRequestPolicyAllowedX509Subject{},
+						IsCA:           ptr.To(false),
+					},
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Name: ptr.To("my-issuer")},
+					},
+				},
+			},
+			strictPolicyMode: true,
+
+			expectedError: ptr.To("[spec.allowed.uris: Required value: this cluster requires policies to explicitly allow or deny every request attribute, hint: `{}` denies all, " +
+				"spec.allowed.usages: Required value: this cluster requires policies to explicitly allow or deny every request attribute, hint: `[]` denies all, " +
+				"spec.constraints.maxDuration: Required value: this cluster requires policies to explicitly constrain the duration of requests with minDuration or maxDuration]"),
+		},
+		"if the issuerRef selector references a configured alias, allow it": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("internal-mtls")},
+					},
+				},
+			},
+		},
+		"if the issuerRef selector references an alias which is not configured, allow it with a warning": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("public-acme")},
+					},
+				},
+			},
+			expectedWarnings: admission.Warnings{`issuer alias "public-acme" is not configured in this cluster, so the policy will match no requests`},
+		},
+		"if the issuerRef selector combines an alias with a name, return an error": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta:   testTypeMeta,
+				ObjectMeta: testObjectMeta,
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{Alias: ptr.To("internal-mtls"), Name: ptr.To("my-issuer")},
+					},
+				},
+			},
+			expectedError: ptr.To(`spec.selector.issuerRef.alias: Invalid value: "internal-mtls": alias cannot be combined with name, kind or group`),
+		},
+		"if metric label annotations are invalid, return an error": {
+			crp: &policyapi.CertificateRequestPolicy{
+				TypeMeta: testTypeMeta,
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Annotations: map[string]string{
+					"metrics.policy.cert-manager.io/team":      "payments",
+					"metrics.policy.cert-manager.io/cost-code": "1234",
+					"metrics.policy.cert-manager.io/policy":    "a",
+				}},
+				Spec: policyapi.CertificateRequestPolicySpec{
+					Selector: policyapi.CertificateRequestPolicySelector{
+						IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{},
+					},
+				},
+			},
+
+			expectedError: ptr.To(`[metadata.annotations[metrics.policy.cert-manager.io/cost-code]: Invalid value: "metrics.policy.cert-manager.io/cost-code": "cost-code" is not a valid Prometheus label name, ` +
+				`metadata.annotations[metrics.policy.cert-manager.io/policy]: Invalid value: "metrics.policy.cert-manager.io/policy": "policy" is reserved and cannot be used as a policy metric label]`),
+		},
+		"if a break glass CertificateRequestPolicy expires in the future, allow it with a warning": {
+			crp:              breakGlassPolicy(fixedTime.Add(time.Hour), "INC-123"),
+			expectedWarnings: admission.Warnings{"break glass policy approves all selected requests from bound users until 2024-03-01T13:00:00Z"},
+		},
+		"if a break glass CertificateRequestPolicy is created already expired, return an error": {
+			crp:              breakGlassPolicy(fixedTime.Add(-time.Hour), "INC-123"),
+			expectedWarnings: admission.Warnings{"break glass policy approves all selected requests from bound users until 2024-03-01T11:00:00Z"},
+			expectedError:    ptr.To(`spec.breakGlass.expiresAt: Invalid value: "2024-03-01T11:00:00Z": must be in the future`),
+		},
+		"if a break glass CertificateRequestPolicy expires beyond the maximum break glass duration, return an error": {
+			crp:              breakGlassPolicy(fixedTime.Add(73*time.Hour), "INC-123"),
+			expectedWarnings: admission.Warnings{"break glass policy approves all selected requests from bound users until 2024-03-04T13:00:00Z"},
+			expectedError:    ptr.To(`spec.breakGlass.expiresAt: Invalid value: "2024-03-04T13:00:00Z": must be no more than 72h0m0s in the future`),
+		},
+		"if an expired break glass CertificateRequestPolicy is updated without changing its expiry, allow it": {
+			oldCRP:           breakGlassPolicy(fixedTime.Add(-time.Hour), "INC-123"),
+			crp:              breakGlassPolicy(fixedTime.Add(-time.Hour), "INC-123"),
+			expectedWarnings: admission.Warnings{"break glass policy approves all selected requests from bound users until 2024-03-01T11:00:00Z"},
+		},
+		"if a break glass CertificateRequestPolicy has no incident and sets allowed, return an error": {
+			crp: func() runtime.Object {
+				crp := breakGlassPolicy(fixedTime.Add(time.Hour), "")
+				crp.Spec.Allowed = &policyapi.CertificateRequestPolicyAllowed{}
+				return crp
+			}(),
+			expectedWarnings: admission.Warnings{"break glass policy approves all selected requests from bound users until 2024-03-01T13:00:00Z"},
+			expectedError:    ptr.To("[spec.breakGlass.incidentRef: Required value: break glass policies must reference an incident, spec.allowed: Forbidden: must not be set on a break glass policy]"),
+		},
 	}
 
 	for name, test := range tests {
@@ -214,8 +405,10 @@ func Test_validate(t *testing.T) {
 				WithScheme(policyapi.GlobalScheme).
 				Build()
 
-			v := &validator{lister: fakeclient, log: ktesting.NewLogger(t, ktesting.DefaultConfig), webhooks: test.webhooks, registeredPlugins: test.registeredPlugins}
-			gotWarnings, gotErr := v.validate(context.Background(), test.crp)
+			v := &validator{lister: fakeclient, log: ktesting.NewLogger(t, ktesting.DefaultConfig), webhooks: test.webhooks, registeredPlugins: test.registeredPlugins,
+				requireSelectorMode: test.requireSelectorMode, strictPolicyMode: test.strictPolicyMode, maxBreakGlass: 72 * time.Hour, clock: fakeclock.NewFakePassiveClock(fixedTime),
+				issuerAliases: map[string]cmmeta.ObjectReference{"internal-mtls": {Name: "vault-mtls", Kind: "ClusterIssuer", Group: "cert-manager.io"}}}
+			gotWarnings, gotErr := v.validate(context.Background(), test.oldCRP, test.crp)
 			if test.expectedError == nil && gotErr != nil {
 				t.Errorf("unexpected error: %v", gotErr)
 			} else if test.expectedError != nil && (gotErr == nil || *test.expectedError != gotErr.Error()) {
@@ -225,3 +418,59 @@ func Test_validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateCreateMaxPolicies(t *testing.T) {
+	existing := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing-policy"},
+		Spec: policyapi.CertificateRequestPolicySpec{
+			Selector: policyapi.CertificateRequestPolicySelector{IssuerRef: &policyapi.CertificateRequestPolicySelectorIssuerRef{}},
+		},
+	}
+	policy := &policyapi.CertificateRequestPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "new-policy"},
+		Spec:       existing.Spec,
+	}
+
+	tests := map[string]struct {
+		maxPolicies   int
+		expRejections float64
+		expErr        string
+	}{
+		"if there is no maximum, allow the policy": {
+			maxPolicies: 0,
+		},
+		"if the maximum has not been reached, allow the policy": {
+			maxPolicies: 2,
+		},
+		"if the maximum has been reached, reject the policy": {
+			maxPolicies:   1,
+			expRejections: 1,
+			expErr:        "the cluster already has 1 CertificateRequestPolicies, the maximum allowed is 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fakeclient := fakeclient.NewClientBuilder().
+				WithScheme(policyapi.GlobalScheme).
+				WithObjects(existing).
+				Build()
+
+			v := &validator{lister: fakeclient, log: ktesting.NewLogger(t, ktesting.DefaultConfig), maxPolicies: test.maxPolicies,
+				clock: fakeclock.NewFakePassiveClock(time.Now())}
+
+			rejections := testutil.ToFloat64(metrics.PolicyLimitRejections)
+			_, err := v.ValidateCreate(context.Background(), policy)
+			if len(test.expErr) > 0 {
+				assert.EqualError(t, err, test.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, rejections+test.expRejections, testutil.ToFloat64(metrics.PolicyLimitRejections))
+
+			// Updates of existing policies are always allowed.
+			_, err = v.ValidateUpdate(context.Background(), existing, existing)
+			assert.NoError(t, err)
+		})
+	}
+}
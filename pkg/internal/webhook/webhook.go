@@ -19,10 +19,15 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"time"
 
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/go-logr/logr"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
 	"github.com/cert-manager/approver-policy/pkg/approver"
@@ -42,8 +47,49 @@ type Options struct {
 	// approver-policy instance. The webhook will register its endpoints and
 	// runnables against.
 	Manager manager.Manager
+
+	// RequireSelectorMode rejects CertificateRequestPolicies with an empty
+	// selector which do not explicitly set `spec.selector.mode`.
+	RequireSelectorMode bool
+
+	// StrictPolicyMode rejects CertificateRequestPolicies which do not
+	// explicitly allow or deny every category of request attribute, and
+	// constrain the duration of requests.
+	StrictPolicyMode bool
+
+	// IssuerAliases are the issuer aliases configured for the cluster.
+	// Policies selecting an alias which is not configured are warned about.
+	IssuerAliases map[string]cmmeta.ObjectReference
+
+	// MaxPolicies rejects the creation of CertificateRequestPolicies once the
+	// cluster has this many. 0 is unlimited.
+	MaxPolicies int
+
+	// MaxBreakGlassDuration is the furthest in the future that the expiry of
+	// a break glass policy may be set.
+	MaxBreakGlassDuration time.Duration
+
+	// ResponseCacheTTL is how long the results of validating policies are
+	// cached for, so that identical policies applied repeatedly are not
+	// validated again. Zero disables caching.
+	ResponseCacheTTL time.Duration
+
+	// ResponseCacheSize is the maximum number of cached validation results.
+	ResponseCacheSize int
+
+	// PolicyDefaults are set on the spec of CertificateRequestPolicies as they
+	// are created, for fields which the policy does not set. Nil sets no
+	// defaults.
+	PolicyDefaults *policyapi.CertificateRequestPolicySpec
+
+	// MutatingServer serves the mutating webhook on its own listener. Nil
+	// serves it on the webhook server of the Manager.
+	MutatingServer ctrlwebhook.Server
 }
 
+// MutatePath is the path that the mutating webhook is served on.
+const MutatePath = "/mutate-policy-cert-manager-io-v1alpha1-certificaterequestpolicy"
+
 // Register the approver-policy Webhook endpoints against the
 // controller-manager Manager.
 func Register(ctx context.Context, opts Options) error {
@@ -58,10 +104,17 @@ func Register(ctx context.Context, opts Options) error {
 
 	log.Info("registering webhook endpoints")
 	validator := &validator{
-		log:               log.WithName("validation"),
-		lister:            opts.Manager.GetCache(),
-		webhooks:          opts.Webhooks,
-		registeredPlugins: registerdPlugins,
+		log:                 log.WithName("validation"),
+		lister:              opts.Manager.GetCache(),
+		webhooks:            opts.Webhooks,
+		registeredPlugins:   registerdPlugins,
+		requireSelectorMode: opts.RequireSelectorMode,
+		strictPolicyMode:    opts.StrictPolicyMode,
+		issuerAliases:       opts.IssuerAliases,
+		maxPolicies:         opts.MaxPolicies,
+		maxBreakGlass:       opts.MaxBreakGlassDuration,
+		cache:               newResponseCache(clock.RealClock{}, opts.ResponseCacheTTL, opts.ResponseCacheSize),
+		clock:               clock.RealClock{},
 	}
 
 	err := builder.WebhookManagedBy(opts.Manager).
@@ -72,6 +125,29 @@ func Register(ctx context.Context, opts Options) error {
 		return fmt.Errorf("error registering webhook: %v", err)
 	}
 
+	// The mutating webhook is always served, since as well as setting any
+	// defaults it canonicalizes the durations of policies.
+	defaulter, err := newDefaulter(log.WithName("defaulting"), opts.PolicyDefaults)
+	if err != nil {
+		return err
+	}
+
+	server := opts.MutatingServer
+	if server == nil {
+		server = opts.Manager.GetWebhookServer()
+	} else if err := opts.Manager.AddReadyzCheck("mutating", server.StartedChecker()); err != nil {
+		return fmt.Errorf("error adding readyz check: %v", err)
+	}
+	server.Register(MutatePath, admission.WithCustomDefaulter(policyapi.GlobalScheme, &policyapi.CertificateRequestPolicy{}, defaulter))
+
+	if err := opts.Manager.Add(&startupValidation{
+		log:       log.WithName("startup-validation"),
+		validator: validator,
+		recorder:  opts.Manager.GetEventRecorderFor("policy.cert-manager.io"),
+	}); err != nil {
+		return fmt.Errorf("error adding startup validation: %v", err)
+	}
+
 	if err := opts.Manager.AddReadyzCheck("validator", opts.Manager.GetWebhookServer().StartedChecker()); err != nil {
 		return fmt.Errorf("error adding readyz check: %v", err)
 	}
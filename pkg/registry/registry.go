@@ -28,11 +28,15 @@ var (
 	Shared = &Registry{}
 )
 
-// Registry is a store of Approvers. Consumers can store approvers, and load
-// all registered approvers. Approvers must be uniquely named.
+// Registry is a store of Approvers, PostProcessors and RequestDecoders.
+// Consumers can store approvers, post-processors and request decoders, and
+// load all that are registered. Approvers, PostProcessors and RequestDecoders
+// must each be uniquely named.
 type Registry struct {
-	lock      sync.RWMutex
-	approvers []approver.Interface
+	lock            sync.RWMutex
+	approvers       []approver.Interface
+	postProcessors  []approver.PostProcessor
+	requestDecoders []approver.RequestDecoder
 }
 
 // Store will store an Approver into the shared approver registry.
@@ -58,6 +62,52 @@ func (r *Registry) Approvers() []approver.Interface {
 	return r.approvers
 }
 
+// StorePostProcessors will store PostProcessors into the registry.
+func (r *Registry) StorePostProcessors(postProcessors ...approver.PostProcessor) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, existing := range r.postProcessors {
+		for _, toStore := range postProcessors {
+			if existing.Name() == toStore.Name() {
+				panic("post-processor already registered with same name: " + toStore.Name())
+			}
+		}
+	}
+	r.postProcessors = append(r.postProcessors, postProcessors...)
+	return r
+}
+
+// PostProcessors returns the list of PostProcessors that have been registered
+// to the registry.
+func (r *Registry) PostProcessors() []approver.PostProcessor {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.postProcessors
+}
+
+// StoreRequestDecoders will store RequestDecoders into the registry.
+func (r *Registry) StoreRequestDecoders(requestDecoders ...approver.RequestDecoder) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, existing := range r.requestDecoders {
+		for _, toStore := range requestDecoders {
+			if existing.Name() == toStore.Name() {
+				panic("request decoder already registered with same name: " + toStore.Name())
+			}
+		}
+	}
+	r.requestDecoders = append(r.requestDecoders, requestDecoders...)
+	return r
+}
+
+// RequestDecoders returns the list of RequestDecoders that have been
+// registered to the registry.
+func (r *Registry) RequestDecoders() []approver.RequestDecoder {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.requestDecoders
+}
+
 // Evaluators returns the list of Evaluators that have been registered as
 // Approvers to the registry.
 func (r *Registry) Evaluators() []approver.Evaluator {
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testharness
+
+import (
+	"fmt"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+)
+
+// Verdict is the expected verdict of the review of a request.
+type Verdict = fixtures.Verdict
+
+const (
+	// VerdictApproved is the verdict of requests which are approved.
+	VerdictApproved = fixtures.VerdictApproved
+
+	// VerdictDenied is the verdict of requests which are denied.
+	VerdictDenied = fixtures.VerdictDenied
+
+	// VerdictUnprocessed is the verdict of requests which no policy is bound
+	// or applicable to, which approver-policy neither approves nor denies.
+	VerdictUnprocessed = fixtures.VerdictUnprocessed
+)
+
+// Case is a CertificateRequest with the expected result of its review.
+type Case struct {
+	// Name is the name of the Case. Defaults to the file and index of the
+	// document the Case was loaded from.
+	Name string `json:"name,omitempty"`
+
+	// File is the file the Case was loaded from.
+	File string `json:"-"`
+
+	// Request is the CertificateRequest to review. Only the namespace and
+	// spec are used.
+	Request cmapi.CertificateRequest `json:"request"`
+
+	// Expect is the expected result of the review.
+	Expect Expectation `json:"expect"`
+}
+
+// Expectation is the expected result of the review of a request.
+type Expectation struct {
+	// Verdict is the expected verdict.
+	Verdict Verdict `json:"verdict"`
+
+	// Policies are the names of the CertificateRequestPolicies expected to
+	// have approved the request if it is approved, or denied the request if
+	// it is denied. Not checked if unset.
+	Policies []string `json:"policies,omitempty"`
+
+	// MessageContains is a substring expected in the message of the
+	// review, such as a violation of a denied request.
+	MessageContains string `json:"messageContains,omitempty"`
+}
+
+// LoadObjects returns the objects of every YAML document in the directory.
+// Documents must be of a kind known to approver-policy, such as
+// CertificateRequestPolicies, RBAC and Namespaces.
+func LoadObjects(dir string) ([]client.Object, error) {
+	return fixtures.LoadObjects(dir)
+}
+
+// LoadCases returns the Cases of every YAML document in the directory.
+func LoadCases(dir string) ([]Case, error) {
+	var cases []Case
+	err := fixtures.WalkDocuments(dir, func(path string, index int, doc []byte) error {
+		var c Case
+		if err := yaml.UnmarshalStrict(doc, &c); err != nil {
+			return fmt.Errorf("%s: failed to decode case %d: %w", path, index, err)
+		}
+
+		switch c.Expect.Verdict {
+		case VerdictApproved, VerdictDenied, VerdictUnprocessed:
+		default:
+			return fmt.Errorf("%s: case %d: expect.verdict must be one of %q, %q or %q, got %q",
+				path, index, VerdictApproved, VerdictDenied, VerdictUnprocessed, c.Expect.Verdict)
+		}
+
+		c.File = path
+		if len(c.Name) == 0 {
+			c.Name = fmt.Sprintf("%s#%d", path, index)
+		}
+		cases = append(cases, c)
+		return nil
+	})
+	return cases, err
+}
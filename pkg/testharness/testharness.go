@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testharness runs fixtures of CertificateRequests with expected
+// verdicts against a set of CertificateRequestPolicies, using the same
+// evaluation engine as approver-policy. This allows platform teams to keep
+// regression suites for their policy sets.
+//
+// The objects directory holds YAML manifests of the CertificateRequestPolicies
+// under test, along with any other objects evaluation depends on: the RBAC
+// binding policies to requesters, labelled Namespaces matched by policy
+// namespace selectors, and Certificates owning requests. The cases directory
+// holds YAML documents of Cases. Both directories are read recursively, and
+// files may hold multiple documents separated by "---".
+//
+// Policies are evaluated with every Approver registered with
+// registry.Shared, which always includes the built-in approvers. Plugin
+// approvers are included by importing them, as is done when building
+// approver-policy. Approvers are prepared against the fixtures rather than a
+// cluster, so only approvers which read objects through the manager's client
+// or API reader are supported. Requests are normalized with the
+// RequestDecoders registered with registry.Shared before they are reviewed.
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	policyapi "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	approvermanager "github.com/cert-manager/approver-policy/pkg/approver/manager"
+	"github.com/cert-manager/approver-policy/pkg/internal/fixtures"
+)
+
+// Options configures a run of the fixtures.
+type Options struct {
+	// ObjectsDir is the directory holding the CertificateRequestPolicies
+	// under test, and the objects their evaluation depends on.
+	ObjectsDir string
+
+	// CasesDir is the directory holding the Cases.
+	CasesDir string
+
+	// DefaultSelectorMode is the selector mode of policies which do not set
+	// one, as configured by --default-selector-mode. Defaults to "All".
+	DefaultSelectorMode policyapi.SelectorMode
+
+	// IssuerAliases are the issuer aliases referenced by policy selectors,
+	// as configured by --issuer-alias.
+	IssuerAliases map[string]cmmeta.ObjectReference
+}
+
+// Result is the result of running a Case.
+type Result struct {
+	// Case is the Case which was run.
+	Case Case
+
+	// Response is the response of the review of the request.
+	Response approvermanager.ReviewResponse
+
+	// Err is set if the request could not be reviewed.
+	Err error
+
+	// Failures are the expectations of the Case which were not met.
+	Failures []string
+}
+
+// Passed returns true if the request was reviewed and met every expectation
+// of the Case.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Run loads the fixtures and reviews the request of every Case, returning
+// the Results in the order the Cases were loaded. An error is returned if
+// the fixtures could not be loaded. Run must not be called concurrently, as
+// the registered Approvers are prepared against the fixtures of each run.
+func Run(ctx context.Context, opts Options) ([]Result, error) {
+	objects, err := LoadObjects(opts.ObjectsDir)
+	if err != nil {
+		return nil, err
+	}
+	cases, err := LoadCases(opts.CasesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(cases))
+	for _, c := range cases {
+		namespaces = append(namespaces, c.Request.Namespace)
+	}
+
+	reviewer, err := fixtures.NewReviewer(ctx, objects, namespaces, fixtures.Options{
+		DefaultSelectorMode: opts.DefaultSelectorMode,
+		IssuerAliases:       opts.IssuerAliases,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		result := Result{Case: c}
+		result.Response, result.Err = reviewer.Review(ctx, c.Request.DeepCopy())
+		if result.Err == nil {
+			result.Failures = c.Expect.check(result.Response)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Test runs the fixtures as subtests of t, named after each Case, failing
+// the subtests of Cases which did not meet their expectations.
+func Test(t *testing.T, opts Options) {
+	t.Helper()
+
+	results, err := Run(context.TODO(), opts)
+	if err != nil {
+		t.Fatalf("failed to run fixtures: %s", err)
+	}
+
+	for _, result := range results {
+		t.Run(result.Case.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Fatalf("%s: failed to review request: %s", result.Case.File, result.Err)
+			}
+			for _, failure := range result.Failures {
+				t.Errorf("%s: %s", result.Case.File, failure)
+			}
+		})
+	}
+}
+
+// check returns the expectations which the response does not meet.
+func (e Expectation) check(response approvermanager.ReviewResponse) []string {
+	var failures []string
+
+	if verdict := fixtures.VerdictOf(response.Result); verdict != e.Verdict {
+		failures = append(failures, fmt.Sprintf("expected verdict %s, got %s: %s", e.Verdict, verdict, response.Message))
+	}
+
+	if e.Policies != nil {
+		revisions := response.ApprovedByAll
+		if response.Result == approvermanager.ResultDenied {
+			revisions = response.DeniedBy
+		}
+		policies := make([]string, 0, len(revisions))
+		for _, revision := range revisions {
+			policies = append(policies, revision.Name)
+		}
+
+		expPolicies := slices.Clone(e.Policies)
+		slices.Sort(expPolicies)
+		if !slices.Equal(expPolicies, policies) {
+			failures = append(failures, fmt.Sprintf("expected policies %v, got %v", expPolicies, policies))
+		}
+	}
+
+	if len(e.MessageContains) > 0 && !strings.Contains(response.Message, e.MessageContains) {
+		failures = append(failures, fmt.Sprintf("expected message to contain %q, got %q", e.MessageContains, response.Message))
+	}
+
+	return failures
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testharness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	approvermanager "github.com/cert-manager/approver-policy/pkg/approver/manager"
+)
+
+func Test_Run(t *testing.T) {
+	results, err := Run(context.TODO(), Options{ObjectsDir: "testdata/objects", CasesDir: "testdata/cases"})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for _, result := range results {
+		assert.NoError(t, result.Err, result.Case.Name)
+		assert.Empty(t, result.Failures, result.Case.Name)
+		assert.True(t, result.Passed(), result.Case.Name)
+	}
+}
+
+func Test_Test(t *testing.T) {
+	Test(t, Options{ObjectsDir: "testdata/objects", CasesDir: "testdata/cases"})
+}
+
+func Test_check(t *testing.T) {
+	approved := approvermanager.ReviewResponse{
+		Result:        approvermanager.ResultApproved,
+		Message:       `Approved by CertificateRequestPolicy: "policy-a"`,
+		ApprovedByAll: []approvermanager.PolicyRevision{{Name: "policy-a"}, {Name: "policy-b"}},
+	}
+
+	tests := map[string]struct {
+		expect      Expectation
+		response    approvermanager.ReviewResponse
+		expFailures []string
+	}{
+		"matching verdict, policies in any order and message should pass": {
+			expect:   Expectation{Verdict: VerdictApproved, Policies: []string{"policy-b", "policy-a"}, MessageContains: "policy-a"},
+			response: approved,
+		},
+		"unset policies should not be checked": {
+			expect:   Expectation{Verdict: VerdictApproved},
+			response: approved,
+		},
+		"unmet expectations should all fail": {
+			expect:   Expectation{Verdict: VerdictDenied, Policies: []string{"policy-a"}, MessageContains: "denied"},
+			response: approved,
+			expFailures: []string{
+				`expected verdict Denied, got Approved: Approved by CertificateRequestPolicy: "policy-a"`,
+				"expected policies [policy-a], got [policy-a policy-b]",
+				`expected message to contain "denied", got "Approved by CertificateRequestPolicy: \"policy-a\""`,
+			},
+		},
+		"unprocessed response should not match approved verdict": {
+			expect:      Expectation{Verdict: VerdictApproved},
+			response:    approvermanager.ReviewResponse{Result: approvermanager.ResultUnprocessed, Message: "No CertificateRequestPolicies bound or applicable"},
+			expFailures: []string{"expected verdict Approved, got Unprocessed: No CertificateRequestPolicies bound or applicable"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expFailures, test.expect.check(test.response))
+		})
+	}
+}